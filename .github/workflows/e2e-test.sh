@@ -165,6 +165,64 @@ function events_handlers() {
   kubectl delete -f config/samples/events-handlers-sample-serving-only.yaml
 }
 
+function kubernetes_source() {
+  kubectl apply -f config/rbac/kubernetes_source_role.yaml
+  kubectl apply -f config/samples/events-kubernetes-source-sample.yaml
+  kubectl wait --for=condition=Available deployment/event-display --timeout=300s
+
+  kubectl create configmap kubernetes-source-e2e --from-literal=message=Hello
+  kubectl label configmap kubernetes-source-e2e app=settings
+
+  while /bin/true; do
+    kubectl logs deployment/event-display |grep "kubernetes-source-e2e"
+    if [ $? -eq 0 ]; then
+      echo "Kubernetes event source tested successfully!"
+      break
+    else
+      sleep 1
+      continue
+    fi
+  done
+
+  kubectl delete configmap kubernetes-source-e2e
+  kubectl delete -f config/samples/events-kubernetes-source-sample.yaml
+}
+
+function s3_source() {
+  kubectl apply -f config/samples/events-s3-minio-sample.yaml
+  kubectl wait --for=condition=Available deployment/minio deployment/event-display --timeout=300s
+  kubectl run mc --rm -i --restart=Never --image=minio/mc:latest --command -- \
+    sh -c "mc alias set local http://minio:9000 minioadmin minioadmin && mc mb -p local/images"
+
+  # the objects which exist when the handler runs for the first time are not published,
+  # so upload the object after the handler has saved its cursor
+  while /bin/true; do
+    cursor=$(kubectl get configmap esw-images-s3-uploads -o jsonpath='{.data.lastModified}')
+    if [ -z "$cursor" ]; then
+      sleep 1
+      continue
+    else
+      echo "The handler of the S3 event source is polling the bucket"
+      break
+    fi
+  done
+  kubectl run mc --rm -i --restart=Never --image=minio/mc:latest --command -- \
+    sh -c "mc alias set local http://minio:9000 minioadmin minioadmin && echo Hello | mc pipe local/images/s3-source-e2e.jpg"
+
+  while /bin/true; do
+    kubectl logs deployment/event-display |grep "s3-source-e2e.jpg"
+    if [ $? -eq 0 ]; then
+      echo "S3 event source tested successfully!"
+      break
+    else
+      sleep 1
+      continue
+    fi
+  done
+
+  kubectl delete -f config/samples/events-s3-minio-sample.yaml
+}
+
 case $1 in
 
   knative)
@@ -190,4 +248,12 @@ case $1 in
   events)
     events_handlers
     ;;
+
+  kubernetes_source)
+    kubernetes_source
+    ;;
+
+  s3_source)
+    s3_source
+    ;;
 esac
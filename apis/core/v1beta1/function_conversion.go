@@ -17,8 +17,10 @@ import (
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"gopkg.in/yaml.v3"
 	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	"github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/networking/gateway"
 )
 
 /*
@@ -92,8 +94,8 @@ func (src *Function) ConvertTo(dstRaw conversion.Hub) error {
 
 	if src.Status.Route != nil {
 		dst.Status.Route = &v1beta2.RouteStatus{
-			Hosts:      src.Status.Route.Hosts,
-			Paths:      src.Status.Route.Paths,
+			Hosts:      gateway.FromHostnames(src.Status.Route.Hosts),
+			Paths:      gateway.FromHTTPPathMatches(src.Status.Route.Paths),
 			Conditions: src.Status.Route.Conditions,
 		}
 	}
@@ -168,16 +170,19 @@ func convertRouteTo(route *RouteImpl) *v1beta2.RouteImpl {
 	}
 
 	nr := &v1beta2.RouteImpl{
-		Hostnames: route.Hostnames,
-		Rules:     route.Rules,
+		Hostnames: gateway.FromHostnames(route.Hostnames),
+		Rules:     gateway.FromHTTPRouteRules(route.Rules),
 	}
 	if route.CommonRouteSpec.GatewayRef != nil {
 		nr.CommonRouteSpec = v1beta2.CommonRouteSpec{
 			GatewayRef: &v1beta2.GatewayRef{
-				Name:      route.GatewayRef.Name,
-				Namespace: route.GatewayRef.Namespace,
+				Name: v1beta2.ObjectName(route.GatewayRef.Name),
 			},
 		}
+		if route.GatewayRef.Namespace != nil {
+			namespace := v1beta2.Namespace(*route.GatewayRef.Namespace)
+			nr.GatewayRef.Namespace = &namespace
+		}
 	}
 
 	return nr
@@ -442,8 +447,8 @@ func (dst *Function) ConvertFrom(srcRaw conversion.Hub) error {
 
 		if src.Status.Route != nil {
 			dst.Status.Route = &RouteStatus{
-				Hosts:      src.Status.Route.Hosts,
-				Paths:      src.Status.Route.Paths,
+				Hosts:      gateway.ToHostnames(src.Status.Route.Hosts),
+				Paths:      gateway.ToHTTPPathMatches(src.Status.Route.Paths),
 				Conditions: src.Status.Route.Conditions,
 			}
 		}
@@ -641,16 +646,19 @@ func convertRouteFrom(route *v1beta2.RouteImpl) *RouteImpl {
 	}
 
 	nr := &RouteImpl{
-		Hostnames: route.Hostnames,
-		Rules:     route.Rules,
+		Hostnames: gateway.ToHostnames(route.Hostnames),
+		Rules:     gateway.ToHTTPRouteRules(route.Rules),
 	}
 	if route.CommonRouteSpec.GatewayRef != nil {
 		nr.CommonRouteSpec = CommonRouteSpec{
 			GatewayRef: &GatewayRef{
-				Name:      route.GatewayRef.Name,
-				Namespace: route.GatewayRef.Namespace,
+				Name: k8sgatewayapiv1beta1.ObjectName(route.GatewayRef.Name),
 			},
 		}
+		if route.GatewayRef.Namespace != nil {
+			namespace := k8sgatewayapiv1beta1.Namespace(*route.GatewayRef.Namespace)
+			nr.GatewayRef.Namespace = &namespace
+		}
 	}
 
 	return nr
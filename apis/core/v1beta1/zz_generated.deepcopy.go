@@ -27,7 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
-	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+	apisv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -531,7 +531,7 @@ func (in *GatewayRef) DeepCopyInto(out *GatewayRef) {
 	*out = *in
 	if in.Namespace != nil {
 		in, out := &in.Namespace, &out.Namespace
-		*out = new(v1alpha2.Namespace)
+		*out = new(apisv1beta1.Namespace)
 		**out = **in
 	}
 }
@@ -738,12 +738,12 @@ func (in *RouteImpl) DeepCopyInto(out *RouteImpl) {
 	in.CommonRouteSpec.DeepCopyInto(&out.CommonRouteSpec)
 	if in.Hostnames != nil {
 		in, out := &in.Hostnames, &out.Hostnames
-		*out = make([]v1alpha2.Hostname, len(*in))
+		*out = make([]apisv1beta1.Hostname, len(*in))
 		copy(*out, *in)
 	}
 	if in.Rules != nil {
 		in, out := &in.Rules, &out.Rules
-		*out = make([]v1alpha2.HTTPRouteRule, len(*in))
+		*out = make([]apisv1beta1.HTTPRouteRule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -765,12 +765,12 @@ func (in *RouteStatus) DeepCopyInto(out *RouteStatus) {
 	*out = *in
 	if in.Hosts != nil {
 		in, out := &in.Hosts, &out.Hosts
-		*out = make([]v1alpha2.Hostname, len(*in))
+		*out = make([]apisv1beta1.Hostname, len(*in))
 		copy(*out, *in)
 	}
 	if in.Paths != nil {
 		in, out := &in.Paths, &out.Paths
-		*out = make([]v1alpha2.HTTPPathMatch, len(*in))
+		*out = make([]apisv1beta1.HTTPPathMatch, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
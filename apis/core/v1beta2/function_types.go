@@ -19,7 +19,6 @@ package v1beta2
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -80,10 +79,10 @@ const ExternalAddressType AddressType = "External"
 type GatewayRef struct {
 	// Name is the name of the referent.
 	// It refers to the name of a Gateway resource.
-	Name k8sgatewayapiv1alpha2.ObjectName `json:"name"`
+	Name ObjectName `json:"name"`
 	// Namespace is the namespace of the referent. When unspecified,
 	// this refers to the local namespace of the Route.
-	Namespace *k8sgatewayapiv1alpha2.Namespace `json:"namespace"`
+	Namespace *Namespace `json:"namespace"`
 }
 
 // CommonRouteSpec defines the common attributes that all Routes MUST include
@@ -96,6 +95,7 @@ type CommonRouteSpec struct {
 	GatewayRef *GatewayRef `json:"gatewayRef,omitempty"`
 }
 
+// RouteImpl is translated into an HTTPRoute of the Gateway API version served by the cluster.
 type RouteImpl struct {
 	CommonRouteSpec `json:",inline"`
 	// Hostnames defines a set of hostname that should match against the HTTP
@@ -103,12 +103,48 @@ type RouteImpl struct {
 	//
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	Hostnames []k8sgatewayapiv1alpha2.Hostname `json:"hostnames,omitempty"`
+	Hostnames []Hostname `json:"hostnames,omitempty"`
 	// Rules are a list of HTTP matchers, filters and actions.
 	//
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	Rules []k8sgatewayapiv1alpha2.HTTPRouteRule `json:"rules,omitempty"`
+	Rules []HTTPRouteRule `json:"rules,omitempty"`
+	// StripPathPrefix rewrites the matched path prefix of the rules to `/` with the URLRewrite filter before the
+	// requests are forwarded to the function, it overrides the `stripPathPrefix` of the Gateway. Only the rules
+	// matching path prefixes are rewritten, and the PathPrefixStripped condition of the route reports it when the
+	// prefix can not be stripped, such as in the Ingress routing mode.
+	//
+	// +optional
+	StripPathPrefix *bool `json:"stripPathPrefix,omitempty"`
+	// VersionRoutes route the requests matching the rules to the servings of other versions of the function,
+	// the other requests are served by the current version. It is only supported by the Knative engine
+	// in the Gateway API routing mode.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	VersionRoutes []VersionRoute `json:"versionRoutes,omitempty"`
+	// Mirror holds the cutover to a new serving of the function. The requests keep being served by the last
+	// successful serving, while a copy of each request is sent to the new serving and its responses are discarded.
+	// The new serving takes over the requests once the mirror is disabled.
+	// The mirrored requests carry the headers of the original requests, and it is only supported by the Knative engine.
+	// In the Gateway API routing mode, the requests are mirrored to the instances of the new serving directly,
+	// so they are dropped while the new serving is scaled to zero.
+	//
+	// +optional
+	Mirror bool `json:"mirror,omitempty"`
+}
+
+// VersionRoute routes the matched requests to a version of the function.
+type VersionRoute struct {
+	// Version is the `spec.version` of the function which serves the matched requests,
+	// the latest running serving of the version is retained as long as it is referred.
+	Version string `json:"version"`
+	// Matches are the header, query parameter and path matchers of the requests,
+	// a matcher without path matches the default path of the function.
+	//
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=8
+	Matches []HTTPRouteMatch `json:"matches"`
 }
 
 // FunctionSpec defines the desired state of Function
@@ -163,12 +199,22 @@ type RouteStatus struct {
 	//
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	Hosts []k8sgatewayapiv1alpha2.Hostname `json:"hosts,omitempty"`
+	Hosts []Hostname `json:"hosts,omitempty"`
 	// Paths list all actual paths of HTTPRoute.
 	//
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
-	Paths []k8sgatewayapiv1alpha2.HTTPPathMatch `json:"paths,omitempty"`
+	Paths []HTTPPathMatch `json:"paths,omitempty"`
+	// UpstreamPaths list the path prefix the function receives for each of the Paths,
+	// it is `/` if the prefix of the path is stripped.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	UpstreamPaths []string `json:"upstreamPaths,omitempty"`
+	// Mirror is the status of the serving which receives the mirrored requests.
+	//
+	// +optional
+	Mirror *MirrorStatus `json:"mirror,omitempty"`
 	// Conditions describes the status of the route with respect to the Gateway.
 	// Note that the route's availability is also subject to the Gateway's own
 	// status conditions and listener status.
@@ -180,6 +226,15 @@ type RouteStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+type MirrorStatus struct {
+	// Serving is the name of the serving which receives the mirrored requests.
+	Serving string `json:"serving"`
+	// Target is the name of the Knative revision which receives the mirrored requests.
+	Target string `json:"target"`
+	// StartTime is the time the requests started to be mirrored to the target.
+	StartTime metav1.Time `json:"startTime"`
+}
+
 type Revision struct {
 	ImageDigest string `json:"imageDigest,omitempty"`
 }
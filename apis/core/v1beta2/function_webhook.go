@@ -18,13 +18,17 @@ package v1beta2
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	shipwrightv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -78,6 +82,14 @@ var (
 	HPAScalingPolicyTypesSlice          = convertMapKeysToStringSlice(HPAScalingPolicyTypes)
 	kedaScaledJobScalingStrategies      = map[string]bool{"default": true, "custom": true, "accurate": true}
 	kedaScaledJobScalingStrategiesSlice = convertMapKeysToStringSlice(kedaScaledJobScalingStrategies)
+	resiliencyRetryPolicies             = map[string]bool{"constant": true, "exponential": true}
+	resiliencyRetryPoliciesSlice        = convertMapKeysToStringSlice(resiliencyRetryPolicies)
+	daprProxyAntiAffinities             = map[string]bool{
+		DaprProxyAntiAffinityPreferred: true,
+		DaprProxyAntiAffinityRequired:  true,
+		DaprProxyAntiAffinityNone:      true,
+	}
+	daprProxyAntiAffinitiesSlice = convertMapKeysToStringSlice(daprProxyAntiAffinities)
 )
 
 // log is for logging in this package.
@@ -105,7 +117,8 @@ func (r *Function) Default() {
 		r.Spec.Serving.Triggers = &Triggers{}
 	}
 
-	if r.Spec.Serving != nil && len(r.Spec.Serving.Triggers.Dapr) == 0 {
+	// The handlers of EventSources which watch the sources by themselves run without http trigger.
+	if r.Spec.Serving != nil && len(r.Spec.Serving.Triggers.Dapr) == 0 && !r.isEventSourceHandler() {
 		if r.Spec.Serving.Triggers.Http == nil {
 			r.Spec.Serving.Triggers.Http = &HttpTrigger{}
 		}
@@ -124,25 +137,31 @@ func (r *Function) Default() {
 			r.Spec.Serving.Triggers.Http.Port = &port
 		}
 
-		namespace := constants.DefaultGatewayNamespace
+		namespace := Namespace(constants.DefaultGatewayNamespace)
 		if r.Spec.Serving.Triggers.Http.Route == nil {
 			route := RouteImpl{
 				CommonRouteSpec: CommonRouteSpec{
 					GatewayRef: &GatewayRef{
-						Name:      constants.DefaultGatewayName,
+						Name:      ObjectName(constants.DefaultGatewayName),
 						Namespace: &namespace,
 					},
 				},
 			}
 			r.Spec.Serving.Triggers.Http.Route = &route
 		} else if r.Spec.Serving.Triggers.Http.Route.GatewayRef == nil {
-			r.Spec.Serving.Triggers.Http.Route.GatewayRef = &GatewayRef{Name: constants.DefaultGatewayName, Namespace: &namespace}
+			r.Spec.Serving.Triggers.Http.Route.GatewayRef = &GatewayRef{Name: ObjectName(constants.DefaultGatewayName), Namespace: &namespace}
 		}
 	}
 
 	r.HandleWorkloadRuntime()
 }
 
+// isEventSourceHandler returns true if the function is a handler controlled by an EventSource.
+func (r *Function) isEventSourceHandler() bool {
+	owner := metav1.GetControllerOf(r)
+	return owner != nil && owner.Kind == "EventSource" && strings.HasPrefix(owner.APIVersion, "events.openfunction.io/")
+}
+
 func (r *Function) HandleWorkloadRuntime() {
 	if r.Annotations == nil {
 		r.Annotations = make(map[string]string)
@@ -403,6 +422,322 @@ func (r *Function) ValidateServing() error {
 		}
 	}
 
+	if err := r.ValidateParamsFrom(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateHttpAuth(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateConcurrency(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateVersionRoutes(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateResiliency(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateComponents(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateDaprProxy(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Function) ValidateHttpAuth() error {
+	if r.Spec.Serving.Triggers == nil || r.Spec.Serving.Triggers.Http == nil || r.Spec.Serving.Triggers.Http.Auth == nil {
+		return nil
+	}
+
+	auth := r.Spec.Serving.Triggers.Http.Auth
+	path := field.NewPath("spec", "serving", "triggers", "http", "auth")
+	if auth.JWT == nil && auth.APIKey == nil && auth.Basic == nil {
+		return field.Required(path, "at least one of jwt, apiKey and basic should be enabled")
+	}
+	if jwt := auth.JWT; jwt != nil {
+		if jwt.Issuer == "" {
+			return field.Required(path.Child("jwt", "issuer"), "must be specified")
+		}
+		if u, err := url.Parse(jwt.JWKSURI); err != nil || u.Scheme != "https" || u.Host == "" {
+			return field.Invalid(path.Child("jwt", "jwksUri"), jwt.JWKSURI, "must be an https URL")
+		}
+	}
+	if apiKey := auth.APIKey; apiKey != nil && apiKey.SecretRef.Name == "" {
+		return field.Required(path.Child("apiKey", "secretRef", "name"), "must be specified")
+	}
+	if basic := auth.Basic; basic != nil && basic.SecretRef.Name == "" {
+		return field.Required(path.Child("basic", "secretRef", "name"), "must be specified")
+	}
+	return nil
+}
+
+func (r *Function) ValidateConcurrency() error {
+	triggers := r.Spec.Serving.Triggers
+	if triggers == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "serving", "triggers")
+	if triggers.MaxConcurrency != nil {
+		if triggers.Http != nil {
+			return field.Forbidden(path.Child("maxConcurrency"), "use `http.maxConcurrency` for the http functions")
+		}
+		if *triggers.MaxConcurrency < 1 {
+			return field.Invalid(path.Child("maxConcurrency"), *triggers.MaxConcurrency, "cannot be less than 1")
+		}
+	}
+	if triggers.Http == nil {
+		return nil
+	}
+
+	path = path.Child("http")
+	if maxConcurrency := triggers.Http.MaxConcurrency; maxConcurrency != nil && *maxConcurrency < 1 {
+		return field.Invalid(path.Child("maxConcurrency"), *maxConcurrency, "cannot be less than 1")
+	}
+	if rateLimit := triggers.Http.RateLimit; rateLimit != nil {
+		path = path.Child("rateLimit")
+		if rateLimit.RequestsPerSecond < 1 {
+			return field.Invalid(path.Child("requestsPerSecond"), rateLimit.RequestsPerSecond, "cannot be less than 1")
+		}
+		if rateLimit.Burst != nil && *rateLimit.Burst < rateLimit.RequestsPerSecond {
+			return field.Invalid(path.Child("burst"), *rateLimit.Burst, "cannot be less than requestsPerSecond")
+		}
+		if key := rateLimit.Key; key != nil && (key.Header == "") == !key.ClientIP {
+			return field.Invalid(path.Child("key"), key, "exactly one of header and clientIP should be set")
+		}
+	}
+	return nil
+}
+
+func (r *Function) ValidateVersionRoutes() error {
+	triggers := r.Spec.Serving.Triggers
+	if triggers == nil || triggers.Http == nil || triggers.Http.Route == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "serving", "triggers", "http", "route", "versionRoutes")
+	for index, versionRoute := range triggers.Http.Route.VersionRoutes {
+		if versionRoute.Version == "" {
+			return field.Required(path.Index(index).Child("version"), "must be specified")
+		}
+		if r.Spec.Version != nil && versionRoute.Version == *r.Spec.Version {
+			return field.Invalid(path.Index(index).Child("version"), versionRoute.Version,
+				"cannot be the current version of the function")
+		}
+		if len(versionRoute.Matches) == 0 {
+			return field.Required(path.Index(index).Child("matches"), "must be specified")
+		}
+	}
+	return nil
+}
+
+func (r *Function) ValidateDaprProxy() error {
+	proxy := r.Spec.Serving.DaprProxy
+	if proxy == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "serving", "daprProxy")
+	if proxy.Replicas != nil && *proxy.Replicas < 1 {
+		return field.Invalid(path.Child("replicas"), *proxy.Replicas, "cannot be less than 1")
+	}
+
+	if _, ok := daprProxyAntiAffinities[proxy.AntiAffinity]; proxy.AntiAffinity != "" && !ok {
+		return field.NotSupported(path.Child("antiAffinity"), proxy.AntiAffinity, daprProxyAntiAffinitiesSlice)
+	}
+
+	if autoscaling := proxy.Autoscaling; autoscaling != nil {
+		if autoscaling.MaxReplicas < 1 {
+			return field.Invalid(path.Child("autoscaling", "maxReplicas"), autoscaling.MaxReplicas, "cannot be less than 1")
+		}
+		if autoscaling.MinReplicas != nil {
+			if *autoscaling.MinReplicas < 1 {
+				return field.Invalid(path.Child("autoscaling", "minReplicas"), *autoscaling.MinReplicas, "cannot be less than 1")
+			}
+			if *autoscaling.MinReplicas > autoscaling.MaxReplicas {
+				return field.Invalid(path.Child("autoscaling", "minReplicas"), *autoscaling.MinReplicas, "cannot be greater than maxReplicas")
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateComponents ensures the type of the secret stores, configuration stores and lock stores
+// matches the building block they are declared in.
+func (r *Function) ValidateComponents() error {
+	buildingBlocks := []struct {
+		name       string
+		prefix     string
+		components map[string]*componentsv1alpha1.ComponentSpec
+	}{
+		{"secretStores", "secretstores.", r.Spec.Serving.SecretStores},
+		{"configurations", "configuration.", r.Spec.Serving.Configurations},
+		{"locks", "lock.", r.Spec.Serving.Locks},
+	}
+
+	for _, bb := range buildingBlocks {
+		for name, component := range bb.components {
+			path := field.NewPath("spec", "serving", bb.name).Key(name)
+			if component == nil || component.Type == "" {
+				return field.Required(path.Child("type"), "must be specified")
+			}
+			if !strings.HasPrefix(component.Type, bb.prefix) {
+				return field.Invalid(path.Child("type"), component.Type,
+					fmt.Sprintf("must start with `%s`", bb.prefix))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Function) ValidateResiliency() error {
+	if triggers := r.Spec.Serving.Triggers; triggers != nil {
+		for index, trigger := range triggers.Dapr {
+			if trigger == nil {
+				continue
+			}
+			path := field.NewPath("spec", "serving", "triggers", "dapr").Index(index).Child("resiliency")
+			if err := validateResiliencyPolicy(path, trigger.Resiliency); err != nil {
+				return err
+			}
+		}
+
+		for index, input := range triggers.Inputs {
+			if input == nil || input.Dapr == nil {
+				continue
+			}
+			path := field.NewPath("spec", "serving", "triggers", "inputs").Index(index).Child("dapr", "resiliency")
+			if err := validateResiliencyPolicy(path, input.Dapr.Resiliency); err != nil {
+				return err
+			}
+		}
+	}
+
+	for index, output := range r.Spec.Serving.Outputs {
+		if output == nil || output.Dapr == nil {
+			continue
+		}
+		path := field.NewPath("spec", "serving", "outputs").Index(index).Child("dapr", "resiliency")
+		if err := validateResiliencyPolicy(path, output.Dapr.Resiliency); err != nil {
+			return err
+		}
+	}
+
+	for name, state := range r.Spec.Serving.States {
+		if state == nil {
+			continue
+		}
+		path := field.NewPath("spec", "serving", "states").Key(name).Child("resiliency")
+		if err := validateResiliencyPolicy(path, state.Resiliency); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateResiliencyPolicy(path *field.Path, policy *ResiliencyPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if err := validateDuration(path.Child("timeout"), policy.Timeout); err != nil {
+		return err
+	}
+
+	if retry := policy.Retry; retry != nil {
+		if _, ok := resiliencyRetryPolicies[retry.Policy]; retry.Policy != "" && !ok {
+			return field.NotSupported(path.Child("retry", "policy"), retry.Policy, resiliencyRetryPoliciesSlice)
+		}
+		if err := validateDuration(path.Child("retry", "duration"), retry.Duration); err != nil {
+			return err
+		}
+		if err := validateDuration(path.Child("retry", "maxInterval"), retry.MaxInterval); err != nil {
+			return err
+		}
+		if retry.MaxRetries < -1 {
+			return field.Invalid(path.Child("retry", "maxRetries"), retry.MaxRetries, "cannot be less than -1")
+		}
+	}
+
+	if circuitBreaker := policy.CircuitBreaker; circuitBreaker != nil {
+		if circuitBreaker.Trip == "" {
+			return field.Required(path.Child("circuitBreaker", "trip"), "must be specified")
+		}
+		if circuitBreaker.MaxRequests < 0 {
+			return field.Invalid(path.Child("circuitBreaker", "maxRequests"), circuitBreaker.MaxRequests, "cannot be less than 0")
+		}
+		if err := validateDuration(path.Child("circuitBreaker", "interval"), circuitBreaker.Interval); err != nil {
+			return err
+		}
+		if err := validateDuration(path.Child("circuitBreaker", "timeout"), circuitBreaker.Timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateDuration(path *field.Path, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if d, err := time.ParseDuration(value); err != nil {
+		return field.Invalid(path, value, err.Error())
+	} else if d < 0 {
+		return field.Invalid(path, value, "cannot be less than 0")
+	}
+
+	return nil
+}
+
+func (r *Function) ValidateParamsFrom() error {
+	for name, source := range r.Spec.Serving.ParamsFrom {
+		path := field.NewPath("spec", "serving", "paramsFrom").Key(name)
+		if _, ok := r.Spec.Serving.Params[name]; ok {
+			return field.Duplicate(path, name)
+		}
+
+		if source == nil || (source.SecretKeyRef == nil) == (source.ConfigMapKeyRef == nil) {
+			return field.Required(path, "exactly one of `secretKeyRef` and `configMapKeyRef` must be specified")
+		}
+
+		if source.SecretKeyRef != nil && (source.SecretKeyRef.Name == "" || source.SecretKeyRef.Key == "") {
+			return field.Required(path.Child("secretKeyRef"), "`name` and `key` must be specified")
+		}
+
+		if source.ConfigMapKeyRef != nil && (source.ConfigMapKeyRef.Name == "" || source.ConfigMapKeyRef.Key == "") {
+			return field.Required(path.Child("configMapKeyRef"), "`name` and `key` must be specified")
+		}
+	}
+
+	for index, source := range r.Spec.Serving.EnvFrom {
+		path := field.NewPath("spec", "serving", "envFrom").Index(index)
+		if (source.SecretRef == nil) == (source.ConfigMapRef == nil) {
+			return field.Required(path, "exactly one of `secretRef` and `configMapRef` must be specified")
+		}
+
+		if source.SecretRef != nil && source.SecretRef.Name == "" {
+			return field.Required(path.Child("secretRef", "name"), "must be specified")
+		}
+
+		if source.ConfigMapRef != nil && source.ConfigMapRef.Name == "" {
+			return field.Required(path.Child("configMapRef", "name"), "must be specified")
+		}
+	}
+
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
@@ -43,6 +44,9 @@ func Test_Validate(t *testing.T) {
 	stabilizationWindowSecondsNegative := int32(-1)
 	stabilizationWindowSecondsLimit := int32(3601)
 	var selectPolicy autoscalingv2.ScalingPolicySelect = "test"
+	burst := int32(20)
+	maxConcurrency := int32(10)
+	version := "v2"
 
 	tests := []struct {
 		name    string
@@ -711,6 +715,429 @@ func Test_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "function.spec.serving.paramsFrom",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						ParamsFrom: map[string]*ParamSource{
+							"PASSWORD": {
+								SecretKeyRef: &v1.SecretKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "secret"},
+									Key:                  "password",
+								},
+							},
+						},
+						EnvFrom: []v1.EnvFromSource{
+							{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "config"}}},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.paramsFrom.duplicate",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						Params:   map[string]string{"PASSWORD": "plain"},
+						ParamsFrom: map[string]*ParamSource{
+							"PASSWORD": {
+								SecretKeyRef: &v1.SecretKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "secret"},
+									Key:                  "password",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.paramsFrom.source",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						ParamsFrom: map[string]*ParamSource{
+							"PASSWORD": {
+								SecretKeyRef: &v1.SecretKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "secret"},
+									Key:                  "password",
+								},
+								ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "config"},
+									Key:                  "password",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.paramsFrom.secretKeyRef.key",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						ParamsFrom: map[string]*ParamSource{
+							"PASSWORD": {
+								SecretKeyRef: &v1.SecretKeySelector{
+									LocalObjectReference: v1.LocalObjectReference{Name: "secret"},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.envFrom.secretRef.name",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						EnvFrom: []v1.EnvFromSource{
+							{SecretRef: &v1.SecretEnvSource{}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.outputs.dapr.resiliency",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						Outputs: []*Output{
+							{
+								Dapr: &DaprOutput{
+									DaprComponentRef: &DaprComponentRef{Name: "kafka"},
+									Resiliency: &ResiliencyPolicy{
+										Timeout:        "5s",
+										Retry:          &RetryPolicy{Policy: "exponential", MaxInterval: "15s", MaxRetries: -1},
+										CircuitBreaker: &CircuitBreakerPolicy{MaxRequests: 1, Timeout: "30s", Trip: "consecutiveFailures >= 5"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.outputs.dapr.resiliency.timeout",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						Outputs: []*Output{
+							{
+								Dapr: &DaprOutput{
+									DaprComponentRef: &DaprComponentRef{Name: "kafka"},
+									Resiliency:       &ResiliencyPolicy{Timeout: "5"},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.outputs.dapr.resiliency.retry.policy",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						Outputs: []*Output{
+							{
+								Dapr: &DaprOutput{
+									DaprComponentRef: &DaprComponentRef{Name: "kafka"},
+									Resiliency:       &ResiliencyPolicy{Retry: &RetryPolicy{Policy: "linear"}},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.states.resiliency.circuitBreaker.trip",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						States: map[string]*State{
+							"redis": {Resiliency: &ResiliencyPolicy{CircuitBreaker: &CircuitBreakerPolicy{MaxRequests: 1}}},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.secretStores",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						SecretStores: map[string]*componentsv1alpha1.ComponentSpec{
+							"vault": {Type: "secretstores.hashicorp.vault", Version: "v1"},
+						},
+						Configurations: map[string]*componentsv1alpha1.ComponentSpec{
+							"config": {Type: "configuration.redis", Version: "v1"},
+						},
+						Locks: map[string]*componentsv1alpha1.ComponentSpec{
+							"lock": {Type: "lock.redis", Version: "v1"},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.locks.type",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						Locks: map[string]*componentsv1alpha1.ComponentSpec{
+							"lock": {Type: "state.redis", Version: "v1"},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.daprProxy",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						DaprProxy: &DaprProxy{
+							Replicas:     &minReplicas,
+							AntiAffinity: DaprProxyAntiAffinityRequired,
+							Autoscaling:  &DaprProxyAutoscaling{MinReplicas: &minReplicas, MaxReplicas: 10},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.daprProxy.antiAffinity",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers:  &Triggers{Http: &HttpTrigger{}},
+						DaprProxy: &DaprProxy{AntiAffinity: "zone"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.daprProxy.autoscaling.minReplicas",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}},
+						DaprProxy: &DaprProxy{
+							Autoscaling: &DaprProxyAutoscaling{MinReplicas: &minReplicas, MaxReplicas: 2},
+						},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.auth",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Auth: &HttpAuth{
+							JWT:    &JWTAuth{Issuer: "https://issuer.example.com", JWKSURI: "https://issuer.example.com/jwks.json"},
+							APIKey: &APIKeyAuth{SecretRef: v1.LocalObjectReference{Name: "api-keys"}},
+							Basic:  &BasicAuth{SecretRef: v1.LocalObjectReference{Name: "users"}},
+						}}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.triggers.http.auth.empty",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Auth: &HttpAuth{}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.auth.jwt.jwksUri",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Auth: &HttpAuth{
+							JWT: &JWTAuth{Issuer: "https://issuer.example.com", JWKSURI: "http://issuer.example.com/jwks.json"},
+						}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.auth.apiKey.secretRef",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Auth: &HttpAuth{APIKey: &APIKeyAuth{}}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.rateLimit",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{
+							RateLimit:      &RateLimit{RequestsPerSecond: 10, Burst: &burst, Key: &RateLimitKey{Header: "x-user"}},
+							MaxConcurrency: &maxConcurrency,
+						}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.triggers.http.rateLimit.burst",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{
+							RateLimit: &RateLimit{RequestsPerSecond: 100, Burst: &burst},
+						}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.rateLimit.key",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{
+							RateLimit: &RateLimit{RequestsPerSecond: 10, Key: &RateLimitKey{Header: "x-user", ClientIP: true}},
+						}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.maxConcurrency",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{}, MaxConcurrency: &maxConcurrency},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.route.versionRoutes",
+			r: Function{
+				Spec: FunctionSpec{
+					Image:   "test",
+					Version: &version,
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Route: &RouteImpl{
+							VersionRoutes: []VersionRoute{{
+								Version: "v1",
+								Matches: []HTTPRouteMatch{{
+									Headers: []HTTPHeaderMatch{{Name: "X-Api-Version", Value: "1"}},
+								}},
+							}},
+						}}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "function.spec.serving.triggers.http.route.versionRoutes.version",
+			r: Function{
+				Spec: FunctionSpec{
+					Image:   "test",
+					Version: &version,
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Route: &RouteImpl{
+							VersionRoutes: []VersionRoute{{
+								Version: version,
+								Matches: []HTTPRouteMatch{{}},
+							}},
+						}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "function.spec.serving.triggers.http.route.versionRoutes.matches",
+			r: Function{
+				Spec: FunctionSpec{
+					Image: "test",
+					Serving: &ServingImpl{
+						Triggers: &Triggers{Http: &HttpTrigger{Route: &RouteImpl{
+							VersionRoutes: []VersionRoute{{Version: "v1"}},
+						}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -723,3 +1150,43 @@ func Test_Validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_DefaultHttpTrigger(t *testing.T) {
+	controller := true
+	tests := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		wantHttp bool
+	}{
+		{
+			name:     "default http trigger",
+			wantHttp: true,
+		},
+		{
+			name: "eventsource handler",
+			owners: []metav1.OwnerReference{
+				{APIVersion: "events.openfunction.io/v1alpha1", Kind: "EventSource", Name: "es", Controller: &controller},
+			},
+		},
+		{
+			name: "not controlled by eventsource",
+			owners: []metav1.OwnerReference{
+				{APIVersion: "events.openfunction.io/v1alpha1", Kind: "EventSource", Name: "es"},
+			},
+			wantHttp: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Function{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: tt.owners},
+				Spec:       FunctionSpec{Image: "test", Serving: &ServingImpl{}},
+			}
+			r.Default()
+			if got := r.Spec.Serving.Triggers.Http != nil; got != tt.wantHttp {
+				t.Errorf("Default() http trigger = %v, want %v", got, tt.wantHttp)
+			}
+		})
+	}
+}
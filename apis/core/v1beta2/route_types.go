@@ -0,0 +1,409 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta2
+
+// The route types below are independent of the Gateway API versions, they share the schema of the fields
+// supported by all the Gateway API versions served by OpenFunction, and are translated into the HTTPRoute
+// of the served version by the controller.
+
+// Hostname is the fully qualified domain name of a network host, a wildcard label `*.` is allowed as the first label.
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=253
+// +kubebuilder:validation:Pattern=`^(\*\.)?[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+type Hostname string
+
+// Group refers to a Kubernetes API group, the empty string refers to the core API group.
+//
+// +kubebuilder:validation:MaxLength=253
+// +kubebuilder:validation:Pattern=`^$|^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+type Group string
+
+// Kind refers to a Kubernetes kind.
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=63
+// +kubebuilder:validation:Pattern=`^[a-zA-Z]([-a-zA-Z0-9]*[a-zA-Z0-9])?$`
+type Kind string
+
+// ObjectName refers to the name of a Kubernetes object.
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=253
+type ObjectName string
+
+// Namespace refers to a Kubernetes namespace.
+//
+// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=63
+type Namespace string
+
+// PortNumber defines a network port.
+//
+// +kubebuilder:validation:Minimum=1
+// +kubebuilder:validation:Maximum=65535
+type PortNumber int32
+
+// HTTPRouteRule defines the matchers, filters and backends of the requests.
+type HTTPRouteRule struct {
+	// Matches define the conditions used for matching the rule against incoming HTTP requests,
+	// a request matches the rule if it matches any of the matchers.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:default={{path:{ type: "PathPrefix", value: "/"}}}
+	Matches []HTTPRouteMatch `json:"matches,omitempty"`
+	// Filters define the filters that are applied to the requests that match this rule.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Filters []HTTPRouteFilter `json:"filters,omitempty"`
+	// BackendRefs defines the backends where the matching requests should be sent,
+	// the function is the backend if it is not set. The backends in other namespaces require ReferenceGrants
+	// created by the owners of those namespaces, the missing ones are reported by the ResolvedRefs condition of the route.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	BackendRefs []HTTPBackendRef `json:"backendRefs,omitempty"`
+}
+
+// PathMatchType specifies the semantics of how HTTP paths should be compared.
+//
+// +kubebuilder:validation:Enum=Exact;PathPrefix;RegularExpression
+type PathMatchType string
+
+const (
+	PathMatchExact             PathMatchType = "Exact"
+	PathMatchPathPrefix        PathMatchType = "PathPrefix"
+	PathMatchRegularExpression PathMatchType = "RegularExpression"
+)
+
+// HTTPPathMatch describes how to select an HTTP route by matching the HTTP request path.
+type HTTPPathMatch struct {
+	// Type specifies how to match against the path Value.
+	//
+	// +optional
+	// +kubebuilder:default=PathPrefix
+	Type *PathMatchType `json:"type,omitempty"`
+	// Value of the HTTP path to match against.
+	//
+	// +optional
+	// +kubebuilder:default="/"
+	// +kubebuilder:validation:MaxLength=1024
+	Value *string `json:"value,omitempty"`
+}
+
+// HeaderMatchType specifies the semantics of how HTTP header values should be compared.
+//
+// +kubebuilder:validation:Enum=Exact;RegularExpression
+type HeaderMatchType string
+
+const (
+	HeaderMatchExact             HeaderMatchType = "Exact"
+	HeaderMatchRegularExpression HeaderMatchType = "RegularExpression"
+)
+
+// HTTPHeaderName is the name of an HTTP header, it is case insensitive.
+//
+// +kubebuilder:validation:MinLength=1
+// +kubebuilder:validation:MaxLength=256
+// +kubebuilder:validation:Pattern=`^[A-Za-z0-9!#$%&'*+\-.^_\x60|~]+$`
+type HTTPHeaderName string
+
+// HTTPHeaderMatch describes how to select an HTTP route by matching HTTP request headers.
+type HTTPHeaderMatch struct {
+	// Type specifies how to match against the value of the header.
+	//
+	// +optional
+	// +kubebuilder:default=Exact
+	Type *HeaderMatchType `json:"type,omitempty"`
+	// Name is the name of the HTTP Header to be matched.
+	Name HTTPHeaderName `json:"name"`
+	// Value is the value of the HTTP Header to be matched.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	Value string `json:"value"`
+}
+
+// QueryParamMatchType specifies the semantics of how HTTP query parameter values should be compared.
+//
+// +kubebuilder:validation:Enum=Exact;RegularExpression
+type QueryParamMatchType string
+
+const (
+	QueryParamMatchExact             QueryParamMatchType = "Exact"
+	QueryParamMatchRegularExpression QueryParamMatchType = "RegularExpression"
+)
+
+// HTTPQueryParamMatch describes how to select an HTTP route by matching HTTP query parameters.
+type HTTPQueryParamMatch struct {
+	// Type specifies how to match against the value of the query parameter.
+	//
+	// +optional
+	// +kubebuilder:default=Exact
+	Type *QueryParamMatchType `json:"type,omitempty"`
+	// Name is the name of the HTTP query param to be matched.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	Name string `json:"name"`
+	// Value is the value of HTTP query param to be matched.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	Value string `json:"value"`
+}
+
+// HTTPMethod describes how to select an HTTP route by matching the HTTP method.
+//
+// +kubebuilder:validation:Enum=GET;HEAD;POST;PUT;DELETE;CONNECT;OPTIONS;TRACE;PATCH
+type HTTPMethod string
+
+// HTTPRouteMatch defines the predicate used to match requests to a given action,
+// all the conditions must be satisfied for a request to match.
+type HTTPRouteMatch struct {
+	// Path specifies a HTTP request path matcher.
+	//
+	// +optional
+	// +kubebuilder:default={type: "PathPrefix", value: "/"}
+	Path *HTTPPathMatch `json:"path,omitempty"`
+	// Headers specifies HTTP request header matchers.
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Headers []HTTPHeaderMatch `json:"headers,omitempty"`
+	// QueryParams specifies HTTP query parameter matchers.
+	//
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	QueryParams []HTTPQueryParamMatch `json:"queryParams,omitempty"`
+	// Method specifies HTTP method matcher.
+	//
+	// +optional
+	Method *HTTPMethod `json:"method,omitempty"`
+}
+
+// HTTPRouteFilterType identifies a type of HTTPRoute filter.
+//
+// +kubebuilder:validation:Enum=RequestHeaderModifier;RequestMirror;RequestRedirect;URLRewrite;ExtensionRef
+type HTTPRouteFilterType string
+
+const (
+	HTTPRouteFilterRequestHeaderModifier HTTPRouteFilterType = "RequestHeaderModifier"
+	HTTPRouteFilterRequestRedirect       HTTPRouteFilterType = "RequestRedirect"
+	HTTPRouteFilterRequestMirror         HTTPRouteFilterType = "RequestMirror"
+	HTTPRouteFilterURLRewrite            HTTPRouteFilterType = "URLRewrite"
+	HTTPRouteFilterExtensionRef          HTTPRouteFilterType = "ExtensionRef"
+)
+
+// HTTPRouteFilter defines processing steps that must be completed during the request or response lifecycle,
+// exactly the field matching the Type must be set.
+type HTTPRouteFilter struct {
+	// Type identifies the type of filter to apply.
+	Type HTTPRouteFilterType `json:"type"`
+	// RequestHeaderModifier defines a schema for a filter that modifies request headers.
+	//
+	// +optional
+	RequestHeaderModifier *HTTPRequestHeaderFilter `json:"requestHeaderModifier,omitempty"`
+	// RequestMirror defines a schema for a filter that mirrors requests.
+	// Requests are sent to the specified destination, but responses from that destination are ignored.
+	//
+	// +optional
+	RequestMirror *HTTPRequestMirrorFilter `json:"requestMirror,omitempty"`
+	// RequestRedirect defines a schema for a filter that responds to the request with an HTTP redirection.
+	//
+	// +optional
+	RequestRedirect *HTTPRequestRedirectFilter `json:"requestRedirect,omitempty"`
+	// URLRewrite defines a schema for a filter that modifies a request during forwarding.
+	//
+	// +optional
+	URLRewrite *HTTPURLRewriteFilter `json:"urlRewrite,omitempty"`
+	// ExtensionRef is an optional, implementation-specific extension to the "filter" behavior.
+	//
+	// +optional
+	ExtensionRef *LocalObjectReference `json:"extensionRef,omitempty"`
+}
+
+// HTTPHeader represents an HTTP Header name and value.
+type HTTPHeader struct {
+	// Name is the name of the HTTP Header to be matched.
+	Name HTTPHeaderName `json:"name"`
+	// Value is the value of HTTP Header to be matched.
+	//
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	Value string `json:"value"`
+}
+
+// HTTPRequestHeaderFilter defines configuration for the RequestHeaderModifier filter.
+type HTTPRequestHeaderFilter struct {
+	// Set overwrites the request with the given header (name, value) before the action.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	Set []HTTPHeader `json:"set,omitempty"`
+	// Add adds the given header(s) (name, value) to the request before the action.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=16
+	Add []HTTPHeader `json:"add,omitempty"`
+	// Remove the given header(s) from the HTTP request before the action.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Remove []string `json:"remove,omitempty"`
+}
+
+// HTTPRequestRedirectFilter defines a filter that redirects a request.
+type HTTPRequestRedirectFilter struct {
+	// Scheme is the scheme to be used in the value of the `Location` header in the response.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=http;https
+	Scheme *string `json:"scheme,omitempty"`
+	// Hostname is the hostname to be used in the value of the `Location` header in the response.
+	//
+	// +optional
+	Hostname *Hostname `json:"hostname,omitempty"`
+	// Port is the port to be used in the value of the `Location` header in the response.
+	//
+	// +optional
+	Port *PortNumber `json:"port,omitempty"`
+	// StatusCode is the HTTP status code to be used in response.
+	//
+	// +optional
+	// +kubebuilder:default=302
+	// +kubebuilder:validation:Enum=301;302
+	StatusCode *int `json:"statusCode,omitempty"`
+}
+
+// HTTPURLRewriteFilter defines a filter that modifies a request during forwarding.
+type HTTPURLRewriteFilter struct {
+	// Hostname is the value to be used to replace the Host header value during forwarding.
+	//
+	// +optional
+	Hostname *Hostname `json:"hostname,omitempty"`
+	// Path defines a path rewrite.
+	//
+	// +optional
+	Path *HTTPPathModifier `json:"path,omitempty"`
+}
+
+// HTTPPathModifierType defines the type of path redirect or rewrite.
+//
+// +kubebuilder:validation:Enum=ReplaceFullPath;ReplacePrefixMatch
+type HTTPPathModifierType string
+
+const (
+	FullPathHTTPPathModifier    HTTPPathModifierType = "ReplaceFullPath"
+	PrefixMatchHTTPPathModifier HTTPPathModifierType = "ReplacePrefixMatch"
+)
+
+// HTTPPathModifier defines configuration for path modifiers.
+type HTTPPathModifier struct {
+	// Type defines the type of path modifier.
+	Type HTTPPathModifierType `json:"type"`
+	// ReplaceFullPath specifies the value with which to replace the full path of a request during a rewrite.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	ReplaceFullPath *string `json:"replaceFullPath,omitempty"`
+	// ReplacePrefixMatch specifies the value with which to replace the prefix match of a request during a rewrite.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	ReplacePrefixMatch *string `json:"replacePrefixMatch,omitempty"`
+}
+
+// HTTPRequestMirrorFilter defines configuration for the RequestMirror filter.
+type HTTPRequestMirrorFilter struct {
+	// BackendRef references a resource where mirrored requests are sent.
+	BackendRef BackendObjectReference `json:"backendRef"`
+}
+
+// HTTPBackendRef defines how a HTTPRoute should forward an HTTP request.
+type HTTPBackendRef struct {
+	// BackendRef is a reference to a backend to forward matched requests to.
+	//
+	// +optional
+	BackendRef `json:",inline"`
+	// Filters defined at this level should be executed if and only if the request is being forwarded
+	// to the backend defined here.
+	//
+	// +optional
+	// +kubebuilder:validation:MaxItems=16
+	Filters []HTTPRouteFilter `json:"filters,omitempty"`
+}
+
+// BackendRef defines how a Route should forward a request to a Kubernetes resource.
+// A ReferenceGrant is required in the namespace of the backend if it is not in the namespace of the function,
+// it is not created by the controller.
+type BackendRef struct {
+	// BackendObjectReference references a Kubernetes object.
+	BackendObjectReference `json:",inline"`
+	// Weight specifies the proportion of requests forwarded to the referenced backend.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000000
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// BackendObjectReference defines how an ObjectReference that is specific to BackendRef.
+type BackendObjectReference struct {
+	// Group is the group of the referent, the core API group is inferred if it is empty.
+	//
+	// +optional
+	// +kubebuilder:default=""
+	Group *Group `json:"group,omitempty"`
+	// Kind is kind of the referent.
+	//
+	// +optional
+	// +kubebuilder:default=Service
+	Kind *Kind `json:"kind,omitempty"`
+	// Name is the name of the referent.
+	Name ObjectName `json:"name"`
+	// Namespace is the namespace of the backend, the namespace of the function is inferred if it is not set.
+	//
+	// +optional
+	Namespace *Namespace `json:"namespace,omitempty"`
+	// Port specifies the destination port number to use for this resource.
+	//
+	// +optional
+	Port *PortNumber `json:"port,omitempty"`
+}
+
+// LocalObjectReference identifies an API object within the namespace of the referrer.
+type LocalObjectReference struct {
+	// Group is the group of the referent, the core API group is inferred if it is empty.
+	Group Group `json:"group"`
+	// Kind is kind of the referent.
+	Kind Kind `json:"kind"`
+	// Name is the name of the referent.
+	Name ObjectName `json:"name"`
+}
@@ -19,8 +19,10 @@ package v1beta2
 import (
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -32,6 +34,9 @@ const (
 	HookPolicyAppend   = "Append"
 	HookPolicyOverride = "Override"
 
+	DaprBindings = "bindings"
+	DaprPubsub   = "pubsub"
+
 	WorkloadTypeJob                = "Job"
 	WorkloadTypeStatefulSet        = "StatefulSet"
 	WorkloadTypeDeployment         = "Deployment"
@@ -43,6 +48,10 @@ type Triggers struct {
 	Http   *HttpTrigger   `json:"http,omitempty"`
 	Dapr   []*DaprTrigger `json:"dapr,omitempty"`
 	Inputs []*Input       `json:"inputs,omitempty"`
+	// MaxConcurrency is the maximum number of events delivered concurrently by Dapr to an instance of an async function.
+	// Use `http.maxConcurrency` for the http functions.
+	// +optional
+	MaxConcurrency *int32 `json:"maxConcurrency,omitempty"`
 }
 
 type HttpTrigger struct {
@@ -56,10 +65,94 @@ type HttpTrigger struct {
 	// Http function runtime engine, can be set to knative or keda, default to knative if not set
 	// +optional
 	Engine *Engine `json:"engine,omitempty"`
+	// Auth defines how the requests to the function are authenticated at the gateway.
+	// All the configured methods must succeed for a request to be forwarded to the function.
+	// +optional
+	Auth *HttpAuth `json:"auth,omitempty"`
+	// RateLimit limits the rate of the requests to the function at the gateway.
+	// +optional
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	// MaxConcurrency is the maximum number of requests processed concurrently by an instance of the function.
+	// It is the container concurrency of Knative, the target pending requests per instance of KEDA if
+	// `scaleOptions.keda.httpScaledObject.targetPendingRequests` is not set, and also limits the events
+	// delivered concurrently by Dapr. The requests are not limited with KEDA, which is reported by the
+	// ConcurrencyLimited condition of the route.
+	// +optional
+	MaxConcurrency *int32 `json:"maxConcurrency,omitempty"`
+}
+
+type RateLimit struct {
+	// RequestsPerSecond is the number of requests allowed per second.
+	RequestsPerSecond int32 `json:"requestsPerSecond"`
+	// Burst is the number of requests allowed to exceed the rate at once, default to requestsPerSecond.
+	// It is ignored by Envoy Gateway, which counts the requests in fixed windows, and this is reported by the
+	// RateLimited condition of the route.
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+	// Key separates the requests into buckets which are limited independently,
+	// all the requests share one bucket if it is not set.
+	// In the Ingress routing mode, the requests are always limited per client IP. In the GatewayAPI routing mode,
+	// the keyed rate limit requires the rate limit service of Envoy Gateway to be enabled.
+	// +optional
+	Key *RateLimitKey `json:"key,omitempty"`
+}
+
+// RateLimitKey defines how to separate the requests, exactly one of the fields should be set.
+type RateLimitKey struct {
+	// Header limits the requests with different values of the header independently.
+	// +optional
+	Header string `json:"header,omitempty"`
+	// ClientIP limits the requests from different client IPs independently.
+	// +optional
+	ClientIP bool `json:"clientIP,omitempty"`
+}
+
+// HttpAuth defines the authentication methods of an http function, it is enforced by the SecurityPolicy of Envoy Gateway.
+// The route of the function is not exposed if the gateway is not served by Envoy Gateway, and it serves no request
+// until the policy is accepted. The internal address of the function is served by the gateway as well, so the
+// in-cluster callers such as the event sinks must authenticate too.
+type HttpAuth struct {
+	// JWT validates the bearer token of the requests.
+	// +optional
+	JWT *JWTAuth `json:"jwt,omitempty"`
+	// APIKey validates the API key carried in a header of the requests.
+	// +optional
+	APIKey *APIKeyAuth `json:"apiKey,omitempty"`
+	// Basic validates the credentials of the HTTP basic authentication.
+	// +optional
+	Basic *BasicAuth `json:"basic,omitempty"`
+}
+
+type JWTAuth struct {
+	// Issuer is the expected `iss` claim of the tokens.
+	Issuer string `json:"issuer"`
+	// JWKSURI is the HTTPS URI to fetch the JSON Web Key Set which verifies the tokens.
+	JWKSURI string `json:"jwksUri"`
+	// Audiences are the accepted `aud` claims of the tokens, any audience is accepted if not set.
+	// +optional
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+type APIKeyAuth struct {
+	// SecretRef refers to a Secret in the namespace of the function,
+	// each key of the Secret is a client ID and the value is the API key of the client.
+	SecretRef v1.LocalObjectReference `json:"secretRef"`
+	// Header is the name of the header carrying the API key, default to `x-api-key`.
+	// +optional
+	Header string `json:"header,omitempty"`
+}
+
+type BasicAuth struct {
+	// SecretRef refers to a Secret in the namespace of the function,
+	// which stores the users in the htpasswd format under the `.htpasswd` key.
+	SecretRef v1.LocalObjectReference `json:"secretRef"`
 }
 
 type DaprTrigger struct {
 	*DaprComponentRef `json:",inline"`
+	// Resiliency defines the inbound resiliency policies of the component.
+	// +optional
+	Resiliency *ResiliencyPolicy `json:"resiliency,omitempty"`
 	// Deprecated: Only for compatibility with v1beta1
 	InputName string `json:"inputName,omitempty"`
 }
@@ -75,6 +168,9 @@ type DaprComponentRef struct {
 
 type DaprInput struct {
 	*DaprComponentRef `json:",inline"`
+	// Resiliency defines the inbound resiliency policies of the component.
+	// +optional
+	Resiliency *ResiliencyPolicy `json:"resiliency,omitempty"`
 }
 
 type Input struct {
@@ -89,6 +185,9 @@ type DaprOutput struct {
 	// Operation field tells the Dapr component which operation it should perform.
 	// +optional
 	Operation string `json:"operation,omitempty"`
+	// Resiliency defines the outbound resiliency policies of the component.
+	// +optional
+	Resiliency *ResiliencyPolicy `json:"resiliency,omitempty"`
 	// Deprecated: Only for compatibility with v1beta1
 	OutputName string `json:"outputName,omitempty"`
 }
@@ -99,6 +198,53 @@ type Output struct {
 
 type State struct {
 	Spec *componentsv1alpha1.ComponentSpec `json:"spec,omitempty"`
+	// Resiliency defines the outbound resiliency policies of the state store.
+	// +optional
+	Resiliency *ResiliencyPolicy `json:"resiliency,omitempty"`
+}
+
+// ResiliencyPolicy defines the Dapr resiliency policies applied to a component.
+// Refer to https://docs.dapr.io/operations/resiliency/policies/ to learn more.
+type ResiliencyPolicy struct {
+	// Timeout of a single operation, in the format of a duration like `5s`.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+	// Retry defines how failed operations are retried.
+	// +optional
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// CircuitBreaker defines when the calls to the component should be stopped.
+	// +optional
+	CircuitBreaker *CircuitBreakerPolicy `json:"circuitBreaker,omitempty"`
+}
+
+type RetryPolicy struct {
+	// Policy is the back-off policy, known values are `constant` and `exponential`, default to `constant`.
+	// +optional
+	Policy string `json:"policy,omitempty"`
+	// Duration is the interval between retries when using the `constant` policy.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+	// MaxInterval is the maximum interval between retries when using the `exponential` policy.
+	// +optional
+	MaxInterval string `json:"maxInterval,omitempty"`
+	// MaxRetries is the maximum number of retries, `-1` means retrying indefinitely.
+	// +optional
+	MaxRetries int `json:"maxRetries,omitempty"`
+}
+
+type CircuitBreakerPolicy struct {
+	// MaxRequests is the maximum number of requests allowed to pass through when the circuit breaker is half-open.
+	// +optional
+	MaxRequests int `json:"maxRequests,omitempty"`
+	// Interval is the cyclical period of time used by the circuit breaker to clear its internal counts.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// Timeout is the period of the open state, after which the circuit breaker switches to half-open.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+	// Trip is a Common Expression Language (CEL) statement evaluated by the circuit breaker,
+	// such as `consecutiveFailures > 5`, the circuit breaker trips open when it evaluates to true.
+	Trip string `json:"trip"`
 }
 
 type HTTPScaledObject struct {
@@ -161,6 +307,45 @@ type ScaleOptions struct {
 	Knative *map[string]string `json:"knative,omitempty"`
 }
 
+const (
+	DaprProxyAntiAffinityPreferred = "preferred"
+	DaprProxyAntiAffinityRequired  = "required"
+	DaprProxyAntiAffinityNone      = "none"
+)
+
+type DaprProxy struct {
+	// Number of the dapr-proxy replicas, default to 1.
+	// It is the initial number of replicas when `autoscaling` is enabled.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// AntiAffinity spreads the dapr-proxy replicas across nodes,
+	// one of `preferred`, `required` or `none`, default to `preferred`.
+	// +optional
+	AntiAffinity string `json:"antiAffinity,omitempty"`
+	// MinAvailable of the PodDisruptionBudget of the dapr-proxy.
+	// A PodDisruptionBudget is created when the dapr-proxy can have more than one replica,
+	// and allows one replica to be unavailable if `minAvailable` is not set.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// Autoscaling creates a HorizontalPodAutoscaler for the dapr-proxy.
+	// +optional
+	Autoscaling *DaprProxyAutoscaling `json:"autoscaling,omitempty"`
+	// Compute resources of the dapr-proxy container.
+	// +optional
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+type DaprProxyAutoscaling struct {
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	MaxReplicas int32  `json:"maxReplicas"`
+	// Metrics used to calculate the desired replicas, default to 80% average CPU utilization.
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+	// +optional
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
 type Hooks struct {
 	Pre    []string `json:"pre,omitempty"`
 	Post   []string `json:"post,omitempty"`
@@ -189,6 +374,17 @@ type Exporter struct {
 	Protocol    string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
 }
 
+// ParamSource represents the source of a parameter's value.
+// Exactly one of its fields must be set.
+type ParamSource struct {
+	// Selects a key of a Secret in the function's namespace.
+	// +optional
+	SecretKeyRef *v1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// Selects a key of a ConfigMap in the function's namespace.
+	// +optional
+	ConfigMapKeyRef *v1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
 type ServingImpl struct {
 	// Triggers used to trigger the Function.
 	// +optional
@@ -209,10 +405,33 @@ type ServingImpl struct {
 	// It can refer to an existing state when the `state.spec` is nil.
 	// +optional
 	States map[string]*State `json:"states,omitempty"`
+	// Configurations of dapr secret store components.
+	// +optional
+	SecretStores map[string]*componentsv1alpha1.ComponentSpec `json:"secretStores,omitempty"`
+	// Configurations of dapr configuration store components.
+	// +optional
+	Configurations map[string]*componentsv1alpha1.ComponentSpec `json:"configurations,omitempty"`
+	// Configurations of dapr distributed lock components.
+	// +optional
+	Locks map[string]*componentsv1alpha1.ComponentSpec `json:"locks,omitempty"`
+	// Configurations of the dapr-proxy, it only takes effect when the dapr service mode is `standalone`.
+	// +optional
+	DaprProxy *DaprProxy `json:"daprProxy,omitempty"`
 	// Parameters to pass to the serving.
 	// All parameters will be injected into the pod as environment variables.
 	// Function code can use these parameters by getting environment variables
 	Params map[string]string `json:"params,omitempty"`
+	// Parameters whose values are sourced from a key of a Secret or ConfigMap.
+	// They will be injected into the pod as environment variables like `params`,
+	// changes of the referenced keys of the Secrets and ConfigMaps labelled
+	// `openfunction.io/params-source=true` will trigger a rollout of the serving.
+	// +optional
+	ParamsFrom map[string]*ParamSource `json:"paramsFrom,omitempty"`
+	// List of Secrets or ConfigMaps whose data will all be injected into the pod as environment variables.
+	// Changes of the data of the ones labelled `openfunction.io/params-source=true`
+	// will trigger a rollout of the serving.
+	// +optional
+	EnvFrom []v1.EnvFromSource `json:"envFrom,omitempty"`
 	// Parameters of asyncFunc runtime, must not be nil when runtime is OpenFuncAsync.
 	Labels map[string]string `json:"labels,omitempty"`
 	// Annotations that will be added to the workload.
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_AddCondition(t *testing.T) {
+	es := &EventSource{}
+
+	for i := 0; i < 3; i++ {
+		es.AddCondition(*CreateCondition(Pending, metav1.ConditionUnknown, PendingCreation))
+		es.AddCondition(*CreateCondition(Created, metav1.ConditionTrue, EventSourceFunctionCreated))
+		es.AddCondition(*CreateCondition(Ready, metav1.ConditionTrue, EventSourceIsReady))
+	}
+	if len(es.Status.Conditions) != 2 {
+		t.Errorf("AddCondition() conditions = %v, want one Created and one Ready condition", es.Status.Conditions)
+	}
+
+	es.AddCondition(*CreateCondition(Error, metav1.ConditionFalse, EventsNotReady).SetMessage("kafka/a: failed"))
+	ready := es.GetCondition(Ready)
+	if ready == nil || ready.Status != metav1.ConditionFalse || ready.Reason != EventsNotReady {
+		t.Errorf("AddCondition() ready = %v, want not ready with reason %s", ready, EventsNotReady)
+	}
+	if es.GetCondition(Error) == nil {
+		t.Errorf("AddCondition() error condition not found")
+	}
+
+	es.AddCondition(*CreateCondition(Ready, metav1.ConditionTrue, EventSourceIsReady))
+	if es.GetCondition(Error) != nil {
+		t.Errorf("AddCondition() error condition is not cleared by the Ready condition")
+	}
+	if len(es.Status.Conditions) != 2 {
+		t.Errorf("AddCondition() conditions = %v, want one Created and one Ready condition", es.Status.Conditions)
+	}
+}
@@ -46,6 +46,17 @@ type EventSourceSpec struct {
 	// Mqtt event source, the Key is used to refer to the name of the event
 	// +optional
 	Mqtt map[string]*MQTTSpec `json:"mqtt,omitempty"`
+	// RabbitMQ event source, the Key is used to refer to the name of the event
+	// +optional
+	RabbitMQ map[string]*RabbitMQSpec `json:"rabbitmq,omitempty"`
+	// Kubernetes event source which watches the changes of Kubernetes objects,
+	// the Key is used to refer to the name of the event
+	// +optional
+	Kubernetes map[string]*KubernetesSpec `json:"kubernetes,omitempty"`
+	// S3 event source which watches the objects in a bucket of an S3 compatible object storage,
+	// the Key is used to refer to the name of the event
+	// +optional
+	S3 map[string]*S3Spec `json:"s3,omitempty"`
 	// Sink is a callable address, such as Knative Service
 	// +optional
 	Sink *SinkSpec `json:"sink,omitempty"`
@@ -57,12 +68,28 @@ type EventSourceSpec struct {
 	LogLevel *string `json:"logLevel,omitempty"`
 }
 
-// SinkSpec specifies the receiver of the events an EventSource received, sinks in the Uri format have higher priority than sinks in Reference format.
+// SinkSpec specifies the receiver of the events an EventSource received, sinks in the Uri format have higher priority than sinks in Reference format,
+// which have higher priority than sinks in Selector format.
 type SinkSpec struct {
 	Ref *Reference `json:"ref,omitempty"`
 	Uri *string    `json:"uri,omitempty"`
+	// Selector fans out the events to all the Functions it selects, the set of Functions is updated
+	// when Functions are added or removed. It is only supported by the sink of Trigger subscribers,
+	// the event is sent to the dead letter sink once for each selected Function it fails to be delivered to.
+	// +optional
+	Selector *SinkSelector `json:"selector,omitempty"`
+}
+
+// SinkSelector selects Functions by labels.
+type SinkSelector struct {
+	// Namespace of the Functions, default to the namespace of the Trigger.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// LabelSelector selects the Functions by labels.
+	LabelSelector *metav1.LabelSelector `json:"labelSelector"`
 }
 
+// Reference refers to a Knative Service, a Function, a Serving or a Service.
 type Reference struct {
 	// Kind of the referent.
 	Kind string `json:"kind"`
@@ -81,9 +108,36 @@ func (ref Reference) GroupVersionKind() schema.GroupVersionKind {
 
 // EventSourceStatus defines the observed state of EventSource
 type EventSourceStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the generation of the EventSource observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions holds the latest condition of each type, the `Ready` condition reports the overall readiness.
 	Conditions []Condition `json:"conditions,omitempty" description:"List of auditable conditions of EventSource"`
+	// Events holds the status of each event declared in the EventSource.
+	// +optional
+	Events []EventStatus `json:"events,omitempty"`
+}
+
+// EventStatus defines the observed state of an event declared in the EventSource.
+type EventStatus struct {
+	// Name of the event.
+	Name string `json:"name"`
+	// Kind of the event source, such as `kafka` or `cron`.
+	Kind string `json:"kind"`
+	// Ready indicates whether the event is being delivered, one of True, False, Unknown.
+	Ready metav1.ConditionStatus `json:"ready"`
+	// The reason for the readiness of the event.
+	// +optional
+	Reason ConditionReason `json:"reason,omitempty"`
+	// A human readable message indicating details about the readiness of the event.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Component is the name of the Dapr component generated for the event.
+	// +optional
+	Component string `json:"component,omitempty"`
+	// Function is the name of the Function which handles the event.
+	// +optional
+	Function string `json:"function,omitempty"`
 }
 
 //+genclient
@@ -92,7 +146,8 @@ type EventSourceStatus struct {
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="EventBus",type=string,JSONPath=`.spec.eventBus`
 //+kubebuilder:printcolumn:name="Sink",type=string,JSONPath=`.spec.sink.uri`
-//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[-1].type"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
 
 // EventSource is the Schema for the eventsources API
 type EventSource struct {
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,6 +35,18 @@ type TriggerSpec struct {
 	Inputs map[string]*Input `json:"inputs"`
 	// Subscribers defines the subscribers associated with the Trigger
 	Subscribers []*Subscriber `json:"subscribers"`
+	// Correlation groups the events of the inputs by a key, so that the conditions of the subscribers
+	// are evaluated on the events sharing the same key, e.g. `A && B` fires when A and B of the same order arrive.
+	// +optional
+	Correlation *CorrelationSpec `json:"correlation,omitempty"`
+	// Window accumulates the events of each input before the conditions of the subscribers are evaluated,
+	// it is applied to each correlation key if correlation is set.
+	// +optional
+	Window *WindowSpec `json:"window,omitempty"`
+	// StateStore keeps the pending events of the correlations and windows, it must be set if correlation or window is set.
+	// The trigger handler runs a single replica if correlation or window is set.
+	// +optional
+	StateStore *StateStoreSpec `json:"stateStore,omitempty"`
 	// The logging level of the event source handler, e.g. "1", "2", "3".
 	// The level increases as the value increases, default is "1".
 	// +optional
@@ -50,7 +63,14 @@ type Input struct {
 }
 
 type Subscriber struct {
-	// Condition for judging events
+	// Condition for judging events, it is a CEL expression which must evaluate to a bool.
+	// The names of the inputs can be used as bool variables indicating whether their events have been received
+	// in the last minute, `inputs` holds these variables of all inputs, including the inputs whose names are not CEL identifiers,
+	// `event` holds the CloudEvent attributes of the current event and `events` holds those of the last event
+	// of each input received in the last minute, e.g. `A && B`, `inputs["input-a"]`, `event.type == "order.created"`
+	// or `events.A.source == "orders"`. If correlation is set, only the events sharing the correlation key of the current
+	// event are considered instead of those received in the last minute. The conditions of the existing subscribers
+	// are kept on update even if they no longer compile. Each subscriber must have a different condition.
 	Condition string `json:"condition"`
 	// Sink and DeadLetterSink are used to handle subscribers who use the synchronous call method
 	Sink           *SinkSpec `json:"sink,omitempty"`
@@ -58,21 +78,158 @@ type Subscriber struct {
 	// Topic and DeadLetterTopic are used to handle subscribers who use the asynchronous call method
 	Topic           string `json:"topic,omitempty"`
 	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
+	// Transform reshapes the event before it is delivered to the sink or topic.
+	// +optional
+	Transform *Transform `json:"transform,omitempty"`
+	// Delivery defines how failed deliveries to the sink and the topic are retried.
+	// The subscribers publishing to the same topic must have the same delivery timeout.
+	// +optional
+	Delivery *DeliverySpec `json:"delivery,omitempty"`
+}
+
+const (
+	// WindowTypeDebounce only evaluates the last event of a burst, once no event arrives during the window duration.
+	WindowTypeDebounce = "debounce"
+	// WindowTypeBatch evaluates the events in batches, a batch is closed once it holds `size` events
+	// or the window duration has elapsed since its first event.
+	WindowTypeBatch = "batch"
+)
+
+// CorrelationSpec defines how to correlate the events of the inputs.
+type CorrelationSpec struct {
+	// Key is a CEL expression evaluated to the correlation key of an event, it must evaluate to a string
+	// and can use the `event` variable, e.g. `event.data.orderId` or `event.source`.
+	Key string `json:"key"`
+	// InputKeys overrides Key for the inputs whose events carry the correlation key in a different place,
+	// the key of the map is the name of the input.
+	// +optional
+	InputKeys map[string]string `json:"inputKeys,omitempty"`
+	// TTL is how long the events of a correlation key are kept since the first of them arrived,
+	// in the format of a duration like `5m`. The events are discarded once it expires or a subscriber is triggered by them.
+	TTL string `json:"ttl"`
+}
+
+// WindowSpec defines how to accumulate events.
+type WindowSpec struct {
+	// Type of the window, known values are `debounce` and `batch`.
+	Type string `json:"type"`
+	// Duration of the window, in the format of a duration like `10s`. It is the quiet period of the `debounce` window,
+	// and the maximum age of a batch of the `batch` window.
+	// +optional
+	Duration string `json:"duration,omitempty"`
+	// Size is the number of events of a batch, only used by the `batch` window.
+	// The event evaluated for a batch has the attributes of the last event of the batch,
+	// and its data is the list of the events in the batch.
+	// +optional
+	Size *int32 `json:"size,omitempty"`
+}
+
+// StateStoreSpec defines the Dapr state store used by the trigger handler, either an existing one or a new one.
+type StateStoreSpec struct {
+	// Name of an existing Dapr state store component in the namespace of the Trigger, used if Spec is not set.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Spec of the Dapr state store component to be created for the trigger handler.
+	// +optional
+	Spec *componentsv1alpha1.ComponentSpec `json:"spec,omitempty"`
+}
+
+// DeliverySpec defines the retry policy of event deliveries.
+// The deliveries are retried by the trigger handler, the timeout is also applied to the Dapr resiliency policy
+// of the sink and topic outputs.
+type DeliverySpec struct {
+	// MaxRetries is the maximum number of retries after the first failed delivery, no retry is performed if not set.
+	// Events which still fail to be delivered are sent to the dead letter sink or topic.
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+	// BackoffPolicy is the policy to compute the delay between retries, known values are `constant`, `linear`
+	// and `exponential`, default to `exponential`. The n-th retry is delayed by `backoffDelay` with the `constant`
+	// policy, by n times `backoffDelay` with the `linear` policy and by 2^(n-1) times `backoffDelay`
+	// with the `exponential` policy.
+	// +optional
+	BackoffPolicy string `json:"backoffPolicy,omitempty"`
+	// BackoffDelay is the base delay between retries, in the format of a duration like `1s`, default to `1s`.
+	// +optional
+	BackoffDelay string `json:"backoffDelay,omitempty"`
+	// MaxBackoffDelay is the upper bound of the delay between retries of the `linear` and `exponential` policies,
+	// in the format of a duration like `1m`.
+	// +optional
+	MaxBackoffDelay string `json:"maxBackoffDelay,omitempty"`
+	// Timeout of a single delivery attempt, in the format of a duration like `10s`.
+	// +optional
+	Timeout string `json:"timeout,omitempty"`
+	// RetryableStatusCodes lists the HTTP status codes of the sink which are retried, e.g. `[429, 502, 503]`.
+	// The failures with other status codes are not retried, the failures without a status code,
+	// such as connection errors and timeouts, are always retried. All failures are retried if not set.
+	// It requires the sink to be set and only applies to it.
+	// +optional
+	RetryableStatusCodes []int32 `json:"retryableStatusCodes,omitempty"`
+}
+
+const (
+	BackoffPolicyConstant    = "constant"
+	BackoffPolicyLinear      = "linear"
+	BackoffPolicyExponential = "exponential"
+)
+
+// Transform defines how to reshape an event with CEL expressions, which can use the same variables as the `condition`.
+// `event.data` holds the payload of the current event.
+type Transform struct {
+	// Data is a CEL expression evaluated to the new payload of the event, which is encoded in JSON,
+	// e.g. `{"id": event.data.orderId, "source": event.source}`.
+	// +optional
+	Data string `json:"data,omitempty"`
+	// Attributes sets the CloudEvent attributes or extensions of the event,
+	// each value is a CEL expression which must evaluate to a string, e.g. `type: '"order.shipped"'`.
+	// +optional
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Remove lists the extension attributes to be removed from the event,
+	// an attribute can be renamed by setting the new one in `attributes` and removing the old one.
+	// +optional
+	Remove []string `json:"remove,omitempty"`
 }
 
 // TriggerStatus defines the observed state of Trigger
 type TriggerStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
-
+	// ObservedGeneration is the generation of the Trigger observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions holds the latest condition of each type, the `Ready` condition reports the overall readiness.
 	Conditions []Condition `json:"conditions,omitempty" description:"List of auditable conditions of Trigger"`
+	// Inputs holds the status of each input declared in the Trigger.
+	// +optional
+	Inputs []InputStatus `json:"inputs,omitempty"`
+	// Component is the name of the Dapr component generated for the event bus.
+	// +optional
+	Component string `json:"component,omitempty"`
+	// Function is the name of the Function which handles the events.
+	// +optional
+	Function string `json:"function,omitempty"`
+}
+
+// InputStatus defines the observed state of an input declared in the Trigger.
+type InputStatus struct {
+	// Name of the input.
+	Name string `json:"name"`
+	// Topic of the event bus subscribed by the input.
+	// +optional
+	Topic string `json:"topic,omitempty"`
+	// Ready indicates whether the event of the input is being delivered, one of True, False, Unknown.
+	Ready metav1.ConditionStatus `json:"ready"`
+	// The reason for the readiness of the input.
+	// +optional
+	Reason ConditionReason `json:"reason,omitempty"`
+	// A human readable message indicating details about the readiness of the input.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 //+genclient
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="EventBus",type=string,JSONPath=`.spec.eventBus`
-//+kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.conditions[-1].type"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Reason",type="string",JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
 
 // Trigger is the Schema for the triggers API
 type Trigger struct {
@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/openfunction/pkg/event/condition"
+)
+
+var (
+	deliveryBackoffPolicies = []string{BackoffPolicyConstant, BackoffPolicyLinear, BackoffPolicyExponential}
+	windowTypes             = []string{WindowTypeDebounce, WindowTypeBatch}
+)
+
+// log is for logging in this package.
+var triggerlog = logf.Log.WithName("trigger-resource")
+
+func (r *Trigger) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-events-openfunction-io-v1alpha1-trigger,mutating=false,failurePolicy=fail,sideEffects=None,groups=events.openfunction.io,resources=triggers,verbs=create;update,versions=v1alpha1,name=vtriggers.of.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Trigger{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Trigger) ValidateCreate() error {
+	triggerlog.Info("validate create", "name", r.Name)
+	return r.Validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Trigger) ValidateUpdate(old runtime.Object) error {
+	triggerlog.Info("validate update", "name", r.Name)
+	if oldTrigger, ok := old.(*Trigger); ok {
+		return r.validate(oldTrigger.hasCondition)
+	}
+	return r.Validate()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Trigger) ValidateDelete() error {
+	triggerlog.Info("validate delete", "name", r.Name)
+	return nil
+}
+
+func (r *Trigger) Validate() error {
+	return r.validate(func(string) bool { return false })
+}
+
+// validate validates the Trigger, the conditions which do not compile are accepted if keep returns true for them.
+// The conditions of the existing subscribers are kept on update, so that a Trigger created before its conditions
+// were validated can still be updated.
+func (r *Trigger) validate(keep func(condition string) bool) error {
+	if r.Spec.EventBus == "" {
+		return field.Required(field.NewPath("spec", "eventBus"), "must be specified")
+	}
+
+	for name, input := range r.Spec.Inputs {
+		path := field.NewPath("spec", "inputs").Key(name)
+		if input == nil || input.EventSource == "" {
+			return field.Required(path.Child("eventSource"), "must be specified")
+		}
+		if input.Event == "" {
+			return field.Required(path.Child("event"), "must be specified")
+		}
+	}
+
+	// The subscribers publishing to the same topic share its output, whose resiliency policy has the timeout
+	// of the delivery, so they must share the timeout as well.
+	topicTimeouts := map[string]string{}
+	// The handler evaluates each condition once, so the subscribers are keyed by their conditions.
+	conditions := map[string]bool{}
+	for index, sub := range r.Spec.Subscribers {
+		path := field.NewPath("spec", "subscribers").Index(index)
+		if sub == nil {
+			return field.Required(path, "must be specified")
+		}
+		if conditions[sub.Condition] {
+			return field.Duplicate(path.Child("condition"), sub.Condition)
+		}
+		conditions[sub.Condition] = true
+		if err := validateDelivery(path.Child("delivery"), sub.Delivery); err != nil {
+			return err
+		}
+		if err := validateSinkSelector(path.Child("sink"), sub.Sink); err != nil {
+			return err
+		}
+		if sub.DeadLetterSink != nil && sub.DeadLetterSink.Uri == nil && sub.DeadLetterSink.Ref == nil && sub.DeadLetterSink.Selector != nil {
+			return field.Forbidden(path.Child("deadLetterSink", "selector"), "dead letter sink must be a single receiver")
+		}
+		if sub.Delivery != nil && len(sub.Delivery.RetryableStatusCodes) > 0 && sub.Sink == nil {
+			return field.Forbidden(path.Child("delivery", "retryableStatusCodes"),
+				"only applies to the sink, which is not set")
+		}
+		if sub.Topic != "" {
+			timeout := ""
+			if sub.Delivery != nil {
+				timeout = sub.Delivery.Timeout
+			}
+			if t, ok := topicTimeouts[sub.Topic]; ok && t != timeout {
+				return field.Invalid(path.Child("delivery", "timeout"), timeout,
+					"must be the same as the delivery timeout of the other subscribers of topic "+sub.Topic)
+			}
+			topicTimeouts[sub.Topic] = timeout
+		}
+	}
+
+	if err := r.ValidateCorrelation(); err != nil {
+		return err
+	}
+
+	return r.compileConditions(keep)
+}
+
+// ValidateCorrelation validates the correlation, window and state store of the Trigger.
+func (r *Trigger) ValidateCorrelation() error {
+	if correlation := r.Spec.Correlation; correlation != nil {
+		path := field.NewPath("spec", "correlation")
+		if correlation.Key == "" {
+			return field.Required(path.Child("key"), "must be specified")
+		}
+		if err := condition.CompileKey(correlation.Key); err != nil {
+			return field.Invalid(path.Child("key"), correlation.Key, err.Error())
+		}
+		for name, expr := range correlation.InputKeys {
+			if _, ok := r.Spec.Inputs[name]; !ok {
+				return field.NotFound(path.Child("inputKeys").Key(name), name)
+			}
+			if err := condition.CompileKey(expr); err != nil {
+				return field.Invalid(path.Child("inputKeys").Key(name), expr, err.Error())
+			}
+		}
+		if correlation.TTL == "" {
+			return field.Required(path.Child("ttl"), "must be specified")
+		}
+		if err := validatePositiveDuration(path.Child("ttl"), correlation.TTL); err != nil {
+			return err
+		}
+	}
+
+	if window := r.Spec.Window; window != nil {
+		path := field.NewPath("spec", "window")
+		switch window.Type {
+		case WindowTypeDebounce:
+			if window.Duration == "" {
+				return field.Required(path.Child("duration"), "must be specified for the debounce window")
+			}
+			if window.Size != nil {
+				return field.Forbidden(path.Child("size"), "is only used by the batch window")
+			}
+		case WindowTypeBatch:
+			if window.Size == nil && window.Duration == "" {
+				return field.Required(path, "must specify at least one of `size` or `duration` for the batch window")
+			}
+			if window.Size != nil && *window.Size < 1 {
+				return field.Invalid(path.Child("size"), *window.Size, "must be greater than 0")
+			}
+		default:
+			return field.NotSupported(path.Child("type"), window.Type, windowTypes)
+		}
+		if window.Duration != "" {
+			if err := validatePositiveDuration(path.Child("duration"), window.Duration); err != nil {
+				return err
+			}
+		}
+	}
+
+	path := field.NewPath("spec", "stateStore")
+	if r.Spec.StateStore == nil {
+		if r.Spec.Correlation != nil || r.Spec.Window != nil {
+			return field.Required(path, "must be specified if correlation or window is set")
+		}
+		return nil
+	}
+
+	if (r.Spec.StateStore.Name == "") == (r.Spec.StateStore.Spec == nil) {
+		return field.Invalid(path, r.Spec.StateStore, "must specify exactly one of `name` or `spec`")
+	}
+	if spec := r.Spec.StateStore.Spec; spec != nil && !strings.HasPrefix(spec.Type, "state.") {
+		return field.Invalid(path.Child("spec", "type"), spec.Type, "must be a Dapr state store")
+	}
+
+	return nil
+}
+
+// CompileConditions compiles and type-checks the CEL conditions and transforms of the subscribers.
+// The legacy conditions are accepted as the trigger handler still evaluates them, see condition.IsLegacy.
+func (r *Trigger) CompileConditions() error {
+	inputs := r.InputNames()
+	return r.compileConditions(func(c string) bool {
+		return condition.IsLegacy(inputs, c)
+	})
+}
+
+// hasCondition returns true if a subscriber of the Trigger has the condition.
+func (r *Trigger) hasCondition(condition string) bool {
+	for _, sub := range r.Spec.Subscribers {
+		if sub != nil && sub.Condition == condition {
+			return true
+		}
+	}
+	return false
+}
+
+// InputNames returns the sorted names of the inputs of the Trigger.
+func (r *Trigger) InputNames() []string {
+	var inputs []string
+	for name := range r.Spec.Inputs {
+		inputs = append(inputs, name)
+	}
+	sort.Strings(inputs)
+	return inputs
+}
+
+func (r *Trigger) compileConditions(keep func(condition string) bool) error {
+	inputs := r.InputNames()
+
+	env, err := condition.NewEnv(inputs)
+	if err != nil {
+		return field.InternalError(field.NewPath("spec", "inputs"), err)
+	}
+
+	for index, sub := range r.Spec.Subscribers {
+		if sub == nil {
+			continue
+		}
+
+		path := field.NewPath("spec", "subscribers").Index(index).Child("condition")
+		if sub.Condition == "" {
+			return field.Required(path, "must be specified")
+		}
+		if err := condition.Compile(env, sub.Condition); err != nil && !keep(sub.Condition) {
+			return field.Invalid(path, sub.Condition, err.Error())
+		}
+
+		if err := validateTransform(env, field.NewPath("spec", "subscribers").Index(index).Child("transform"), sub.Transform); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateTransform(env *cel.Env, path *field.Path, transform *Transform) error {
+	if transform == nil {
+		return nil
+	}
+
+	if transform.Data == "" && len(transform.Attributes) == 0 && len(transform.Remove) == 0 {
+		return field.Required(path, "must specify at least one of `data`, `attributes` or `remove`")
+	}
+
+	if transform.Data != "" {
+		if err := condition.CompileData(env, transform.Data); err != nil {
+			return field.Invalid(path.Child("data"), transform.Data, err.Error())
+		}
+	}
+
+	for name, expr := range transform.Attributes {
+		if err := condition.ValidateAttributeName(name); err != nil {
+			return field.Invalid(path.Child("attributes").Key(name), name, err.Error())
+		}
+		if err := condition.CompileAttribute(env, expr); err != nil {
+			return field.Invalid(path.Child("attributes").Key(name), expr, err.Error())
+		}
+	}
+
+	for index, name := range transform.Remove {
+		if err := condition.ValidateRemovedAttribute(name); err != nil {
+			return field.Invalid(path.Child("remove").Index(index), name, err.Error())
+		}
+		if _, ok := transform.Attributes[name]; ok {
+			return field.Invalid(path.Child("remove").Index(index), name, "cannot be both set and removed")
+		}
+	}
+
+	return nil
+}
+
+func validateSinkSelector(path *field.Path, sink *SinkSpec) error {
+	if sink == nil || sink.Selector == nil {
+		return nil
+	}
+
+	if sink.Selector.LabelSelector == nil {
+		return field.Required(path.Child("selector", "labelSelector"), "must be specified")
+	}
+	if _, err := metav1.LabelSelectorAsSelector(sink.Selector.LabelSelector); err != nil {
+		return field.Invalid(path.Child("selector", "labelSelector"), sink.Selector.LabelSelector, err.Error())
+	}
+
+	return nil
+}
+
+func validateDelivery(path *field.Path, delivery *DeliverySpec) error {
+	if delivery == nil {
+		return nil
+	}
+
+	if delivery.MaxRetries != nil && *delivery.MaxRetries < 0 {
+		return field.Invalid(path.Child("maxRetries"), *delivery.MaxRetries, "must be greater than or equal to 0")
+	}
+
+	if delivery.BackoffPolicy != "" {
+		valid := false
+		for _, policy := range deliveryBackoffPolicies {
+			if delivery.BackoffPolicy == policy {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return field.NotSupported(path.Child("backoffPolicy"), delivery.BackoffPolicy, deliveryBackoffPolicies)
+		}
+	}
+
+	if err := validateDuration(path.Child("backoffDelay"), delivery.BackoffDelay); err != nil {
+		return err
+	}
+	if err := validateDuration(path.Child("maxBackoffDelay"), delivery.MaxBackoffDelay); err != nil {
+		return err
+	}
+	if err := validateDuration(path.Child("timeout"), delivery.Timeout); err != nil {
+		return err
+	}
+
+	if delivery.BackoffPolicy == BackoffPolicyConstant && delivery.MaxBackoffDelay != "" {
+		return field.Forbidden(path.Child("maxBackoffDelay"), "only supported by the `linear` and `exponential` backoff policies")
+	}
+
+	for index, code := range delivery.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return field.Invalid(path.Child("retryableStatusCodes").Index(index), code, "must be an HTTP status code")
+		}
+	}
+
+	return nil
+}
+
+func validatePositiveDuration(path *field.Path, value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return field.Invalid(path, value, err.Error())
+	}
+	if d <= 0 {
+		return field.Invalid(path, value, "must be positive")
+	}
+
+	return nil
+}
+
+func validateDuration(path *field.Path, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return field.Invalid(path, value, err.Error())
+	}
+	if d < 0 {
+		return field.Invalid(path, value, "must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,581 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_TriggerValidate(t *testing.T) {
+	inputs := map[string]*Input{
+		"A": {EventSource: "es-a", Event: "event-a"},
+		"B": {EventSource: "es-b", Event: "event-b"},
+	}
+	maxRetries := int32(3)
+	negativeRetries := int32(-1)
+	uri := "http://sink.default.svc.cluster.local"
+	batchSize := int32(10)
+	redis := &StateStoreSpec{Spec: &componentsv1alpha1.ComponentSpec{Type: "state.redis", Version: "v1"}}
+	subscribers := []*Subscriber{{Condition: "A && B", Topic: "orders"}}
+
+	tests := []struct {
+		name    string
+		r       Trigger
+		wantErr bool
+	}{
+		{
+			name:    "trigger.spec.eventBus",
+			r:       Trigger{Spec: TriggerSpec{Inputs: inputs}},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.condition",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A || B", Topic: "metrics"},
+						{Condition: `A && event.type == "order.created"`, Topic: "orders"},
+						{Condition: `events.B.source == "es-b" && B`, Topic: "es-b"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.subscribers.condition.duplicate",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A && B", Topic: "orders"},
+						{Condition: "A && B", Topic: "metrics"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.condition.undeclared",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: []*Subscriber{{Condition: "A && C", Topic: "metrics"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.condition.syntax",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: []*Subscriber{{Condition: "A &&", Topic: "metrics"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.condition.type",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: []*Subscriber{{Condition: "event.type", Topic: "metrics"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.inputs.name",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs: map[string]*Input{
+						"input-a": {EventSource: "es-a", Event: "event-a"},
+						"event":   {EventSource: "es-b", Event: "event-b"},
+					},
+					Subscribers: []*Subscriber{
+						{Condition: `inputs["input-a"] && events["input-a"].source == "es-a"`, Topic: "metrics"},
+						{Condition: `inputs["event"] && event.type == "event-b"`, Topic: "orders"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.inputs.name.variable",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      map[string]*Input{"input-a": {EventSource: "es-a", Event: "event-a"}},
+					Subscribers: []*Subscriber{{Condition: "input-a", Topic: "metrics"}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.transform",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{
+							Condition: "A",
+							Topic:     "orders",
+							Transform: &Transform{
+								Data:       `{"id": event.data.orderId, "source": event.source}`,
+								Attributes: map[string]string{"type": `"order.shipped"`, "orderid": "event.orderid"},
+								Remove:     []string{"orderid2"},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.subscribers.transform.attributes.type",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Transform: &Transform{Attributes: map[string]string{"type": "1 + 1"}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.transform.attributes.name",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Transform: &Transform{Attributes: map[string]string{"specversion": `"2.0"`}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.transform.remove",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Transform: &Transform{Remove: []string{"type"}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.transform.data",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Transform: &Transform{Data: "{event.data"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "constant", BackoffDelay: "1s", Timeout: "10s"}},
+						{Condition: "B", Topic: "metrics", Delivery: &DeliverySpec{MaxRetries: &maxRetries, MaxBackoffDelay: "30s"}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.maxRetries",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{MaxRetries: &negativeRetries}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.backoffPolicy",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{BackoffPolicy: "random"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.timeout",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{Timeout: "10"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.maxBackoffDelay",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{BackoffPolicy: "constant", MaxBackoffDelay: "1s"}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.topic",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{Timeout: "10s"}},
+						{Condition: "B", Topic: "orders", Delivery: &DeliverySpec{Timeout: "10s"}},
+						{Condition: "A && B", Topic: "orders"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.linear",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{
+							Condition: "A",
+							Sink:      &SinkSpec{Uri: &uri},
+							Delivery: &DeliverySpec{
+								MaxRetries:           &maxRetries,
+								BackoffPolicy:        "linear",
+								BackoffDelay:         "1s",
+								MaxBackoffDelay:      "5s",
+								RetryableStatusCodes: []int32{429, 503},
+							},
+						},
+						{Condition: "B", Topic: "orders", Delivery: &DeliverySpec{MaxRetries: &maxRetries, Timeout: "10s"}},
+						{Condition: "A && B", Topic: "orders", Delivery: &DeliverySpec{BackoffDelay: "1s", Timeout: "10s"}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.retryableStatusCodes",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Sink: &SinkSpec{Uri: &uri}, Delivery: &DeliverySpec{RetryableStatusCodes: []int32{503, 1000}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.delivery.retryableStatusCodes.sink",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", Delivery: &DeliverySpec{RetryableStatusCodes: []int32{503}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.sink.selector",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Sink: &SinkSpec{Selector: &SinkSelector{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders"}},
+						}}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.subscribers.sink.selector.labelSelector",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Sink: &SinkSpec{Selector: &SinkSelector{}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.subscribers.deadLetterSink.selector",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus: "default",
+					Inputs:   inputs,
+					Subscribers: []*Subscriber{
+						{Condition: "A", Topic: "orders", DeadLetterSink: &SinkSpec{Selector: &SinkSelector{
+							LabelSelector: &metav1.LabelSelector{},
+						}}},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.correlation",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Correlation: &CorrelationSpec{
+						Key:       "event.data.orderId",
+						InputKeys: map[string]string{"B": "event.source"},
+						TTL:       "5m",
+					},
+					Window:     &WindowSpec{Type: WindowTypeBatch, Size: &batchSize, Duration: "1m"},
+					StateStore: redis,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "trigger.spec.correlation.key",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Correlation: &CorrelationSpec{Key: "event.data.orderId > 1", TTL: "5m"},
+					StateStore:  redis,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.correlation.inputKeys",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Correlation: &CorrelationSpec{Key: "event.subject", InputKeys: map[string]string{"C": "event.subject"}, TTL: "5m"},
+					StateStore:  redis,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.correlation.ttl",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Correlation: &CorrelationSpec{Key: "event.subject", TTL: "0s"},
+					StateStore:  redis,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.window.debounce",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Window:      &WindowSpec{Type: WindowTypeDebounce},
+					StateStore:  redis,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.window.type",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Window:      &WindowSpec{Type: "sliding", Duration: "1m"},
+					StateStore:  redis,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.stateStore",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Window:      &WindowSpec{Type: WindowTypeDebounce, Duration: "10s"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trigger.spec.stateStore.type",
+			r: Trigger{
+				Spec: TriggerSpec{
+					EventBus:    "default",
+					Inputs:      inputs,
+					Subscribers: subscribers,
+					Window:      &WindowSpec{Type: WindowTypeDebounce, Duration: "10s"},
+					StateStore:  &StateStoreSpec{Spec: &componentsv1alpha1.ComponentSpec{Type: "pubsub.redis"}},
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.r.Validate()
+			if (got != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", got, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_TriggerValidateUpdate(t *testing.T) {
+	// The input named event was a bool variable before event was declared as the map of the event attributes.
+	inputs := map[string]*Input{
+		"A":     {EventSource: "es-a", Event: "event-a"},
+		"event": {EventSource: "es-b", Event: "event-b"},
+	}
+	trigger := func(conditions ...string) *Trigger {
+		r := &Trigger{Spec: TriggerSpec{EventBus: "default", Inputs: inputs}}
+		for _, c := range conditions {
+			r.Spec.Subscribers = append(r.Spec.Subscribers, &Subscriber{Condition: c, Topic: "metrics"})
+		}
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		old     *Trigger
+		r       *Trigger
+		wantErr bool
+	}{
+		{
+			name: "legacy condition kept",
+			old:  trigger("A && event"),
+			r:    trigger("A && event", `event.type == "created"`),
+		},
+		{
+			name: "invalid condition kept",
+			old:  trigger("A &&"),
+			r:    trigger("A &&", "A"),
+		},
+		{
+			name:    "legacy condition added",
+			old:     trigger("A"),
+			r:       trigger("A", "A && event"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid condition added",
+			old:     trigger("A && event"),
+			r:       trigger("A && event", "A &&"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); err == nil {
+				t.Errorf("Validate() should reject the conditions")
+			}
+			got := tt.r.ValidateUpdate(tt.old)
+			if (got != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_TriggerCompileConditions(t *testing.T) {
+	r := &Trigger{Spec: TriggerSpec{
+		EventBus: "default",
+		Inputs: map[string]*Input{
+			"A":     {EventSource: "es-a", Event: "event-a"},
+			"event": {EventSource: "es-b", Event: "event-b"},
+		},
+		Subscribers: []*Subscriber{{Condition: "A && event", Topic: "metrics"}},
+	}}
+	if err := r.CompileConditions(); err != nil {
+		t.Errorf("CompileConditions() should accept the legacy condition, got %v", err)
+	}
+
+	r.Spec.Subscribers = append(r.Spec.Subscribers, &Subscriber{Condition: "A &&", Topic: "metrics"})
+	if err := r.CompileConditions(); err == nil {
+		t.Errorf("CompileConditions() should reject the invalid condition")
+	}
+}
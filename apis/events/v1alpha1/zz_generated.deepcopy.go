@@ -22,10 +22,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
-
 	"github.com/openfunction/apis/core/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -101,6 +103,28 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CorrelationSpec) DeepCopyInto(out *CorrelationSpec) {
+	*out = *in
+	if in.InputKeys != nil {
+		in, out := &in.InputKeys, &out.InputKeys
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CorrelationSpec.
+func (in *CorrelationSpec) DeepCopy() *CorrelationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CorrelationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CronSpec) DeepCopyInto(out *CronSpec) {
 	*out = *in
@@ -116,6 +140,31 @@ func (in *CronSpec) DeepCopy() *CronSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeliverySpec) DeepCopyInto(out *DeliverySpec) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RetryableStatusCodes != nil {
+		in, out := &in.RetryableStatusCodes, &out.RetryableStatusCodes
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeliverySpec.
+func (in *DeliverySpec) DeepCopy() *DeliverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeliverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EventBus) DeepCopyInto(out *EventBus) {
 	*out = *in
@@ -316,6 +365,51 @@ func (in *EventSourceSpec) DeepCopyInto(out *EventSourceSpec) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.RabbitMQ != nil {
+		in, out := &in.RabbitMQ, &out.RabbitMQ
+		*out = make(map[string]*RabbitMQSpec, len(*in))
+		for key, val := range *in {
+			var outVal *RabbitMQSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(RabbitMQSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.Kubernetes != nil {
+		in, out := &in.Kubernetes, &out.Kubernetes
+		*out = make(map[string]*KubernetesSpec, len(*in))
+		for key, val := range *in {
+			var outVal *KubernetesSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(KubernetesSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = make(map[string]*S3Spec, len(*in))
+		for key, val := range *in {
+			var outVal *S3Spec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(S3Spec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
 	if in.Sink != nil {
 		in, out := &in.Sink, &out.Sink
 		*out = new(SinkSpec)
@@ -351,6 +445,11 @@ func (in *EventSourceStatus) DeepCopyInto(out *EventSourceStatus) {
 		*out = make([]Condition, len(*in))
 		copy(*out, *in)
 	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]EventStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSourceStatus.
@@ -363,6 +462,21 @@ func (in *EventSourceStatus) DeepCopy() *EventSourceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventStatus) DeepCopyInto(out *EventStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventStatus.
+func (in *EventStatus) DeepCopy() *EventStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EventStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GenericScaleOption) DeepCopyInto(out *GenericScaleOption) {
 	*out = *in
@@ -430,6 +544,21 @@ func (in *Input) DeepCopy() *Input {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InputStatus) DeepCopyInto(out *InputStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InputStatus.
+func (in *InputStatus) DeepCopy() *InputStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InputStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaScaleOption) DeepCopyInto(out *KafkaScaleOption) {
 	*out = *in
@@ -485,6 +614,31 @@ func (in *KafkaSpec) DeepCopy() *KafkaSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesSpec) DeepCopyInto(out *KubernetesSpec) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesSpec.
+func (in *KubernetesSpec) DeepCopy() *KubernetesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KubernetesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MQTTSpec) DeepCopyInto(out *MQTTSpec) {
 	*out = *in
@@ -625,6 +779,86 @@ func (in *NatsStreamingSpec) DeepCopy() *NatsStreamingSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitMQScaleOption) DeepCopyInto(out *RabbitMQScaleOption) {
+	*out = *in
+	if in.GenericScaleOption != nil {
+		in, out := &in.GenericScaleOption, &out.GenericScaleOption
+		*out = new(GenericScaleOption)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VhostName != nil {
+		in, out := &in.VhostName, &out.VhostName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQScaleOption.
+func (in *RabbitMQScaleOption) DeepCopy() *RabbitMQScaleOption {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitMQScaleOption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RabbitMQSpec) DeepCopyInto(out *RabbitMQSpec) {
+	*out = *in
+	if in.HostSecret != nil {
+		in, out := &in.HostSecret, &out.HostSecret
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exchange != nil {
+		in, out := &in.Exchange, &out.Exchange
+		*out = new(string)
+		**out = **in
+	}
+	if in.RoutingKey != nil {
+		in, out := &in.RoutingKey, &out.RoutingKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.Durable != nil {
+		in, out := &in.Durable, &out.Durable
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DeleteWhenUnused != nil {
+		in, out := &in.DeleteWhenUnused, &out.DeleteWhenUnused
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PrefetchCount != nil {
+		in, out := &in.PrefetchCount, &out.PrefetchCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.TTLInSeconds != nil {
+		in, out := &in.TTLInSeconds, &out.TTLInSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ScaleOption != nil {
+		in, out := &in.ScaleOption, &out.ScaleOption
+		*out = new(RabbitMQScaleOption)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RabbitMQSpec.
+func (in *RabbitMQSpec) DeepCopy() *RabbitMQSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RabbitMQSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RedisSpec) DeepCopyInto(out *RedisSpec) {
 	*out = *in
@@ -750,6 +984,66 @@ func (in *Reference) DeepCopy() *Reference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3Spec) DeepCopyInto(out *S3Spec) {
+	*out = *in
+	if in.EventTypes != nil {
+		in, out := &in.EventTypes, &out.EventTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AccessKeyID != nil {
+		in, out := &in.AccessKeyID, &out.AccessKeyID
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretAccessKey != nil {
+		in, out := &in.SecretAccessKey, &out.SecretAccessKey
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForcePathStyle != nil {
+		in, out := &in.ForcePathStyle, &out.ForcePathStyle
+		*out = new(bool)
+		**out = **in
+	}
+	if in.PollingInterval != nil {
+		in, out := &in.PollingInterval, &out.PollingInterval
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3Spec.
+func (in *S3Spec) DeepCopy() *S3Spec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3Spec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SinkSelector) DeepCopyInto(out *SinkSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SinkSelector.
+func (in *SinkSelector) DeepCopy() *SinkSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(SinkSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SinkSpec) DeepCopyInto(out *SinkSpec) {
 	*out = *in
@@ -763,6 +1057,11 @@ func (in *SinkSpec) DeepCopyInto(out *SinkSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(SinkSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SinkSpec.
@@ -775,6 +1074,26 @@ func (in *SinkSpec) DeepCopy() *SinkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateStoreSpec) DeepCopyInto(out *StateStoreSpec) {
+	*out = *in
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = new(componentsv1alpha1.ComponentSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateStoreSpec.
+func (in *StateStoreSpec) DeepCopy() *StateStoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StateStoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subscriber) DeepCopyInto(out *Subscriber) {
 	*out = *in
@@ -788,6 +1107,16 @@ func (in *Subscriber) DeepCopyInto(out *Subscriber) {
 		*out = new(SinkSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = new(Transform)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Delivery != nil {
+		in, out := &in.Delivery, &out.Delivery
+		*out = new(DeliverySpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Subscriber.
@@ -800,6 +1129,33 @@ func (in *Subscriber) DeepCopy() *Subscriber {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Transform) DeepCopyInto(out *Transform) {
+	*out = *in
+	if in.Attributes != nil {
+		in, out := &in.Attributes, &out.Attributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
+func (in *Transform) DeepCopy() *Transform {
+	if in == nil {
+		return nil
+	}
+	out := new(Transform)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Trigger) DeepCopyInto(out *Trigger) {
 	*out = *in
@@ -888,6 +1244,21 @@ func (in *TriggerSpec) DeepCopyInto(out *TriggerSpec) {
 			}
 		}
 	}
+	if in.Correlation != nil {
+		in, out := &in.Correlation, &out.Correlation
+		*out = new(CorrelationSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(WindowSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StateStore != nil {
+		in, out := &in.StateStore, &out.StateStore
+		*out = new(StateStoreSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LogLevel != nil {
 		in, out := &in.LogLevel, &out.LogLevel
 		*out = new(string)
@@ -913,6 +1284,11 @@ func (in *TriggerStatus) DeepCopyInto(out *TriggerStatus) {
 		*out = make([]Condition, len(*in))
 		copy(*out, *in)
 	}
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]InputStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TriggerStatus.
@@ -924,3 +1300,23 @@ func (in *TriggerStatus) DeepCopy() *TriggerStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WindowSpec) DeepCopyInto(out *WindowSpec) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WindowSpec.
+func (in *WindowSpec) DeepCopy() *WindowSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WindowSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// RouteTemplateData holds the variables of the HostTemplate and the PathTemplate,
+// such as `{{.Labels.team}}.{{.Domain}}`.
+//
+// +kubebuilder:object:generate=false
+type RouteTemplateData struct {
+	// Name of the function.
+	Name string
+	// Namespace of the function.
+	Namespace string
+	// Domain of the gateway.
+	Domain string
+	// Version of the function, empty if not set.
+	Version string
+	// Labels of the function.
+	Labels map[string]string
+	// Annotations of the function.
+	Annotations map[string]string
+	// Engine serving the http function, such as `knative` and `keda`.
+	Engine string
+}
+
+// RenderHostTemplate renders the HostTemplate of the gateway, it fails if a referred label or annotation is missing.
+func (r *Gateway) RenderHostTemplate(data RouteTemplateData) (string, error) {
+	return renderRouteTemplate("host", r.Spec.HostTemplate, data, "error")
+}
+
+// RenderPathTemplate renders the PathTemplate of the gateway, it fails if a referred label or annotation is missing.
+func (r *Gateway) RenderPathTemplate(data RouteTemplateData) (string, error) {
+	return renderRouteTemplate("path", r.Spec.PathTemplate, data, "error")
+}
+
+func renderRouteTemplate(name string, text string, data RouteTemplateData, missingKey string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=" + missingKey).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
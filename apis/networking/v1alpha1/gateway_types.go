@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -31,12 +33,62 @@ const (
 	GatewayListenersAnnotation   = "networking.openfunction.io/injected-listeners"
 	DefaultGatewayServiceName    = "gateway"
 	DefaultK8sGatewayServiceName = "envoy"
+	DefaultHttpsListenerName     = "ofn-https-external"
+	DefaultHttpsListenerPort     = 443
+	// FunctionHttpsListenerPrefix is the prefix of the listeners generated for the certificates of functions.
+	FunctionHttpsListenerPrefix = "ofn-https-fn-"
+	// MaxListeners is the maximum number of listeners of a k8s Gateway.
+	MaxListeners = 64
+	// GatewayLabel is added to the resources generated for a gateway, the value is `{namespace}.{name}` of the gateway.
+	GatewayLabel = "networking.openfunction.io/gateway"
+)
+
+// RoutingMode is how the routes of functions are served.
+type RoutingMode string
+
+const (
+	// RoutingModeGatewayAPI serves the routes of functions with the HTTPRoutes attached to a k8s Gateway.
+	RoutingModeGatewayAPI RoutingMode = "GatewayAPI"
+	// RoutingModeIngress serves the routes of functions with networking.k8s.io/v1 Ingresses.
+	RoutingModeIngress RoutingMode = "Ingress"
+
+	DefaultIngressControllerServiceName      = "ingress-nginx-controller"
+	DefaultIngressControllerServiceNamespace = "ingress-nginx"
+)
+
+const (
+	CertManagerGroup  = "cert-manager.io"
+	IssuerKind        = "Issuer"
+	ClusterIssuerKind = "ClusterIssuer"
+	// WildcardCertificateNameTmpl is the name of the wildcard certificate issued for a gateway,
+	// `ofn-wildcard.{namespace}.{name}`, the Secret of the certificate is named `{certificate}-tls`.
+	WildcardCertificateNameTmpl = "ofn-wildcard.%s.%s"
+)
+
+// CertificateMode is how the certificates of functions are issued by the issuer.
+type CertificateMode string
+
+const (
+	// CertificateModeWildcard issues a single wildcard certificate for `*.{Domain}`,
+	// which serves all the functions with the default HTTPS listener.
+	CertificateModeWildcard CertificateMode = "Wildcard"
+	// CertificateModePerFunction issues a certificate for each function,
+	// each function is then served by a dedicated HTTPS listener.
+	CertificateModePerFunction CertificateMode = "PerFunction"
 )
 
 const (
-	GatewayReasonNotFound           k8sgatewayapiv1alpha2.GatewayConditionReason = "NotFound"
-	GatewayReasonCreationFailure    k8sgatewayapiv1alpha2.GatewayConditionReason = "CreationFailure"
-	GatewayReasonResourcesAvailable k8sgatewayapiv1alpha2.GatewayConditionReason = "ResourcesAvailable"
+	GatewayReasonNotFound           k8sgatewayapiv1beta1.GatewayConditionReason = "NotFound"
+	GatewayReasonCreationFailure    k8sgatewayapiv1beta1.GatewayConditionReason = "CreationFailure"
+	GatewayReasonResourcesAvailable k8sgatewayapiv1beta1.GatewayConditionReason = "ResourcesAvailable"
+	// GatewayReasonGatewayAPINotServed means the GatewayAPI routing mode is set but the cluster does not serve Gateway API.
+	GatewayReasonGatewayAPINotServed k8sgatewayapiv1beta1.GatewayConditionReason = "GatewayAPINotServed"
+	// GatewayReasonGatewayNotSpecified means the routes are served by Gateway API but no k8s Gateway is specified.
+	GatewayReasonGatewayNotSpecified k8sgatewayapiv1beta1.GatewayConditionReason = "GatewayNotSpecified"
+
+	// GatewayConditionFunctionListeners reports the functions which can not be served by dedicated HTTPS listeners.
+	GatewayConditionFunctionListeners  = "FunctionListeners"
+	GatewayReasonListenerLimitExceeded = "ListenerLimitExceeded"
 )
 
 type GatewayRef struct {
@@ -71,7 +123,7 @@ type GatewayDef struct {
 	Namespace string `json:"namespace"`
 	// GatewayClassName used for this Gateway.
 	// This is the name of a GatewayClass resource.
-	GatewayClassName k8sgatewayapiv1alpha2.ObjectName `json:"gatewayClassName"`
+	GatewayClassName k8sgatewayapiv1beta1.ObjectName `json:"gatewayClassName"`
 }
 
 type K8sGatewaySpec struct {
@@ -85,7 +137,7 @@ type K8sGatewaySpec struct {
 	// +listType=map
 	// +listMapKey=name
 	// +kubebuilder:validation:MinItems=1
-	Listeners []k8sgatewayapiv1alpha2.Listener `json:"listeners"`
+	Listeners []k8sgatewayapiv1beta1.Listener `json:"listeners"`
 }
 
 // GatewaySpec defines the desired state of Gateway
@@ -97,16 +149,24 @@ type GatewaySpec struct {
 	// +optional
 	// +kubebuilder:default="cluster.local"
 	ClusterDomain string `json:"clusterDomain,omitempty"`
-	// Used to generate the hostname of attaching HTTPRoute
+	// Used to generate the hostname of attaching HTTPRoute. The variables of the function are
+	// `{{.Name}}`, `{{.Namespace}}`, `{{.Domain}}`, `{{.Version}}`, `{{.Labels}}`, `{{.Annotations}}` and `{{.Engine}}`,
+	// such as `{{.Labels.team}}.{{.Domain}}`. The route of a function fails if a referred label or annotation is missing.
 	//
 	// +optional
 	// +kubebuilder:default="{{.Name}}.{{.Namespace}}.{{.Domain}}"
 	HostTemplate string `json:"hostTemplate,omitempty"`
-	// Used to generate the path of attaching HTTPRoute
+	// Used to generate the path of attaching HTTPRoute, with the same variables as HostTemplate.
 	//
 	// +optional
 	// +kubebuilder:default="{{.Namespace}}/{{.Name}}"
 	PathTemplate string `json:"pathTemplate,omitempty"`
+	// StripPathPrefix rewrites the matched path prefix of the routes of functions to `/`, so that a function
+	// receives the requests without the path generated by PathTemplate. It can be overridden by the route of
+	// each function, and is only supported in the GatewayAPI routing mode.
+	//
+	// +optional
+	StripPathPrefix bool `json:"stripPathPrefix,omitempty"`
 	// Label key to add to the HTTPRoute generated by function
 	// The value will be the `gateway.openfunction.openfunction.io` CR's namespaced name
 	//
@@ -123,6 +183,100 @@ type GatewaySpec struct {
 	GatewayDef *GatewayDef `json:"gatewayDef,omitempty"`
 	// GatewaySpec defines the desired state of k8s Gateway.
 	GatewaySpec K8sGatewaySpec `json:"gatewaySpec"`
+	// RoutingMode is how the routes of functions are served, one of `GatewayAPI` and `Ingress`.
+	// If not set, the routes are served by Gateway API if the cluster serves HTTPRoutes, otherwise by Ingress.
+	// One of gatewayRef and gatewayDef is required once the routes are served by Gateway API.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=GatewayAPI;Ingress
+	RoutingMode RoutingMode `json:"routingMode,omitempty"`
+	// Ingress configures the Ingresses generated for functions in the `Ingress` routing mode.
+	//
+	// +optional
+	Ingress *IngressConfig `json:"ingress,omitempty"`
+	// TLS serves the external routes of functions over HTTPS,
+	// the external addresses of functions are reported as https urls once it is set.
+	//
+	// +optional
+	TLS *GatewayTLS `json:"tls,omitempty"`
+}
+
+// IngressConfig defines how the Ingresses of functions are generated.
+type IngressConfig struct {
+	// IngressClassName is the class of the Ingresses, the default IngressClass of the cluster is used if not set.
+	//
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// Annotations are added to the Ingresses, such as the annotations configuring the ingress controller.
+	//
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// ControllerService refers to the Service of the ingress controller, the internal addresses of functions
+	// are resolved to it. Default to `ingress-nginx/ingress-nginx-controller`.
+	//
+	// +optional
+	ControllerService *GatewayRef `json:"controllerService,omitempty"`
+}
+
+// GatewayTLS defines the certificates used to serve the external routes of functions,
+// exactly one of certificateRef and issuerRef must be set.
+// The external routes are only served over HTTPS once it is set, the external HTTP listeners are removed.
+type GatewayTLS struct {
+	// Port of the HTTPS listeners.
+	//
+	// +optional
+	// +kubebuilder:default=443
+	Port k8sgatewayapiv1beta1.PortNumber `json:"port,omitempty"`
+	// CertificateRef refers to the Secret of a wildcard certificate for `*.{Domain}`, which serves all the functions.
+	// The Secret must be in the namespace of the k8s Gateway unless a ReferenceGrant allows the reference.
+	// A wildcard only covers a single label, so the HostTemplate must render a single label before the Domain,
+	// such as `{{.Name}}-{{.Namespace}}.{{.Domain}}`.
+	//
+	// +optional
+	CertificateRef *k8sgatewayapiv1beta1.SecretObjectReference `json:"certificateRef,omitempty"`
+	// IssuerRef refers to a cert-manager issuer which issues the certificates of functions as set by CertificateMode.
+	// An Issuer must be in the namespace of the k8s Gateway.
+	//
+	// +optional
+	IssuerRef *IssuerRef `json:"issuerRef,omitempty"`
+	// CertificateMode is how the issuer issues the certificates of functions, one of `Wildcard` and `PerFunction`.
+	// `Wildcard` issues a single certificate for `*.{Domain}` served by the default HTTPS listener,
+	// the issuer must be able to issue wildcard certificates, such as an ACME issuer with a DNS01 solver,
+	// and the HostTemplate must render a single label before the Domain.
+	// `PerFunction` issues a certificate for each function served by a dedicated HTTPS listener of the k8s Gateway.
+	// A k8s Gateway has at most 64 listeners, the functions beyond the limit are not served over HTTPS
+	// and are reported in the `FunctionListeners` condition of the gateway.
+	//
+	// +optional
+	// +kubebuilder:default=Wildcard
+	// +kubebuilder:validation:Enum=Wildcard;PerFunction
+	CertificateMode CertificateMode `json:"certificateMode,omitempty"`
+}
+
+// WildcardCertificateName returns the name of the wildcard certificate issued for the gateway.
+func (r *Gateway) WildcardCertificateName() string {
+	return fmt.Sprintf(WildcardCertificateNameTmpl, r.Namespace, r.Name)
+}
+
+// WildcardCertificateSecretName returns the name of the Secret of the wildcard certificate issued for the gateway.
+func (r *Gateway) WildcardCertificateSecretName() string {
+	return fmt.Sprintf("%s-tls", r.WildcardCertificateName())
+}
+
+// IssuerRef refers to a cert-manager issuer.
+type IssuerRef struct {
+	// Name of the issuer.
+	Name string `json:"name"`
+	// Kind of the issuer, `Issuer` or `ClusterIssuer`.
+	//
+	// +optional
+	// +kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer.
+	//
+	// +optional
+	// +kubebuilder:default=cert-manager.io
+	Group string `json:"group,omitempty"`
 }
 
 type Condition struct {
@@ -134,7 +288,7 @@ type Condition struct {
 
 type ListenerStatus struct {
 	// Name is the name of the Listener that this status corresponds to.
-	Name k8sgatewayapiv1alpha2.SectionName `json:"name"`
+	Name k8sgatewayapiv1beta1.SectionName `json:"name"`
 
 	// SupportedKinds is the list indicating the Kinds supported by this
 	// listener. This MUST represent the kinds an implementation supports for
@@ -147,7 +301,7 @@ type ListenerStatus struct {
 	// reference the valid Route kinds that have been specified.
 	//
 	// +kubebuilder:validation:MaxItems=8
-	SupportedKinds []k8sgatewayapiv1alpha2.RouteGroupKind `json:"supportedKinds"`
+	SupportedKinds []k8sgatewayapiv1beta1.RouteGroupKind `json:"supportedKinds"`
 
 	// AttachedRoutes represents the total number of Routes that have been
 	// successfully attached to this Listener.
@@ -169,7 +323,7 @@ type GatewayStatus struct {
 	// +optional
 	// +kubebuilder:validation:MaxItems=16
 
-	Addresses []k8sgatewayapiv1alpha2.GatewayAddress `json:"addresses,omitempty"`
+	Addresses []k8sgatewayapiv1beta1.GatewayAddress `json:"addresses,omitempty"`
 	// Conditions describe the current conditions of the Gateway.
 	//
 	// Known condition types are:
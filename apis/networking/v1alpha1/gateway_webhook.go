@@ -17,9 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"bytes"
 	"fmt"
-	"text/template"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/json"
 
@@ -30,9 +29,11 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
+const routeTemplateVariables = "{{.Name}}, {{.Namespace}}, {{.Domain}}, {{.Version}}, {{.Labels}}, {{.Annotations}} and {{.Engine}}"
+
 // log is for logging in this package.
 var gatewaylog = logf.Log.WithName("gateway-resource")
 
@@ -60,33 +61,33 @@ func (r *Gateway) Default() {
 	for index, listener := range r.Spec.GatewaySpec.Listeners {
 		if listener.Name == DefaultHttpListenerName {
 			needInjectDefaultListeners = false
-			internalHostname := k8sgatewayapiv1alpha2.Hostname(fmt.Sprintf("*.%s", r.Spec.ClusterDomain))
-			namespaceFromAll := k8sgatewayapiv1alpha2.NamespacesFromAll
+			internalHostname := k8sgatewayapiv1beta1.Hostname(fmt.Sprintf("*.%s", r.Spec.ClusterDomain))
+			namespaceFromAll := k8sgatewayapiv1beta1.NamespacesFromAll
 			listener.Hostname = &internalHostname
 			listener.Port = constants.DefaultGatewayListenerPort
 			listener.Protocol = constants.DefaultGatewayListenerProtocol
-			listener.AllowedRoutes = &k8sgatewayapiv1alpha2.AllowedRoutes{
-				Namespaces: &k8sgatewayapiv1alpha2.RouteNamespaces{
+			listener.AllowedRoutes = &k8sgatewayapiv1beta1.AllowedRoutes{
+				Namespaces: &k8sgatewayapiv1beta1.RouteNamespaces{
 					From: &namespaceFromAll,
 				},
 			}
 		} else {
-			hostname := k8sgatewayapiv1alpha2.Hostname(fmt.Sprintf("*.%s", r.Spec.Domain))
+			hostname := k8sgatewayapiv1beta1.Hostname(fmt.Sprintf("*.%s", r.Spec.Domain))
 			listener.Hostname = &hostname
 		}
 		r.Spec.GatewaySpec.Listeners[index] = listener
 	}
 
 	if needInjectDefaultListeners {
-		internalHostname := k8sgatewayapiv1alpha2.Hostname(fmt.Sprintf("*.%s", r.Spec.ClusterDomain))
-		namespaceFromAll := k8sgatewayapiv1alpha2.NamespacesFromAll
-		internalHttpListener := k8sgatewayapiv1alpha2.Listener{
+		internalHostname := k8sgatewayapiv1beta1.Hostname(fmt.Sprintf("*.%s", r.Spec.ClusterDomain))
+		namespaceFromAll := k8sgatewayapiv1beta1.NamespacesFromAll
+		internalHttpListener := k8sgatewayapiv1beta1.Listener{
 			Name:     DefaultHttpListenerName,
 			Hostname: &internalHostname,
 			Port:     constants.DefaultGatewayListenerPort,
 			Protocol: constants.DefaultGatewayListenerProtocol,
-			AllowedRoutes: &k8sgatewayapiv1alpha2.AllowedRoutes{
-				Namespaces: &k8sgatewayapiv1alpha2.RouteNamespaces{
+			AllowedRoutes: &k8sgatewayapiv1beta1.AllowedRoutes{
+				Namespaces: &k8sgatewayapiv1beta1.RouteNamespaces{
 					From: &namespaceFromAll,
 				},
 			},
@@ -94,6 +95,8 @@ func (r *Gateway) Default() {
 		r.Spec.GatewaySpec.Listeners = append(r.Spec.GatewaySpec.Listeners, internalHttpListener)
 	}
 
+	r.defaultTLS()
+
 	if r.Annotations == nil {
 		annotations := make(map[string]string)
 		gatewaySpecAnnotation, _ := json.Marshal(r.Spec)
@@ -105,6 +108,70 @@ func (r *Gateway) Default() {
 	}
 }
 
+// defaultTLS injects the HTTPS listener serving the wildcard certificate, either referred to by certificateRef
+// or issued by the issuer, and removes it once the wildcard certificate is no longer used.
+// The external HTTP listeners are removed once TLS is set, so that the external routes are only served over HTTPS.
+func (r *Gateway) defaultTLS() {
+	tls := r.Spec.TLS
+	if tls != nil && tls.Port == 0 {
+		tls.Port = DefaultHttpsListenerPort
+	}
+	if tls != nil && tls.IssuerRef != nil {
+		if tls.IssuerRef.Kind == "" {
+			tls.IssuerRef.Kind = IssuerKind
+		}
+		if tls.IssuerRef.Group == "" {
+			tls.IssuerRef.Group = CertManagerGroup
+		}
+		if tls.CertificateMode == "" {
+			tls.CertificateMode = CertificateModeWildcard
+		}
+	}
+
+	var listeners []k8sgatewayapiv1beta1.Listener
+	for _, listener := range r.Spec.GatewaySpec.Listeners {
+		if listener.Name == DefaultHttpsListenerName {
+			continue
+		}
+		if tls != nil && listener.Name != DefaultHttpListenerName && listener.Protocol == k8sgatewayapiv1beta1.HTTPProtocolType {
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	var certificateRef *k8sgatewayapiv1beta1.SecretObjectReference
+	if tls != nil && tls.CertificateRef != nil {
+		certificateRef = tls.CertificateRef
+	} else if tls != nil && tls.IssuerRef != nil && tls.CertificateMode == CertificateModeWildcard {
+		// The wildcard certificate is issued in the namespace of the k8s Gateway.
+		certificateRef = &k8sgatewayapiv1beta1.SecretObjectReference{
+			Name: k8sgatewayapiv1beta1.ObjectName(r.WildcardCertificateSecretName()),
+		}
+	}
+
+	if certificateRef != nil {
+		hostname := k8sgatewayapiv1beta1.Hostname(fmt.Sprintf("*.%s", r.Spec.Domain))
+		namespaceFromAll := k8sgatewayapiv1beta1.NamespacesFromAll
+		mode := k8sgatewayapiv1beta1.TLSModeTerminate
+		listeners = append(listeners, k8sgatewayapiv1beta1.Listener{
+			Name:     DefaultHttpsListenerName,
+			Hostname: &hostname,
+			Port:     tls.Port,
+			Protocol: k8sgatewayapiv1beta1.HTTPSProtocolType,
+			TLS: &k8sgatewayapiv1beta1.GatewayTLSConfig{
+				Mode:            &mode,
+				CertificateRefs: []k8sgatewayapiv1beta1.SecretObjectReference{*certificateRef},
+			},
+			AllowedRoutes: &k8sgatewayapiv1beta1.AllowedRoutes{
+				Namespaces: &k8sgatewayapiv1beta1.RouteNamespaces{
+					From: &namespaceFromAll,
+				},
+			},
+		})
+	}
+	r.Spec.GatewaySpec.Listeners = listeners
+}
+
 //+kubebuilder:webhook:path=/validate-networking-openfunction-io-v1alpha1-gateway,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.openfunction.io,resources=gateways,verbs=create;update,versions=v1alpha1,name=vgateway.of.io,admissionReviewVersions=v1
 
 var _ webhook.Validator = &Gateway{}
@@ -129,43 +196,37 @@ func (r *Gateway) ValidateDelete() error {
 }
 
 func (r *Gateway) Validate() error {
-	var hostnameBuffer bytes.Buffer
-	var pathBuffer bytes.Buffer
 	if r.Spec.Domain == "" {
 		return field.Required(field.NewPath("spec", "domain"),
 			"must specify domain")
 	}
 
-	if hostTemplate, err := template.New("host").Parse(r.Spec.HostTemplate); err != nil {
-		return field.Invalid(field.NewPath("spec", "hostTemplate"),
-			r.Spec.HostTemplate, "invalid template")
-	} else {
-		hostInfoObj := struct {
-			Name      string
-			Namespace string
-			Domain    string
-		}{Name: r.Name, Namespace: r.Namespace, Domain: r.Spec.Domain}
-		if err := hostTemplate.Execute(&hostnameBuffer, hostInfoObj); err != nil {
-			return field.Invalid(field.NewPath("spec", "hostTemplate"),
-				r.Spec.HostTemplate, "invalid host template, please make sure that only the {{Name}}, {{Namespace}}, {{Domain}} are included in the template")
-		}
+	// The labels and annotations of functions are unknown here, the templates are rendered with
+	// the missing keys resolved to empty values to check the syntax and the variables.
+	data := RouteTemplateData{Name: r.Name, Namespace: r.Namespace, Domain: r.Spec.Domain}
+	if _, err := renderRouteTemplate("host", r.Spec.HostTemplate, data, "zero"); err != nil {
+		return field.Invalid(field.NewPath("spec", "hostTemplate"), r.Spec.HostTemplate,
+			fmt.Sprintf("invalid host template, only %s are supported: %s", routeTemplateVariables, err))
+	}
+	if _, err := renderRouteTemplate("path", r.Spec.PathTemplate, data, "zero"); err != nil {
+		return field.Invalid(field.NewPath("spec", "pathTemplate"), r.Spec.PathTemplate,
+			fmt.Sprintf("invalid path template, only %s are supported: %s", routeTemplateVariables, err))
 	}
 
-	if pathTemplate, err := template.New("path").Parse(r.Spec.PathTemplate); err != nil {
-		return field.Invalid(field.NewPath("spec", "pathTemplate"),
-			r.Spec.HostTemplate, "invalid path template")
-	} else {
-		pathInfoObj := struct {
-			Name      string
-			Namespace string
-		}{Name: r.Name, Namespace: r.Namespace}
-		if err := pathTemplate.Execute(&pathBuffer, pathInfoObj); err != nil {
-			return field.Invalid(field.NewPath("spec", "pathTemplate"),
-				r.Spec.HostTemplate, "invalid path template, please make sure that only the {{Name}} and {{Namespace}} are included in the template")
+	if r.Spec.RoutingMode == RoutingModeIngress {
+		if r.Spec.TLS != nil {
+			return field.Forbidden(field.NewPath("spec", "tls"),
+				"tls is only supported in the GatewayAPI routing mode")
 		}
+		return nil
 	}
 
 	if r.Spec.GatewayRef == nil && r.Spec.GatewayDef == nil {
+		// The gateway may fall back to the Ingress routing mode if the routing mode is not set,
+		// the controller reports the missing k8s Gateway once the routes are served by Gateway API.
+		if r.Spec.RoutingMode == "" && r.Spec.TLS == nil {
+			return nil
+		}
 		return field.Required(field.NewPath("spec", "gatewayRef"),
 			"must specify at least one of gatewayRef and gatewayDef")
 	}
@@ -175,9 +236,93 @@ func (r *Gateway) Validate() error {
 			r.Spec.GatewayRef, "specify at most one of gatewayRef and gatewayDef")
 	}
 
-	if len(r.Spec.GatewaySpec.Listeners) == DefaultHttpListenersCount {
+	if len(r.Spec.GatewaySpec.Listeners) == DefaultHttpListenersCount && r.Spec.TLS == nil {
 		return field.Required(field.NewPath("spec", "gatewaySpec", "listeners"),
 			"must specify at least one listener")
 	}
+
+	if len(r.Spec.GatewaySpec.Listeners) > MaxListeners {
+		return field.TooMany(field.NewPath("spec", "gatewaySpec", "listeners"),
+			len(r.Spec.GatewaySpec.Listeners), MaxListeners)
+	}
+
+	return r.validateTLS()
+}
+
+func (r *Gateway) validateTLS() error {
+	tls := r.Spec.TLS
+	if tls == nil {
+		return nil
+	}
+
+	tlsPath := field.NewPath("spec", "tls")
+	if tls.CertificateRef == nil && tls.IssuerRef == nil {
+		return field.Required(tlsPath, "must specify one of certificateRef and issuerRef")
+	}
+
+	if tls.CertificateRef != nil && tls.IssuerRef != nil {
+		return field.Invalid(tlsPath.Child("issuerRef"), tls.IssuerRef.Name,
+			"specify at most one of certificateRef and issuerRef")
+	}
+
+	if tls.CertificateRef != nil && tls.CertificateRef.Name == "" {
+		return field.Required(tlsPath.Child("certificateRef", "name"), "must specify the name of the certificate secret")
+	}
+
+	if tls.CertificateMode != "" && tls.CertificateMode != CertificateModeWildcard &&
+		tls.CertificateMode != CertificateModePerFunction {
+		return field.NotSupported(tlsPath.Child("certificateMode"), tls.CertificateMode,
+			[]string{string(CertificateModeWildcard), string(CertificateModePerFunction)})
+	}
+
+	if tls.CertificateRef != nil && tls.CertificateMode == CertificateModePerFunction {
+		return field.Invalid(tlsPath.Child("certificateMode"), tls.CertificateMode,
+			"the PerFunction certificate mode requires issuerRef")
+	}
+
+	if tls.CertificateMode != CertificateModePerFunction {
+		if err := r.validateWildcardHost(); err != nil {
+			return err
+		}
+	}
+
+	if tls.IssuerRef != nil {
+		if tls.IssuerRef.Name == "" {
+			return field.Required(tlsPath.Child("issuerRef", "name"), "must specify the name of the issuer")
+		}
+		if tls.IssuerRef.Kind != IssuerKind && tls.IssuerRef.Kind != ClusterIssuerKind {
+			return field.NotSupported(tlsPath.Child("issuerRef", "kind"), tls.IssuerRef.Kind,
+				[]string{IssuerKind, ClusterIssuerKind})
+		}
+	}
+
+	for _, listener := range r.Spec.GatewaySpec.Listeners {
+		if listener.Name == DefaultHttpsListenerName {
+			continue
+		}
+		if listener.Port == tls.Port && listener.Protocol != k8sgatewayapiv1beta1.HTTPSProtocolType {
+			return field.Invalid(tlsPath.Child("port"), tls.Port,
+				fmt.Sprintf("port is used by the non HTTPS listener %s", listener.Name))
+		}
+	}
+
+	return nil
+}
+
+// validateWildcardHost checks that the hosts rendered from the HostTemplate are covered by the wildcard certificate
+// for `*.{Domain}`, whose wildcard only matches a single label.
+func (r *Gateway) validateWildcardHost() error {
+	data := RouteTemplateData{Name: r.Name, Namespace: r.Namespace, Domain: r.Spec.Domain, Version: "v1", Engine: "knative"}
+	host, err := renderRouteTemplate("host", r.Spec.HostTemplate, data, "zero")
+	if err != nil {
+		return nil
+	}
+
+	suffix := "." + r.Spec.Domain
+	if !strings.HasSuffix(host, suffix) || strings.Contains(strings.TrimSuffix(host, suffix), ".") {
+		return field.Invalid(field.NewPath("spec", "hostTemplate"), r.Spec.HostTemplate,
+			fmt.Sprintf("the host %s is not covered by the wildcard certificate for *.%s, "+
+				"the template must render a single label before {{.Domain}}", host, r.Spec.Domain))
+	}
 	return nil
 }
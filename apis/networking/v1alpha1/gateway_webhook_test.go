@@ -0,0 +1,336 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_GatewayValidateTemplates(t *testing.T) {
+	newGateway := func(hostTemplate, pathTemplate string) Gateway {
+		return Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+			Spec: GatewaySpec{
+				Domain:       "ofn.io",
+				HostTemplate: hostTemplate,
+				PathTemplate: pathTemplate,
+				RoutingMode:  RoutingModeIngress,
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		r       Gateway
+		wantErr bool
+	}{
+		{
+			name:    "gateway.spec.templates.default",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", "{{.Namespace}}/{{.Name}}"),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.templates.variables",
+			r:       newGateway("{{.Labels.team}}.{{.Domain}}", "{{.Engine}}/{{.Version}}/{{index .Annotations \"app\"}}"),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.hostTemplate.syntax",
+			r:       newGateway("{{.Name", "{{.Namespace}}/{{.Name}}"),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.hostTemplate.unknown",
+			r:       newGateway("{{.Team}}.{{.Domain}}", "{{.Namespace}}/{{.Name}}"),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.pathTemplate.unknown",
+			r:       newGateway("{{.Name}}.{{.Domain}}", "{{.Namespace}}/{{.Domain.Name}}"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_GatewayRenderTemplates(t *testing.T) {
+	gateway := Gateway{Spec: GatewaySpec{Domain: "ofn.io", HostTemplate: "{{.Labels.team}}.{{.Domain}}"}}
+
+	host, err := gateway.RenderHostTemplate(RouteTemplateData{Domain: "ofn.io", Labels: map[string]string{"team": "payments"}})
+	if err != nil || host != "payments.ofn.io" {
+		t.Errorf("RenderHostTemplate() = %s, %v, want payments.ofn.io", host, err)
+	}
+
+	if _, err := gateway.RenderHostTemplate(RouteTemplateData{Domain: "ofn.io"}); err == nil {
+		t.Errorf("RenderHostTemplate() should fail if the label is missing")
+	}
+}
+
+func Test_GatewayValidateTLS(t *testing.T) {
+	newGateway := func(hostTemplate string, tls *GatewayTLS, listeners int) Gateway {
+		gateway := Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+			Spec: GatewaySpec{
+				Domain:        "ofn.io",
+				ClusterDomain: "cluster.local",
+				HostTemplate:  hostTemplate,
+				PathTemplate:  "{{.Namespace}}/{{.Name}}",
+				GatewayRef:    &GatewayRef{Namespace: "gateway", Name: "gateway"},
+				TLS:           tls,
+			},
+		}
+		for i := 0; i < listeners; i++ {
+			gateway.Spec.GatewaySpec.Listeners = append(gateway.Spec.GatewaySpec.Listeners, k8sgatewayapiv1beta1.Listener{
+				Name:     k8sgatewayapiv1beta1.SectionName(fmt.Sprintf("listener-%d", i)),
+				Port:     k8sgatewayapiv1beta1.PortNumber(8000 + i),
+				Protocol: k8sgatewayapiv1beta1.HTTPSProtocolType,
+			})
+		}
+		gateway.Default()
+		return gateway
+	}
+	wildcard := func() *GatewayTLS {
+		return &GatewayTLS{CertificateRef: &k8sgatewayapiv1beta1.SecretObjectReference{Name: "wildcard"}}
+	}
+	issuer := func() *GatewayTLS {
+		return &GatewayTLS{IssuerRef: &IssuerRef{Name: "issuer"}}
+	}
+	perFunction := func() *GatewayTLS {
+		return &GatewayTLS{IssuerRef: &IssuerRef{Name: "issuer"}, CertificateMode: CertificateModePerFunction}
+	}
+
+	tests := []struct {
+		name    string
+		r       Gateway
+		wantErr bool
+	}{
+		{
+			name:    "gateway.spec.tls.certificateRef.singleLabel",
+			r:       newGateway("{{.Name}}-{{.Namespace}}.{{.Domain}}", wildcard(), 0),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.tls.certificateRef.multipleLabels",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", wildcard(), 0),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.tls.certificateRef.otherDomain",
+			r:       newGateway("{{.Name}}.example.com", wildcard(), 0),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.tls.issuerRef.singleLabel",
+			r:       newGateway("{{.Name}}-{{.Namespace}}.{{.Domain}}", issuer(), 0),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.tls.issuerRef.multipleLabels",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", issuer(), 0),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.tls.issuerRef.perFunction.multipleLabels",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", perFunction(), 0),
+			wantErr: false,
+		},
+		{
+			name: "gateway.spec.tls.certificateRef.perFunction",
+			r: newGateway("{{.Name}}-{{.Namespace}}.{{.Domain}}", &GatewayTLS{
+				CertificateRef:  &k8sgatewayapiv1beta1.SecretObjectReference{Name: "wildcard"},
+				CertificateMode: CertificateModePerFunction,
+			}, 0),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.gatewaySpec.listeners.max",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", perFunction(), MaxListeners-1),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.gatewaySpec.listeners.tooMany",
+			r:       newGateway("{{.Name}}.{{.Namespace}}.{{.Domain}}", perFunction(), MaxListeners),
+			wantErr: true,
+		},
+		{
+			// The default HTTPS listener serving the wildcard certificate counts as well.
+			name:    "gateway.spec.gatewaySpec.listeners.wildcard.tooMany",
+			r:       newGateway("{{.Name}}-{{.Namespace}}.{{.Domain}}", issuer(), MaxListeners-1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_GatewayValidateRoutingMode(t *testing.T) {
+	newGateway := func(mode RoutingMode, ref *GatewayRef, tls *GatewayTLS) Gateway {
+		gateway := Gateway{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+			Spec: GatewaySpec{
+				Domain:        "ofn.io",
+				ClusterDomain: "cluster.local",
+				HostTemplate:  "{{.Name}}.{{.Namespace}}.{{.Domain}}",
+				PathTemplate:  "{{.Namespace}}/{{.Name}}",
+				GatewayRef:    ref,
+				TLS:           tls,
+				RoutingMode:   mode,
+			},
+		}
+		gateway.Spec.GatewaySpec.Listeners = []k8sgatewayapiv1beta1.Listener{
+			{Name: "http", Port: 80, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType},
+		}
+		gateway.Default()
+		return gateway
+	}
+	ref := &GatewayRef{Namespace: "gateway", Name: "gateway"}
+	issuer := &GatewayTLS{IssuerRef: &IssuerRef{Name: "issuer"}}
+
+	tests := []struct {
+		name    string
+		r       Gateway
+		wantErr bool
+	}{
+		{
+			name:    "gateway.spec.routingMode.auto.gatewayRef",
+			r:       newGateway("", ref, nil),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.routingMode.auto.noGatewayRef",
+			r:       newGateway("", nil, nil),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.routingMode.auto.noGatewayRef.tls",
+			r:       newGateway("", nil, issuer),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.routingMode.gatewayAPI.noGatewayRef",
+			r:       newGateway(RoutingModeGatewayAPI, nil, nil),
+			wantErr: true,
+		},
+		{
+			name:    "gateway.spec.routingMode.ingress.noGatewayRef",
+			r:       newGateway(RoutingModeIngress, nil, nil),
+			wantErr: false,
+		},
+		{
+			name:    "gateway.spec.routingMode.ingress.tls",
+			r:       newGateway(RoutingModeIngress, ref, issuer),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_GatewayDefaultTLS(t *testing.T) {
+	gateway := Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+		Spec: GatewaySpec{
+			Domain:        "ofn.io",
+			ClusterDomain: "cluster.local",
+			GatewaySpec: K8sGatewaySpec{Listeners: []k8sgatewayapiv1beta1.Listener{
+				{Name: "ofn-http-external", Port: 80, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType},
+			}},
+			TLS: &GatewayTLS{CertificateRef: &k8sgatewayapiv1beta1.SecretObjectReference{Name: "wildcard"}},
+		},
+	}
+	gateway.Default()
+
+	var names []string
+	for _, listener := range gateway.Spec.GatewaySpec.Listeners {
+		names = append(names, string(listener.Name))
+	}
+	if want := []string{DefaultHttpListenerName, DefaultHttpsListenerName}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected listeners %v, got %v", want, names)
+	}
+
+	gateway.Spec.TLS = nil
+	gateway.Spec.GatewaySpec.Listeners = append(gateway.Spec.GatewaySpec.Listeners, k8sgatewayapiv1beta1.Listener{
+		Name: "ofn-http-external", Port: 80, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType,
+	})
+	gateway.Default()
+	names = nil
+	for _, listener := range gateway.Spec.GatewaySpec.Listeners {
+		names = append(names, string(listener.Name))
+	}
+	if want := []string{DefaultHttpListenerName, "ofn-http-external"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected listeners %v, got %v", want, names)
+	}
+}
+
+func Test_GatewayDefaultTLSIssuer(t *testing.T) {
+	gateway := Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+		Spec: GatewaySpec{
+			Domain:        "ofn.io",
+			ClusterDomain: "cluster.local",
+			TLS:           &GatewayTLS{IssuerRef: &IssuerRef{Name: "issuer"}},
+		},
+	}
+	gateway.Default()
+
+	if gateway.Spec.TLS.CertificateMode != CertificateModeWildcard {
+		t.Errorf("expected the %s certificate mode by default, got %s", CertificateModeWildcard, gateway.Spec.TLS.CertificateMode)
+	}
+	var https *k8sgatewayapiv1beta1.Listener
+	for index := range gateway.Spec.GatewaySpec.Listeners {
+		if gateway.Spec.GatewaySpec.Listeners[index].Name == DefaultHttpsListenerName {
+			https = &gateway.Spec.GatewaySpec.Listeners[index]
+		}
+	}
+	if https == nil {
+		t.Fatal("expected the HTTPS listener serving the wildcard certificate")
+	}
+	if *https.Hostname != "*.ofn.io" ||
+		string(https.TLS.CertificateRefs[0].Name) != "ofn-wildcard.openfunction.openfunction-tls" {
+		t.Errorf("unexpected HTTPS listener %s with certificate %s", *https.Hostname, https.TLS.CertificateRefs[0].Name)
+	}
+
+	// The functions are served by dedicated listeners in the PerFunction certificate mode.
+	gateway.Spec.TLS.CertificateMode = CertificateModePerFunction
+	gateway.Default()
+	for _, listener := range gateway.Spec.GatewaySpec.Listeners {
+		if listener.Name == DefaultHttpsListenerName {
+			t.Errorf("unexpected HTTPS listener in the %s certificate mode", CertificateModePerFunction)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/openfunction/pkg/event"
+	"github.com/openfunction/pkg/event/eventsource/rabbitmq"
+	"github.com/openfunction/pkg/event/eventsource/standalone"
+)
+
+func main() {
+	config, err := standalone.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the handler config: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	// The log level of the EventSource is "1", "2" or "3", higher levels print the debug logs.
+	level, err := strconv.Atoi(config.LogLevel)
+	if err != nil || level < 1 {
+		level = 1
+	}
+	opts := zap.Options{
+		Level:       zapcore.Level(1 - level),
+		TimeEncoder: zapcore.RFC3339TimeEncoder,
+	}
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("StandaloneEventSourceHandler")
+
+	// The init container of the handler of a RabbitMQ event source only binds the queue before the handler starts.
+	if config.RabbitMQ != nil {
+		if err := rabbitmq.BindQueue(os.Getenv(event.RabbitMQHostEnv), config.RabbitMQ); err != nil {
+			log.Error(err, "Failed to bind the queue", "queue", config.RabbitMQ.QueueName, "exchange", config.RabbitMQ.Exchange)
+			os.Exit(1)
+		}
+		log.Info("Bound the queue", "queue", config.RabbitMQ.QueueName, "exchange", config.RabbitMQ.Exchange)
+		return
+	}
+
+	handler, err := standalone.NewHandler(log, config, ctrl.GetConfigOrDie())
+	if err != nil {
+		log.Error(err, "Failed to create the handler")
+		os.Exit(1)
+	}
+
+	if err := handler.Run(ctrl.SetupSignalHandler()); err != nil {
+		log.Error(err, "Failed to run the handler")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+	"github.com/openfunction/pkg/util"
+)
+
+// syncAuthPolicy translates the authentication of a function into a SecurityPolicy of Envoy Gateway which targets
+// the HTTPRoute of the function, and deletes the policy once the authentication is removed.
+// A condition is returned if the authentication is not enforced. The route must not be exposed if the authentication
+// can not be enforced, and it must be held without backends until the policy is accepted by Envoy Gateway, as the
+// policy is only processed once the route it targets exists.
+func (r *FunctionReconciler) syncAuthPolicy(
+	fn *openfunction.Function,
+	gateway *networkingv1alpha1.Gateway,
+	ingressMode bool) (*metav1.Condition, error) {
+	log := r.Log.WithName("syncAuthPolicy")
+	auth := fn.Spec.Serving.Triggers.Http.Auth
+
+	if auth == nil || ingressMode {
+		if err := r.deleteGatewayPolicy(fn, ofngateway.SecurityPolicyGVK); err != nil {
+			return nil, err
+		}
+		if auth == nil {
+			return nil, nil
+		}
+		return authNotSupportedCondition("authentication is not supported in the Ingress routing mode"), nil
+	}
+
+	if _, err := r.RESTMapper().RESTMapping(ofngateway.SecurityPolicyGVK.GroupKind(), ofngateway.SecurityPolicyGVK.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return authNotSupportedCondition(fmt.Sprintf("authentication requires %s of Envoy Gateway, which is not served",
+				ofngateway.SecurityPolicyGVK.GroupVersion().WithResource("securitypolicies").GroupResource())), nil
+		}
+		return nil, err
+	}
+
+	// Other implementations ignore the SecurityPolicy, the route would be exposed without authentication.
+	controllerName, err := r.gatewayControllerName(gateway)
+	if err != nil {
+		if util.IsNotFound(err) {
+			return authNotSupportedCondition(fmt.Sprintf("failed to find the GatewayClass of the gateway: %v", err)), nil
+		}
+		return nil, err
+	}
+	if controllerName != ofngateway.EnvoyGatewayControllerName {
+		return authNotSupportedCondition(fmt.Sprintf("authentication requires a GatewayClass of Envoy Gateway, "+
+			"the GatewayClass of the gateway is controlled by %s", controllerName)), nil
+	}
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ofngateway.SecurityPolicyGVK)
+	policy.SetNamespace(fn.Namespace)
+	policy.SetName(fn.Name)
+	op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, policy, func() error {
+		policy.Object["spec"] = ofngateway.NewSecurityPolicySpec(fn.Name, auth)
+		return ctrl.SetControllerReference(fn, policy, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to CreateOrUpdate SecurityPolicy", "namespace", fn.Namespace, "name", fn.Name)
+		return nil, err
+	}
+	log.V(1).Info(fmt.Sprintf("SecurityPolicy %s", op))
+
+	accepted, err := ofngateway.PolicyAcceptedCondition(policy)
+	if err != nil {
+		log.Error(err, "Failed to get the status of SecurityPolicy", "namespace", fn.Namespace, "name", fn.Name)
+		return nil, err
+	}
+	if accepted == nil {
+		return &metav1.Condition{
+			Type:    RouteConditionAccepted,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonAuthPending,
+			Message: "the route is held until the SecurityPolicy is accepted",
+		}, nil
+	}
+	if accepted.Status != metav1.ConditionTrue {
+		return &metav1.Condition{
+			Type:    RouteConditionAccepted,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonAuthNotAccepted,
+			Message: fmt.Sprintf("the route is held as the SecurityPolicy is not accepted: %s", accepted.Message),
+		}, nil
+	}
+	return nil, nil
+}
+
+// gatewayControllerName returns the controller name of the GatewayClass of the k8s Gateway of the gateway.
+func (r *FunctionReconciler) gatewayControllerName(gateway *networkingv1alpha1.Gateway) (string, error) {
+	key := client.ObjectKey{}
+	if gateway.Spec.GatewayRef != nil {
+		key = client.ObjectKey{Namespace: gateway.Spec.GatewayRef.Namespace, Name: gateway.Spec.GatewayRef.Name}
+	}
+	if gateway.Spec.GatewayDef != nil {
+		key = client.ObjectKey{Namespace: gateway.Spec.GatewayDef.Namespace, Name: gateway.Spec.GatewayDef.Name}
+	}
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{}
+	if err := r.Get(r.ctx, key, k8sGateway); err != nil {
+		return "", err
+	}
+	gatewayClass := &k8sgatewayapiv1beta1.GatewayClass{}
+	if err := r.Get(r.ctx, client.ObjectKey{Name: string(k8sGateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
+		return "", err
+	}
+	return string(gatewayClass.Spec.ControllerName), nil
+}
+
+// authHeld returns whether the route of a function is held by the condition of its authentication.
+func authHeld(condition *metav1.Condition) bool {
+	return condition != nil && (condition.Reason == RouteReasonAuthPending || condition.Reason == RouteReasonAuthNotAccepted)
+}
+
+// mutateAuthHTTPRoute removes the backends of the HTTPRoute of a function while the route is held by its
+// authentication, the requests are answered by the gateway with an error then. The route keeps serving the internal
+// hostname, which is authenticated by the SecurityPolicy as well, as the gateway can not tell the in-cluster callers
+// from the external ones sending the internal hostname.
+func mutateAuthHTTPRoute(
+	fn *openfunction.Function,
+	held bool,
+	httpRoute *k8sgatewayapiv1beta1.HTTPRoute,
+	mutate controllerutil.MutateFn) controllerutil.MutateFn {
+	return func() error {
+		if err := mutate(); err != nil {
+			return err
+		}
+		if fn.Spec.Serving.Triggers.Http.Auth == nil || !held {
+			return nil
+		}
+		for index := range httpRoute.Spec.Rules {
+			httpRoute.Spec.Rules[index].BackendRefs = nil
+			httpRoute.Spec.Rules[index].Filters = nil
+		}
+		return nil
+	}
+}
+
+// deleteGatewayPolicy deletes the policy of the gateway implementation generated for a function.
+func (r *FunctionReconciler) deleteGatewayPolicy(fn *openfunction.Function, gvk schema.GroupVersionKind) error {
+	log := r.Log.WithName("deleteGatewayPolicy")
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(gvk)
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: fn.Name}, policy); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return util.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(policy, fn) {
+		return nil
+	}
+	if err := r.Delete(r.ctx, policy); util.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to delete policy", "kind", gvk.Kind, "namespace", fn.Namespace, "name", fn.Name)
+		return err
+	}
+	log.Info("Policy deleted", "kind", gvk.Kind, "namespace", fn.Namespace, "name", fn.Name)
+	return nil
+}
+
+func authNotSupportedCondition(message string) *metav1.Condition {
+	return &metav1.Condition{
+		Type:    RouteConditionAccepted,
+		Status:  metav1.ConditionFalse,
+		Reason:  RouteReasonAuthNotSupported,
+		Message: message,
+	}
+}
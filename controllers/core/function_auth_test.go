@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+func newPolicyTestGateway(controllerName string) (*networkingv1alpha1.Gateway, []client.Object) {
+	gateway := &networkingv1alpha1.Gateway{}
+	gateway.Spec.ClusterDomain = "cluster.local"
+	gateway.Spec.GatewayRef = &networkingv1alpha1.GatewayRef{Namespace: "gateway", Name: "eg"}
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "gateway", Name: "eg"},
+		Spec:       k8sgatewayapiv1beta1.GatewaySpec{GatewayClassName: "eg"},
+	}
+	gatewayClass := &k8sgatewayapiv1beta1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "eg"},
+		Spec:       k8sgatewayapiv1beta1.GatewayClassSpec{ControllerName: k8sgatewayapiv1beta1.GatewayController(controllerName)},
+	}
+	return gateway, []client.Object{k8sGateway, gatewayClass}
+}
+
+func newAuthTestFunction() *ofcore.Function {
+	fn := newRouteTestFunction(&ofcore.RouteImpl{})
+	fn.UID = "uid"
+	fn.Spec.Serving.Triggers.Http.Auth = &ofcore.HttpAuth{
+		JWT: &ofcore.JWTAuth{Issuer: "https://issuer", JWKSURI: "https://issuer/jwks"},
+	}
+	return fn
+}
+
+func Test_syncAuthPolicy(t *testing.T) {
+	fn := newAuthTestFunction()
+
+	gateway, objs := newPolicyTestGateway("projectcontour.io/gateway-controller")
+	r := newTestReconciler(t, objs...)
+	condition, err := r.syncAuthPolicy(fn, gateway, false)
+	if err != nil {
+		t.Fatalf("syncAuthPolicy() error = %v", err)
+	}
+	if condition == nil || condition.Reason != RouteReasonAuthNotSupported {
+		t.Errorf("expected the route of other implementations not to be exposed, got %v", condition)
+	}
+
+	gateway, objs = newPolicyTestGateway(ofngateway.EnvoyGatewayControllerName)
+	r = newTestReconciler(t, objs...)
+	condition, err = r.syncAuthPolicy(fn, gateway, false)
+	if err != nil {
+		t.Fatalf("syncAuthPolicy() error = %v", err)
+	}
+	if !authHeld(condition) || condition.Reason != RouteReasonAuthPending {
+		t.Errorf("expected the route to be held until the policy is accepted, got %v", condition)
+	}
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ofngateway.SecurityPolicyGVK)
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: fn.Name}, policy); err != nil {
+		t.Fatalf("failed to get the policy: %v", err)
+	}
+	setPolicyAccepted := func(status string) {
+		setPolicyAcceptedStatus(t, r, policy, status)
+	}
+
+	setPolicyAccepted("False")
+	if condition, _ = r.syncAuthPolicy(fn, gateway, false); !authHeld(condition) || condition.Reason != RouteReasonAuthNotAccepted {
+		t.Errorf("expected the route to be held while the policy is not accepted, got %v", condition)
+	}
+	setPolicyAccepted("True")
+	if condition, _ = r.syncAuthPolicy(fn, gateway, false); condition != nil {
+		t.Errorf("expected the route to be served once the policy is accepted, got %v", condition)
+	}
+
+	if condition, _ = r.syncAuthPolicy(fn, gateway, true); condition == nil || condition.Reason != RouteReasonAuthNotSupported {
+		t.Errorf("expected authentication not to be supported in the Ingress routing mode, got %v", condition)
+	}
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: fn.Name}, policy); err == nil {
+		t.Errorf("expected the policy to be deleted in the Ingress routing mode")
+	}
+}
+
+func Test_mutateAuthHTTPRoute(t *testing.T) {
+	fn := newAuthTestFunction()
+	hostnames := []k8sgatewayapiv1beta1.Hostname{"sample.default.ofn.io", "sample.default.svc.cluster.local"}
+	mutate := func(httpRoute *k8sgatewayapiv1beta1.HTTPRoute) func() error {
+		return func() error {
+			httpRoute.Spec.Hostnames = hostnames
+			httpRoute.Spec.Rules = []k8sgatewayapiv1beta1.HTTPRouteRule{pathRule(k8sgatewayapiv1beta1.PathMatchPathPrefix, "/")}
+			httpRoute.Spec.Rules[0].BackendRefs = []k8sgatewayapiv1beta1.HTTPBackendRef{{}}
+			return nil
+		}
+	}
+
+	httpRoute := &k8sgatewayapiv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: fn.Name}}
+	if err := mutateAuthHTTPRoute(fn, true, httpRoute, mutate(httpRoute))(); err != nil {
+		t.Fatal(err)
+	}
+	if len(httpRoute.Spec.Rules[0].BackendRefs) != 0 {
+		t.Errorf("expected the backends of the held route to be removed")
+	}
+
+	httpRoute = &k8sgatewayapiv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: fn.Name}}
+	if err := mutateAuthHTTPRoute(fn, false, httpRoute, mutate(httpRoute))(); err != nil {
+		t.Fatal(err)
+	}
+	if len(httpRoute.Spec.Rules[0].BackendRefs) != 1 {
+		t.Errorf("expected the backends of the route to be kept once the policy is accepted")
+	}
+	// The internal hostname is served by the route targeted by the SecurityPolicy, so it is authenticated as well.
+	if !reflect.DeepEqual(httpRoute.Spec.Hostnames, hostnames) {
+		t.Errorf("expected the hostnames %v, got %v", hostnames, httpRoute.Spec.Hostnames)
+	}
+	spec := ofngateway.NewSecurityPolicySpec(fn.Name, fn.Spec.Serving.Triggers.Http.Auth)
+	targetRefs := spec["targetRefs"].([]interface{})
+	if len(targetRefs) != 1 || targetRefs[0].(map[string]interface{})["name"] != httpRoute.Name {
+		t.Errorf("expected the SecurityPolicy to target the route %s serving the internal hostname, got %v", httpRoute.Name, targetRefs)
+	}
+}
+
+// setPolicyAcceptedStatus reports the Accepted condition of a policy as Envoy Gateway does.
+func setPolicyAcceptedStatus(t *testing.T, r *FunctionReconciler, policy *unstructured.Unstructured, status string) {
+	policy.Object["status"] = map[string]interface{}{
+		"ancestors": []interface{}{map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{
+				"type":               "Accepted",
+				"status":             status,
+				"reason":             "Test",
+				"message":            "test",
+				"lastTransitionTime": "2023-01-01T00:00:00Z",
+			}},
+		}},
+	}
+	if err := r.Update(r.ctx, policy); err != nil {
+		t.Fatalf("failed to update the policy: %v", err)
+	}
+}
@@ -17,28 +17,31 @@ limitations under the License.
 package core
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"net/url"
 	"reflect"
 	"sort"
 	"strings"
-	"text/template"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/events"
 	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -47,17 +50,58 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	openfunction "github.com/openfunction/apis/core/v1beta2"
 	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
 	"github.com/openfunction/pkg/constants"
 	"github.com/openfunction/pkg/core/serving/common"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
 	"github.com/openfunction/pkg/util"
 )
 
 const (
-	GatewayField = ".spec.route.gatewayRef"
+	GatewayField          = ".spec.route.gatewayRef"
+	ParamsSourceField     = ".spec.serving.paramsSource"
+	BackendNamespaceField = ".spec.route.backendNamespace"
+
+	RouteConditionAccepted     = "Accepted"
+	RouteReasonAccepted        = "Accepted"
+	RouteReasonPending         = "Pending"
+	RouteReasonInvalidTemplate = "InvalidTemplate"
+	// RouteReasonGatewayAPINotServed and RouteReasonGatewayNotSpecified mean the route is served by Gateway API,
+	// but the cluster does not serve Gateway API or the gateway specifies no k8s Gateway.
+	RouteReasonGatewayAPINotServed = "GatewayAPINotServed"
+	RouteReasonGatewayNotSpecified = "GatewayNotSpecified"
+	RouteConditionResolvedRefs     = "ResolvedRefs"
+	RouteReasonRefNotPermitted     = "RefNotPermitted"
+	// RouteReasonAuthNotSupported means the route is not exposed as its authentication can not be enforced.
+	RouteReasonAuthNotSupported = "AuthNotSupported"
+	// RouteReasonAuthPending and RouteReasonAuthNotAccepted mean the route is held without backends
+	// until the gateway implementation accepts its authentication.
+	RouteReasonAuthPending     = "AuthPending"
+	RouteReasonAuthNotAccepted = "AuthNotAccepted"
+	// RouteConditionRateLimited reports whether the rate limit of the route is enforced.
+	RouteConditionRateLimited        = "RateLimited"
+	RouteReasonRateLimitNotSupported = "RateLimitNotSupported"
+	RouteReasonRateLimitNotAccepted  = "RateLimitNotAccepted"
+	RouteReasonBurstNotSupported     = "BurstNotSupported"
+	// RouteConditionConcurrencyLimited reports whether the concurrent requests to an instance of the function are limited.
+	RouteConditionConcurrencyLimited        = "ConcurrencyLimited"
+	RouteReasonConcurrencyLimitNotSupported = "ConcurrencyLimitNotSupported"
+	// RouteConditionVersionRoutesResolved reports whether the version routes are served by the revisions of the versions.
+	RouteConditionVersionRoutesResolved  = "VersionRoutesResolved"
+	RouteReasonVersionRoutesNotSupported = "VersionRoutesNotSupported"
+	RouteReasonVersionNotFound           = "VersionNotFound"
+	// RouteConditionMirrored reports whether the requests are mirrored to the new serving.
+	RouteConditionMirrored        = "Mirrored"
+	RouteReasonMirrorNotSupported = "MirrorNotSupported"
+	// RouteConditionPathPrefixStripped reports whether the matched path prefix is stripped as the route requires.
+	RouteConditionPathPrefixStripped       = "PathPrefixStripped"
+	RouteReasonStripPathPrefixNotSupported = "StripPathPrefixNotSupported"
+	// RouteConditionFeaturesSupported reports whether all the features of the route rules are served in the routing mode.
+	RouteConditionFeaturesSupported = "FeaturesSupported"
+	RouteReasonFeaturesDropped      = "FeaturesDropped"
 
 	buildAction   = "Build"
 	servingAction = "Serving"
@@ -66,22 +110,32 @@ const (
 // FunctionReconciler reconciles a Function object
 type FunctionReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	ctx      context.Context
-	interval time.Duration
+	// paramsSources reads the Secrets and ConfigMaps referenced by `paramsFrom` and `envFrom` from a cache
+	// which only holds the ones labelled `openfunction.io/params-source=true`.
+	paramsSources client.Reader
+	Log           logr.Logger
+	Scheme        *runtime.Scheme
+	ctx           context.Context
+	interval      time.Duration
+	// gatewayAPIVersion is the Gateway API version served by the cluster.
+	gatewayAPIVersion string
 
 	eventRecorder events.EventRecorder
 }
 
-func NewFunctionReconciler(mgr manager.Manager, interval time.Duration, eventRecorder events.EventRecorder) *FunctionReconciler {
+func NewFunctionReconciler(
+	mgr manager.Manager,
+	interval time.Duration,
+	gatewayAPIVersion string,
+	eventRecorder events.EventRecorder) *FunctionReconciler {
 
 	r := &FunctionReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Log:           ctrl.Log.WithName("controllers").WithName("Function"),
-		interval:      interval,
-		eventRecorder: eventRecorder,
+		Client:            ofngateway.NewClient(mgr.GetClient(), mgr.GetCache(), gatewayAPIVersion),
+		gatewayAPIVersion: gatewayAPIVersion,
+		Scheme:            mgr.GetScheme(),
+		Log:               ctrl.Log.WithName("controllers").WithName("Function"),
+		interval:          interval,
+		eventRecorder:     eventRecorder,
 	}
 
 	r.startFunctionWatcher()
@@ -92,8 +146,13 @@ func NewFunctionReconciler(mgr manager.Manager, interval time.Duration, eventRec
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=list;get;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=list;watch
 //+kubebuilder:rbac:groups=serving.knative.dev,resources=services,verbs=get;list;watch
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gatewayclasses;gateways,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants;referencepolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.envoyproxy.io,resources=securitypolicies;backendtrafficpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=networking.openfunction.io,resources=gateways,verbs=get;list;watch
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=get;list;watch;create;update;patch;delete
@@ -396,7 +455,13 @@ func (r *FunctionReconciler) createServing(fn *openfunction.Function) error {
 	log := r.Log.WithName("CreateServing").
 		WithValues("Function", fmt.Sprintf("%s/%s", fn.Namespace, fn.Name))
 
-	if !r.needToCreateServing(fn) {
+	needToCreate, err := r.needToCreateServing(fn)
+	if err != nil {
+		log.Error(err, "Failed to check whether the serving needs to be created")
+		return err
+	}
+
+	if !needToCreate {
 		log.V(1).Info("No need to create Serving")
 
 		if err := r.updateFuncWithServingStatus(fn); err != nil {
@@ -438,6 +503,13 @@ func (r *FunctionReconciler) createServing(fn *openfunction.Function) error {
 		log.V(1).Info("Skip serving")
 		return nil
 	}
+
+	spec, err := r.createServingSpec(fn)
+	if err != nil {
+		log.Error(err, "Failed to create serving spec")
+		return err
+	}
+
 	serving := &openfunction.Serving{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "serving-",
@@ -447,7 +519,7 @@ func (r *FunctionReconciler) createServing(fn *openfunction.Function) error {
 			},
 			Annotations: fn.Annotations,
 		},
-		Spec: r.createServingSpec(fn),
+		Spec: spec,
 	}
 	serving.SetOwnerReferences(nil)
 	if err := ctrl.SetControllerReference(fn, serving, r.Scheme); err != nil {
@@ -511,29 +583,38 @@ func (r *FunctionReconciler) updateFuncWithServingStatus(fn *openfunction.Functi
 	}
 
 	// If serving status changed, update function serving status.
-	if fn.Status.Serving.State != serving.Status.State ||
+	stateChanged := fn.Status.Serving.State != serving.Status.State ||
 		fn.Status.Serving.Reason != serving.Status.Reason ||
-		fn.Status.Serving.Message != serving.Status.Message {
+		fn.Status.Serving.Message != serving.Status.Message
+	if stateChanged {
 		fn.Status.Serving.State = serving.Status.State
 		fn.Status.Serving.Reason = serving.Status.Reason
 		fn.Status.Serving.Message = serving.Status.Message
+	}
 
-		// If new serving is running, clean old serving.
-		if serving.Status.State == openfunction.Running {
-			fn.Status.Serving.LastSuccessfulResourceRef = fn.Status.Serving.ResourceRef
-			fn.Status.Serving.Service = serving.Status.Service
-			if err := r.cleanServing(fn); err != nil {
-				log.Error(err, "Failed to clean Serving")
-				return err
-			}
-			log.V(1).Info("Serving is running", "serving", serving.Name)
+	// If new serving is running, clean old serving.
+	// The cutover is held while the requests are mirrored to the new serving, unless there is no serving to fall back.
+	cutover := serving.Status.State == openfunction.Running &&
+		fn.Status.Serving.LastSuccessfulResourceRef != fn.Status.Serving.ResourceRef &&
+		!(holdsCutover(fn) && fn.Status.Serving.Service != "")
+	if cutover {
+		fn.Status.Serving.LastSuccessfulResourceRef = fn.Status.Serving.ResourceRef
+		fn.Status.Serving.Service = serving.Status.Service
+		if err := r.cleanServing(fn); err != nil {
+			log.Error(err, "Failed to clean Serving")
+			return err
 		}
+		log.V(1).Info("Serving is running", "serving", serving.Name)
+	}
 
+	if stateChanged || cutover {
 		if err := r.Status().Update(r.ctx, fn); err != nil {
 			log.Error(err, "Failed to update function status")
 			return err
 		}
+	}
 
+	if stateChanged {
 		r.recordEvent(fn, &serving, servingAction, fn.Status.Serving.State, fn.Status.Serving.Message)
 	}
 
@@ -557,8 +638,9 @@ func (r *FunctionReconciler) cleanServing(fn *openfunction.Function) error {
 		return err
 	}
 
+	retained := versionServings(fn, servings.Items)
 	for _, item := range servings.Items {
-		if item.Name != name && item.Name != oldName {
+		if item.Name != name && item.Name != oldName && !retained[item.Name] {
 			if err := r.Delete(context.Background(), &item); util.IgnoreNotFound(err) != nil {
 				return err
 			}
@@ -569,9 +651,9 @@ func (r *FunctionReconciler) cleanServing(fn *openfunction.Function) error {
 	return nil
 }
 
-func (r *FunctionReconciler) createServingSpec(fn *openfunction.Function) openfunction.ServingSpec {
+func (r *FunctionReconciler) createServingSpec(fn *openfunction.Function) (openfunction.ServingSpec, error) {
 	if fn.Spec.Serving == nil {
-		return openfunction.ServingSpec{}
+		return openfunction.ServingSpec{}, nil
 	}
 
 	spec := openfunction.ServingSpec{
@@ -580,8 +662,25 @@ func (r *FunctionReconciler) createServingSpec(fn *openfunction.Function) openfu
 		ImageCredentials: fn.Spec.ImageCredentials,
 		ServingImpl:      *fn.Spec.Serving.DeepCopy(),
 	}
+	// Toggling the mirror only holds or completes the cutover, a new serving is not needed.
+	if triggers := spec.Triggers; triggers != nil && triggers.Http != nil && triggers.Http.Route != nil {
+		triggers.Http.Route.Mirror = false
+	}
 
-	return spec
+	// Record the hash of the referenced Secrets and ConfigMaps, so that a new serving
+	// will be rolled out when their data changes.
+	hash, err := common.GetParamsHash(r.ctx, r.paramsSources, r.Client, fn.Namespace, fn.Spec.Serving)
+	if err != nil {
+		return spec, err
+	}
+	if hash != "" {
+		if spec.Annotations == nil {
+			spec.Annotations = map[string]string{}
+		}
+		spec.Annotations[common.ParamsHashAnnotation] = hash
+	}
+
+	return spec, nil
 }
 
 func getServingImage(fn *openfunction.Function) string {
@@ -647,7 +746,7 @@ func getBuilderHash(spec openfunction.BuilderSpec) string {
 	return util.Hash(newSpec)
 }
 
-func (r *FunctionReconciler) needToCreateServing(fn *openfunction.Function) bool {
+func (r *FunctionReconciler) needToCreateServing(fn *openfunction.Function) (bool, error) {
 
 	log := r.Log.WithName("NeedToCreateServing").
 		WithValues("Function", fmt.Sprintf("%s/%s", fn.Namespace, fn.Name))
@@ -658,7 +757,7 @@ func (r *FunctionReconciler) needToCreateServing(fn *openfunction.Function) bool
 			fn.Status.Build.State != openfunction.Succeeded &&
 			fn.Status.Build.State != openfunction.Skipped) {
 		log.V(1).Info("Build not completed")
-		return false
+		return false, nil
 	}
 
 	oldHash := fn.Status.Serving.ResourceHash
@@ -666,19 +765,24 @@ func (r *FunctionReconciler) needToCreateServing(fn *openfunction.Function) bool
 	// Serving had not created, need to create.
 	if fn.Status.Serving.State == "" || oldHash == "" || (oldName == "" && fn.Spec.Serving != nil) {
 		log.V(1).Info("Serving not created")
-		return true
+		return true, nil
+	}
+
+	spec, err := r.createServingSpec(fn)
+	if err != nil {
+		return false, err
 	}
 
-	newHash := util.Hash(r.createServingSpec(fn))
+	newHash := util.Hash(spec)
 	// Serving changed, need to update.
 	if newHash != oldHash {
 		log.V(1).Info("Serving changed", "old", oldHash, "new", newHash)
-		return true
+		return true, nil
 	}
 
 	// It will skip serving, no need to create serving.
 	if fn.Spec.Serving == nil {
-		return false
+		return false, nil
 	}
 
 	var serving openfunction.Serving
@@ -686,10 +790,10 @@ func (r *FunctionReconciler) needToCreateServing(fn *openfunction.Function) bool
 	if err := r.Get(r.ctx, key, &serving); util.IsNotFound(err) {
 		// If the serving is deleted, need to be recreated.
 		log.V(1).Info("Serving had been deleted")
-		return true
+		return true, nil
 	}
 
-	return false
+	return false, nil
 }
 
 func (r *FunctionReconciler) createOrUpdateHTTPRoute(fn *openfunction.Function) error {
@@ -705,19 +809,19 @@ func (r *FunctionReconciler) createOrUpdateHTTPRoute(fn *openfunction.Function)
 		return nil
 	}
 
-	namespace := constants.DefaultGatewayNamespace
+	namespace := openfunction.Namespace(constants.DefaultGatewayNamespace)
 	if fn.Spec.Serving.Triggers.Http.Route == nil {
 		route := openfunction.RouteImpl{
 			CommonRouteSpec: openfunction.CommonRouteSpec{
 				GatewayRef: &openfunction.GatewayRef{
-					Name:      constants.DefaultGatewayName,
+					Name:      openfunction.ObjectName(constants.DefaultGatewayName),
 					Namespace: &namespace,
 				},
 			},
 		}
 		fn.Spec.Serving.Triggers.Http.Route = &route
 	} else if fn.Spec.Serving.Triggers.Http.Route.GatewayRef == nil {
-		fn.Spec.Serving.Triggers.Http.Route.GatewayRef = &openfunction.GatewayRef{Name: constants.DefaultGatewayName, Namespace: &namespace}
+		fn.Spec.Serving.Triggers.Http.Route.GatewayRef = &openfunction.GatewayRef{Name: openfunction.ObjectName(constants.DefaultGatewayName), Namespace: &namespace}
 	}
 
 	route := fn.Spec.Serving.Triggers.Http.Route
@@ -732,9 +836,22 @@ func (r *FunctionReconciler) createOrUpdateHTTPRoute(fn *openfunction.Function)
 		return err
 	}
 
+	// The templates may refer to the labels and annotations of the function, which are not validated by the webhook.
+	if err := validateRouteTemplates(fn, gateway); err != nil {
+		log.Error(err, "Failed to render route templates", "namespace", fn.Namespace, "name", fn.Name)
+		return r.updateFuncWithRouteCondition(fn, metav1.Condition{
+			Type:    RouteConditionAccepted,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonInvalidTemplate,
+			Message: err.Error(),
+		})
+	}
+
+	var knativeService *kservingv1.Service
+	var kedaService *corev1.Service
 	if fn.Spec.Serving.Triggers.Http.Engine == nil || *fn.Spec.Serving.Triggers.Http.Engine == "" ||
 		*fn.Spec.Serving.Triggers.Http.Engine == openfunction.HttpEngineKnative {
-		knativeService := &kservingv1.Service{
+		knativeService = &kservingv1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      fn.Status.Serving.Service,
 				Namespace: fn.Namespace,
@@ -745,47 +862,129 @@ func (r *FunctionReconciler) createOrUpdateHTTPRoute(fn *openfunction.Function)
 				"namespace", fn.Namespace, "name", fn.Status.Serving.Service)
 			return err
 		}
-
-		httpRoute := &k8sgatewayapiv1alpha2.HTTPRoute{
-			ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace, Name: fn.Name},
-		}
-		op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, httpRoute, r.mutateHTTPRoute(fn, knativeService, nil, gateway, httpRoute))
-		if err != nil {
-			log.Error(err, "Failed to CreateOrUpdate HTTPRoute")
-			return err
-		}
-		log.V(1).Info(fmt.Sprintf("HTTPRoute %s", op))
-
-		if err := r.updateFuncWithHTTPRouteStatus(fn, gateway, httpRoute); err != nil {
-			return err
-		}
 	} else if *fn.Spec.Serving.Triggers.Http.Engine == openfunction.HttpEngineKeda {
-		service := &corev1.Service{
+		kedaService = &corev1.Service{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      fn.Status.Serving.Service,
 				Namespace: fn.Namespace,
 			},
 		}
-		if err := r.Get(r.ctx, client.ObjectKeyFromObject(service), service); err != nil {
+		if err := r.Get(r.ctx, client.ObjectKeyFromObject(kedaService), kedaService); err != nil {
 			log.Error(err, "Failed to get keda service",
 				"namespace", fn.Namespace, "name", fn.Status.Serving.Service)
 			return err
 		}
+	}
 
-		httpRoute := &k8sgatewayapiv1alpha2.HTTPRoute{
-			ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace, Name: fn.Name},
+	ingressMode := ofngateway.RoutingModeFor(gateway, r.gatewayAPIVersion) == networkingv1alpha1.RoutingModeIngress
+	if condition := r.gatewayAPICondition(gateway, ingressMode); condition != nil {
+		if r.gatewayAPIVersion != "" {
+			if err := r.deleteStaleRoute(fn, &k8sgatewayapiv1beta1.HTTPRoute{}); err != nil {
+				return err
+			}
 		}
-
-		op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, httpRoute, r.mutateHTTPRoute(fn, nil, service, gateway, httpRoute))
-		if err != nil {
-			log.Error(err, "Failed to CreateOrUpdate HTTPRoute")
+		if err := r.deleteStaleRoute(fn, &networkingv1.Ingress{}); err != nil {
 			return err
 		}
-		log.V(1).Info(fmt.Sprintf("HTTPRoute %s", op))
-
-		if err := r.updateFuncWithHTTPRouteStatus(fn, gateway, httpRoute); err != nil {
+		log.Info("Route not exposed", "namespace", fn.Namespace, "name", fn.Name, "reason", condition.Message)
+		return r.updateFuncWithRouteCondition(fn, *condition)
+	}
+	authCondition, err := r.syncAuthPolicy(fn, gateway, ingressMode)
+	if err != nil {
+		return err
+	}
+	if authCondition != nil && !authHeld(authCondition) {
+		// Fail closed, the route is removed rather than exposed without authentication.
+		if r.gatewayAPIVersion != "" {
+			if err := r.deleteStaleRoute(fn, &k8sgatewayapiv1beta1.HTTPRoute{}); err != nil {
+				return err
+			}
+		}
+		if err := r.deleteStaleRoute(fn, &networkingv1.Ingress{}); err != nil {
 			return err
 		}
+		log.Info("Route not exposed", "namespace", fn.Namespace, "name", fn.Name, "reason", authCondition.Message)
+		return r.updateFuncWithRouteCondition(fn, *authCondition)
+	}
+
+	var extraConditions []metav1.Condition
+	if authCondition != nil {
+		extraConditions = append(extraConditions, *authCondition)
+	}
+	rateLimitCondition, err := r.syncRateLimitPolicy(fn, gateway, ingressMode)
+	if err != nil {
+		return err
+	}
+	if rateLimitCondition != nil {
+		extraConditions = append(extraConditions, *rateLimitCondition)
+	}
+	if concurrencyCondition := concurrencyCondition(fn); concurrencyCondition != nil {
+		extraConditions = append(extraConditions, *concurrencyCondition)
+	}
+	versionBackends, versionCondition, err := r.resolveVersionRoutes(fn, knativeService, ingressMode)
+	if err != nil {
+		return err
+	}
+	if versionCondition != nil {
+		extraConditions = append(extraConditions, *versionCondition)
+	}
+	mirror, mirrorCondition, err := r.resolveMirror(fn, knativeService)
+	if err != nil {
+		return err
+	}
+	if mirrorCondition != nil {
+		extraConditions = append(extraConditions, *mirrorCondition)
+	}
+
+	if knativeService != nil || kedaService != nil {
+		if ingressMode {
+			if err := r.createOrUpdateIngress(fn, knativeService, kedaService, gateway, mirror, extraConditions); err != nil {
+				return err
+			}
+		} else {
+			// The URLRewrite filter is served since Gateway API v1beta1.
+			if stripPathPrefix(fn, gateway) && r.gatewayAPIVersion == ofngateway.V1Alpha2 {
+				extraConditions = append(extraConditions, metav1.Condition{
+					Type:    RouteConditionPathPrefixStripped,
+					Status:  metav1.ConditionFalse,
+					Reason:  RouteReasonStripPathPrefixNotSupported,
+					Message: "the path prefix is not stripped as the cluster does not serve the URLRewrite filter",
+				})
+			}
+			httpRoute := &k8sgatewayapiv1beta1.HTTPRoute{
+				ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace, Name: fn.Name},
+			}
+			op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, httpRoute, mutateAuthHTTPRoute(fn, authHeld(authCondition), httpRoute,
+				r.mutateHTTPRoute(fn, knativeService, kedaService, gateway, versionBackends, mirror, httpRoute)))
+			if err != nil {
+				log.Error(err, "Failed to CreateOrUpdate HTTPRoute")
+				return err
+			}
+			log.V(1).Info(fmt.Sprintf("HTTPRoute %s", op))
+
+			refCondition, err := r.checkReferenceGrants(httpRoute)
+			if err != nil {
+				return err
+			}
+			if refCondition != nil {
+				extraConditions = append(extraConditions, *refCondition)
+			}
+
+			if err := r.updateFuncWithHTTPRouteStatus(fn, gateway, httpRoute, mirrorStatus(fn, mirror), extraConditions); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Clean up the route generated in the other routing mode.
+	if ingressMode {
+		if r.gatewayAPIVersion != "" {
+			if err := r.deleteStaleRoute(fn, &k8sgatewayapiv1beta1.HTTPRoute{}); err != nil {
+				return err
+			}
+		}
+	} else if err := r.deleteStaleRoute(fn, &networkingv1.Ingress{}); err != nil {
+		return err
 	}
 
 	service := &corev1.Service{
@@ -801,111 +1000,110 @@ func (r *FunctionReconciler) createOrUpdateHTTPRoute(fn *openfunction.Function)
 	return nil
 }
 
+// gatewayAPICondition returns the condition of a route which can not be served by Gateway API,
+// nil is returned if the route is served by Ingress.
+func (r *FunctionReconciler) gatewayAPICondition(gateway *networkingv1alpha1.Gateway, ingressMode bool) *metav1.Condition {
+	if ingressMode {
+		return nil
+	}
+	if r.gatewayAPIVersion == "" {
+		return &metav1.Condition{
+			Type:    RouteConditionAccepted,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonGatewayAPINotServed,
+			Message: "the gateway sets the GatewayAPI routing mode, but the cluster does not serve Gateway API",
+		}
+	}
+	if gateway.Spec.GatewayRef == nil && gateway.Spec.GatewayDef == nil {
+		return &metav1.Condition{
+			Type:    RouteConditionAccepted,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonGatewayNotSpecified,
+			Message: "the route is served by Gateway API, but the gateway specifies neither gatewayRef nor gatewayDef",
+		}
+	}
+	return nil
+}
+
 func (r *FunctionReconciler) mutateHTTPRoute(
 	fn *openfunction.Function,
 	knativeService *kservingv1.Service,
 	service *corev1.Service,
 	gateway *networkingv1alpha1.Gateway,
-	httpRoute *k8sgatewayapiv1alpha2.HTTPRoute) controllerutil.MutateFn {
+	versionBackends []versionBackend,
+	mirror *mirrorBackend,
+	httpRoute *k8sgatewayapiv1beta1.HTTPRoute) controllerutil.MutateFn {
 	return func() error {
-		var clusterHostname = k8sgatewayapiv1alpha2.Hostname(
-			fmt.Sprintf("%s.%s.svc.%s", fn.Name, fn.Namespace, gateway.Spec.ClusterDomain))
-		var hostnames []k8sgatewayapiv1alpha2.Hostname
-		var rules []k8sgatewayapiv1alpha2.HTTPRouteRule
+		var rules []k8sgatewayapiv1beta1.HTTPRouteRule
 		var port = constants.DefaultFunctionServicePort
 		if service != nil {
 			port = constants.DefaultInterceptorPort
 		}
-		var namespace = k8sgatewayapiv1alpha2.Namespace(fn.Namespace)
-		var httpHeaders []k8sgatewayapiv1alpha2.HTTPHeader
+		var namespace = k8sgatewayapiv1beta1.Namespace(fn.Namespace)
+		var httpHeaders []k8sgatewayapiv1beta1.HTTPHeader
 		if knativeService != nil {
-			httpHeaders = []k8sgatewayapiv1alpha2.HTTPHeader{{
+			httpHeaders = []k8sgatewayapiv1beta1.HTTPHeader{{
 				Name:  "Host",
 				Value: fmt.Sprintf("%s.%s.svc.%s", knativeService.Status.LatestReadyRevisionName, fn.Namespace, gateway.Spec.ClusterDomain),
 			}}
 		} else if service != nil {
 			for _, hostname := range fn.Spec.Serving.Triggers.Http.Route.Hostnames {
-				httpHeaders = append(httpHeaders, k8sgatewayapiv1alpha2.HTTPHeader{
+				httpHeaders = append(httpHeaders, k8sgatewayapiv1beta1.HTTPHeader{
 					Name:  "Host",
 					Value: string(hostname),
 				})
 			}
 		}
-		var filter = k8sgatewayapiv1alpha2.HTTPRouteFilter{
-			Type: k8sgatewayapiv1alpha2.HTTPRouteFilterRequestHeaderModifier,
-			RequestHeaderModifier: &k8sgatewayapiv1alpha2.HTTPRequestHeaderFilter{
+		var filter = k8sgatewayapiv1beta1.HTTPRouteFilter{
+			Type: k8sgatewayapiv1beta1.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &k8sgatewayapiv1beta1.HTTPHeaderFilter{
 				Add: httpHeaders,
 			},
 		}
-		var parentRefName k8sgatewayapiv1alpha2.ObjectName
-		var parentRefNamespace k8sgatewayapiv1alpha2.Namespace
+		// The requests served by the current version are mirrored to the new serving.
+		filters := []k8sgatewayapiv1beta1.HTTPRouteFilter{filter}
+		if mirror != nil {
+			filters = append(filters, newMirrorFilter(fn, mirror))
+		}
+		var parentRefName k8sgatewayapiv1beta1.ObjectName
+		var parentRefNamespace k8sgatewayapiv1beta1.Namespace
 		if gateway.Spec.GatewayRef != nil {
-			parentRefName = k8sgatewayapiv1alpha2.ObjectName(gateway.Spec.GatewayRef.Name)
-			parentRefNamespace = k8sgatewayapiv1alpha2.Namespace(gateway.Spec.GatewayRef.Namespace)
+			parentRefName = k8sgatewayapiv1beta1.ObjectName(gateway.Spec.GatewayRef.Name)
+			parentRefNamespace = k8sgatewayapiv1beta1.Namespace(gateway.Spec.GatewayRef.Namespace)
 		}
 		if gateway.Spec.GatewayDef != nil {
-			parentRefName = k8sgatewayapiv1alpha2.ObjectName(gateway.Spec.GatewayDef.Name)
-			parentRefNamespace = k8sgatewayapiv1alpha2.Namespace(gateway.Spec.GatewayDef.Namespace)
+			parentRefName = k8sgatewayapiv1beta1.ObjectName(gateway.Spec.GatewayDef.Name)
+			parentRefNamespace = k8sgatewayapiv1beta1.Namespace(gateway.Spec.GatewayDef.Namespace)
 		}
 
-		if fn.Spec.Serving.Triggers.Http.Route.Hostnames == nil {
-			var hostnameBuffer bytes.Buffer
-
-			hostTemplate := template.Must(template.New("host").Parse(gateway.Spec.HostTemplate))
-			hostInfoObj := struct {
-				Name      string
-				Namespace string
-				Domain    string
-			}{Name: fn.Name, Namespace: fn.Namespace, Domain: gateway.Spec.Domain}
-			if err := hostTemplate.Execute(&hostnameBuffer, hostInfoObj); err != nil {
-				return err
-			}
-			hostname := k8sgatewayapiv1alpha2.Hostname(hostnameBuffer.String())
-			hostnames = append(hostnames, hostname)
-		} else {
-			hostnames = fn.Spec.Serving.Triggers.Http.Route.Hostnames
-		}
-		if !containsHTTPHostname(fn.Spec.Serving.Triggers.Http.Route.Hostnames, clusterHostname) {
-			hostnames = append(hostnames, clusterHostname)
+		hostnames, err := routeHostnames(fn, gateway)
+		if err != nil {
+			return err
 		}
 
-		var backendGroup k8sgatewayapiv1alpha2.Group = ""
-		var backendKind k8sgatewayapiv1alpha2.Kind = "Service"
+		var backendGroup k8sgatewayapiv1beta1.Group = ""
+		var backendKind k8sgatewayapiv1beta1.Kind = "Service"
 		var backendWeight int32 = 1
-		var backendRefName k8sgatewayapiv1alpha2.ObjectName
+		var backendRefName k8sgatewayapiv1beta1.ObjectName
 		if knativeService != nil {
-			backendRefName = k8sgatewayapiv1alpha2.ObjectName(knativeService.Status.LatestReadyRevisionName)
+			backendRefName = k8sgatewayapiv1beta1.ObjectName(knativeService.Status.LatestReadyRevisionName)
 		} else if service != nil {
 			backendRefName = constants.DefaultKedaInterceptorProxyName
 		}
 		if fn.Spec.Serving.Triggers.Http.Route.Rules == nil {
-			var path string
-			if fn.Spec.Serving.Triggers.Http.Route.Hostnames == nil {
-				path = "/"
-			} else {
-				var pathBuffer bytes.Buffer
-				pathTemplate := template.Must(template.New("path").Parse(gateway.Spec.PathTemplate))
-				pathInfoObj := struct {
-					Name      string
-					Namespace string
-				}{Name: fn.Name, Namespace: fn.Namespace}
-				if err := pathTemplate.Execute(&pathBuffer, pathInfoObj); err != nil {
-					return err
-				}
-				path = pathBuffer.String()
-				if !strings.HasPrefix(path, "/") {
-					path = fmt.Sprintf("/%s", path)
-				}
+			path, err := routeDefaultPath(fn, gateway)
+			if err != nil {
+				return err
 			}
-			matchType := k8sgatewayapiv1alpha2.PathMatchPathPrefix
-			rule := k8sgatewayapiv1alpha2.HTTPRouteRule{
-				Matches: []k8sgatewayapiv1alpha2.HTTPRouteMatch{{
-					Path: &k8sgatewayapiv1alpha2.HTTPPathMatch{Type: &matchType, Value: &path},
+			matchType := k8sgatewayapiv1beta1.PathMatchPathPrefix
+			rule := k8sgatewayapiv1beta1.HTTPRouteRule{
+				Matches: []k8sgatewayapiv1beta1.HTTPRouteMatch{{
+					Path: &k8sgatewayapiv1beta1.HTTPPathMatch{Type: &matchType, Value: &path},
 				}},
-				BackendRefs: []k8sgatewayapiv1alpha2.HTTPBackendRef{
+				BackendRefs: []k8sgatewayapiv1beta1.HTTPBackendRef{
 					{
-						BackendRef: k8sgatewayapiv1alpha2.BackendRef{
-							BackendObjectReference: k8sgatewayapiv1alpha2.BackendObjectReference{
+						BackendRef: k8sgatewayapiv1beta1.BackendRef{
+							BackendObjectReference: k8sgatewayapiv1beta1.BackendObjectReference{
 								Group:     &backendGroup,
 								Kind:      &backendKind,
 								Name:      backendRefName,
@@ -916,14 +1114,14 @@ func (r *FunctionReconciler) mutateHTTPRoute(
 						},
 					},
 				},
-				Filters: []k8sgatewayapiv1alpha2.HTTPRouteFilter{filter},
+				Filters: filters,
 			}
 			rules = append(rules, rule)
 		} else {
-			for _, rule := range fn.Spec.Serving.Triggers.Http.Route.Rules {
-				rule.BackendRefs = []k8sgatewayapiv1alpha2.HTTPBackendRef{{
-					BackendRef: k8sgatewayapiv1alpha2.BackendRef{
-						BackendObjectReference: k8sgatewayapiv1alpha2.BackendObjectReference{
+			for _, rule := range ofngateway.ToHTTPRouteRules(fn.Spec.Serving.Triggers.Http.Route.Rules) {
+				rule.BackendRefs = []k8sgatewayapiv1beta1.HTTPBackendRef{{
+					BackendRef: k8sgatewayapiv1beta1.BackendRef{
+						BackendObjectReference: k8sgatewayapiv1beta1.BackendObjectReference{
 							Group:     &backendGroup,
 							Kind:      &backendKind,
 							Name:      backendRefName,
@@ -932,19 +1130,60 @@ func (r *FunctionReconciler) mutateHTTPRoute(
 						},
 						Weight: &backendWeight,
 					}}}
-				rule.Filters = append(rule.Filters, filter)
+				rule.Filters = append(rule.Filters, filters...)
 				rules = append(rules, rule)
 			}
 		}
+		// The version routes are served by the revisions of the other versions.
+		var versionRules []k8sgatewayapiv1beta1.HTTPRouteRule
+		for _, backend := range versionBackends {
+			path, err := routeDefaultPath(fn, gateway)
+			if err != nil {
+				return err
+			}
+			var matches []k8sgatewayapiv1beta1.HTTPRouteMatch
+			for _, match := range backend.matches {
+				match := *match.DeepCopy()
+				if match.Path == nil {
+					matchType := k8sgatewayapiv1beta1.PathMatchPathPrefix
+					match.Path = &k8sgatewayapiv1beta1.HTTPPathMatch{Type: &matchType, Value: &path}
+				}
+				matches = append(matches, match)
+			}
+			versionRules = append(versionRules, k8sgatewayapiv1beta1.HTTPRouteRule{
+				Matches: matches,
+				BackendRefs: []k8sgatewayapiv1beta1.HTTPBackendRef{{
+					BackendRef: k8sgatewayapiv1beta1.BackendRef{
+						BackendObjectReference: k8sgatewayapiv1beta1.BackendObjectReference{
+							Group:     &backendGroup,
+							Kind:      &backendKind,
+							Name:      k8sgatewayapiv1beta1.ObjectName(backend.revision),
+							Namespace: &namespace,
+							Port:      &port,
+						},
+						Weight: &backendWeight,
+					}}},
+				Filters: []k8sgatewayapiv1beta1.HTTPRouteFilter{{
+					Type: k8sgatewayapiv1beta1.HTTPRouteFilterRequestHeaderModifier,
+					RequestHeaderModifier: &k8sgatewayapiv1beta1.HTTPHeaderFilter{
+						Add: []k8sgatewayapiv1beta1.HTTPHeader{{
+							Name:  "Host",
+							Value: fmt.Sprintf("%s.%s.svc.%s", backend.revision, fn.Namespace, gateway.Spec.ClusterDomain),
+						}},
+					},
+				}},
+			})
+		}
+		rules = append(versionRules, rules...)
 		httpRouteLabelValue := fmt.Sprintf("%s.%s", gateway.Namespace, gateway.Name)
 		if httpRoute.Labels == nil {
 			httpRoute.Labels = map[string]string{gateway.Spec.HttpRouteLabelKey: httpRouteLabelValue}
 		} else {
 			httpRoute.Labels[gateway.Spec.HttpRouteLabelKey] = httpRouteLabelValue
 		}
-		var parentGroup k8sgatewayapiv1alpha2.Group = "gateway.networking.k8s.io"
-		var parentKind k8sgatewayapiv1alpha2.Kind = "Gateway"
-		httpRoute.Spec.ParentRefs = []k8sgatewayapiv1alpha2.ParentRef{
+		var parentGroup k8sgatewayapiv1beta1.Group = "gateway.networking.k8s.io"
+		var parentKind k8sgatewayapiv1beta1.Kind = "Gateway"
+		httpRoute.Spec.ParentRefs = []k8sgatewayapiv1beta1.ParentReference{
 			{
 				Group:     &parentGroup,
 				Kind:      &parentKind,
@@ -954,6 +1193,10 @@ func (r *FunctionReconciler) mutateHTTPRoute(
 		}
 		httpRoute.Spec.Hostnames = hostnames
 		httpRoute.Spec.Rules = rules
+		// The version rules match the paths of the function, so their prefixes are stripped as well.
+		if r.gatewayAPIVersion != ofngateway.V1Alpha2 {
+			httpRoute.Spec.Rules = withURLRewrites(fn, gateway, rules)
+		}
 		return ctrl.SetControllerReference(fn, httpRoute, r.Scheme)
 	}
 }
@@ -968,15 +1211,25 @@ func (r *FunctionReconciler) mutateService(
 			networkingv1alpha1.DefaultGatewayServiceName,
 			gateway.Namespace,
 			gateway.Spec.ClusterDomain)
-		for _, listener := range gateway.Spec.GatewaySpec.Listeners {
-			if strings.HasSuffix(string(*listener.Hostname), gateway.Spec.ClusterDomain) {
-				servicePort := corev1.ServicePort{
-					Name:       string(listener.Name),
-					Protocol:   corev1.ProtocolTCP,
-					Port:       int32(listener.Port),
-					TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: int32(listener.Port)},
+		// The gateway service is resolved to the ingress controller in the Ingress routing mode.
+		if ofngateway.RoutingModeFor(gateway, r.gatewayAPIVersion) == networkingv1alpha1.RoutingModeIngress {
+			servicePorts = append(servicePorts, corev1.ServicePort{
+				Name:       "http",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       int32(constants.DefaultGatewayListenerPort),
+				TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: int32(constants.DefaultGatewayListenerPort)},
+			})
+		} else {
+			for _, listener := range gateway.Spec.GatewaySpec.Listeners {
+				if strings.HasSuffix(string(*listener.Hostname), gateway.Spec.ClusterDomain) {
+					servicePort := corev1.ServicePort{
+						Name:       string(listener.Name),
+						Protocol:   corev1.ProtocolTCP,
+						Port:       int32(listener.Port),
+						TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: int32(listener.Port)},
+					}
+					servicePorts = append(servicePorts, servicePort)
 				}
-				servicePorts = append(servicePorts, servicePort)
 			}
 		}
 		service.Spec.Type = corev1.ServiceTypeExternalName
@@ -1024,39 +1277,137 @@ func (r *FunctionReconciler) CreateOrUpdateServiceForAsyncFunc(fn *openfunction.
 func (r *FunctionReconciler) updateFuncWithHTTPRouteStatus(
 	fn *openfunction.Function,
 	gateway *networkingv1alpha1.Gateway,
-	httpRoute *k8sgatewayapiv1alpha2.HTTPRoute) error {
-	log := r.Log.WithName("updateFuncWithHTTPRouteStatus")
+	httpRoute *k8sgatewayapiv1beta1.HTTPRoute,
+	mirror *openfunction.MirrorStatus,
+	extraConditions []metav1.Condition) error {
+	var paths []k8sgatewayapiv1beta1.HTTPPathMatch
+	var upstreamPaths []string
+	var conditions []metav1.Condition
+	if len(httpRoute.Status.RouteStatus.Parents) != 0 {
+		conditions = append(conditions, httpRoute.Status.Parents[0].Conditions...)
+	}
+	conditions = mergeRouteConditions(conditions, extraConditions)
+	for _, httpRule := range httpRoute.Spec.Rules {
+		prefix, rewritten := ofngateway.PrefixRewriteOf(httpRule)
+		for _, match := range httpRule.Matches {
+			// The version routes may share the paths of the function.
+			if containsPathMatch(paths, *match.Path) {
+				continue
+			}
+			paths = append(paths, *match.Path)
+			if rewritten {
+				upstreamPaths = append(upstreamPaths, prefix)
+			} else {
+				upstreamPaths = append(upstreamPaths, *match.Path.Value)
+			}
+		}
+	}
+	return r.updateFuncWithRouteStatus(fn, gateway, httpRoute.Spec.Hostnames, paths, upstreamPaths, mirror, conditions)
+}
+
+// withURLRewrites adds the URLRewrite filter which strips the matched path prefix to the rules if it is enabled.
+// Only the rules of which all the matches are path prefixes other than `/` are rewritten.
+func withURLRewrites(
+	fn *openfunction.Function,
+	gateway *networkingv1alpha1.Gateway,
+	rules []k8sgatewayapiv1beta1.HTTPRouteRule) []k8sgatewayapiv1beta1.HTTPRouteRule {
+	if !stripPathPrefix(fn, gateway) {
+		return rules
+	}
+
+	for index, rule := range rules {
+		if len(rule.Matches) == 0 {
+			continue
+		}
+		matchesPrefix := true
+		for _, match := range rule.Matches {
+			if match.Path == nil || match.Path.Value == nil || *match.Path.Value == "/" ||
+				(match.Path.Type != nil && *match.Path.Type != k8sgatewayapiv1beta1.PathMatchPathPrefix) {
+				matchesPrefix = false
+				break
+			}
+		}
+		if _, rewritten := ofngateway.PrefixRewriteOf(rule); matchesPrefix && !rewritten {
+			rules[index].Filters = append(rules[index].Filters, ofngateway.NewPrefixRewrite("/"))
+		}
+	}
+	return rules
+}
+
+// stripPathPrefix returns whether the matched path prefix of the route of a function is stripped.
+func stripPathPrefix(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) bool {
+	if fn.Spec.Serving.Triggers.Http.Route.StripPathPrefix != nil {
+		return *fn.Spec.Serving.Triggers.Http.Route.StripPathPrefix
+	}
+	return gateway.Spec.StripPathPrefix
+}
+
+// updateFuncWithRouteStatus updates the route status and the addresses of a function with the hostnames and paths
+// of its route, the conditions of the route are kept if the given conditions are nil.
+func (r *FunctionReconciler) updateFuncWithRouteStatus(
+	fn *openfunction.Function,
+	gateway *networkingv1alpha1.Gateway,
+	hostnames []k8sgatewayapiv1beta1.Hostname,
+	paths []k8sgatewayapiv1beta1.HTTPPathMatch,
+	upstreamPaths []string,
+	mirror *openfunction.MirrorStatus,
+	conditions []metav1.Condition) error {
+	log := r.Log.WithName("updateFuncWithRouteStatus")
 	var addresses []openfunction.FunctionAddress
-	var paths []k8sgatewayapiv1alpha2.HTTPPathMatch
 	var oldRouteStatus = fn.Status.Route.DeepCopy()
+	var oldAddresses = fn.Status.Addresses
 	if fn.Status.Route == nil {
 		fn.Status.Route = &openfunction.RouteStatus{}
 	}
-	if len(httpRoute.Status.RouteStatus.Parents) != 0 {
-		fn.Status.Route.Conditions = httpRoute.Status.Parents[0].Conditions
+	if conditions != nil {
+		fn.Status.Route.Conditions = conditions
+	} else {
+		// The conditions reported by the controller are stale once the route is served.
+		fn.Status.Route.Conditions = removeRouteConditions(fn.Status.Route.Conditions,
+			RouteReasonInvalidTemplate,
+			RouteReasonGatewayAPINotServed,
+			RouteReasonGatewayNotSpecified,
+			RouteReasonMirrorNotSupported,
+			RouteReasonRefNotPermitted,
+			RouteReasonAuthNotSupported,
+			RouteReasonAuthPending,
+			RouteReasonAuthNotAccepted,
+			RouteReasonRateLimitNotSupported,
+			RouteReasonRateLimitNotAccepted,
+			RouteReasonBurstNotSupported,
+			RouteReasonVersionRoutesNotSupported,
+			RouteReasonVersionNotFound,
+			RouteReasonStripPathPrefixNotSupported,
+			RouteReasonFeaturesDropped)
 	}
 	// Set a fixed value to prevent the Status of the Function from being updated frequently when the traffic is heavy.
 	for index := 0; index < len(fn.Status.Route.Conditions); index++ {
 		fn.Status.Route.Conditions[index].LastTransitionTime = fn.CreationTimestamp
 	}
-	fn.Status.Route.Hosts = httpRoute.Spec.Hostnames
-	for _, httpRule := range httpRoute.Spec.Rules {
-		for _, match := range httpRule.Matches {
-			paths = append(paths, *match.Path)
-		}
-	}
-	fn.Status.Route.Paths = paths
-	for _, hostname := range httpRoute.Spec.Hostnames {
+	fn.Status.Route.Hosts = ofngateway.FromHostnames(hostnames)
+	fn.Status.Route.Paths = ofngateway.FromHTTPPathMatches(paths)
+	fn.Status.Route.UpstreamPaths = upstreamPaths
+	fn.Status.Route.Mirror = mirror
+	for _, hostname := range hostnames {
 		var addressType openfunction.AddressType
+		scheme, host := "http", string(hostname)
 		if strings.HasSuffix(string(hostname), gateway.Spec.ClusterDomain) {
 			addressType = openfunction.InternalAddressType
 		} else {
 			addressType = openfunction.ExternalAddressType
+			// The external routes are served by the HTTPS listeners once TLS is enabled on the gateway.
+			if tls := gateway.Spec.TLS; tls != nil &&
+				ofngateway.RoutingModeFor(gateway, r.gatewayAPIVersion) == networkingv1alpha1.RoutingModeGatewayAPI {
+				scheme = "https"
+				if tls.Port != 0 && tls.Port != networkingv1alpha1.DefaultHttpsListenerPort {
+					host = fmt.Sprintf("%s:%d", hostname, tls.Port)
+				}
+			}
 		}
 		for _, path := range paths {
 			addressValue := url.URL{
-				Scheme: "http",
-				Host:   string(hostname),
+				Scheme: scheme,
+				Host:   host,
 				Path:   *path.Value,
 			}
 			address := openfunction.FunctionAddress{
@@ -1067,7 +1418,8 @@ func (r *FunctionReconciler) updateFuncWithHTTPRouteStatus(
 		}
 	}
 	fn.Status.Addresses = addresses
-	if !equality.Semantic.DeepEqual(oldRouteStatus, fn.Status.Route.DeepCopy()) {
+	if !equality.Semantic.DeepEqual(oldRouteStatus, fn.Status.Route.DeepCopy()) ||
+		!equality.Semantic.DeepEqual(oldAddresses, fn.Status.Addresses) {
 		if err := r.Status().Update(r.ctx, fn); err != nil {
 			log.Error(err, "Failed to update status on function", "namespace", fn.Namespace, "name", fn.Name)
 			return err
@@ -1079,7 +1431,138 @@ func (r *FunctionReconciler) updateFuncWithHTTPRouteStatus(
 	return nil
 }
 
-func containsHTTPHostname(hostnames []k8sgatewayapiv1alpha2.Hostname, hostname k8sgatewayapiv1alpha2.Hostname) bool {
+// validateRouteTemplates renders the templates of the gateway used by the route of a function.
+func validateRouteTemplates(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) error {
+	if _, err := routeHostnames(fn, gateway); err != nil {
+		return fmt.Errorf("failed to render host template: %v", err)
+	}
+	if _, err := routeDefaultPath(fn, gateway); err != nil {
+		return fmt.Errorf("failed to render path template: %v", err)
+	}
+	return nil
+}
+
+// updateFuncWithRouteCondition reports a condition of the route of a function which fails to be generated,
+// the hosts and paths of the route are kept.
+func (r *FunctionReconciler) updateFuncWithRouteCondition(fn *openfunction.Function, condition metav1.Condition) error {
+	log := r.Log.WithName("updateFuncWithRouteCondition")
+	var oldRouteStatus = fn.Status.Route.DeepCopy()
+	if fn.Status.Route == nil {
+		fn.Status.Route = &openfunction.RouteStatus{}
+	}
+	// Set a fixed value to prevent the Status of the Function from being updated frequently.
+	condition.LastTransitionTime = fn.CreationTimestamp
+	fn.Status.Route.Conditions = []metav1.Condition{condition}
+	if equality.Semantic.DeepEqual(oldRouteStatus, fn.Status.Route) {
+		return nil
+	}
+	if err := r.Status().Update(r.ctx, fn); err != nil {
+		log.Error(err, "Failed to update status on function", "namespace", fn.Namespace, "name", fn.Name)
+		return err
+	}
+	log.Info("Updated route condition on function", "namespace", fn.Namespace, "name", fn.Name, "reason", condition.Reason)
+	return nil
+}
+
+// mergeRouteConditions adds the conditions reported by the controller to the conditions of the route,
+// a condition of the route with the same type is replaced, such as the ResolvedRefs condition of a missing ReferenceGrant.
+func mergeRouteConditions(conditions []metav1.Condition, extraConditions []metav1.Condition) []metav1.Condition {
+	for _, extra := range extraConditions {
+		var kept []metav1.Condition
+		for _, condition := range conditions {
+			if condition.Type != extra.Type {
+				kept = append(kept, condition)
+			}
+		}
+		conditions = append(kept, extra)
+	}
+	return conditions
+}
+
+func removeRouteConditions(conditions []metav1.Condition, reasons ...string) []metav1.Condition {
+	var kept []metav1.Condition
+	for _, condition := range conditions {
+		removed := false
+		for _, reason := range reasons {
+			if condition.Reason == reason {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, condition)
+		}
+	}
+	return kept
+}
+
+func containsPathMatch(paths []k8sgatewayapiv1beta1.HTTPPathMatch, path k8sgatewayapiv1beta1.HTTPPathMatch) bool {
+	for _, item := range paths {
+		if equality.Semantic.DeepEqual(item, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// routeTemplateData returns the variables of the host and path templates of the gateway for a function.
+func routeTemplateData(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) networkingv1alpha1.RouteTemplateData {
+	data := networkingv1alpha1.RouteTemplateData{
+		Name:        fn.Name,
+		Namespace:   fn.Namespace,
+		Domain:      gateway.Spec.Domain,
+		Labels:      fn.Labels,
+		Annotations: fn.Annotations,
+		Engine:      string(openfunction.HttpEngineKnative),
+	}
+	if fn.Spec.Version != nil {
+		data.Version = *fn.Spec.Version
+	}
+	if engine := fn.Spec.Serving.Triggers.Http.Engine; engine != nil && *engine != "" {
+		data.Engine = string(*engine)
+	}
+	return data
+}
+
+// routeHostnames returns the hostnames of the route of a function, the hostname is generated with the HostTemplate
+// of the gateway unless the route specifies its hostnames, and the internal hostname of the function is always included.
+func routeHostnames(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) ([]k8sgatewayapiv1beta1.Hostname, error) {
+	var hostnames []k8sgatewayapiv1beta1.Hostname
+	clusterHostname := k8sgatewayapiv1beta1.Hostname(
+		fmt.Sprintf("%s.%s.svc.%s", fn.Name, fn.Namespace, gateway.Spec.ClusterDomain))
+	if fn.Spec.Serving.Triggers.Http.Route.Hostnames == nil {
+		hostname, err := gateway.RenderHostTemplate(routeTemplateData(fn, gateway))
+		if err != nil {
+			return nil, err
+		}
+		hostnames = append(hostnames, k8sgatewayapiv1beta1.Hostname(hostname))
+	} else {
+		hostnames = append(hostnames, ofngateway.ToHostnames(fn.Spec.Serving.Triggers.Http.Route.Hostnames)...)
+	}
+	if !containsHTTPHostname(hostnames, clusterHostname) {
+		hostnames = append(hostnames, clusterHostname)
+	}
+	return hostnames, nil
+}
+
+// routeDefaultPath returns the path of the route of a function which does not specify its rules,
+// the path is generated with the PathTemplate of the gateway if the route specifies its hostnames.
+func routeDefaultPath(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) (string, error) {
+	if fn.Spec.Serving.Triggers.Http.Route.Hostnames == nil {
+		return "/", nil
+	}
+
+	path, err := gateway.RenderPathTemplate(routeTemplateData(fn, gateway))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = fmt.Sprintf("/%s", path)
+	}
+	return path, nil
+}
+
+func containsHTTPHostname(hostnames []k8sgatewayapiv1beta1.Hostname, hostname k8sgatewayapiv1beta1.Hostname) bool {
 	for _, item := range hostnames {
 		if item == hostname {
 			return true
@@ -1210,17 +1693,85 @@ func (r *FunctionReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}); err != nil {
 		return err
 	}
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &openfunction.Function{}, ParamsSourceField, func(rawObj client.Object) []string {
+		fn := rawObj.(*openfunction.Function)
+		return common.GetParamsSources(fn.Spec.Serving)
+	}); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &openfunction.Function{}, BackendNamespaceField, func(rawObj client.Object) []string {
+		return getBackendNamespaces(rawObj.(*openfunction.Function))
+	}); err != nil {
+		return err
+	}
+	// Only the data of the labelled Secrets and ConfigMaps is cached, the others are only watched for their metadata.
+	selector := labels.SelectorFromSet(labels.Set{common.ParamsSourceLabel: "true"})
+	paramsSources, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+		SelectorsByObject: cache.SelectorsByObject{
+			&corev1.Secret{}:    {Label: selector},
+			&corev1.ConfigMap{}: {Label: selector},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(paramsSources); err != nil {
+		return err
+	}
+	r.paramsSources = paramsSources
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&openfunction.Function{}).
 		Owns(&openfunction.Builder{}).
 		Owns(&openfunction.Serving{}).
 		Owns(&corev1.Service{}).
-		Owns(&k8sgatewayapiv1alpha2.HTTPRoute{}, ctrlbuilder.WithPredicates(predicate.Funcs{UpdateFunc: r.filterHttpRouteUpdateEvent})).
+		Owns(&networkingv1.Ingress{})
+	// There are no HTTPRoutes to watch if Gateway API is not served.
+	if r.gatewayAPIVersion != "" {
+		b = b.Owns(ofngateway.NewObject(&k8sgatewayapiv1beta1.HTTPRoute{}, r.gatewayAPIVersion),
+			ctrlbuilder.WithPredicates(predicate.Funcs{UpdateFunc: r.filterHttpRouteUpdateEvent}))
+	}
+	// The policies of Envoy Gateway are watched for their status if Envoy Gateway is installed.
+	for _, gvk := range []schema.GroupVersionKind{ofngateway.SecurityPolicyGVK, ofngateway.BackendTrafficPolicyGVK} {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			policy := &unstructured.Unstructured{}
+			policy.SetGroupVersionKind(gvk)
+			b = b.Owns(policy)
+		}
+	}
+	// The ReferenceGrants, or the ReferencePolicies of Gateway API v0.4.x, are watched to resolve the backends
+	// of the routes in their namespaces once they are granted, or to report them once the grants are removed.
+	if gvk, err := ofngateway.ReferenceGrantGVK(mgr.GetRESTMapper()); err == nil {
+		grant := &unstructured.Unstructured{}
+		grant.SetGroupVersionKind(gvk)
+		b = b.Watches(
+			&source.Kind{Type: grant},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForReferenceGrant),
+			ctrlbuilder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	}
+	return b.
 		Watches(
 			&source.Kind{Type: &networkingv1alpha1.Gateway{}},
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForGateway),
 			ctrlbuilder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
+		// The metadata watch of the Secrets and ConfigMaps triggers the rollout, the data of the labelled ones
+		// is read from paramsSources when the hash of the params is calculated.
+		Watches(
+			&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForParamsSource),
+			ctrlbuilder.OnlyMetadata,
+			ctrlbuilder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForParamsSource),
+			ctrlbuilder.OnlyMetadata,
+			ctrlbuilder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
 		Complete(r)
 }
 
@@ -1229,8 +1780,11 @@ func (r *FunctionReconciler) filterHttpRouteUpdateEvent(e event.UpdateEvent) boo
 		return false
 	}
 
-	oldRoute := e.ObjectOld.(*k8sgatewayapiv1alpha2.HTTPRoute).DeepCopy()
-	newRoute := e.ObjectNew.(*k8sgatewayapiv1alpha2.HTTPRoute).DeepCopy()
+	oldRoute := &k8sgatewayapiv1beta1.HTTPRoute{}
+	newRoute := &k8sgatewayapiv1beta1.HTTPRoute{}
+	if ofngateway.ConvertObject(e.ObjectOld, oldRoute) != nil || ofngateway.ConvertObject(e.ObjectNew, newRoute) != nil {
+		return true
+	}
 
 	if !reflect.DeepEqual(oldRoute.Spec, newRoute.Spec) {
 		return true
@@ -1267,3 +1821,31 @@ func (r *FunctionReconciler) findObjectsForGateway(gateway client.Object) []reco
 	}
 	return requests
 }
+
+// findObjectsForParamsSource returns the Functions whose `paramsFrom` or `envFrom` reference the Secret or ConfigMap.
+func (r *FunctionReconciler) findObjectsForParamsSource(obj client.Object) []reconcile.Request {
+	key := common.GetParamsSourceKey(obj)
+	if key == "" {
+		return []reconcile.Request{}
+	}
+
+	functions := &openfunction.FunctionList{}
+	listOps := &client.ListOptions{
+		Namespace:     obj.GetNamespace(),
+		FieldSelector: fields.OneTermEqualSelector(ParamsSourceField, key),
+	}
+	if err := r.List(context.TODO(), functions, listOps); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(functions.Items))
+	for i, item := range functions.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			},
+		}
+	}
+	return requests
+}
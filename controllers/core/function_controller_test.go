@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+// newTestReconciler returns a reconciler with a fake client, which serves the policies of Envoy Gateway.
+func newTestReconciler(t *testing.T, objs ...client.Object) *FunctionReconciler {
+	scheme := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme, ofcore.AddToScheme, k8sgatewayapiv1beta1.AddToScheme, kservingv1.AddToScheme,
+	} {
+		if err := add(scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{ofngateway.SecurityPolicyGVK, ofngateway.BackendTrafficPolicyGVK} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	return &FunctionReconciler{
+		Client:        c,
+		paramsSources: c,
+		Log:           logr.Discard(),
+		Scheme:        scheme,
+		ctx:           context.TODO(),
+	}
+}
+
+func newRouteTestFunction(route *ofcore.RouteImpl) *ofcore.Function {
+	fn := &ofcore.Function{}
+	fn.Namespace = "default"
+	fn.Name = "sample"
+	fn.Spec.Serving = &ofcore.ServingImpl{
+		Triggers: &ofcore.Triggers{Http: &ofcore.HttpTrigger{Route: route}},
+	}
+	return fn
+}
+
+func pathRule(matchType k8sgatewayapiv1beta1.PathMatchType, path string) k8sgatewayapiv1beta1.HTTPRouteRule {
+	return k8sgatewayapiv1beta1.HTTPRouteRule{
+		Matches: []k8sgatewayapiv1beta1.HTTPRouteMatch{{
+			Path: &k8sgatewayapiv1beta1.HTTPPathMatch{Type: &matchType, Value: &path},
+		}},
+	}
+}
+
+func Test_withURLRewrites(t *testing.T) {
+	disabled := false
+	gateway := &networkingv1alpha1.Gateway{}
+	gateway.Spec.StripPathPrefix = true
+
+	newRules := func() []k8sgatewayapiv1beta1.HTTPRouteRule {
+		rewritten := pathRule(k8sgatewayapiv1beta1.PathMatchPathPrefix, "/v2")
+		rewritten.Filters = append(rewritten.Filters, ofngateway.NewPrefixRewrite("/api"))
+		return []k8sgatewayapiv1beta1.HTTPRouteRule{
+			pathRule(k8sgatewayapiv1beta1.PathMatchPathPrefix, "/default/sample"),
+			pathRule(k8sgatewayapiv1beta1.PathMatchPathPrefix, "/"),
+			pathRule(k8sgatewayapiv1beta1.PathMatchExact, "/default/sample/healthz"),
+			rewritten,
+		}
+	}
+
+	rules := withURLRewrites(newRouteTestFunction(&ofcore.RouteImpl{}), gateway, newRules())
+	wantPrefixes := []string{"/", "", "", "/api"}
+	for index, rule := range rules {
+		prefix, _ := ofngateway.PrefixRewriteOf(rule)
+		if prefix != wantPrefixes[index] {
+			t.Errorf("rule %d: expected prefix rewrite %q, got %q", index, wantPrefixes[index], prefix)
+		}
+	}
+	if len(rules[3].Filters) != 1 {
+		t.Errorf("expected the rewrite of the rule to be kept, got %v", rules[3].Filters)
+	}
+
+	rules = withURLRewrites(newRouteTestFunction(&ofcore.RouteImpl{StripPathPrefix: &disabled}), gateway, newRules())
+	if _, ok := ofngateway.PrefixRewriteOf(rules[0]); ok {
+		t.Errorf("expected the route to override stripPathPrefix of the gateway")
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+	"github.com/openfunction/pkg/util"
+)
+
+// NginxUpstreamVhostAnnotation sets the Host header of the requests ingress-nginx forwards to the function.
+const NginxUpstreamVhostAnnotation = "nginx.ingress.kubernetes.io/upstream-vhost"
+
+// createOrUpdateIngress serves the route of a function with an Ingress in the Ingress routing mode,
+// the Ingress is generated from the same hostnames and paths as the HTTPRoute.
+func (r *FunctionReconciler) createOrUpdateIngress(
+	fn *openfunction.Function,
+	knativeService *kservingv1.Service,
+	service *corev1.Service,
+	gateway *networkingv1alpha1.Gateway,
+	mirror *mirrorBackend,
+	extraConditions []metav1.Condition) error {
+	log := r.Log.WithName("createOrUpdateIngress")
+
+	hostnames, err := routeHostnames(fn, gateway)
+	if err != nil {
+		log.Error(err, "Failed to generate hostnames of Ingress")
+		return err
+	}
+	paths, err := routePaths(fn, gateway)
+	if err != nil {
+		log.Error(err, "Failed to generate paths of Ingress")
+		return err
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace, Name: fn.Name},
+	}
+	op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, ingress,
+		r.mutateIngress(fn, knativeService, service, gateway, hostnames, paths, mirror, ingress))
+	if err != nil {
+		log.Error(err, "Failed to CreateOrUpdate Ingress")
+		return err
+	}
+	log.V(1).Info(fmt.Sprintf("Ingress %s", op))
+
+	condition := metav1.Condition{
+		Type:    RouteConditionAccepted,
+		Status:  metav1.ConditionFalse,
+		Reason:  RouteReasonPending,
+		Message: "Waiting for the ingress controller to serve the Ingress",
+	}
+	if len(ingress.Status.LoadBalancer.Ingress) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = RouteReasonAccepted
+		condition.Message = "Ingress is served by the ingress controller"
+	}
+	// The path prefix is not stripped in the Ingress routing mode.
+	var upstreamPaths []string
+	for _, path := range paths {
+		upstreamPaths = append(upstreamPaths, *path.Value)
+	}
+	if stripPathPrefix(fn, gateway) {
+		extraConditions = append(extraConditions, metav1.Condition{
+			Type:    RouteConditionPathPrefixStripped,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonStripPathPrefixNotSupported,
+			Message: "the path prefix is not stripped in the Ingress routing mode",
+		})
+	}
+	// The rules are reduced to their paths in the Ingress routing mode.
+	if dropped := droppedIngressFeatures(fn); len(dropped) > 0 {
+		extraConditions = append(extraConditions, metav1.Condition{
+			Type:   RouteConditionFeaturesSupported,
+			Status: metav1.ConditionFalse,
+			Reason: RouteReasonFeaturesDropped,
+			Message: fmt.Sprintf("the %s of the route rules are dropped in the Ingress routing mode, "+
+				"the matched paths are served regardless of them", strings.Join(dropped, ", ")),
+		})
+	}
+	conditions := mergeRouteConditions([]metav1.Condition{condition}, extraConditions)
+	return r.updateFuncWithRouteStatus(fn, gateway, hostnames, paths, upstreamPaths, mirrorStatus(fn, mirror), conditions)
+}
+
+func (r *FunctionReconciler) mutateIngress(
+	fn *openfunction.Function,
+	knativeService *kservingv1.Service,
+	service *corev1.Service,
+	gateway *networkingv1alpha1.Gateway,
+	hostnames []k8sgatewayapiv1beta1.Hostname,
+	paths []k8sgatewayapiv1beta1.HTTPPathMatch,
+	mirror *mirrorBackend,
+	ingress *networkingv1.Ingress) controllerutil.MutateFn {
+	return func() error {
+		backend := networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Port: networkingv1.ServiceBackendPort{Number: int32(constants.DefaultFunctionServicePort)},
+			},
+		}
+		annotations := make(map[string]string)
+		if gateway.Spec.Ingress != nil {
+			for k, v := range gateway.Spec.Ingress.Annotations {
+				annotations[k] = v
+			}
+		}
+		for k, v := range rateLimitAnnotations(fn.Spec.Serving.Triggers.Http.RateLimit) {
+			annotations[k] = v
+		}
+		for k, v := range mirrorAnnotations(fn, gateway, mirror) {
+			annotations[k] = v
+		}
+		if knativeService != nil {
+			backend.Service.Name = knativeService.Status.LatestReadyRevisionName
+			annotations[NginxUpstreamVhostAnnotation] = fmt.Sprintf("%s.%s.svc.%s",
+				knativeService.Status.LatestReadyRevisionName, fn.Namespace, gateway.Spec.ClusterDomain)
+		} else if service != nil {
+			backend.Service.Name = constants.DefaultKedaInterceptorProxyName
+			backend.Service.Port.Number = int32(constants.DefaultInterceptorPort)
+		}
+
+		var httpPaths []networkingv1.HTTPIngressPath
+		for _, path := range paths {
+			httpPaths = append(httpPaths, networkingv1.HTTPIngressPath{
+				Path:     *path.Value,
+				PathType: ingressPathType(path.Type),
+				Backend:  backend,
+			})
+		}
+		var rules []networkingv1.IngressRule
+		for _, hostname := range hostnames {
+			rules = append(rules, networkingv1.IngressRule{
+				Host: string(hostname),
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{Paths: httpPaths},
+				},
+			})
+		}
+
+		if ingress.Labels == nil {
+			ingress.Labels = make(map[string]string)
+		}
+		ingress.Labels[gateway.Spec.HttpRouteLabelKey] = fmt.Sprintf("%s.%s", gateway.Namespace, gateway.Name)
+		ingress.Annotations = annotations
+		ingress.Spec.IngressClassName = nil
+		if gateway.Spec.Ingress != nil {
+			ingress.Spec.IngressClassName = gateway.Spec.Ingress.IngressClassName
+		}
+		ingress.Spec.Rules = rules
+		return ctrl.SetControllerReference(fn, ingress, r.Scheme)
+	}
+}
+
+// routePaths returns the paths the route of a function matches.
+func routePaths(fn *openfunction.Function, gateway *networkingv1alpha1.Gateway) ([]k8sgatewayapiv1beta1.HTTPPathMatch, error) {
+	prefix := k8sgatewayapiv1beta1.PathMatchPathPrefix
+	if fn.Spec.Serving.Triggers.Http.Route.Rules == nil {
+		path, err := routeDefaultPath(fn, gateway)
+		if err != nil {
+			return nil, err
+		}
+		return []k8sgatewayapiv1beta1.HTTPPathMatch{{Type: &prefix, Value: &path}}, nil
+	}
+
+	var paths []k8sgatewayapiv1beta1.HTTPPathMatch
+	for _, rule := range ofngateway.ToHTTPRouteRules(fn.Spec.Serving.Triggers.Http.Route.Rules) {
+		for _, match := range rule.Matches {
+			if match.Path != nil && match.Path.Value != nil {
+				paths = append(paths, *match.Path)
+			}
+		}
+	}
+	// The rules match all the requests if none of them matches the path.
+	if len(paths) == 0 {
+		path := "/"
+		paths = append(paths, k8sgatewayapiv1beta1.HTTPPathMatch{Type: &prefix, Value: &path})
+	}
+	return paths, nil
+}
+
+// droppedIngressFeatures returns the features of the route rules which are not served by the Ingress.
+func droppedIngressFeatures(fn *openfunction.Function) []string {
+	var headers, queryParams, methods, filters bool
+	for _, rule := range ofngateway.ToHTTPRouteRules(fn.Spec.Serving.Triggers.Http.Route.Rules) {
+		for _, match := range rule.Matches {
+			headers = headers || len(match.Headers) > 0
+			queryParams = queryParams || len(match.QueryParams) > 0
+			methods = methods || match.Method != nil
+		}
+		filters = filters || len(rule.Filters) > 0
+	}
+
+	var dropped []string
+	for _, feature := range []struct {
+		name    string
+		dropped bool
+	}{
+		{"header matches", headers},
+		{"query parameter matches", queryParams},
+		{"method matches", methods},
+		{"filters", filters},
+	} {
+		if feature.dropped {
+			dropped = append(dropped, feature.name)
+		}
+	}
+	return dropped
+}
+
+func ingressPathType(matchType *k8sgatewayapiv1beta1.PathMatchType) *networkingv1.PathType {
+	pathType := networkingv1.PathTypePrefix
+	if matchType != nil {
+		switch *matchType {
+		case k8sgatewayapiv1beta1.PathMatchExact:
+			pathType = networkingv1.PathTypeExact
+		case k8sgatewayapiv1beta1.PathMatchRegularExpression:
+			pathType = networkingv1.PathTypeImplementationSpecific
+		}
+	}
+	return &pathType
+}
+
+// deleteStaleRoute deletes the route of a function generated in the other routing mode or no longer required.
+func (r *FunctionReconciler) deleteStaleRoute(fn *openfunction.Function, route client.Object) error {
+	log := r.Log.WithName("deleteStaleRoute")
+	// The route is named after the function unless its name is given.
+	name := route.GetName()
+	if name == "" {
+		name = fn.Name
+	}
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: name}, route); err != nil {
+		return util.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(route, fn) {
+		return nil
+	}
+	if err := r.Delete(r.ctx, route); util.IgnoreNotFound(err) != nil {
+		log.Error(err, "Failed to delete stale route", "namespace", fn.Namespace, "name", name)
+		return err
+	}
+	log.Info("Stale route deleted", "namespace", fn.Namespace, "name", name)
+	return nil
+}
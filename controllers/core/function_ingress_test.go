@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+)
+
+func newIngressTestGateway() *networkingv1alpha1.Gateway {
+	gateway := &networkingv1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+	}
+	gateway.Spec.Domain = "ofn.io"
+	gateway.Spec.ClusterDomain = "cluster.local"
+	gateway.Spec.HostTemplate = "{{.Name}}.{{.Namespace}}.{{.Domain}}"
+	gateway.Spec.PathTemplate = "{{.Namespace}}/{{.Name}}"
+	gateway.Spec.HttpRouteLabelKey = "app.kubernetes.io/managed-by"
+	gateway.Spec.RoutingMode = networkingv1alpha1.RoutingModeIngress
+	return gateway
+}
+
+func ingressRule(path string, match ofcore.HTTPRouteMatch, filters ...ofcore.HTTPRouteFilter) ofcore.HTTPRouteRule {
+	prefix := ofcore.PathMatchPathPrefix
+	match.Path = &ofcore.HTTPPathMatch{Type: &prefix, Value: &path}
+	return ofcore.HTTPRouteRule{Matches: []ofcore.HTTPRouteMatch{match}, Filters: filters}
+}
+
+func Test_routePaths(t *testing.T) {
+	gateway := newIngressTestGateway()
+	method := ofcore.HTTPMethod("GET")
+
+	tests := []struct {
+		name      string
+		hostnames []ofcore.Hostname
+		rules     []ofcore.HTTPRouteRule
+		want      []string
+	}{
+		{
+			name: "default path of the host template",
+			want: []string{"/"},
+		},
+		{
+			name:      "default path of the hostnames",
+			hostnames: []ofcore.Hostname{"ofn.io"},
+			want:      []string{"/default/sample"},
+		},
+		{
+			name: "rule paths",
+			rules: []ofcore.HTTPRouteRule{
+				ingressRule("/v1", ofcore.HTTPRouteMatch{}),
+				ingressRule("/v2", ofcore.HTTPRouteMatch{Method: &method}),
+			},
+			want: []string{"/v1", "/v2"},
+		},
+		{
+			name:  "no path match",
+			rules: []ofcore.HTTPRouteRule{{Matches: []ofcore.HTTPRouteMatch{{Method: &method}}}},
+			want:  []string{"/"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newRouteTestFunction(&ofcore.RouteImpl{Hostnames: tt.hostnames, Rules: tt.rules})
+			paths, err := routePaths(fn, gateway)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, path := range paths {
+				got = append(got, *path.Value)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected paths %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_ingressPathType(t *testing.T) {
+	exact := k8sgatewayapiv1beta1.PathMatchExact
+	regex := k8sgatewayapiv1beta1.PathMatchRegularExpression
+	prefix := k8sgatewayapiv1beta1.PathMatchPathPrefix
+
+	tests := []struct {
+		matchType *k8sgatewayapiv1beta1.PathMatchType
+		want      networkingv1.PathType
+	}{
+		{nil, networkingv1.PathTypePrefix},
+		{&prefix, networkingv1.PathTypePrefix},
+		{&exact, networkingv1.PathTypeExact},
+		{&regex, networkingv1.PathTypeImplementationSpecific},
+	}
+	for _, tt := range tests {
+		if got := ingressPathType(tt.matchType); *got != tt.want {
+			t.Errorf("ingressPathType(%v) = %v, want %v", tt.matchType, *got, tt.want)
+		}
+	}
+}
+
+func Test_droppedIngressFeatures(t *testing.T) {
+	method := ofcore.HTTPMethod("GET")
+	header := ofcore.HTTPRouteMatch{Headers: []ofcore.HTTPHeaderMatch{{Name: "version", Value: "v2"}}}
+	filter := ofcore.HTTPRouteFilter{
+		Type:                  ofcore.HTTPRouteFilterRequestHeaderModifier,
+		RequestHeaderModifier: &ofcore.HTTPRequestHeaderFilter{Remove: []string{"cookie"}},
+	}
+
+	tests := []struct {
+		name  string
+		rules []ofcore.HTTPRouteRule
+		want  []string
+	}{
+		{
+			name: "default rules",
+		},
+		{
+			name:  "path matches",
+			rules: []ofcore.HTTPRouteRule{ingressRule("/v1", ofcore.HTTPRouteMatch{})},
+		},
+		{
+			name: "header matches and filters",
+			rules: []ofcore.HTTPRouteRule{
+				ingressRule("/v1", ofcore.HTTPRouteMatch{}),
+				ingressRule("/v2", header, filter),
+			},
+			want: []string{"header matches", "filters"},
+		},
+		{
+			name:  "method matches",
+			rules: []ofcore.HTTPRouteRule{ingressRule("/v1", ofcore.HTTPRouteMatch{Method: &method})},
+			want:  []string{"method matches"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newRouteTestFunction(&ofcore.RouteImpl{Rules: tt.rules})
+			if got := droppedIngressFeatures(fn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected dropped features %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_createOrUpdateIngress(t *testing.T) {
+	gateway := newIngressTestGateway()
+	header := ofcore.HTTPRouteMatch{Headers: []ofcore.HTTPHeaderMatch{{Name: "version", Value: "v2"}}}
+	fn := newRouteTestFunction(&ofcore.RouteImpl{
+		Rules: []ofcore.HTTPRouteRule{ingressRule("/v2", header)},
+	})
+	fn.UID = "uid"
+	knativeService := &kservingv1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample-ksvc"}}
+	knativeService.Status.LatestReadyRevisionName = "rev-1"
+
+	r := newTestReconciler(t, fn)
+	if err := r.createOrUpdateIngress(fn, knativeService, nil, gateway, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ingress := &networkingv1.Ingress{}
+	if err := r.Get(r.ctx, client.ObjectKeyFromObject(fn), ingress); err != nil {
+		t.Fatal(err)
+	}
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+		paths := rule.HTTP.Paths
+		if len(paths) != 1 || paths[0].Path != "/v2" || paths[0].Backend.Service.Name != "rev-1" {
+			t.Errorf("expected the path of the rule to be served by the revision, got %v", paths)
+		}
+	}
+	if want := []string{"sample.default.ofn.io", "sample.default.svc.cluster.local"}; !reflect.DeepEqual(hosts, want) {
+		t.Errorf("expected the Ingress to serve the hosts %v, got %v", want, hosts)
+	}
+	if ingress.Annotations[NginxUpstreamVhostAnnotation] != "rev-1.default.svc.cluster.local" {
+		t.Errorf("expected the upstream host of the revision, got %v", ingress.Annotations)
+	}
+
+	updated := &ofcore.Function{}
+	if err := r.Get(r.ctx, client.ObjectKeyFromObject(fn), updated); err != nil {
+		t.Fatal(err)
+	}
+	conditions := updated.Status.Route.Conditions
+	accepted := meta.FindStatusCondition(conditions, RouteConditionAccepted)
+	if accepted == nil || accepted.Reason != RouteReasonPending {
+		t.Errorf("expected the route to wait for the ingress controller, got %v", accepted)
+	}
+	features := meta.FindStatusCondition(conditions, RouteConditionFeaturesSupported)
+	if features == nil || features.Status != metav1.ConditionFalse || features.Reason != RouteReasonFeaturesDropped {
+		t.Errorf("expected the header matches to be reported as dropped, got %v", features)
+	}
+}
+
+func Test_gatewayAPICondition(t *testing.T) {
+	gateway := newIngressTestGateway()
+	gateway.Spec.RoutingMode = networkingv1alpha1.RoutingModeGatewayAPI
+
+	r := newTestReconciler(t)
+	if condition := r.gatewayAPICondition(gateway, false); condition == nil ||
+		condition.Reason != RouteReasonGatewayAPINotServed {
+		t.Errorf("expected the GatewayAPI routing mode to be rejected if Gateway API is not served, got %v", condition)
+	}
+
+	r.gatewayAPIVersion = "v1beta1"
+	if condition := r.gatewayAPICondition(gateway, false); condition == nil ||
+		condition.Reason != RouteReasonGatewayNotSpecified {
+		t.Errorf("expected the route to be rejected without k8s Gateway, got %v", condition)
+	}
+
+	gateway.Spec.GatewayRef = &networkingv1alpha1.GatewayRef{Namespace: "gateway", Name: "gateway"}
+	if condition := r.gatewayAPICondition(gateway, false); condition != nil {
+		t.Errorf("expected the route to be served by Gateway API, got %v", condition)
+	}
+	if condition := r.gatewayAPICondition(&networkingv1alpha1.Gateway{}, true); condition != nil {
+		t.Errorf("expected the route to be served by Ingress, got %v", condition)
+	}
+}
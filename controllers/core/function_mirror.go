@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	knames "knative.dev/serving/pkg/reconciler/serverlessservice/resources/names"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	NginxMirrorTargetAnnotation = "nginx.ingress.kubernetes.io/mirror-target"
+	NginxMirrorHostAnnotation   = "nginx.ingress.kubernetes.io/mirror-host"
+)
+
+// mirrorBackend is the Knative revision of the new serving which receives the mirrored requests.
+type mirrorBackend struct {
+	serving  string
+	revision string
+}
+
+func mirrorEnabled(fn *openfunction.Function) bool {
+	return fn.Spec.Serving != nil && fn.Spec.Serving.Triggers != nil && fn.Spec.Serving.Triggers.Http != nil &&
+		fn.Spec.Serving.Triggers.Http.Route != nil && fn.Spec.Serving.Triggers.Http.Route.Mirror
+}
+
+// holdsCutover returns whether the cutover to a new serving is held for the mirror,
+// the mirror is only supported by the Knative engine.
+func holdsCutover(fn *openfunction.Function) bool {
+	if !mirrorEnabled(fn) {
+		return false
+	}
+	engine := fn.Spec.Serving.Triggers.Http.Engine
+	return engine == nil || *engine == "" || *engine == openfunction.HttpEngineKnative
+}
+
+// resolveMirror returns the revision of the new serving while its cutover is held by the mirror,
+// nil is returned if there is no running serving to mirror the requests to.
+func (r *FunctionReconciler) resolveMirror(
+	fn *openfunction.Function,
+	knativeService *kservingv1.Service) (*mirrorBackend, *metav1.Condition, error) {
+	log := r.Log.WithName("resolveMirror")
+	if !mirrorEnabled(fn) {
+		return nil, nil, nil
+	}
+	if knativeService == nil {
+		return nil, &metav1.Condition{
+			Type:    RouteConditionMirrored,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonMirrorNotSupported,
+			Message: "mirror is only supported by the Knative engine",
+		}, nil
+	}
+
+	name := fn.Status.Serving.ResourceRef
+	if name == "" || name == fn.Status.Serving.LastSuccessfulResourceRef {
+		return nil, nil, nil
+	}
+	serving := &openfunction.Serving{}
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: name}, serving); err != nil {
+		if util.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		log.Error(err, "Failed to get serving", "namespace", fn.Namespace, "name", name)
+		return nil, nil, err
+	}
+	if serving.Status.State != openfunction.Running || serving.Status.Service == "" ||
+		serving.Status.Service == knativeService.Name {
+		return nil, nil, nil
+	}
+
+	service := &kservingv1.Service{}
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: serving.Status.Service}, service); err != nil {
+		if util.IsNotFound(err) {
+			return nil, nil, nil
+		}
+		log.Error(err, "Failed to get knative service", "namespace", fn.Namespace, "name", serving.Status.Service)
+		return nil, nil, err
+	}
+	if service.Status.LatestReadyRevisionName == "" {
+		return nil, nil, nil
+	}
+	return &mirrorBackend{serving: serving.Name, revision: service.Status.LatestReadyRevisionName}, nil, nil
+}
+
+// mirrorStatus returns the mirror status of the route, the start time is kept while the target is unchanged.
+func mirrorStatus(fn *openfunction.Function, backend *mirrorBackend) *openfunction.MirrorStatus {
+	if backend == nil {
+		return nil
+	}
+	if fn.Status.Route != nil && fn.Status.Route.Mirror != nil &&
+		fn.Status.Route.Mirror.Serving == backend.serving && fn.Status.Route.Mirror.Target == backend.revision {
+		return fn.Status.Route.Mirror.DeepCopy()
+	}
+	return &openfunction.MirrorStatus{
+		Serving:   backend.serving,
+		Target:    backend.revision,
+		StartTime: metav1.Now(),
+	}
+}
+
+// newMirrorFilter returns the filter which mirrors the requests to the revision of the mirror backend.
+// The mirrored requests carry the Host of the current revision set by the other filters of the rule, so they are
+// sent to the private service of the revision, which is served by the pods of the revision regardless of the Host.
+// The private service bypasses the activator of Knative, the requests are not mirrored while the revision
+// is scaled to zero.
+func newMirrorFilter(fn *openfunction.Function, backend *mirrorBackend) k8sgatewayapiv1beta1.HTTPRouteFilter {
+	var group k8sgatewayapiv1beta1.Group = ""
+	var kind k8sgatewayapiv1beta1.Kind = "Service"
+	var namespace = k8sgatewayapiv1beta1.Namespace(fn.Namespace)
+	var port = constants.DefaultFunctionServicePort
+	return k8sgatewayapiv1beta1.HTTPRouteFilter{
+		Type: k8sgatewayapiv1beta1.HTTPRouteFilterRequestMirror,
+		RequestMirror: &k8sgatewayapiv1beta1.HTTPRequestMirrorFilter{
+			BackendRef: k8sgatewayapiv1beta1.BackendObjectReference{
+				Group:     &group,
+				Kind:      &kind,
+				Name:      k8sgatewayapiv1beta1.ObjectName(knames.PrivateService(backend.revision)),
+				Namespace: &namespace,
+				Port:      &port,
+			},
+		},
+	}
+}
+
+// mirrorAnnotations returns the annotations of ingress-nginx which mirror the requests to the revision of the mirror backend.
+func mirrorAnnotations(
+	fn *openfunction.Function,
+	gateway *networkingv1alpha1.Gateway,
+	backend *mirrorBackend) map[string]string {
+	if backend == nil {
+		return nil
+	}
+	host := fmt.Sprintf("%s.%s.svc.%s", backend.revision, fn.Namespace, gateway.Spec.ClusterDomain)
+	return map[string]string{
+		NginxMirrorTargetAnnotation: fmt.Sprintf("http://%s$request_uri", host),
+		NginxMirrorHostAnnotation:   host,
+	}
+}
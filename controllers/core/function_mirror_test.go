@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+)
+
+func newMirrorTestFunction() *ofcore.Function {
+	fn := newRouteTestFunction(&ofcore.RouteImpl{Mirror: true})
+	fn.UID = "uid"
+	fn.Status.Serving = &ofcore.Condition{
+		State:                     ofcore.Running,
+		ResourceRef:               "new",
+		LastSuccessfulResourceRef: "old",
+		Service:                   "ksvc-old",
+	}
+	return fn
+}
+
+func Test_holdsCutover(t *testing.T) {
+	keda := ofcore.HttpEngineKeda
+	knative := ofcore.HttpEngineKnative
+	tests := []struct {
+		name   string
+		mirror bool
+		engine *ofcore.Engine
+		want   bool
+	}{
+		{name: "mirror disabled", mirror: false, want: false},
+		{name: "default engine", mirror: true, want: true},
+		{name: "knative engine", mirror: true, engine: &knative, want: true},
+		{name: "keda engine", mirror: true, engine: &keda, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newRouteTestFunction(&ofcore.RouteImpl{Mirror: tt.mirror})
+			fn.Spec.Serving.Triggers.Http.Engine = tt.engine
+			if got := holdsCutover(fn); got != tt.want {
+				t.Errorf("holdsCutover() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_updateFuncWithServingStatus_heldCutover(t *testing.T) {
+	fn := newMirrorTestFunction()
+	serving := newVersionTestServing("new", "", ofcore.Running, 0)
+	serving.Status.Phase = ofcore.ServingPhase
+	r := newTestReconciler(t, fn, serving, newVersionTestServing("old", "", ofcore.Running, time.Hour))
+
+	if err := r.updateFuncWithServingStatus(fn); err != nil {
+		t.Fatalf("updateFuncWithServingStatus() error = %v", err)
+	}
+	if fn.Status.Serving.LastSuccessfulResourceRef != "old" || fn.Status.Serving.Service != "ksvc-old" {
+		t.Errorf("expected the cutover to be held by the mirror, got %v", fn.Status.Serving)
+	}
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: "default", Name: "old"}, &ofcore.Serving{}); err != nil {
+		t.Errorf("expected the last successful serving to be kept, got %v", err)
+	}
+
+	fn.Spec.Serving.Triggers.Http.Route.Mirror = false
+	if err := r.updateFuncWithServingStatus(fn); err != nil {
+		t.Fatalf("updateFuncWithServingStatus() error = %v", err)
+	}
+	if fn.Status.Serving.LastSuccessfulResourceRef != "new" || fn.Status.Serving.Service != "ksvc-new" {
+		t.Errorf("expected the new serving to take over once the mirror is disabled, got %v", fn.Status.Serving)
+	}
+}
+
+func Test_resolveMirror(t *testing.T) {
+	fn := newMirrorTestFunction()
+	knativeService := newVersionTestKnativeService("ksvc-old", "rev-old")
+	r := newTestReconciler(t,
+		newVersionTestServing("new", "", ofcore.Running, 0),
+		newVersionTestKnativeService("ksvc-new", "rev-new"),
+	)
+
+	backend, condition, err := r.resolveMirror(fn, knativeService)
+	if err != nil {
+		t.Fatalf("resolveMirror() error = %v", err)
+	}
+	if condition != nil || backend == nil || backend.serving != "new" || backend.revision != "rev-new" {
+		t.Errorf("expected the requests to be mirrored to rev-new, got %v, %v", backend, condition)
+	}
+	if filter := newMirrorFilter(fn, backend); string(filter.RequestMirror.BackendRef.Name) != "rev-new-private" {
+		t.Errorf("expected the requests to be mirrored to the private service, got %s", filter.RequestMirror.BackendRef.Name)
+	}
+
+	if backend, condition, _ = r.resolveMirror(fn, nil); backend != nil || condition == nil ||
+		condition.Reason != RouteReasonMirrorNotSupported {
+		t.Errorf("expected the mirror not to be supported by the KEDA engine, got %v, %v", backend, condition)
+	}
+
+	fn.Status.Serving.LastSuccessfulResourceRef = "new"
+	if backend, condition, _ = r.resolveMirror(fn, knativeService); backend != nil || condition != nil {
+		t.Errorf("expected nothing to be mirrored without a new serving, got %v, %v", backend, condition)
+	}
+}
+
+func Test_mirrorStatus(t *testing.T) {
+	fn := newMirrorTestFunction()
+	if status := mirrorStatus(fn, nil); status != nil {
+		t.Errorf("expected no mirror status without a mirror backend, got %v", status)
+	}
+
+	startTime := metav1.NewTime(versionTestTime)
+	fn.Status.Route = &ofcore.RouteStatus{
+		Mirror: &ofcore.MirrorStatus{Serving: "new", Target: "rev-new", StartTime: startTime},
+	}
+	status := mirrorStatus(fn, &mirrorBackend{serving: "new", revision: "rev-new"})
+	if status == nil || !status.StartTime.Equal(&startTime) {
+		t.Errorf("expected the start time to be kept while the target is unchanged, got %v", status)
+	}
+
+	status = mirrorStatus(fn, &mirrorBackend{serving: "new", revision: "rev-new-2"})
+	if status == nil || status.Target != "rev-new-2" || status.StartTime.Equal(&startTime) {
+		t.Errorf("expected the start time to be reset once the target changes, got %v", status)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	NginxLimitRPSAnnotation             = "nginx.ingress.kubernetes.io/limit-rps"
+	NginxLimitBurstMultiplierAnnotation = "nginx.ingress.kubernetes.io/limit-burst-multiplier"
+)
+
+// syncRateLimitPolicy translates the rate limit of a function into a BackendTrafficPolicy of Envoy Gateway which
+// targets the HTTPRoute of the function, the rate limit is served by the annotations of the Ingress in the Ingress
+// routing mode. The RateLimited condition reports whether the policy is accepted by Envoy Gateway, the route is
+// exposed even if the rate limit is not enforced.
+func (r *FunctionReconciler) syncRateLimitPolicy(
+	fn *openfunction.Function,
+	gateway *networkingv1alpha1.Gateway,
+	ingressMode bool) (*metav1.Condition, error) {
+	log := r.Log.WithName("syncRateLimitPolicy")
+	rateLimit := fn.Spec.Serving.Triggers.Http.RateLimit
+
+	if rateLimit == nil || ingressMode {
+		if err := r.deleteGatewayPolicy(fn, ofngateway.BackendTrafficPolicyGVK); err != nil {
+			return nil, err
+		}
+		if rateLimit != nil && rateLimit.Key != nil && rateLimit.Key.Header != "" {
+			return rateLimitNotSupportedCondition("the Ingress routing mode can only limit the requests per client IP"), nil
+		}
+		return nil, nil
+	}
+
+	if _, err := r.RESTMapper().RESTMapping(ofngateway.BackendTrafficPolicyGVK.GroupKind(), ofngateway.BackendTrafficPolicyGVK.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return rateLimitNotSupportedCondition(fmt.Sprintf("rate limit requires %s of Envoy Gateway, which is not served",
+				ofngateway.BackendTrafficPolicyGVK.GroupVersion().WithResource("backendtrafficpolicies").GroupResource())), nil
+		}
+		return nil, err
+	}
+
+	controllerName, err := r.gatewayControllerName(gateway)
+	if err != nil {
+		if util.IsNotFound(err) {
+			return rateLimitNotSupportedCondition(fmt.Sprintf("failed to find the GatewayClass of the gateway: %v", err)), nil
+		}
+		return nil, err
+	}
+	if controllerName != ofngateway.EnvoyGatewayControllerName {
+		if err := r.deleteGatewayPolicy(fn, ofngateway.BackendTrafficPolicyGVK); err != nil {
+			return nil, err
+		}
+		return rateLimitNotSupportedCondition(fmt.Sprintf("rate limit requires a GatewayClass of Envoy Gateway, "+
+			"the GatewayClass of the gateway is controlled by %s", controllerName)), nil
+	}
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ofngateway.BackendTrafficPolicyGVK)
+	policy.SetNamespace(fn.Namespace)
+	policy.SetName(fn.Name)
+	op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, policy, func() error {
+		policy.Object["spec"] = ofngateway.NewBackendTrafficPolicySpec(fn.Name, rateLimit)
+		return ctrl.SetControllerReference(fn, policy, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to CreateOrUpdate BackendTrafficPolicy", "namespace", fn.Namespace, "name", fn.Name)
+		return nil, err
+	}
+	log.V(1).Info(fmt.Sprintf("BackendTrafficPolicy %s", op))
+
+	accepted, err := ofngateway.PolicyAcceptedCondition(policy)
+	if err != nil {
+		log.Error(err, "Failed to get the status of BackendTrafficPolicy", "namespace", fn.Namespace, "name", fn.Name)
+		return nil, err
+	}
+	if accepted == nil {
+		return &metav1.Condition{
+			Type:    RouteConditionRateLimited,
+			Status:  metav1.ConditionUnknown,
+			Reason:  RouteReasonPending,
+			Message: "waiting for the BackendTrafficPolicy to be accepted",
+		}, nil
+	}
+	if accepted.Status != metav1.ConditionTrue {
+		message := fmt.Sprintf("the BackendTrafficPolicy is not accepted: %s", accepted.Message)
+		if rateLimit.Key != nil {
+			message += ", the keyed rate limit requires the rate limit service of Envoy Gateway to be enabled"
+		}
+		return &metav1.Condition{
+			Type:    RouteConditionRateLimited,
+			Status:  metav1.ConditionFalse,
+			Reason:  RouteReasonRateLimitNotAccepted,
+			Message: message,
+		}, nil
+	}
+	// Envoy Gateway counts the requests in fixed windows.
+	if rateLimit.Burst != nil {
+		return &metav1.Condition{
+			Type:    RouteConditionRateLimited,
+			Status:  metav1.ConditionTrue,
+			Reason:  RouteReasonBurstNotSupported,
+			Message: "the requests are limited without the burst, which is not supported by Envoy Gateway",
+		}, nil
+	}
+	return &metav1.Condition{
+		Type:   RouteConditionRateLimited,
+		Status: metav1.ConditionTrue,
+		Reason: RouteReasonAccepted,
+	}, nil
+}
+
+// rateLimitAnnotations returns the annotations of ingress-nginx which limit the requests per client IP.
+func rateLimitAnnotations(rateLimit *openfunction.RateLimit) map[string]string {
+	if rateLimit == nil {
+		return nil
+	}
+	annotations := map[string]string{
+		NginxLimitRPSAnnotation: fmt.Sprintf("%d", rateLimit.RequestsPerSecond),
+	}
+	if rateLimit.Burst != nil {
+		// The burst of ingress-nginx is a multiple of the rate.
+		multiplier := (*rateLimit.Burst + rateLimit.RequestsPerSecond - 1) / rateLimit.RequestsPerSecond
+		annotations[NginxLimitBurstMultiplierAnnotation] = fmt.Sprintf("%d", multiplier)
+	}
+	return annotations
+}
+
+func rateLimitNotSupportedCondition(message string) *metav1.Condition {
+	return &metav1.Condition{
+		Type:    RouteConditionRateLimited,
+		Status:  metav1.ConditionFalse,
+		Reason:  RouteReasonRateLimitNotSupported,
+		Message: message,
+	}
+}
+
+// concurrencyCondition reports whether `http.maxConcurrency` is enforced. It is the container concurrency of Knative,
+// while the KEDA http add-on only scales out on it, the requests are forwarded to the instances without a limit.
+func concurrencyCondition(fn *openfunction.Function) *metav1.Condition {
+	http := fn.Spec.Serving.Triggers.Http
+	if http == nil || http.MaxConcurrency == nil {
+		return nil
+	}
+
+	if http.Engine != nil && *http.Engine == openfunction.HttpEngineKeda {
+		return &metav1.Condition{
+			Type:   RouteConditionConcurrencyLimited,
+			Status: metav1.ConditionFalse,
+			Reason: RouteReasonConcurrencyLimitNotSupported,
+			Message: "the requests to an instance are not limited by the KEDA http add-on, " +
+				"maxConcurrency is only the target pending requests to scale out on",
+		}
+	}
+	return &metav1.Condition{
+		Type:   RouteConditionConcurrencyLimited,
+		Status: metav1.ConditionTrue,
+		Reason: RouteReasonAccepted,
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+func Test_syncRateLimitPolicy(t *testing.T) {
+	burst := int32(20)
+	fn := newRouteTestFunction(&ofcore.RouteImpl{})
+	fn.UID = "uid"
+	fn.Spec.Serving.Triggers.Http.RateLimit = &ofcore.RateLimit{
+		RequestsPerSecond: 10,
+		Burst:             &burst,
+		Key:               &ofcore.RateLimitKey{ClientIP: true},
+	}
+
+	gateway, objs := newPolicyTestGateway("projectcontour.io/gateway-controller")
+	r := newTestReconciler(t, objs...)
+	condition, err := r.syncRateLimitPolicy(fn, gateway, false)
+	if err != nil {
+		t.Fatalf("syncRateLimitPolicy() error = %v", err)
+	}
+	if condition == nil || condition.Reason != RouteReasonRateLimitNotSupported {
+		t.Errorf("expected the rate limit not to be supported by other implementations, got %v", condition)
+	}
+
+	gateway, objs = newPolicyTestGateway(ofngateway.EnvoyGatewayControllerName)
+	r = newTestReconciler(t, objs...)
+	condition, err = r.syncRateLimitPolicy(fn, gateway, false)
+	if err != nil {
+		t.Fatalf("syncRateLimitPolicy() error = %v", err)
+	}
+	if condition == nil || condition.Status != metav1.ConditionUnknown {
+		t.Errorf("expected the rate limit to be pending, got %v", condition)
+	}
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(ofngateway.BackendTrafficPolicyGVK)
+	if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: fn.Name}, policy); err != nil {
+		t.Fatalf("failed to get the policy: %v", err)
+	}
+
+	// The global rate limit is rejected if the rate limit service of Envoy Gateway is disabled.
+	setPolicyAcceptedStatus(t, r, policy, "False")
+	condition, _ = r.syncRateLimitPolicy(fn, gateway, false)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != RouteReasonRateLimitNotAccepted {
+		t.Errorf("expected the rate limit not to be accepted, got %v", condition)
+	}
+
+	setPolicyAcceptedStatus(t, r, policy, "True")
+	condition, _ = r.syncRateLimitPolicy(fn, gateway, false)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != RouteReasonBurstNotSupported {
+		t.Errorf("expected the burst to be reported as not supported, got %v", condition)
+	}
+
+	fn.Spec.Serving.Triggers.Http.RateLimit.Burst = nil
+	condition, _ = r.syncRateLimitPolicy(fn, gateway, false)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != RouteReasonAccepted {
+		t.Errorf("expected the rate limit to be enforced, got %v", condition)
+	}
+}
+
+func Test_concurrencyCondition(t *testing.T) {
+	fn := newRouteTestFunction(&ofcore.RouteImpl{})
+	if condition := concurrencyCondition(fn); condition != nil {
+		t.Errorf("expected no condition without maxConcurrency, got %v", condition)
+	}
+
+	maxConcurrency := int32(10)
+	fn.Spec.Serving.Triggers.Http.MaxConcurrency = &maxConcurrency
+	if condition := concurrencyCondition(fn); condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Errorf("expected the concurrency to be limited by Knative, got %v", condition)
+	}
+
+	engine := ofcore.HttpEngineKeda
+	fn.Spec.Serving.Triggers.Http.Engine = &engine
+	if condition := concurrencyCondition(fn); condition == nil || condition.Status != metav1.ConditionFalse ||
+		condition.Reason != RouteReasonConcurrencyLimitNotSupported {
+		t.Errorf("expected the concurrency not to be limited by KEDA, got %v", condition)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+// checkReferenceGrants reports the backends of the HTTPRoute of a function in other namespaces which are not
+// permitted by a ReferenceGrant. The backends generated for a function are always in the namespace of the function,
+// so the cross-namespace backends are set by the user, and the grants must be created by the owners of the backends.
+// The condition is updated when the ReferenceGrants in the namespaces of these backends change,
+// see findObjectsForReferenceGrant.
+func (r *FunctionReconciler) checkReferenceGrants(httpRoute *k8sgatewayapiv1beta1.HTTPRoute) (*metav1.Condition, error) {
+	log := r.Log.WithName("checkReferenceGrants")
+
+	from := k8sgatewayapiv1beta1.ReferenceGrantFrom{
+		Group:     ofngateway.GroupName,
+		Kind:      "HTTPRoute",
+		Namespace: k8sgatewayapiv1beta1.Namespace(httpRoute.Namespace),
+	}
+	refs, err := ofngateway.UnpermittedRefs(r.ctx, r.Client, from, ofngateway.CrossNamespaceRefsForHTTPRoute(httpRoute))
+	if err != nil {
+		log.Error(err, "Failed to check ReferenceGrants", "namespace", httpRoute.Namespace, "name", httpRoute.Name)
+		return nil, err
+	}
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.String())
+	}
+	return &metav1.Condition{
+		Type:    RouteConditionResolvedRefs,
+		Status:  metav1.ConditionFalse,
+		Reason:  RouteReasonRefNotPermitted,
+		Message: fmt.Sprintf("no ReferenceGrant allows the route to refer to %s", strings.Join(names, ", ")),
+	}, nil
+}
+
+// getBackendNamespaces returns the namespaces of the backends in other namespaces referred to by the route rules
+// of a function, including the backends of the RequestMirror filters.
+func getBackendNamespaces(fn *openfunction.Function) []string {
+	if fn.Spec.Serving == nil || fn.Spec.Serving.Triggers == nil || fn.Spec.Serving.Triggers.Http == nil ||
+		fn.Spec.Serving.Triggers.Http.Route == nil {
+		return nil
+	}
+	route := &k8sgatewayapiv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace},
+		Spec: k8sgatewayapiv1beta1.HTTPRouteSpec{
+			Rules: ofngateway.ToHTTPRouteRules(fn.Spec.Serving.Triggers.Http.Route.Rules),
+		},
+	}
+	set := make(map[string]bool)
+	var namespaces []string
+	for _, ref := range ofngateway.CrossNamespaceRefsForHTTPRoute(route) {
+		if !set[ref.Namespace] {
+			set[ref.Namespace] = true
+			namespaces = append(namespaces, ref.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// findObjectsForReferenceGrant returns the Functions whose routes refer to the backends in the namespace
+// of the ReferenceGrant, so that the ResolvedRefs condition of their routes is updated.
+func (r *FunctionReconciler) findObjectsForReferenceGrant(grant client.Object) []reconcile.Request {
+	functions := &openfunction.FunctionList{}
+	listOps := &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(BackendNamespaceField, grant.GetNamespace()),
+	}
+	if err := r.List(context.TODO(), functions, listOps); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(functions.Items))
+	for i, item := range functions.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			},
+		}
+	}
+	return requests
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+)
+
+func Test_getBackendNamespaces(t *testing.T) {
+	backend := func(namespace string) ofcore.BackendObjectReference {
+		ns := ofcore.Namespace(namespace)
+		return ofcore.BackendObjectReference{Name: "backend", Namespace: &ns}
+	}
+	mirror := func(namespace string) ofcore.HTTPRouteFilter {
+		return ofcore.HTTPRouteFilter{
+			Type:          ofcore.HTTPRouteFilterRequestMirror,
+			RequestMirror: &ofcore.HTTPRequestMirrorFilter{BackendRef: backend(namespace)},
+		}
+	}
+
+	fn := newRouteTestFunction(&ofcore.RouteImpl{
+		Rules: []ofcore.HTTPRouteRule{
+			{Filters: []ofcore.HTTPRouteFilter{mirror("mirror")}},
+			{
+				BackendRefs: []ofcore.HTTPBackendRef{
+					{BackendRef: ofcore.BackendRef{BackendObjectReference: backend("default")}},
+					{
+						BackendRef: ofcore.BackendRef{BackendObjectReference: backend("backend")},
+						Filters:    []ofcore.HTTPRouteFilter{mirror("mirror")},
+					},
+				},
+			},
+		},
+	})
+	if got, want := getBackendNamespaces(fn), []string{"backend", "mirror"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getBackendNamespaces() = %v, want %v", got, want)
+	}
+	if got := getBackendNamespaces(newRouteTestFunction(nil)); got != nil {
+		t.Errorf("getBackendNamespaces() without route = %v, want nil", got)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/constants"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+	"github.com/openfunction/pkg/util"
+)
+
+// versionBackend is the Knative revision which serves the requests matching a version route.
+type versionBackend struct {
+	matches  []k8sgatewayapiv1beta1.HTTPRouteMatch
+	revision string
+}
+
+// versionServings returns the names of the servings retained for the version routes of a function,
+// which are the latest running serving of each referred version.
+func versionServings(fn *openfunction.Function, servings []openfunction.Serving) map[string]bool {
+	retained := make(map[string]bool)
+	if fn.Spec.Serving == nil || fn.Spec.Serving.Triggers == nil || fn.Spec.Serving.Triggers.Http == nil ||
+		fn.Spec.Serving.Triggers.Http.Route == nil {
+		return retained
+	}
+
+	latest := make(map[string]*openfunction.Serving)
+	for _, versionRoute := range fn.Spec.Serving.Triggers.Http.Route.VersionRoutes {
+		latest[versionRoute.Version] = nil
+	}
+	for index := range servings {
+		serving := &servings[index]
+		if serving.Spec.Version == nil || serving.Status.State != openfunction.Running {
+			continue
+		}
+		current, ok := latest[*serving.Spec.Version]
+		if !ok {
+			continue
+		}
+		if current == nil || current.CreationTimestamp.Before(&serving.CreationTimestamp) {
+			latest[*serving.Spec.Version] = serving
+		}
+	}
+	for _, serving := range latest {
+		if serving != nil {
+			retained[serving.Name] = true
+		}
+	}
+	return retained
+}
+
+// resolveVersionRoutes returns the revisions which serve the version routes of a function, the version routes
+// which can not be resolved are skipped and reported with a condition.
+func (r *FunctionReconciler) resolveVersionRoutes(
+	fn *openfunction.Function,
+	knativeService *kservingv1.Service,
+	ingressMode bool) ([]versionBackend, *metav1.Condition, error) {
+	log := r.Log.WithName("resolveVersionRoutes")
+	versionRoutes := fn.Spec.Serving.Triggers.Http.Route.VersionRoutes
+	if len(versionRoutes) == 0 {
+		return nil, nil, nil
+	}
+	if ingressMode || knativeService == nil {
+		return nil, versionRoutesCondition(RouteReasonVersionRoutesNotSupported,
+			"version routes are only supported by the Knative engine in the Gateway API routing mode"), nil
+	}
+
+	servings := &openfunction.ServingList{}
+	if err := r.List(r.ctx, servings, client.InNamespace(fn.Namespace), client.MatchingLabels{constants.FunctionLabel: fn.Name}); err != nil {
+		log.Error(err, "Failed to list servings", "namespace", fn.Namespace, "name", fn.Name)
+		return nil, nil, err
+	}
+	retained := versionServings(fn, servings.Items)
+	revisions := make(map[string]string)
+	for index := range servings.Items {
+		serving := &servings.Items[index]
+		if !retained[serving.Name] || serving.Status.Service == "" {
+			continue
+		}
+		service := &kservingv1.Service{}
+		if err := r.Get(r.ctx, client.ObjectKey{Namespace: fn.Namespace, Name: serving.Status.Service}, service); err != nil {
+			if util.IsNotFound(err) {
+				continue
+			}
+			log.Error(err, "Failed to get knative service", "namespace", fn.Namespace, "name", serving.Status.Service)
+			return nil, nil, err
+		}
+		if service.Status.LatestReadyRevisionName != "" {
+			revisions[*serving.Spec.Version] = service.Status.LatestReadyRevisionName
+		}
+	}
+
+	var backends []versionBackend
+	var missing []string
+	for _, versionRoute := range versionRoutes {
+		revision, ok := revisions[versionRoute.Version]
+		if !ok {
+			missing = append(missing, versionRoute.Version)
+			continue
+		}
+		backends = append(backends, versionBackend{matches: ofngateway.ToHTTPRouteMatches(versionRoute.Matches), revision: revision})
+	}
+	if len(missing) != 0 {
+		sort.Strings(missing)
+		return backends, versionRoutesCondition(RouteReasonVersionNotFound,
+			fmt.Sprintf("no running serving of version %s", strings.Join(missing, ", "))), nil
+	}
+	return backends, nil, nil
+}
+
+func versionRoutesCondition(reason, message string) *metav1.Condition {
+	return &metav1.Condition{
+		Type:    RouteConditionVersionRoutesResolved,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package core
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+var versionTestTime = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newVersionTestFunction(versions ...string) *ofcore.Function {
+	fn := newRouteTestFunction(&ofcore.RouteImpl{})
+	fn.UID = "uid"
+	for _, version := range versions {
+		name := ofcore.HTTPHeaderName("x-version")
+		fn.Spec.Serving.Triggers.Http.Route.VersionRoutes = append(fn.Spec.Serving.Triggers.Http.Route.VersionRoutes,
+			ofcore.VersionRoute{
+				Version: version,
+				Matches: []ofcore.HTTPRouteMatch{{Headers: []ofcore.HTTPHeaderMatch{{Name: name, Value: version}}}},
+			})
+	}
+	return fn
+}
+
+func newVersionTestServing(name, version string, state string, age time.Duration) *ofcore.Serving {
+	serving := &ofcore.Serving{}
+	serving.Namespace = "default"
+	serving.Name = name
+	serving.Labels = map[string]string{constants.FunctionLabel: "sample"}
+	serving.CreationTimestamp = metav1.NewTime(versionTestTime.Add(-age))
+	if version != "" {
+		serving.Spec.Version = &version
+	}
+	serving.Status.State = state
+	serving.Status.Service = "ksvc-" + name
+	return serving
+}
+
+func newVersionTestKnativeService(name, revision string) *kservingv1.Service {
+	service := &kservingv1.Service{}
+	service.Namespace = "default"
+	service.Name = name
+	service.Status.LatestReadyRevisionName = revision
+	return service
+}
+
+func Test_cleanServing(t *testing.T) {
+	fn := newVersionTestFunction("v1", "v2")
+	fn.Status.Serving = &ofcore.Condition{ResourceRef: "current", LastSuccessfulResourceRef: "last"}
+	r := newTestReconciler(t,
+		newVersionTestServing("v1-old", "v1", ofcore.Running, 2*time.Hour),
+		newVersionTestServing("v1-new", "v1", ofcore.Running, time.Hour),
+		newVersionTestServing("v2-failed", "v2", ofcore.Failed, time.Hour),
+		newVersionTestServing("v3", "v3", ofcore.Running, time.Hour),
+		newVersionTestServing("stale", "", ofcore.Running, time.Hour),
+		newVersionTestServing("current", "v4", ofcore.Running, 0),
+		newVersionTestServing("last", "v3", ofcore.Running, time.Hour),
+	)
+
+	if err := r.cleanServing(fn); err != nil {
+		t.Fatalf("cleanServing() error = %v", err)
+	}
+	servings := &ofcore.ServingList{}
+	if err := r.List(r.ctx, servings, client.InNamespace(fn.Namespace)); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, serving := range servings.Items {
+		names = append(names, serving.Name)
+	}
+	sort.Strings(names)
+	// Only the latest running serving of each referred version is retained besides the current ones.
+	if want := []string{"current", "last", "v1-new"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected the servings %v to be retained, got %v", want, names)
+	}
+}
+
+func Test_resolveVersionRoutes(t *testing.T) {
+	fn := newVersionTestFunction("v1", "v2", "v3")
+	knativeService := newVersionTestKnativeService("ksvc-current", "rev-current")
+	r := newTestReconciler(t,
+		newVersionTestServing("v1", "v1", ofcore.Running, time.Hour),
+		newVersionTestKnativeService("ksvc-v1", "rev-v1"),
+		// The Knative service of v2 has no ready revision yet.
+		newVersionTestServing("v2", "v2", ofcore.Running, time.Hour),
+		newVersionTestKnativeService("ksvc-v2", ""),
+	)
+
+	backends, condition, err := r.resolveVersionRoutes(fn, knativeService, false)
+	if err != nil {
+		t.Fatalf("resolveVersionRoutes() error = %v", err)
+	}
+	if len(backends) != 1 || backends[0].revision != "rev-v1" ||
+		!reflect.DeepEqual(backends[0].matches, ofngateway.ToHTTPRouteMatches(fn.Spec.Serving.Triggers.Http.Route.VersionRoutes[0].Matches)) {
+		t.Errorf("expected the version route of v1 to be served by rev-v1, got %v", backends)
+	}
+	if condition == nil || condition.Reason != RouteReasonVersionNotFound || condition.Message != "no running serving of version v2, v3" {
+		t.Errorf("expected the missing versions to be reported, got %v", condition)
+	}
+
+	if backends, condition, _ = r.resolveVersionRoutes(fn, knativeService, true); backends != nil ||
+		condition == nil || condition.Reason != RouteReasonVersionRoutesNotSupported {
+		t.Errorf("expected the version routes not to be supported in the Ingress routing mode, got %v, %v", backends, condition)
+	}
+	if backends, condition, _ = r.resolveVersionRoutes(fn, nil, false); backends != nil ||
+		condition == nil || condition.Reason != RouteReasonVersionRoutesNotSupported {
+		t.Errorf("expected the version routes not to be supported by the KEDA engine, got %v, %v", backends, condition)
+	}
+}
+
+func Test_mutateHTTPRoute_versionRules(t *testing.T) {
+	fn := newVersionTestFunction("v1")
+	fn.Spec.Serving.Triggers.Http.Route.Hostnames = []ofcore.Hostname{"sample.ofn.io"}
+	gateway := &networkingv1alpha1.Gateway{}
+	gateway.Namespace = "openfunction"
+	gateway.Name = "openfunction"
+	gateway.Spec.ClusterDomain = "cluster.local"
+	gateway.Spec.PathTemplate = "{{.Namespace}}/{{.Name}}"
+	gateway.Spec.HttpRouteLabelKey = "app.kubernetes.io/managed-by"
+	gateway.Spec.GatewayRef = &networkingv1alpha1.GatewayRef{Namespace: "gateway", Name: "eg"}
+	gateway.Spec.StripPathPrefix = true
+
+	r := newTestReconciler(t)
+	r.gatewayAPIVersion = ofngateway.V1Beta1
+	backends := []versionBackend{{
+		matches:  ofngateway.ToHTTPRouteMatches(fn.Spec.Serving.Triggers.Http.Route.VersionRoutes[0].Matches),
+		revision: "rev-v1",
+	}}
+	httpRoute := &k8sgatewayapiv1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Namespace: fn.Namespace, Name: fn.Name}}
+	knativeService := newVersionTestKnativeService("ksvc-current", "rev-current")
+	if err := r.mutateHTTPRoute(fn, knativeService, nil, gateway, backends, nil, httpRoute)(); err != nil {
+		t.Fatalf("mutateHTTPRoute() error = %v", err)
+	}
+
+	rules := httpRoute.Spec.Rules
+	if len(rules) != 2 {
+		t.Fatalf("expected a version rule before the default rule, got %d rules", len(rules))
+	}
+	for index, revision := range []string{"rev-v1", "rev-current"} {
+		rule := rules[index]
+		if len(rule.BackendRefs) != 1 || string(rule.BackendRefs[0].Name) != revision {
+			t.Errorf("rule %d: expected the backend %s, got %v", index, revision, rule.BackendRefs)
+		}
+		// The version rules match the default path of the function, which is stripped as the other rules.
+		if path := rule.Matches[0].Path; path == nil || *path.Value != "/default/sample" {
+			t.Errorf("rule %d: expected the default path, got %v", index, path)
+		}
+		if prefix, ok := ofngateway.PrefixRewriteOf(rule); !ok || prefix != "/" {
+			t.Errorf("rule %d: expected the path prefix to be stripped", index)
+		}
+		host := ""
+		for _, filter := range rule.Filters {
+			if filter.RequestHeaderModifier != nil {
+				host = filter.RequestHeaderModifier.Add[0].Value
+			}
+		}
+		if want := revision + ".default.svc.cluster.local"; host != want {
+			t.Errorf("rule %d: expected the Host %s, got %s", index, want, host)
+		}
+	}
+	if headers := rules[0].Matches[0].Headers; len(headers) != 1 || headers[0].Value != "v1" {
+		t.Errorf("expected the version rule to match the version header, got %v", headers)
+	}
+}
@@ -25,15 +25,20 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	openfunction "github.com/openfunction/apis/core/v1beta2"
 	"github.com/openfunction/pkg/constants"
 	"github.com/openfunction/pkg/core"
+	"github.com/openfunction/pkg/core/serving/common"
 	"github.com/openfunction/pkg/core/serving/kedahttp"
 	"github.com/openfunction/pkg/core/serving/knative"
 	"github.com/openfunction/pkg/core/serving/openfuncasync"
@@ -72,10 +77,14 @@ func NewServingReconciler(mgr manager.Manager, eventRecorder events.EventRecorde
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=servings/finalizers,verbs=update
 //+kubebuilder:rbac:groups=serving.knative.dev,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=dapr.io,resources=components;subscriptions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dapr.io,resources=resiliencies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=keda.sh,resources=scaledjobs;scaledobjects,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=http.keda.sh,resources=httpscaledobjects,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=list;get;watch;update;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -347,7 +356,9 @@ func (r *ServingReconciler) recordEvent(serving *openfunction.Serving) {
 func (r *ServingReconciler) SetupWithManager(mgr ctrl.Manager, owns []client.Object) error {
 
 	b := ctrl.NewControllerManagedBy(mgr).
-		For(&openfunction.Serving{})
+		For(&openfunction.Serving{}).
+		Watches(&source.Kind{Type: &discoveryv1.EndpointSlice{}},
+			handler.EnqueueRequestsFromMapFunc(r.findServingsForDaprProxyEndpoints))
 
 	for _, own := range owns {
 		b.Owns(own)
@@ -355,3 +366,27 @@ func (r *ServingReconciler) SetupWithManager(mgr ctrl.Manager, owns []client.Obj
 
 	return b.Complete(r)
 }
+
+// findServingsForDaprProxyEndpoints returns the servings of the function whose dapr-proxy is served by the EndpointSlice,
+// so that the servings become running once the dapr-proxy has ready endpoints.
+func (r *ServingReconciler) findServingsForDaprProxyEndpoints(obj client.Object) []reconcile.Request {
+	fnName := common.GetFunctionNameOfDaprProxyService(obj.GetNamespace(), obj.GetLabels()[discoveryv1.LabelServiceName])
+	if fnName == "" {
+		return nil
+	}
+
+	servings := &openfunction.ServingList{}
+	if err := r.List(context.Background(), servings,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingLabels{constants.FunctionLabel: fnName}); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, s := range servings.Items {
+		if common.NeedCreateDaprProxy(&s) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&s)})
+		}
+	}
+	return requests
+}
@@ -18,12 +18,14 @@ package events
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,8 +33,11 @@ import (
 	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcorev1beta1 "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
 )
 
 const (
@@ -42,6 +47,8 @@ const (
 	EventBusTopicName          = "eventbus-topic-name"
 
 	DefaultLogLevel = "1"
+	// DefaultClusterDomain is used to address the sinks if the default OpenFunction Gateway does not exist.
+	DefaultClusterDomain = "cluster.local"
 
 	// Component Name Template
 
@@ -51,8 +58,12 @@ const (
 	EventSourceBusComponentNameTmpl = "ebfes-%s"
 	// TriggerBusComponentNameTmpl => ebft(EventBus for Trigger)-{triggerName}
 	TriggerBusComponentNameTmpl = "ebft-%s"
+	// TriggerStateStoreNameTmpl => tss(Trigger State Store)-{triggerName}
+	TriggerStateStoreNameTmpl = "tss-%s"
 	// SinkComponentNameTmpl => ts-{resourceName}-{sinkNamespace}
 	SinkComponentNameTmpl = "ts-%s-%s"
+	// SelectorSinkComponentNameTmpl => ts-{resourceName}-{sinkNamespace}-{functionName}
+	SelectorSinkComponentNameTmpl = "ts-%s-%s-%s"
 
 	// EventSourceWorkloadsNameTmpl => esw(EventSource Workloads)-{eventSourceName}-{sourceKind}-{eventName}
 	EventSourceWorkloadsNameTmpl = "esw-%s-%s-%s"
@@ -80,94 +91,23 @@ const (
 	SourceKindMQTT = "mqtt"
 	// SourceKindRedis indicates redis event source
 	SourceKindRedis = "redis"
+	// SourceKindRabbitMQ indicates rabbitmq event source
+	SourceKindRabbitMQ = "rabbitmq"
+	// SourceKindKubernetes indicates kubernetes api server event source
+	SourceKindKubernetes = "kubernetes"
+	// SourceKindS3 indicates s3 compatible object storage event source
+	SourceKindS3 = "s3"
 )
 
 var (
 	knativeServiceGVK = schema.FromAPIVersionAndKind(kservingv1.SchemeGroupVersion.String(), "Service")
 	ofFunctionGVK     = schema.FromAPIVersionAndKind(ofcore.SchemeGroupVersion.String(), "Function")
+	// Sinks may still refer to v1beta1 Functions, which are served by the same resource.
+	ofFunctionV1beta1GVK = schema.FromAPIVersionAndKind(ofcorev1beta1.SchemeGroupVersion.String(), "Function")
+	ofServingGVK         = schema.FromAPIVersionAndKind(ofcore.SchemeGroupVersion.String(), "Serving")
+	serviceGVK           = schema.FromAPIVersionAndKind(corev1.SchemeGroupVersion.String(), "Service")
 )
 
-type EventSourceConfig struct {
-	EventBusComponent  string `json:"eventBusComponent,omitempty"`
-	EventBusOutputName string `json:"eventBusOutputName,omitempty"`
-	EventBusTopic      string `json:"eventBusTopic,omitempty"`
-	SinkOutputName     string `json:"sinkOutputName,omitempty"`
-	LogLevel           string `json:"logLevel,omitempty"`
-}
-
-type TriggerConfig struct {
-	EventBusComponent string                 `json:"eventBusComponent,omitempty"`
-	Inputs            []*Input               `json:"inputs,omitempty"`
-	Subscribers       map[string]*Subscriber `json:"subscribers,omitempty"`
-	LogLevel          string                 `json:"logLevel,omitempty"`
-}
-
-type Input struct {
-	Name        string `json:"name"`
-	Namespace   string `json:"namespace,omitempty"`
-	EventSource string `json:"eventSource"`
-	Event       string `json:"event"`
-}
-
-type Subscriber struct {
-	SinkOutputName       string `json:"sinkOutputName,omitempty"`
-	DLSinkOutputName     string `json:"dlSinkOutputName,omitempty"`
-	EventBusOutputName   string `json:"eventBusOutputName,omitempty"`
-	DLEventBusOutputName string `json:"dlEventBusOutputName,omitempty"`
-}
-
-func (e *EventSourceConfig) EncodeConfig() (string, error) {
-	return encodeEnv(e)
-}
-
-func (e *TriggerConfig) EncodeConfig() (string, error) {
-	return encodeEnv(e)
-}
-
-func encodeEnv(config interface{}) (string, error) {
-	configBytes, err := json.Marshal(config)
-	if err != nil {
-		return "", err
-	}
-	configEncode := base64.StdEncoding.EncodeToString(configBytes)
-	return configEncode, nil
-}
-
-func (e *EventSourceConfig) DecodeEnv(encodedConfig string) (*EventSourceConfig, error) {
-	var config *EventSourceConfig
-	configSpec, err := decodeConfig(encodedConfig)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(configSpec, &config); err != nil {
-		return nil, err
-	}
-	return config, nil
-}
-
-func (e *TriggerConfig) DecodeEnv(encodedConfig string) (*TriggerConfig, error) {
-	var config *TriggerConfig
-	configSpec, err := decodeConfig(encodedConfig)
-	if err != nil {
-		return nil, err
-	}
-	if err := json.Unmarshal(configSpec, &config); err != nil {
-		return nil, err
-	}
-	return config, nil
-}
-
-func decodeConfig(encodedConfig string) ([]byte, error) {
-	if len(encodedConfig) > 0 {
-		configSpec, err := base64.StdEncoding.DecodeString(encodedConfig)
-		if err != nil {
-			return nil, err
-		}
-		return configSpec, nil
-	}
-	return nil, errors.New("string length is zero")
-}
-
 func newSinkComponentSpec(c client.Client, log logr.Logger, ref *ofevent.Reference) (*componentsv1alpha1.ComponentSpec, error) {
 	ctx := context.Background()
 	sinkNamespace := ref.Namespace
@@ -196,6 +136,9 @@ func newSinkComponentSpec(c client.Client, log logr.Logger, ref *ofevent.Referen
 }
 
 func createSinkComponent(ctx context.Context, c client.Client, log logr.Logger, resource client.Object, sink *ofevent.SinkSpec) (*componentsv1alpha1.Component, error) {
+	if isSelectorSink(sink) {
+		return nil, errors.New("selector sink is only supported by the sink of trigger subscribers")
+	}
 	if sink.Uri == nil && sink.Ref == nil {
 		return nil, errors.New("at least one uri or ref must be set in sink")
 	}
@@ -206,39 +149,163 @@ func createSinkComponent(ctx context.Context, c client.Client, log logr.Logger,
 		// when setting the Uri, use resource.GetNamespace()
 		namespace = resource.GetNamespace()
 	} else {
-		gvk := sink.Ref.GroupVersionKind()
-		switch gvk {
-		case knativeServiceGVK:
-			var ksvc kservingv1.Service
-			if err := c.Get(ctx, types.NamespacedName{Namespace: sink.Ref.Namespace, Name: sink.Ref.Name}, &ksvc); err != nil {
-				log.Error(err, "Failed to find Knative Service", "namespace", sink.Ref.Namespace, "name", sink.Ref.Name)
-				return nil, err
-			}
-			url = ksvc.Status.URL.String()
-		case ofFunctionGVK:
-			var of ofcore.Function
-			if err := c.Get(ctx, types.NamespacedName{Namespace: sink.Ref.Namespace, Name: sink.Ref.Name}, &of); err != nil {
-				log.Error(err, "Failed to find OpenFunction", "namespace", sink.Ref.Namespace, "name", sink.Ref.Name)
-				return nil, err
-			}
-			for _, address := range of.Status.Addresses {
-				if *address.Type == ofcore.InternalAddressType {
-					url = address.Value
-					break
-				}
-			}
-		default:
-			return nil, fmt.Errorf("unsupported reference %s", gvk.String())
+		var err error
+		if url, err = getSinkRefURL(ctx, c, log, sink.Ref); err != nil {
+			return nil, err
 		}
 		namespace = sink.Ref.Namespace
 	}
 
 	sink.Uri = &url
 
+	return newSinkComponent(fmt.Sprintf(SinkComponentNameTmpl, resource.GetName(), namespace), resource.GetNamespace(), url)
+}
+
+// isSelectorSink reports whether the sink fans out to the Functions selected by labels,
+// which is the case only if neither uri nor ref is set.
+func isSelectorSink(sink *ofevent.SinkSpec) bool {
+	return sink != nil && sink.Uri == nil && sink.Ref == nil && sink.Selector != nil
+}
+
+// createSelectorSinkComponents generates a sink component for each of the Functions selected by the sink,
+// the Functions which have no internal address yet are skipped until they get one.
+func createSelectorSinkComponents(ctx context.Context, c client.Client, log logr.Logger, resource client.Object, sink *ofevent.SinkSpec) ([]*componentsv1alpha1.Component, error) {
+	namespace := getSinkSelectorNamespace(resource, sink.Selector)
+	selector, err := metav1.LabelSelectorAsSelector(sink.Selector.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	var functions ofcore.FunctionList
+	if err := c.List(ctx, &functions, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		log.Error(err, "Failed to list OpenFunction", "namespace", namespace, "selector", selector.String())
+		return nil, err
+	}
+
+	sort.Slice(functions.Items, func(i, j int) bool {
+		return functions.Items[i].Name < functions.Items[j].Name
+	})
+
+	var components []*componentsv1alpha1.Component
+	for _, fn := range functions.Items {
+		url := getFunctionInternalAddress(&fn)
+		if url == "" {
+			log.V(1).Info("Skip OpenFunction without internal address", "namespace", fn.Namespace, "name", fn.Name)
+			continue
+		}
+
+		component, err := newSinkComponent(
+			fmt.Sprintf(SelectorSinkComponentNameTmpl, resource.GetName(), namespace, fn.Name),
+			resource.GetNamespace(),
+			url,
+		)
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, component)
+	}
+	return components, nil
+}
+
+// getSinkSelectorNamespace returns the namespace of the Functions selected by the sink,
+// which defaults to the namespace of the resource.
+func getSinkSelectorNamespace(resource client.Object, selector *ofevent.SinkSelector) string {
+	if selector.Namespace != "" {
+		return selector.Namespace
+	}
+	return resource.GetNamespace()
+}
+
+func getSinkRefURL(ctx context.Context, c client.Client, log logr.Logger, ref *ofevent.Reference) (string, error) {
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+	gvk := ref.GroupVersionKind()
+	switch gvk {
+	case knativeServiceGVK:
+		var ksvc kservingv1.Service
+		if err := c.Get(ctx, key, &ksvc); err != nil {
+			log.Error(err, "Failed to find Knative Service", "namespace", ref.Namespace, "name", ref.Name)
+			return "", err
+		}
+		return ksvc.Status.URL.String(), nil
+	case ofFunctionGVK, ofFunctionV1beta1GVK:
+		var of ofcore.Function
+		if err := c.Get(ctx, key, &of); err != nil {
+			log.Error(err, "Failed to find OpenFunction", "namespace", ref.Namespace, "name", ref.Name)
+			return "", err
+		}
+		return getFunctionInternalAddress(&of), nil
+	case ofServingGVK:
+		var serving ofcore.Serving
+		if err := c.Get(ctx, key, &serving); err != nil {
+			log.Error(err, "Failed to find OpenFunction Serving", "namespace", ref.Namespace, "name", ref.Name)
+			return "", err
+		}
+		if serving.Status.Service == "" {
+			return "", fmt.Errorf("serving %s/%s has no service yet", ref.Namespace, ref.Name)
+		}
+		// The services of both Knative and KEDA http servings listen on port 80.
+		return fmt.Sprintf("http://%s.%s.svc.%s", serving.Status.Service, ref.Namespace, getClusterDomain(ctx, c)), nil
+	case serviceGVK:
+		var svc corev1.Service
+		if err := c.Get(ctx, key, &svc); err != nil {
+			log.Error(err, "Failed to find Service", "namespace", ref.Namespace, "name", ref.Name)
+			return "", err
+		}
+		return getServiceURL(&svc, getClusterDomain(ctx, c))
+	default:
+		return "", fmt.Errorf("unsupported reference %s", gvk.String())
+	}
+}
+
+func getFunctionInternalAddress(fn *ofcore.Function) string {
+	for _, address := range fn.Status.Addresses {
+		if address.Type != nil && *address.Type == ofcore.InternalAddressType {
+			return address.Value
+		}
+	}
+	return ""
+}
+
+// getClusterDomain returns the cluster domain configured in the default OpenFunction Gateway,
+// or `cluster.local` if the Gateway does not exist.
+func getClusterDomain(ctx context.Context, c client.Client) string {
+	gateway := &networkingv1alpha1.Gateway{}
+	key := types.NamespacedName{
+		Namespace: string(constants.DefaultGatewayNamespace),
+		Name:      string(constants.DefaultGatewayName),
+	}
+	if err := c.Get(ctx, key, gateway); err != nil || gateway.Spec.ClusterDomain == "" {
+		return DefaultClusterDomain
+	}
+	return gateway.Spec.ClusterDomain
+}
+
+// getServiceURL returns the address of the port named `http` of the Service, or its first port if there is no such port.
+func getServiceURL(svc *corev1.Service, clusterDomain string) (string, error) {
+	if len(svc.Spec.Ports) == 0 {
+		return "", fmt.Errorf("service %s/%s has no port", svc.Namespace, svc.Name)
+	}
+
+	port := svc.Spec.Ports[0]
+	for _, p := range svc.Spec.Ports {
+		if p.Name == "http" {
+			port = p
+			break
+		}
+	}
+
+	host := fmt.Sprintf("%s.%s.svc.%s", svc.Name, svc.Namespace, clusterDomain)
+	if port.Port != 80 {
+		host = fmt.Sprintf("%s:%d", host, port.Port)
+	}
+	return fmt.Sprintf("http://%s", host), nil
+}
+
+func newSinkComponent(name string, namespace string, url string) (*componentsv1alpha1.Component, error) {
 	component := &componentsv1alpha1.Component{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf(SinkComponentNameTmpl, resource.GetName(), namespace),
-			Namespace: resource.GetNamespace(),
+			Name:      name,
+			Namespace: namespace,
 		},
 	}
 
@@ -279,38 +346,121 @@ func retrieveClusterEventBus(ctx context.Context, c client.Client, eventBusName
 
 func addSinkForFunction(name string, function *ofcore.Function, component *componentsv1alpha1.Component) *ofcore.Function {
 	// add sink bindings component
-	function.Spec.Serving.Bindings[component.Name] = &component.Spec
+	addComponentForFunction(name, function, &component.Spec)
 
 	// add sink output
-	output := &ofcore.DaprIO{
-		Name:      name,
-		Component: component.Name,
-		Operation: "post",
-	}
-	function.Spec.Serving.Outputs = append(function.Spec.Serving.Outputs, output)
+	function.Spec.Serving.Outputs = append(function.Spec.Serving.Outputs, newOutput(name, component.Spec.Type, "", "post"))
 
 	return function
 }
 
+// addComponentForFunction declares the component of a handler input or output under the name of the input or output.
+func addComponentForFunction(name string, function *ofcore.Function, spec *componentsv1alpha1.ComponentSpec) {
+	if strings.Split(spec.Type, ".")[0] == ofcore.DaprBindings {
+		function.Spec.Serving.Bindings[name] = spec.DeepCopy()
+	} else {
+		function.Spec.Serving.Pubsub[name] = spec.DeepCopy()
+	}
+}
+
+// newOutput generates a Dapr output, the handlers look up the output by its name,
+// so the component of the output must be declared under the same name.
+func newOutput(name string, componentType string, topic string, operation string) *ofcore.Output {
+	return &ofcore.Output{
+		Dapr: &ofcore.DaprOutput{
+			DaprComponentRef: &ofcore.DaprComponentRef{
+				Name:  name,
+				Type:  componentType,
+				Topic: topic,
+			},
+			Operation: operation,
+		},
+	}
+}
+
+// newDaprTrigger generates a Dapr trigger, the handlers look up the input by its name,
+// so the component of the trigger must be declared under the same name.
+func newDaprTrigger(name string, componentType string, topic string) *ofcore.DaprTrigger {
+	return &ofcore.DaprTrigger{
+		DaprComponentRef: &ofcore.DaprComponentRef{
+			Name:  name,
+			Type:  componentType,
+			Topic: topic,
+		},
+	}
+}
+
+// setDelivery applies the timeout of the delivery to the resiliency policy of the sink or topic output,
+// the retries are performed by the trigger handler.
+func setDelivery(name string, function *ofcore.Function, delivery *ofevent.DeliverySpec) {
+	if delivery == nil || delivery.Timeout == "" {
+		return
+	}
+
+	policy := &ofcore.ResiliencyPolicy{Timeout: delivery.Timeout}
+	for _, output := range function.Spec.Serving.Outputs {
+		if output.Dapr != nil && output.Dapr.Name == name {
+			output.Dapr.Resiliency = policy.DeepCopy()
+		}
+	}
+}
+
 func InitFunction(image string) *ofcore.Function {
+	version := "v1.0.0"
 	function := &ofcore.Function{
 		Spec: ofcore.FunctionSpec{
+			Version: &version,
 			Image:   image,
-			Serving: &ofcore.ServingImpl{},
+			Serving: &ofcore.ServingImpl{
+				Triggers: &ofcore.Triggers{},
+				Outputs:  []*ofcore.Output{},
+				Bindings: map[string]*componentsv1alpha1.ComponentSpec{},
+				Pubsub:   map[string]*componentsv1alpha1.ComponentSpec{},
+				ScaleOptions: &ofcore.ScaleOptions{
+					Keda: &ofcore.KedaScaleOptions{
+						ScaledObject: &ofcore.KedaScaledObject{},
+					},
+				},
+				WorkloadType: ofcore.WorkloadTypeDeployment,
+			},
 		},
 	}
 
-	version := "v1.0.0"
-	function.Spec.Version = &version
-	function.Spec.Serving.Runtime = ofcore.Async
-	function.Spec.Serving.Inputs = []*ofcore.DaprIO{}
-	function.Spec.Serving.Outputs = []*ofcore.DaprIO{}
-	function.Spec.Serving.Bindings = map[string]*componentsv1alpha1.ComponentSpec{}
-	function.Spec.Serving.Pubsub = map[string]*componentsv1alpha1.ComponentSpec{}
-	function.Spec.Serving.ScaleOptions = &ofcore.ScaleOptions{}
-	function.Spec.Serving.ScaleOptions.Keda = &ofcore.KedaScaleOptions{}
-	function.Spec.Serving.ScaleOptions.Keda.ScaledObject = &ofcore.KedaScaledObject{}
-	function.Spec.Serving.Triggers = []ofcore.Triggers{}
-
 	return function
 }
+
+// getFunctionReadiness reports whether the Function handling the events is running.
+func getFunctionReadiness(function *ofcore.Function) (metav1.ConditionStatus, string) {
+	if function.Status.Serving == nil || function.Status.Serving.State == "" {
+		return metav1.ConditionUnknown, fmt.Sprintf("Function %s is pending", function.Name)
+	}
+
+	state := function.Status.Serving.State
+	switch state {
+	case ofcore.Running:
+		return metav1.ConditionTrue, fmt.Sprintf("Function %s is running", function.Name)
+	case ofcore.Failed, ofcore.Timeout, ofcore.Canceled, ofcore.UnknownRuntime:
+		return metav1.ConditionFalse, fmt.Sprintf("Function %s is %s", function.Name, state)
+	default:
+		return metav1.ConditionUnknown, fmt.Sprintf("Function %s is %s", function.Name, state)
+	}
+}
+
+// aggregateReadiness returns False if any of the items is not ready, Unknown if any of them is unknown,
+// otherwise True, along with the names of the items which are not ready.
+func aggregateReadiness(names []string, statuses []metav1.ConditionStatus) (metav1.ConditionStatus, string) {
+	status := metav1.ConditionTrue
+	var notReady []string
+	for i, s := range statuses {
+		if s == metav1.ConditionTrue {
+			continue
+		}
+		notReady = append(notReady, names[i])
+		if s == metav1.ConditionFalse {
+			status = metav1.ConditionFalse
+		} else if status == metav1.ConditionTrue {
+			status = metav1.ConditionUnknown
+		}
+	}
+	return status, strings.Join(notReady, ", ")
+}
@@ -18,21 +18,28 @@ package events
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/logr/testr"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
 	kservingv1 "knative.dev/serving/pkg/apis/serving/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcorev1beta1 "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
+	"github.com/openfunction/pkg/event"
 )
 
 func Test_createSinkComponent(t *testing.T) {
@@ -107,9 +114,20 @@ func Test_createSinkComponent(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
+		if err := networkingv1alpha1.AddToScheme(scheme); err != nil {
+			t.Error(err)
+		}
 		return scheme
 	}
 
+	gateway := &networkingv1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(constants.DefaultGatewayName),
+			Namespace: string(constants.DefaultGatewayNamespace),
+		},
+		Spec: networkingv1alpha1.GatewaySpec{ClusterDomain: "example.org"},
+	}
+
 	tests := []struct {
 		name    string
 		args    args
@@ -200,6 +218,39 @@ func Test_createSinkComponent(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "ref openfunction v1beta1",
+			args: args{
+				ctx: context.Background(),
+				c: fake.NewClientBuilder().WithScheme(newOfScheme(t)).WithRuntimeObjects(&ofcore.Function{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "function",
+						Namespace: "test",
+					},
+					Status: ofcore.FunctionStatus{
+						Addresses: []ofcore.FunctionAddress{{Type: &addressType, Value: "http://test-of"}},
+					},
+				}).Build(),
+				log:      testr.New(t),
+				resource: resource,
+				sink: &ofevent.SinkSpec{
+					Ref: &ofevent.Reference{
+						Kind:       "Function",
+						APIVersion: ofcorev1beta1.SchemeGroupVersion.String(),
+						Namespace:  "test",
+						Name:       "function",
+					},
+				},
+			},
+			want: &componentsv1alpha1.Component{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ts-test-test",
+					Namespace: "test",
+				},
+				Spec: *newSinkSpecFunc(t, "http://test-of"),
+			},
+			wantErr: false,
+		},
 		{
 			name: "Set both",
 			args: args{
@@ -282,6 +333,82 @@ func Test_createSinkComponent(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "ref serving",
+			args: args{
+				ctx: context.Background(),
+				c: fake.NewClientBuilder().WithScheme(newOfScheme(t)).WithRuntimeObjects(&ofcore.Serving{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "serving",
+						Namespace: "test",
+					},
+					Status: ofcore.ServingStatus{Service: "serving-ksvc"},
+				}, gateway).Build(),
+				log:      testr.New(t),
+				resource: resource,
+				sink: &ofevent.SinkSpec{
+					Ref: &ofevent.Reference{
+						Kind:       "Serving",
+						APIVersion: ofcore.GroupVersion.String(),
+						Namespace:  "test",
+						Name:       "serving",
+					},
+				},
+			},
+			want: &componentsv1alpha1.Component{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ts-test-test",
+					Namespace: "test",
+				},
+				Spec: *newSinkSpecFunc(t, "http://serving-ksvc.test.svc.example.org"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "ref service",
+			args: args{
+				ctx: context.Background(),
+				c: fake.NewClientBuilder().WithRuntimeObjects(&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "service",
+						Namespace: "test",
+					},
+					Spec: corev1.ServiceSpec{
+						Ports: []corev1.ServicePort{{Name: "grpc", Port: 9090}, {Name: "http", Port: 8080}},
+					},
+				}).Build(),
+				log:      testr.New(t),
+				resource: resource,
+				sink: &ofevent.SinkSpec{
+					Ref: &ofevent.Reference{
+						Kind:       "Service",
+						APIVersion: "v1",
+						Namespace:  "test",
+						Name:       "service",
+					},
+				},
+			},
+			want: &componentsv1alpha1.Component{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ts-test-test",
+					Namespace: "test",
+				},
+				Spec: *newSinkSpecFunc(t, "http://service.test.svc.cluster.local:8080"),
+			},
+			wantErr: false,
+		},
+		{
+			name: "selector",
+			args: args{
+				ctx:      context.Background(),
+				log:      testr.New(t),
+				resource: resource,
+				sink: &ofevent.SinkSpec{
+					Selector: &ofevent.SinkSelector{LabelSelector: &metav1.LabelSelector{}},
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "unsupported reference",
 			args: args{
@@ -323,3 +450,498 @@ func Test_createSinkComponent(t *testing.T) {
 		})
 	}
 }
+
+func Test_genS3SourceConfig(t *testing.T) {
+	interval := "500ms"
+	forcePathStyle := false
+	secret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "minio"}, Key: "key"}
+
+	tests := []struct {
+		name    string
+		spec    *ofevent.S3Spec
+		want    *event.S3SourceConfig
+		wantErr bool
+	}{
+		{
+			name: "minio",
+			spec: &ofevent.S3Spec{
+				Endpoint:        "http://minio.minio.svc:9000",
+				Bucket:          "images",
+				Suffix:          ".jpg",
+				EventTypes:      []string{ofevent.S3EventTypeObjectCreated},
+				AccessKeyID:     secret,
+				SecretAccessKey: secret,
+			},
+			want: &event.S3SourceConfig{
+				Endpoint:        "minio.minio.svc:9000",
+				Bucket:          "images",
+				Suffix:          ".jpg",
+				EventTypes:      []string{ofevent.S3EventTypeObjectCreated},
+				ForcePathStyle:  true,
+				PollingInterval: defaultS3PollingInterval,
+				CursorConfigMap: "test",
+			},
+		},
+		{
+			name: "s3",
+			spec: &ofevent.S3Spec{
+				Endpoint:       "https://s3.us-east-1.amazonaws.com",
+				Region:         "us-east-1",
+				Bucket:         "images",
+				ForcePathStyle: &forcePathStyle,
+			},
+			want: &event.S3SourceConfig{
+				Endpoint:        "s3.us-east-1.amazonaws.com",
+				Secure:          true,
+				Region:          "us-east-1",
+				Bucket:          "images",
+				EventTypes:      s3EventTypes,
+				PollingInterval: defaultS3PollingInterval,
+				CursorConfigMap: "test",
+			},
+		},
+		{
+			name:    "endpoint without scheme",
+			spec:    &ofevent.S3Spec{Endpoint: "minio:9000", Bucket: "images"},
+			wantErr: true,
+		},
+		{
+			name:    "missing bucket",
+			spec:    &ofevent.S3Spec{Endpoint: "http://minio:9000"},
+			wantErr: true,
+		},
+		{
+			name:    "partial credentials",
+			spec:    &ofevent.S3Spec{Endpoint: "http://minio:9000", Bucket: "images", AccessKeyID: secret},
+			wantErr: true,
+		},
+		{
+			name:    "polling interval too short",
+			spec:    &ofevent.S3Spec{Endpoint: "http://minio:9000", Bucket: "images", PollingInterval: &interval},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported event type",
+			spec:    &ofevent.S3Spec{Endpoint: "http://minio:9000", Bucket: "images", EventTypes: []string{"ObjectAccessed"}},
+			wantErr: true,
+		},
+		{
+			name:    "removals",
+			spec:    &ofevent.S3Spec{Endpoint: "http://minio:9000", Bucket: "images", EventTypes: []string{"ObjectRemoved"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := genS3SourceConfig("test", tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("genS3SourceConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("genS3SourceConfig() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createSelectorSinkComponents(t *testing.T) {
+	internal := ofcore.InternalAddressType
+	newFunction := func(name string, labels map[string]string, address string) *ofcore.Function {
+		fn := &ofcore.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				Labels:    labels,
+			},
+		}
+		if address != "" {
+			fn.Status.Addresses = []ofcore.FunctionAddress{{Type: &internal, Value: address}}
+		}
+		return fn
+	}
+
+	scheme := runtime.NewScheme()
+	if err := ofcore.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(
+		newFunction("b", map[string]string{"app": "orders"}, "http://b.test.svc.cluster.local"),
+		newFunction("a", map[string]string{"app": "orders"}, "http://a.test.svc.cluster.local"),
+		newFunction("pending", map[string]string{"app": "orders"}, ""),
+		newFunction("other", map[string]string{"app": "payments"}, "http://other.test.svc.cluster.local"),
+	).Build()
+
+	resource := &ofevent.Trigger{ObjectMeta: metav1.ObjectMeta{Name: "trigger", Namespace: "test"}}
+	sink := &ofevent.SinkSpec{
+		Selector: &ofevent.SinkSelector{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders"}},
+		},
+	}
+
+	components, err := createSelectorSinkComponents(context.Background(), c, testr.New(t), resource, sink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, component := range components {
+		got = append(got, fmt.Sprintf("%s=%s", component.Name, component.Spec.Metadata[0].Value.String()))
+	}
+	want := []string{
+		"ts-trigger-test-a=http://a.test.svc.cluster.local",
+		"ts-trigger-test-b=http://b.test.svc.cluster.local",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("createSelectorSinkComponents() got = %v, want %v", got, want)
+	}
+}
+
+func Test_selectorSinkFunctionPredicate(t *testing.T) {
+	internal := ofcore.InternalAddressType
+	newFunction := func(labels map[string]string, generation int64, address string) *ofcore.Function {
+		fn := &ofcore.Function{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "fn",
+				Namespace:  "test",
+				Labels:     labels,
+				Generation: generation,
+			},
+		}
+		if address != "" {
+			fn.Status.Addresses = []ofcore.FunctionAddress{{Type: &internal, Value: address}}
+		}
+		return fn
+	}
+	orders := map[string]string{"app": "orders"}
+	trigger := map[string]string{TriggerControlledLabel: "trigger"}
+
+	tests := []struct {
+		name   string
+		oldObj *ofcore.Function
+		newObj *ofcore.Function
+		want   bool
+	}{
+		{
+			name:   "status only",
+			oldObj: newFunction(orders, 1, "http://fn.test.svc.cluster.local"),
+			newObj: newFunction(orders, 1, "http://fn.test.svc.cluster.local"),
+			want:   false,
+		},
+		{
+			name:   "labels",
+			oldObj: newFunction(orders, 1, ""),
+			newObj: newFunction(map[string]string{"app": "payments"}, 1, ""),
+			want:   true,
+		},
+		{
+			name:   "generation",
+			oldObj: newFunction(orders, 1, ""),
+			newObj: newFunction(orders, 2, ""),
+			want:   true,
+		},
+		{
+			name:   "address",
+			oldObj: newFunction(orders, 1, ""),
+			newObj: newFunction(orders, 1, "http://fn.test.svc.cluster.local"),
+			want:   true,
+		},
+		{
+			name:   "trigger function",
+			oldObj: newFunction(trigger, 1, ""),
+			newObj: newFunction(trigger, 2, ""),
+			want:   false,
+		},
+	}
+	p := selectorSinkFunctionPredicate()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Update(ctrlevent.UpdateEvent{ObjectOld: tt.oldObj, ObjectNew: tt.newObj}); got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !p.Create(ctrlevent.CreateEvent{Object: newFunction(orders, 1, "")}) {
+		t.Error("Create() = false, want true")
+	}
+	if !p.Delete(ctrlevent.DeleteEvent{Object: newFunction(orders, 1, "")}) {
+		t.Error("Delete() = false, want true")
+	}
+	if p.Create(ctrlevent.CreateEvent{Object: newFunction(trigger, 1, "")}) {
+		t.Error("Create() of a trigger function = true, want false")
+	}
+}
+
+func Test_getSelectorSinkNamespaces(t *testing.T) {
+	selector := func(namespace string) *ofevent.SinkSpec {
+		return &ofevent.SinkSpec{Selector: &ofevent.SinkSelector{
+			Namespace:     namespace,
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders"}},
+		}}
+	}
+	uri := "http://sink"
+	trigger := &ofevent.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger", Namespace: "test"},
+		Spec: ofevent.TriggerSpec{
+			Subscribers: []*ofevent.Subscriber{
+				{Condition: "A", Sink: selector("orders")},
+				{Condition: "B", Sink: selector("")},
+				{Condition: "C", Sink: selector("orders")},
+				{Condition: "D", Sink: &ofevent.SinkSpec{Uri: &uri}},
+				nil,
+			},
+		},
+	}
+	if got, want := getSelectorSinkNamespaces(trigger), []string{"orders", "test"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getSelectorSinkNamespaces() = %v, want %v", got, want)
+	}
+}
+
+func Test_setDelivery(t *testing.T) {
+	maxRetries := int32(3)
+	tests := []struct {
+		name     string
+		delivery *ofevent.DeliverySpec
+		want     *ofcore.ResiliencyPolicy
+	}{
+		{
+			name: "no delivery",
+		},
+		{
+			name:     "timeout",
+			delivery: &ofevent.DeliverySpec{Timeout: "10s"},
+			want:     &ofcore.ResiliencyPolicy{Timeout: "10s"},
+		},
+		{
+			// The retries are performed by the trigger handler.
+			name:     "retries",
+			delivery: &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "constant", BackoffDelay: "1s"},
+		},
+		{
+			name:     "retries and timeout",
+			delivery: &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "linear", MaxBackoffDelay: "30s", Timeout: "5s"},
+			want:     &ofcore.ResiliencyPolicy{Timeout: "5s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function := InitFunction("sink")
+			function.Spec.Serving.Outputs = append(function.Spec.Serving.Outputs, &ofcore.Output{
+				Dapr: &ofcore.DaprOutput{DaprComponentRef: &ofcore.DaprComponentRef{Name: "sink"}},
+			})
+			setDelivery("sink", function, tt.delivery)
+			if got := function.Spec.Serving.Outputs[0].Dapr.Resiliency; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setDelivery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_addSinkForFunction(t *testing.T) {
+	function := InitFunction("handler")
+	component := &componentsv1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger-sample-sink"},
+		Spec:       componentsv1alpha1.ComponentSpec{Type: "bindings.http"},
+	}
+	addSinkForFunction("so-t-sample-1", function, component)
+	addSinkForFunction("so-t-sample-2", function, component)
+
+	// The handlers look up the outputs by name, so each output declares its component under its own name.
+	for _, name := range []string{"so-t-sample-1", "so-t-sample-2"} {
+		if _, ok := function.Spec.Serving.Bindings[name]; !ok {
+			t.Errorf("component of output %s is not declared", name)
+		}
+	}
+	if _, ok := function.Spec.Serving.Bindings[component.Name]; ok {
+		t.Errorf("component %s should not be declared by its own name", component.Name)
+	}
+	for _, output := range function.Spec.Serving.Outputs {
+		if output.Dapr.OutputName != "" || output.Dapr.Type != "bindings.http" || output.Dapr.Operation != "post" {
+			t.Errorf("unexpected output %+v", output.Dapr)
+		}
+	}
+}
+
+func Test_handleSubscriberTopicDelivery(t *testing.T) {
+	r := &TriggerReconciler{
+		Log:           testr.New(t),
+		Function:      InitFunction("handler"),
+		TriggerConfig: &event.TriggerConfig{Subscribers: map[string]*event.Subscriber{}},
+		eventBus:      &componentsv1alpha1.ComponentSpec{Type: "pubsub.natsstreaming", Version: "v1"},
+	}
+	trigger := &ofevent.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger", Namespace: "test"},
+		Spec: ofevent.TriggerSpec{
+			Subscribers: []*ofevent.Subscriber{
+				{Condition: "A", Topic: "orders", Delivery: &ofevent.DeliverySpec{Timeout: "10s"}},
+				{Condition: "B", Topic: "metrics"},
+			},
+		},
+	}
+	if err := r.handleSubscriber(context.Background(), r.Log, trigger); err != nil {
+		t.Fatal(err)
+	}
+
+	// The delivery is applied to the output of the topic of the subscriber only.
+	policies := map[string]*ofcore.ResiliencyPolicy{}
+	for _, output := range r.Function.Spec.Serving.Outputs {
+		policies[output.Dapr.Topic] = output.Dapr.Resiliency
+	}
+	if want := (&ofcore.ResiliencyPolicy{Timeout: "10s"}); !reflect.DeepEqual(policies["orders"], want) {
+		t.Errorf("unexpected resiliency of topic orders %v, want %v", policies["orders"], want)
+	}
+	if policy, ok := policies["metrics"]; !ok || policy != nil {
+		t.Errorf("unexpected resiliency of topic metrics %v", policy)
+	}
+
+	// The handler retries the deliveries of the subscriber with its delivery.
+	if got := r.TriggerConfig.Subscribers["A"].Delivery; !reflect.DeepEqual(got, trigger.Spec.Subscribers[0].Delivery) {
+		t.Errorf("unexpected delivery of subscriber A %v", got)
+	}
+}
+
+func Test_handleStateStore(t *testing.T) {
+	r := &TriggerReconciler{
+		Log:           testr.New(t),
+		Function:      InitFunction("handler"),
+		TriggerConfig: &event.TriggerConfig{},
+	}
+	redis := &componentsv1alpha1.ComponentSpec{Type: "state.redis", Version: "v1"}
+	trigger := &ofevent.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger", Namespace: "test"},
+		Spec: ofevent.TriggerSpec{
+			Inputs:      map[string]*ofevent.Input{"A": {EventSource: "es-a", Event: "event-a"}},
+			Correlation: &ofevent.CorrelationSpec{Key: "event.source", TTL: "5m"},
+			StateStore:  &ofevent.StateStoreSpec{Spec: redis},
+		},
+	}
+	if err := r.handleStateStore(r.Log, trigger); err != nil {
+		t.Fatal(err)
+	}
+
+	if state := r.Function.Spec.Serving.States["tss-trigger"]; state == nil || !reflect.DeepEqual(state.Spec, redis) {
+		t.Errorf("unexpected state of the function %v", r.Function.Spec.Serving.States)
+	}
+	if r.TriggerConfig.StateStore != "tss-trigger" || r.TriggerConfig.Correlation != trigger.Spec.Correlation {
+		t.Errorf("unexpected config of the handler %v", r.TriggerConfig)
+	}
+	// The pending events live in a single replica of the handler.
+	scaleOptions := r.Function.Spec.Serving.ScaleOptions
+	if scaleOptions == nil || *scaleOptions.MinReplicas != 1 || *scaleOptions.MaxReplicas != 1 {
+		t.Errorf("unexpected scale options of the function %v", scaleOptions)
+	}
+}
+
+func Test_handleSubscriberSelectorSink(t *testing.T) {
+	internal := ofcore.InternalAddressType
+	newFunction := func(name string) *ofcore.Function {
+		return &ofcore.Function{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: map[string]string{"app": "orders"}},
+			Status: ofcore.FunctionStatus{
+				Addresses: []ofcore.FunctionAddress{{Type: &internal, Value: "http://" + name + ".test.svc.cluster.local"}},
+			},
+		}
+	}
+
+	scheme := runtime.NewScheme()
+	if err := ofcore.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	r := &TriggerReconciler{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(newFunction("a"), newFunction("b")).Build(),
+		Log:           testr.New(t),
+		Function:      InitFunction("handler"),
+		TriggerConfig: &event.TriggerConfig{Subscribers: map[string]*event.Subscriber{}},
+		eventBus:      &componentsv1alpha1.ComponentSpec{Type: "pubsub.natsstreaming", Version: "v1"},
+	}
+	trigger := &ofevent.Trigger{
+		ObjectMeta: metav1.ObjectMeta{Name: "trigger", Namespace: "test"},
+		Spec: ofevent.TriggerSpec{
+			Subscribers: []*ofevent.Subscriber{
+				{Condition: "A", Topic: "orders", Sink: &ofevent.SinkSpec{Selector: &ofevent.SinkSelector{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "orders"}},
+				}}},
+				{Condition: "B", Topic: "orders"},
+			},
+		},
+	}
+	if err := r.handleSubscriber(context.Background(), r.Log, trigger); err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler fans out to the output of each selected Function.
+	if got, want := r.TriggerConfig.Subscribers["A"].SinkOutputNames, []string{"so-t-trigger-1", "so-t-trigger-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected sink outputs %v, want %v", got, want)
+	}
+	// The subscribers of the same topic share its output.
+	for _, condition := range []string{"A", "B"} {
+		if got := r.TriggerConfig.Subscribers[condition].EventBusOutputName; got != "ebo-orders" {
+			t.Errorf("unexpected topic output of subscriber %s %q", condition, got)
+		}
+	}
+}
+
+func Test_addRabbitMQQueueBindingForFunction(t *testing.T) {
+	newFunction := func() *ofcore.Function {
+		return &ofcore.Function{Spec: ofcore.FunctionSpec{Serving: &ofcore.ServingImpl{}}}
+	}
+	queue := &event.RabbitMQQueueConfig{QueueName: "orders", Exchange: "orders"}
+	secret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "rabbitmq"}, Key: "host"}
+
+	tests := []struct {
+		name     string
+		spec     *ofevent.RabbitMQSpec
+		config   *event.RabbitMQQueueConfig
+		wantHost *corev1.EnvVar
+	}{
+		{
+			name: "no exchange",
+			spec: &ofevent.RabbitMQSpec{Host: "amqp://rabbitmq:5672", QueueName: "orders"},
+		},
+		{
+			name:     "host",
+			spec:     &ofevent.RabbitMQSpec{Host: "amqp://rabbitmq:5672", QueueName: "orders"},
+			config:   queue,
+			wantHost: &corev1.EnvVar{Name: event.RabbitMQHostEnv, Value: "amqp://rabbitmq:5672"},
+		},
+		{
+			name:     "hostSecret",
+			spec:     &ofevent.RabbitMQSpec{HostSecret: secret, QueueName: "orders"},
+			config:   queue,
+			wantHost: &corev1.EnvVar{Name: event.RabbitMQHostEnv, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: secret}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function := newFunction()
+			if err := addRabbitMQQueueBindingForFunction(function, tt.spec, tt.config, "handler:latest", "1"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantHost == nil {
+				if function.Spec.Serving.Template != nil {
+					t.Errorf("expected no init container, got %v", function.Spec.Serving.Template)
+				}
+				return
+			}
+
+			containers := function.Spec.Serving.Template.InitContainers
+			if len(containers) != 1 || containers[0].Name != RabbitMQQueueBindingContainer || containers[0].Image != "handler:latest" {
+				t.Fatalf("unexpected init containers %v", containers)
+			}
+			env := containers[0].Env
+			if len(env) != 2 || !reflect.DeepEqual(env[1], *tt.wantHost) {
+				t.Errorf("expected host %v, got %v", tt.wantHost, env)
+			}
+			config, err := (&event.EventSourceConfig{}).DecodeEnv(env[0].Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config.RabbitMQ, tt.config) || config.LogLevel != "1" {
+				t.Errorf("expected queue config %v, got %v", tt.config, config)
+			}
+		})
+	}
+}
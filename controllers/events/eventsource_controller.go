@@ -20,11 +20,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/go-logr/logr"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,23 +35,31 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
 	"github.com/openfunction/pkg/event/eventbus/natsstreaming"
 	"github.com/openfunction/pkg/event/eventsource/cron"
 	"github.com/openfunction/pkg/event/eventsource/kafka"
 	"github.com/openfunction/pkg/event/eventsource/mqtt"
+	"github.com/openfunction/pkg/event/eventsource/rabbitmq"
 	"github.com/openfunction/pkg/event/eventsource/redis"
 	"github.com/openfunction/pkg/util"
 )
 
 const (
 	eventSourceHandlerImage = "openfunction/eventsource-handler:v4"
+	// standaloneEventSourceHandlerImage is built from cmd/standalone-eventsource-handler, it implements the
+	// Kubernetes and S3 event sources, which watch the objects and poll the bucket by themselves,
+	// and binds the queues of the RabbitMQ event sources in the init containers of their handlers.
+	standaloneEventSourceHandlerImage = "openfunction/standalone-eventsource-handler:v1"
+	standaloneHandlerImageKey         = "openfunction.eventsource-handler.standalone-image"
 )
 
 // EventSourceReconciler reconciles a EventSource object
@@ -57,7 +67,7 @@ type EventSourceReconciler struct {
 	client.Client
 	Log               logr.Logger
 	Scheme            *runtime.Scheme
-	EventSourceConfig *EventSourceConfig
+	EventSourceConfig *event.EventSourceConfig
 	Function          *ofcore.Function
 	defaultConfig     map[string]string
 	newSinkUri        string
@@ -76,6 +86,10 @@ type EventSourceReconciler struct {
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions/finalizers,verbs=update
 //+kubebuilder:rbac:groups=dapr.io,resources=components;subscriptions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keda.sh,resources=triggerauthentications,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts;configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -91,7 +105,7 @@ func (r *EventSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	log.Info("EventSource reconcile starting...")
 
 	eventSource := &ofevent.EventSource{}
-	r.EventSourceConfig = &EventSourceConfig{}
+	r.EventSourceConfig = &event.EventSourceConfig{}
 	r.EventSourceConfig.LogLevel = DefaultLogLevel
 
 	// Get default global configuration from ConfigMap
@@ -102,6 +116,23 @@ func (r *EventSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, util.IgnoreNotFound(err)
 	}
 
+	if !eventSource.DeletionTimestamp.IsZero() {
+		if err := r.finalizeEventSource(ctx, log, eventSource); err != nil {
+			log.Error(err, "Failed to finalize eventsource",
+				"namespace", eventSource.Namespace, "name", eventSource.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// The finalizer is added before the handlers of Kubernetes event sources are bound outside the namespace.
+	if len(eventSource.Spec.Kubernetes) > 0 && !controllerutil.ContainsFinalizer(eventSource, KubernetesSourceFinalizer) {
+		controllerutil.AddFinalizer(eventSource, KubernetesSourceFinalizer)
+		if err := r.Update(ctx, eventSource); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if err := r.createOrUpdateEventSource(ctx, log, eventSource); err != nil {
 		log.Error(err, "Failed to create or update eventsource",
 			"namespace", eventSource.Namespace, "name", eventSource.Name)
@@ -170,7 +201,13 @@ func (r *EventSourceReconciler) createOrUpdateEventSource(ctx context.Context, l
 	}
 
 	// Handle EventSource reconcile.
-	if err := r.handleEventSource(ctx, log, eventSource); err != nil {
+	events, err := r.handleEventSource(ctx, log, eventSource)
+	eventSource.Status.Events = events
+	if err != nil {
+		condition := ofevent.CreateCondition(
+			ofevent.Error, metav1.ConditionFalse, ofevent.EventsNotReady,
+		).SetMessage(err.Error())
+		eventSource.AddCondition(*condition)
 		return err
 	}
 
@@ -183,11 +220,9 @@ func (r *EventSourceReconciler) createOrUpdateEventSource(ctx context.Context, l
 			"namespace", eventSource.Namespace, "name", eventSource.Name)
 		return err
 	}
-	condition := ofevent.CreateCondition(
-		ofevent.Ready, metav1.ConditionTrue, ofevent.EventSourceIsReady,
-	).SetMessage("EventSource is ready.")
-	eventSource.AddCondition(*condition)
-	eventSource.SaveStatus(ctx, log, r.Client)
+	// The EventSource has been refreshed from the cluster, set the status of events again before it is saved on return.
+	eventSource.Status.Events = events
+	eventSource.AddCondition(*getEventSourceReadyCondition(events))
 	log.Info("EventSource reconcile success.",
 		"namespace", eventSource.Namespace, "name", eventSource.Name)
 	return nil
@@ -239,7 +274,7 @@ func (r *EventSourceReconciler) handleEventBus(ctx context.Context, log logr.Log
 			return err
 		}
 		// Add the component spec to function.
-		r.Function.Spec.Serving.Pubsub[componentName] = &component.Spec
+		addComponentForFunction(r.EventSourceConfig.EventBusOutputName, r.Function, &component.Spec)
 		return nil
 	}
 	err := errors.New("no specification found for eventBus")
@@ -276,8 +311,41 @@ func (r *EventSourceReconciler) handleSink(ctx context.Context, log logr.Logger,
 	return nil
 }
 
-func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.Logger, eventSource *ofevent.EventSource) error {
+func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.Logger, eventSource *ofevent.EventSource) ([]ofevent.EventStatus, error) {
 	var functions []*ofcore.Function
+	var events []ofevent.EventStatus
+	var errs []string
+
+	// A failing event is recorded in its own status, so that it does not prevent the other events from being handled.
+	failed := func(kind string, eventName string, componentName string, err error) {
+		log.Error(err, "Failed to generate eventSource component.",
+			"namespace", eventSource.Namespace, "name", eventSource.Name, "kind", kind, "event", eventName)
+		events = append(events, ofevent.EventStatus{
+			Name:      eventName,
+			Kind:      kind,
+			Ready:     metav1.ConditionFalse,
+			Reason:    ofevent.ErrorGenerateComponent,
+			Message:   err.Error(),
+			Component: componentName,
+		})
+		errs = append(errs, fmt.Sprintf("%s/%s: %s", kind, eventName, err.Error()))
+	}
+
+	added := func(kind string, eventName string, componentName string, function *ofcore.Function) {
+		functions = append(functions, function)
+		events = append(events, ofevent.EventStatus{
+			Name:      eventName,
+			Kind:      kind,
+			Ready:     metav1.ConditionUnknown,
+			Reason:    ofevent.FunctionNotReady,
+			Component: componentName,
+			Function:  function.Name,
+		})
+	}
+
+	// The keys of the resources generated for the events, the others generated before are cleaned.
+	keep := map[string]bool{}
+
 	// Generate dapr components, keda scaleOptions and triggers based on the specification of EventSource.
 	if eventSource.Spec.Kafka != nil {
 		for eventName, spec := range eventSource.Spec.Kafka {
@@ -291,21 +359,14 @@ func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.
 			// Generate Dapr component for Kafka EventSource.
 			component, err := es.GenComponent(eventSource.Namespace, componentName)
 			if err != nil {
-				condition := ofevent.CreateCondition(
-					ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
-				).SetMessage(err.Error())
-				eventSource.AddCondition(*condition)
-				log.Error(err, "Failed to generate eventSource component for Kafka.",
-					"namespace", eventSource.Namespace,
-					"name", eventSource.Name,
-				)
-				return err
+				failed(SourceKindKafka, eventName, componentName, err)
+				continue
 			}
 
 			// Generate Keda scaledObject and scaleTriggers for Kafka EventSource.
-			scaledObject, trigger := es.GenScaleOptions()
-			function := r.addEventSourceForFunction(eventSource, SourceKindKafka, eventName, component, scaledObject, trigger)
-			functions = append(functions, function)
+			scaleOptions, trigger := es.GenScaleOptions()
+			function := r.addEventSourceForFunction(eventSource, SourceKindKafka, eventName, component, scaleOptions, trigger)
+			added(SourceKindKafka, eventName, component.Name, function)
 		}
 	}
 
@@ -318,17 +379,12 @@ func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.
 			// Generate Dapr component for Cron EventSource.
 			component, err := es.GenComponent(eventSource.Namespace, componentName)
 			if err != nil {
-				condition := ofevent.CreateCondition(
-					ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
-				).SetMessage(err.Error())
-				eventSource.AddCondition(*condition)
-				log.Error(err, "Failed to generate eventSource component for Cron.",
-					"namespace", eventSource.Namespace, "name", eventSource.Name)
-				return err
+				failed(SourceKindCron, eventName, componentName, err)
+				continue
 			}
 
 			function := r.addEventSourceForFunction(eventSource, SourceKindCron, eventName, component, nil, nil)
-			functions = append(functions, function)
+			added(SourceKindCron, eventName, component.Name, function)
 		}
 	}
 
@@ -344,17 +400,12 @@ func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.
 			// Generate Dapr component for MQTT EventSource.
 			component, err := es.GenComponent(eventSource.Namespace, componentName)
 			if err != nil {
-				condition := ofevent.CreateCondition(
-					ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
-				).SetMessage(err.Error())
-				eventSource.AddCondition(*condition)
-				log.Error(err, "Failed to generate eventSource component for MQTT.",
-					"namespace", eventSource.Namespace, "name", eventSource.Name)
-				return err
+				failed(SourceKindMQTT, eventName, componentName, err)
+				continue
 			}
 
 			function := r.addEventSourceForFunction(eventSource, SourceKindMQTT, eventName, component, nil, nil)
-			functions = append(functions, function)
+			added(SourceKindMQTT, eventName, component.Name, function)
 		}
 	}
 
@@ -367,32 +418,175 @@ func (r *EventSourceReconciler) handleEventSource(ctx context.Context, log logr.
 			// Generate Dapr component for Redis EventSource.
 			component, err := es.GenComponent(eventSource.Namespace, componentName)
 			if err != nil {
-				condition := ofevent.CreateCondition(
-					ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
-				).SetMessage(err.Error())
-				eventSource.AddCondition(*condition)
-				log.Error(err, "Failed to generate eventSource component for MQTT.",
-					"namespace", eventSource.Namespace, "name", eventSource.Name)
-				return err
+				failed(SourceKindRedis, eventName, componentName, err)
+				continue
 			}
 
 			function := r.addEventSourceForFunction(eventSource, SourceKindRedis, eventName, component, nil, nil)
-			functions = append(functions, function)
+			added(SourceKindRedis, eventName, component.Name, function)
 		}
 	}
 
+	// The standalone handler image also binds the queues of the RabbitMQ event sources.
+	standaloneImage := util.GetConfigOrDefault(r.defaultConfig, standaloneHandlerImageKey, standaloneEventSourceHandlerImage)
+
+	if eventSource.Spec.RabbitMQ != nil {
+		for eventName, spec := range eventSource.Spec.RabbitMQ {
+			esSpec := spec
+			es := rabbitmq.NewRabbitMQEventSource(log, esSpec)
+			componentName := fmt.Sprintf(EventSourceComponentNameTmpl, SourceKindRabbitMQ, eventName)
+
+			// Generate Dapr component for RabbitMQ EventSource.
+			component, err := es.GenComponent(eventSource.Namespace, componentName)
+			if err != nil {
+				failed(SourceKindRabbitMQ, eventName, componentName, err)
+				continue
+			}
+
+			// Generate Keda scaledObject and scaleTriggers for RabbitMQ EventSource.
+			scaleOptions, trigger := es.GenScaleOptions()
+			if auth := es.GenTriggerAuthentication(eventSource.Namespace, componentName); auth != nil {
+				if err := r.createOrUpdateTriggerAuthentication(ctx, eventSource, auth, keep); err != nil {
+					failed(SourceKindRabbitMQ, eventName, componentName, err)
+					continue
+				}
+				trigger.AuthenticationRef = &kedav1alpha1.ScaledObjectAuthRef{Name: auth.Name}
+			}
+			function := r.addEventSourceForFunction(eventSource, SourceKindRabbitMQ, eventName, component, scaleOptions, trigger)
+			if err := addRabbitMQQueueBindingForFunction(function, esSpec, es.GenQueueConfig(), standaloneImage, r.EventSourceConfig.LogLevel); err != nil {
+				failed(SourceKindRabbitMQ, eventName, componentName, err)
+				continue
+			}
+			added(SourceKindRabbitMQ, eventName, component.Name, function)
+		}
+	}
+
+	// The Kubernetes and S3 event sources watch the sources by themselves, so no Dapr component is generated for them.
+	kubernetesConfigs := map[string]*event.KubernetesSourceConfig{}
+	if eventSource.Spec.Kubernetes != nil {
+		clusterRoles := &rbacv1.ClusterRoleList{}
+		if err := r.List(ctx, clusterRoles, client.HasLabels{ofevent.KubernetesSourceClusterRoleLabel}); err != nil {
+			return nil, err
+		}
+
+		for eventName, spec := range eventSource.Spec.Kubernetes {
+			config, err := genKubernetesSourceConfig(r.RESTMapper(), eventSource.Namespace, spec)
+			if err != nil {
+				failed(SourceKindKubernetes, eventName, "", err)
+				continue
+			}
+
+			clusterRole, err := findKubernetesSourceClusterRole(clusterRoles.Items, config, eventSource.Namespace)
+			if err != nil {
+				failed(SourceKindKubernetes, eventName, "", err)
+				continue
+			}
+
+			function := r.addStandaloneSourceForFunction(eventSource, SourceKindKubernetes, eventName, standaloneImage)
+			roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole}
+			if err := r.createOrUpdateHandlerBinding(ctx, eventSource, SourceKindKubernetes, function.Name, config.Namespace, roleRef, keep); err != nil {
+				failed(SourceKindKubernetes, eventName, "", err)
+				continue
+			}
+
+			kubernetesConfigs[function.Name] = config
+			added(SourceKindKubernetes, eventName, "", function)
+		}
+	}
+
+	s3Configs := map[string]*event.S3SourceConfig{}
+	if eventSource.Spec.S3 != nil {
+		for eventName, spec := range eventSource.Spec.S3 {
+			function := r.addStandaloneSourceForFunction(eventSource, SourceKindS3, eventName, standaloneImage)
+			config, err := genS3SourceConfig(function.Name, spec)
+			if err != nil {
+				failed(SourceKindS3, eventName, "", err)
+				continue
+			}
+
+			if err := r.createS3SourceCursor(ctx, eventSource, config, keep); err != nil {
+				failed(SourceKindS3, eventName, "", err)
+				continue
+			}
+			if err := r.createOrUpdateHandlerRole(ctx, eventSource, SourceKindS3, function.Name, s3SourceRules(config), keep); err != nil {
+				failed(SourceKindS3, eventName, "", err)
+				continue
+			}
+
+			addS3CredentialsForFunction(function, spec)
+			s3Configs[function.Name] = config
+			added(SourceKindS3, eventName, "", function)
+		}
+	}
+
+	errs = append(errs, r.cleanHandlerResources(ctx, log, eventSource, keep)...)
+
 	for _, f := range functions {
 		l := f.GetLabels()
 		r.EventSourceConfig.EventBusTopic = l[EventBusTopicName]
+		r.EventSourceConfig.Kubernetes = kubernetesConfigs[f.Name]
+		r.EventSourceConfig.S3 = s3Configs[f.Name]
 
+		status := getEventStatusByFunction(events, f.Name)
 		// Create the workload for EventSource.
 		if err := r.createOrUpdateEventSourceFunction(ctx, log, eventSource, f); err != nil {
-			return err
+			status.Ready = metav1.ConditionFalse
+			status.Reason = ofevent.ErrorCreatingEventSourceFunction
+			status.Message = err.Error()
+			errs = append(errs, fmt.Sprintf("%s/%s: %s", status.Kind, status.Name, err.Error()))
+			continue
+		}
+
+		status.Ready, status.Message = getFunctionReadiness(f)
+		if status.Ready == metav1.ConditionTrue {
+			status.Reason = ofevent.EventIsReady
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Kind != events[j].Kind {
+			return events[i].Kind < events[j].Kind
+		}
+		return events[i].Name < events[j].Name
+	})
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return events, fmt.Errorf("failed to handle events: %s", strings.Join(errs, "; "))
+	}
+	return events, nil
+}
+
+func getEventStatusByFunction(events []ofevent.EventStatus, function string) *ofevent.EventStatus {
+	for i := range events {
+		if events[i].Function == function {
+			return &events[i]
 		}
 	}
 	return nil
 }
 
+// getEventSourceReadyCondition returns the Ready condition of the EventSource,
+// which is ready only if all of its events are ready.
+func getEventSourceReadyCondition(events []ofevent.EventStatus) *ofevent.Condition {
+	var names []string
+	var statuses []metav1.ConditionStatus
+	for _, event := range events {
+		names = append(names, fmt.Sprintf("%s/%s", event.Kind, event.Name))
+		statuses = append(statuses, event.Ready)
+	}
+
+	status, notReady := aggregateReadiness(names, statuses)
+	if status == metav1.ConditionTrue {
+		return ofevent.CreateCondition(
+			ofevent.Ready, metav1.ConditionTrue, ofevent.EventSourceIsReady,
+		).SetMessage("EventSource is ready.")
+	}
+	return ofevent.CreateCondition(
+		ofevent.Ready, status, ofevent.EventsNotReady,
+	).SetMessage(fmt.Sprintf("Events are not ready: %s", notReady))
+}
+
 func (r *EventSourceReconciler) createOrUpdateEventSourceFunction(ctx context.Context, log logr.Logger, eventSource *ofevent.EventSource, function *ofcore.Function) error {
 	log = r.Log.WithName("createOrUpdateEventSourceFunction")
 
@@ -454,6 +648,10 @@ func (r *EventSourceReconciler) mutateEventSource(eventSource *ofevent.EventSour
 		if eventSource.Spec.Sink != nil {
 			eventSource.Spec.Sink.Uri = &uri
 		}
+		// The bindings of the removed Kubernetes event sources have been cleaned.
+		if len(eventSource.Spec.Kubernetes) == 0 {
+			controllerutil.RemoveFinalizer(eventSource, KubernetesSourceFinalizer)
+		}
 		return nil
 	}
 }
@@ -463,7 +661,7 @@ func (r *EventSourceReconciler) addEventSourceForFunction(
 	sourceKind string,
 	eventName string,
 	component *componentsv1alpha1.Component,
-	scaledObject *ofcore.KedaScaledObject,
+	scaleOptions *ofcore.ScaleOptions,
 	trigger *kedav1alpha1.ScaleTriggers,
 ) *ofcore.Function {
 	function := r.Function.DeepCopy()
@@ -471,50 +669,34 @@ func (r *EventSourceReconciler) addEventSourceForFunction(
 	function.Namespace = eventSource.Namespace
 
 	// add eventsource input
-	eventSourceInput := &ofcore.DaprIO{
-		Name:      fmt.Sprintf(EventSourceInputNameTmpl, eventName),
-		Component: component.Name,
-	}
-	function.Spec.Serving.Inputs = append(function.Spec.Serving.Inputs, eventSourceInput)
+	inputName := fmt.Sprintf(EventSourceInputNameTmpl, eventName)
+	eventSourceInput := newDaprTrigger(inputName, component.Spec.Type, "")
+	function.Spec.Serving.Triggers.Dapr = append(function.Spec.Serving.Triggers.Dapr, eventSourceInput)
 
-	// add eventbus output
-	if r.EventSourceConfig.EventBusComponent != "" {
-		eventBusOutput := &ofcore.DaprIO{
-			Name:      fmt.Sprintf(EventBusOutputNameTmpl, eventSource.Name),
-			Component: r.EventSourceConfig.EventBusComponent,
-			Topic:     fmt.Sprintf(EventBusTopicNameTmpl, eventSource.Namespace, eventSource.Name, eventName),
-		}
-		function.Spec.Serving.Outputs = append(function.Spec.Serving.Outputs, eventBusOutput)
-	}
+	r.addEventBusOutputForFunction(function, eventSource, eventName)
 
 	// add eventsource component
-	if strings.Split(component.Spec.Type, ".")[0] == ofcore.DaprBindings {
-		function.Spec.Serving.Bindings[component.Name] = &component.Spec
-	} else {
-		function.Spec.Serving.Pubsub[component.Name] = &component.Spec
-	}
-
-	// add eventsource scaleOption and trigger
-	if scaledObject != nil && trigger != nil {
-		if function.Spec.Serving.ScaleOptions == nil {
-			function.Spec.Serving.ScaleOptions = &ofcore.ScaleOptions{}
-		}
+	addComponentForFunction(inputName, function, &component.Spec)
 
-		if function.Spec.Serving.ScaleOptions.Keda == nil {
-			function.Spec.Serving.ScaleOptions.Keda = &ofcore.KedaScaleOptions{}
-		}
-
-		if function.Spec.Serving.ScaleOptions.Keda.ScaledObject == nil {
-			function.Spec.Serving.ScaleOptions.Keda.ScaledObject = scaledObject
-		}
-
-		if function.Spec.Serving.Triggers == nil {
-			function.Spec.Serving.Triggers = []ofcore.Triggers{}
-		}
+	// add eventsource scaleOptions and trigger
+	if scaleOptions != nil && trigger != nil {
+		function.Spec.Serving.ScaleOptions = scaleOptions
+		function.Spec.Serving.ScaleOptions.Keda.Triggers = append(function.Spec.Serving.ScaleOptions.Keda.Triggers, *trigger)
+	}
+	return function
+}
 
-		function.Spec.Serving.Triggers = append(function.Spec.Serving.Triggers, ofcore.Triggers{
-			ScaleTriggers: *trigger,
-		})
+// addEventBusOutputForFunction adds the eventbus output and the topic label to the function of an event.
+func (r *EventSourceReconciler) addEventBusOutputForFunction(function *ofcore.Function, eventSource *ofevent.EventSource, eventName string) {
+	// add eventbus output
+	if r.EventSourceConfig.EventBusComponent != "" {
+		eventBusOutput := newOutput(
+			r.EventSourceConfig.EventBusOutputName,
+			"",
+			fmt.Sprintf(EventBusTopicNameTmpl, eventSource.Namespace, eventSource.Name, eventName),
+			"",
+		)
+		function.Spec.Serving.Outputs = append(function.Spec.Serving.Outputs, eventBusOutput)
 	}
 
 	if eventSource.Spec.EventBus != "" {
@@ -522,13 +704,13 @@ func (r *EventSourceReconciler) addEventSourceForFunction(
 			EventBusTopicName: fmt.Sprintf(EventBusTopicNameTmpl, eventSource.Namespace, eventSource.Name, eventName),
 		})
 	}
-	return function
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *EventSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ofevent.EventSource{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&ofcore.Function{}).
 		Watches(&source.Kind{Type: &ofevent.EventBus{}}, handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
 			eventSourceList := &ofevent.EventSourceList{}
 			c := mgr.GetClient()
@@ -582,5 +764,48 @@ func (r *EventSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}
 			return reconcileRequests
 		})).
+		// The handlers of Kubernetes event sources are bound to the ClusterRoles provisioned for them.
+		Watches(&source.Kind{Type: &rbacv1.ClusterRole{}}, handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
+			eventSourceList := &ofevent.EventSourceList{}
+			if err := mgr.GetClient().List(context.TODO(), eventSourceList); err != nil {
+				return []reconcile.Request{}
+			}
+
+			var reconcileRequests []reconcile.Request
+			for _, eventSource := range eventSourceList.Items {
+				if len(eventSource.Spec.Kubernetes) > 0 {
+					reconcileRequests = append(reconcileRequests, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Namespace: eventSource.Namespace,
+							Name:      eventSource.Name,
+						},
+					})
+				}
+			}
+			return reconcileRequests
+		}), builder.WithPredicates(kubernetesSourceClusterRolePredicate())).
 		Complete(r)
 }
+
+// kubernetesSourceClusterRolePredicate filters the events of the ClusterRoles provisioned for Kubernetes event sources,
+// including the ones which the label is removed from.
+func kubernetesSourceClusterRolePredicate() predicate.Predicate {
+	labelled := func(object client.Object) bool {
+		_, ok := object.GetLabels()[ofevent.KubernetesSourceClusterRoleLabel]
+		return ok
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e ctrlevent.CreateEvent) bool {
+			return labelled(e.Object)
+		},
+		UpdateFunc: func(e ctrlevent.UpdateEvent) bool {
+			return labelled(e.ObjectOld) || labelled(e.ObjectNew)
+		},
+		DeleteFunc: func(e ctrlevent.DeleteEvent) bool {
+			return labelled(e.Object)
+		},
+		GenericFunc: func(e ctrlevent.GenericEvent) bool {
+			return labelled(e.Object)
+		},
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	// EventSourceNamespaceLabel records the namespace of the EventSource on the resources generated for it.
+	EventSourceNamespaceLabel = "eventsource-namespace"
+	// EventSourceKindLabel records the source kind of the event which the resource is generated for.
+	EventSourceKindLabel = "eventsource-kind"
+	// KubernetesSourceFinalizer is added to the EventSources with Kubernetes event sources, whose handlers can be granted
+	// access outside the namespace of the EventSource.
+	KubernetesSourceFinalizer = "events.openfunction.io/kubernetes-source"
+)
+
+// addStandaloneSourceForFunction generates the function of an event source which has no Dapr input binding,
+// such as the Kubernetes and S3 event sources.
+// The handler watches or polls the source by itself instead of being triggered by a Dapr component,
+// so it runs as an async function without inputs and with exactly one replica, which keeps the handler running
+// and avoids duplicated events. It has no http trigger, so that it is not exposed through a route.
+// The handler runs the image which implements the event source with a ServiceAccount named after the function.
+func (r *EventSourceReconciler) addStandaloneSourceForFunction(eventSource *ofevent.EventSource, sourceKind string, eventName string, image string) *ofcore.Function {
+	function := r.Function.DeepCopy()
+	function.Name = fmt.Sprintf(EventSourceWorkloadsNameTmpl, eventSource.Name, sourceKind, eventName)
+	function.Namespace = eventSource.Namespace
+	function.Spec.Image = image
+
+	replicas := int32(1)
+	function.Spec.Serving.Triggers.Http = nil
+	function.Spec.Serving.ScaleOptions = &ofcore.ScaleOptions{
+		MinReplicas: &replicas,
+		MaxReplicas: &replicas,
+	}
+	function.Spec.Serving.WorkloadType = ""
+	function.Spec.Serving.Template = &corev1.PodSpec{ServiceAccountName: function.Name}
+
+	r.addEventBusOutputForFunction(function, eventSource, eventName)
+	return function
+}
+
+// handlerLabels returns the labels of the resources generated for the events of the EventSource,
+// the source kind is recorded if it is not empty.
+func handlerLabels(eventSource *ofevent.EventSource, sourceKind string) map[string]string {
+	labels := map[string]string{
+		"openfunction.io/managed":  "true",
+		EventSourceControlledLabel: eventSource.Name,
+		EventSourceNamespaceLabel:  eventSource.Namespace,
+	}
+	if sourceKind != "" {
+		labels[EventSourceKindLabel] = sourceKind
+	}
+	return labels
+}
+
+// createOrUpdateHandlerServiceAccount creates the ServiceAccount of a standalone handler which is owned by the EventSource,
+// its key is added to keep.
+func (r *EventSourceReconciler) createOrUpdateHandlerServiceAccount(
+	ctx context.Context,
+	eventSource *ofevent.EventSource,
+	sourceKind string,
+	name string,
+	keep map[string]bool,
+) (*corev1.ServiceAccount, error) {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: eventSource.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, sa, func() error {
+		sa.SetLabels(handlerLabels(eventSource, sourceKind))
+		return controllerutil.SetControllerReference(eventSource, sa, r.Scheme)
+	}); err != nil {
+		return nil, err
+	}
+	keep[resourceKey("ServiceAccount", sa)] = true
+	return sa, nil
+}
+
+// createOrUpdateHandlerRole grants the rules to a standalone handler by a Role in the namespace of the EventSource,
+// the Role is owned by the EventSource and its key is added to keep.
+func (r *EventSourceReconciler) createOrUpdateHandlerRole(
+	ctx context.Context,
+	eventSource *ofevent.EventSource,
+	sourceKind string,
+	name string,
+	rules []rbacv1.PolicyRule,
+	keep map[string]bool,
+) error {
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: eventSource.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		role.SetLabels(handlerLabels(eventSource, sourceKind))
+		role.Rules = rules
+		return controllerutil.SetControllerReference(eventSource, role, r.Scheme)
+	}); err != nil {
+		return err
+	}
+	keep[resourceKey("Role", role)] = true
+
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name}
+	return r.createOrUpdateHandlerBinding(ctx, eventSource, sourceKind, name, eventSource.Namespace, roleRef, keep)
+}
+
+// createOrUpdateHandlerBinding creates the ServiceAccount of a standalone handler and binds it to the role
+// in the namespace, or cluster wide if the namespace is empty, in which case the role must be a ClusterRole.
+// The RoleBinding in the namespace of the EventSource is owned by it. The bindings in other namespaces and the
+// cluster-scoped ones can not be owned by it, so the namespace of the EventSource is added to their names, and they
+// are cleaned by the finalizer of the EventSource. The keys of the resources are added to keep.
+func (r *EventSourceReconciler) createOrUpdateHandlerBinding(
+	ctx context.Context,
+	eventSource *ofevent.EventSource,
+	sourceKind string,
+	name string,
+	namespace string,
+	roleRef rbacv1.RoleRef,
+	keep map[string]bool,
+) error {
+	labels := handlerLabels(eventSource, sourceKind)
+
+	sa, err := r.createOrUpdateHandlerServiceAccount(ctx, eventSource, sourceKind, name, keep)
+	if err != nil {
+		return err
+	}
+
+	owned := namespace == eventSource.Namespace
+	if !owned {
+		name = fmt.Sprintf("%s-%s", eventSource.Namespace, name)
+	}
+
+	if namespace == "" {
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if err := r.deleteStaleBinding(ctx, binding, roleRef); err != nil {
+			return err
+		}
+		if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+			binding.SetLabels(labels)
+			binding.RoleRef = roleRef
+			binding.Subjects = handlerSubjects(sa)
+			return nil
+		}); err != nil {
+			return err
+		}
+		keep[resourceKey("ClusterRoleBinding", binding)] = true
+		return nil
+	}
+
+	binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	if err := r.deleteStaleBinding(ctx, binding, roleRef); err != nil {
+		return err
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, binding, func() error {
+		binding.SetLabels(labels)
+		binding.RoleRef = roleRef
+		binding.Subjects = handlerSubjects(sa)
+		if !owned {
+			return nil
+		}
+		return controllerutil.SetControllerReference(eventSource, binding, r.Scheme)
+	}); err != nil {
+		return err
+	}
+	keep[resourceKey("RoleBinding", binding)] = true
+	return nil
+}
+
+// deleteStaleBinding deletes the existing binding if it refers to another role, as the role of a binding can not be updated.
+func (r *EventSourceReconciler) deleteStaleBinding(ctx context.Context, binding client.Object, roleRef rbacv1.RoleRef) error {
+	var current client.Object
+	switch binding.(type) {
+	case *rbacv1.ClusterRoleBinding:
+		current = &rbacv1.ClusterRoleBinding{}
+	default:
+		current = &rbacv1.RoleBinding{}
+	}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(binding), current); err != nil {
+		return util.IgnoreNotFound(err)
+	}
+
+	switch b := current.(type) {
+	case *rbacv1.ClusterRoleBinding:
+		if b.RoleRef == roleRef {
+			return nil
+		}
+	case *rbacv1.RoleBinding:
+		if b.RoleRef == roleRef {
+			return nil
+		}
+	}
+	return util.IgnoreNotFound(r.Delete(ctx, current))
+}
+
+func handlerSubjects(sa *corev1.ServiceAccount) []rbacv1.Subject {
+	return []rbacv1.Subject{
+		{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      sa.Name,
+			Namespace: sa.Namespace,
+		},
+	}
+}
+
+// createOrUpdateTriggerAuthentication creates the TriggerAuthentication of an event which is owned by the EventSource,
+// its key is added to keep.
+func (r *EventSourceReconciler) createOrUpdateTriggerAuthentication(
+	ctx context.Context,
+	eventSource *ofevent.EventSource,
+	auth *kedav1alpha1.TriggerAuthentication,
+	keep map[string]bool,
+) error {
+	spec := auth.Spec
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, auth, func() error {
+		auth.SetLabels(handlerLabels(eventSource, SourceKindRabbitMQ))
+		auth.Spec = spec
+		return controllerutil.SetControllerReference(eventSource, auth, r.Scheme)
+	}); err != nil {
+		return err
+	}
+
+	keep[resourceKey("TriggerAuthentication", auth)] = true
+	return nil
+}
+
+// cleanHandlerResources deletes the RBAC resources and the cursors generated for the standalone handlers,
+// and the TriggerAuthentications generated for the events of the EventSource which are not in keep.
+// The errors are reported with the source kind recorded on the resources.
+func (r *EventSourceReconciler) cleanHandlerResources(ctx context.Context, log logr.Logger, eventSource *ofevent.EventSource, keep map[string]bool) []string {
+	selector := client.MatchingLabels(handlerLabels(eventSource, ""))
+	lists := []struct {
+		kind string
+		list client.ObjectList
+		opts []client.ListOption
+	}{
+		{"ServiceAccount", &corev1.ServiceAccountList{}, []client.ListOption{selector, client.InNamespace(eventSource.Namespace)}},
+		{"ConfigMap", &corev1.ConfigMapList{}, []client.ListOption{selector, client.InNamespace(eventSource.Namespace)}},
+		{"Role", &rbacv1.RoleList{}, []client.ListOption{selector, client.InNamespace(eventSource.Namespace)}},
+		// The handlers of Kubernetes event sources can be bound in other namespaces or cluster wide.
+		{"RoleBinding", &rbacv1.RoleBindingList{}, []client.ListOption{selector}},
+		{"ClusterRoleBinding", &rbacv1.ClusterRoleBindingList{}, []client.ListOption{selector}},
+		{"TriggerAuthentication", &kedav1alpha1.TriggerAuthenticationList{}, []client.ListOption{selector, client.InNamespace(eventSource.Namespace)}},
+	}
+
+	var errs []string
+	for _, item := range lists {
+		if err := r.List(ctx, item.list, item.opts...); err != nil {
+			// TriggerAuthentications are not served if KEDA is not installed.
+			if !meta.IsNoMatchError(err) {
+				errs = append(errs, fmt.Sprintf("%s: %s", item.kind, err.Error()))
+			}
+			continue
+		}
+
+		kind := item.kind
+		_ = meta.EachListItem(item.list, func(o runtime.Object) error {
+			obj := o.(client.Object)
+			if keep[resourceKey(kind, obj)] {
+				return nil
+			}
+			if err := r.Delete(ctx, obj); util.IgnoreNotFound(err) != nil {
+				key := resourceKey(kind, obj)
+				if sourceKind := obj.GetLabels()[EventSourceKindLabel]; sourceKind != "" {
+					key = fmt.Sprintf("%s/%s", sourceKind, key)
+				}
+				errs = append(errs, fmt.Sprintf("%s: %s", key, err.Error()))
+				return nil
+			}
+			log.V(1).Info("Delete unused resource of event source handler",
+				"kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+			return nil
+		})
+	}
+
+	return errs
+}
+
+// finalizeEventSource cleans the RBAC resources of the handlers which are not owned by the EventSource,
+// and then removes the finalizer of the EventSource. The other resources generated for it are owned by it
+// and are garbage collected.
+func (r *EventSourceReconciler) finalizeEventSource(ctx context.Context, log logr.Logger, eventSource *ofevent.EventSource) error {
+	if !controllerutil.ContainsFinalizer(eventSource, KubernetesSourceFinalizer) {
+		return nil
+	}
+
+	if errs := r.cleanHandlerResources(ctx, log, eventSource, map[string]bool{}); len(errs) > 0 {
+		return fmt.Errorf("failed to clean handler resources: %s", strings.Join(errs, "; "))
+	}
+
+	controllerutil.RemoveFinalizer(eventSource, KubernetesSourceFinalizer)
+	return r.Update(ctx, eventSource)
+}
+
+func resourceKey(kind string, obj client.Object) string {
+	return fmt.Sprintf("%s/%s/%s", kind, obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+var (
+	kubernetesEventTypes = []string{
+		ofevent.KubernetesEventTypeAdd,
+		ofevent.KubernetesEventTypeUpdate,
+		ofevent.KubernetesEventTypeDelete,
+	}
+	kubernetesSourceVerbs = []string{"get", "list", "watch"}
+)
+
+// genKubernetesSourceConfig resolves the resource of the objects to watch and validates the selectors.
+func genKubernetesSourceConfig(mapper meta.RESTMapper, namespace string, spec *ofevent.KubernetesSpec) (*event.KubernetesSourceConfig, error) {
+	gv, err := schema.ParseGroupVersion(spec.APIVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping, err := mapper.RESTMapping(gv.WithKind(spec.Kind).GroupKind(), gv.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &event.KubernetesSourceConfig{
+		Group:         mapping.Resource.Group,
+		Version:       mapping.Resource.Version,
+		Resource:      mapping.Resource.Resource,
+		Kind:          spec.Kind,
+		FieldSelector: spec.FieldSelector,
+		EventTypes:    kubernetesEventTypes,
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		switch spec.Namespace {
+		case "":
+			config.Namespace = namespace
+		case ofevent.KubernetesAllNamespaces:
+			config.Namespace = ""
+		default:
+			config.Namespace = spec.Namespace
+		}
+	}
+	// The events carry the whole objects, Secrets can not be watched or their data would be published to the event bus.
+	if mapping.Resource.GroupResource() == corev1.SchemeGroupVersion.WithResource("secrets").GroupResource() {
+		return nil, fmt.Errorf("kind %s can not be watched", spec.Kind)
+	}
+
+	if spec.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+		config.LabelSelector = selector.String()
+	}
+
+	if spec.FieldSelector != "" {
+		if _, err := fields.ParseSelector(spec.FieldSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(spec.EventTypes) > 0 {
+		for _, t := range spec.EventTypes {
+			if t != ofevent.KubernetesEventTypeAdd &&
+				t != ofevent.KubernetesEventTypeUpdate &&
+				t != ofevent.KubernetesEventTypeDelete {
+				return nil, fmt.Errorf("unsupported event type %s, must be one of %v", t, kubernetesEventTypes)
+			}
+		}
+		config.EventTypes = spec.EventTypes
+	}
+
+	return config, nil
+}
+
+// findKubernetesSourceClusterRole returns the name of the ClusterRole which allows the handler to watch the resource.
+// It is the first ClusterRole provisioned for the Kubernetes event sources which grants get, list and watch
+// on the resource and can be used in the scope of the watch, so that the cluster administrator controls
+// what the handlers can watch instead of the authors of the EventSources. The handler is bound to the ClusterRole
+// by a RoleBinding in the namespace of the watch, or by a ClusterRoleBinding if it watches all namespaces or
+// a cluster-scoped kind, so the operator is only required to be allowed to bind it.
+// A ClusterRole only allows watching outside the namespace of the EventSource if it has the cluster scope and
+// lists the namespace of the EventSource, otherwise any EventSource could read the objects of other namespaces.
+func findKubernetesSourceClusterRole(roles []rbacv1.ClusterRole, config *event.KubernetesSourceConfig, namespace string) (string, error) {
+	inNamespace := config.Namespace == namespace
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	for _, role := range roles {
+		switch role.Labels[ofevent.KubernetesSourceClusterRoleLabel] {
+		case ofevent.KubernetesSourceScopeNamespace:
+			if !inNamespace {
+				continue
+			}
+		case ofevent.KubernetesSourceScopeCluster:
+			if !inNamespace && !clusterScopeAllowed(role, namespace) {
+				continue
+			}
+		default:
+			continue
+		}
+
+		allowed := true
+		for _, verb := range kubernetesSourceVerbs {
+			if !rulesAllow(role.Rules, config.Group, config.Resource, verb) {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return role.Name, nil
+		}
+	}
+
+	resource := schema.GroupResource{Group: config.Group, Resource: config.Resource}
+	if !inNamespace {
+		return "", fmt.Errorf("no ClusterRole labelled %s=%s whose annotation %s allows namespace %s grants %v on %s",
+			ofevent.KubernetesSourceClusterRoleLabel, ofevent.KubernetesSourceScopeCluster,
+			ofevent.KubernetesSourceNamespacesAnnotation, namespace, kubernetesSourceVerbs, resource)
+	}
+	return "", fmt.Errorf("no ClusterRole labelled %s grants %v on %s",
+		ofevent.KubernetesSourceClusterRoleLabel, kubernetesSourceVerbs, resource)
+}
+
+// clusterScopeAllowed returns true if the EventSources in the namespace are allowed to bind the ClusterRole
+// outside their namespace.
+func clusterScopeAllowed(role rbacv1.ClusterRole, namespace string) bool {
+	for _, ns := range strings.Split(role.Annotations[ofevent.KubernetesSourceNamespacesAnnotation], ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == namespace || ns == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// rulesAllow returns true if the verb on all the objects of the resource is allowed by the rules.
+func rulesAllow(rules []rbacv1.PolicyRule, group string, resource string, verb string) bool {
+	contains := func(items []string, item string) bool {
+		for _, i := range items {
+			if i == item || i == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range rules {
+		if len(rule.ResourceNames) == 0 &&
+			contains(rule.APIGroups, group) &&
+			contains(rule.Resources, resource) &&
+			contains(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,478 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+func Test_genKubernetesSourceConfig(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	tests := []struct {
+		name    string
+		spec    *ofevent.KubernetesSpec
+		want    *event.KubernetesSourceConfig
+		wantErr bool
+	}{
+		{
+			name: "default namespace",
+			spec: &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "ConfigMap"},
+			want: &event.KubernetesSourceConfig{
+				Version:    "v1",
+				Resource:   "configmaps",
+				Kind:       "ConfigMap",
+				Namespace:  "default",
+				EventTypes: kubernetesEventTypes,
+			},
+		},
+		{
+			name: "own namespace",
+			spec: &ofevent.KubernetesSpec{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "default",
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "foo"},
+				},
+				EventTypes: []string{ofevent.KubernetesEventTypeAdd},
+			},
+			want: &event.KubernetesSourceConfig{
+				Group:         "apps",
+				Version:       "v1",
+				Resource:      "deployments",
+				Kind:          "Deployment",
+				Namespace:     "default",
+				LabelSelector: "app=foo",
+				EventTypes:    []string{ofevent.KubernetesEventTypeAdd},
+			},
+		},
+		{
+			name: "all namespaces",
+			spec: &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "ConfigMap", Namespace: "*"},
+			want: &event.KubernetesSourceConfig{
+				Version:    "v1",
+				Resource:   "configmaps",
+				Kind:       "ConfigMap",
+				EventTypes: kubernetesEventTypes,
+			},
+		},
+		{
+			name: "other namespace",
+			spec: &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "ConfigMap", Namespace: "kube-system"},
+			want: &event.KubernetesSourceConfig{
+				Version:    "v1",
+				Resource:   "configmaps",
+				Kind:       "ConfigMap",
+				Namespace:  "kube-system",
+				EventTypes: kubernetesEventTypes,
+			},
+		},
+		{
+			name: "cluster-scoped kind",
+			spec: &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "Namespace", Namespace: "default"},
+			want: &event.KubernetesSourceConfig{
+				Version:    "v1",
+				Resource:   "namespaces",
+				Kind:       "Namespace",
+				EventTypes: kubernetesEventTypes,
+			},
+		},
+		{
+			name:    "secrets",
+			spec:    &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "Secret"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported event type",
+			spec:    &ofevent.KubernetesSpec{APIVersion: "v1", Kind: "ConfigMap", EventTypes: []string{"Patch"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := genKubernetesSourceConfig(mapper, "default", tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("genKubernetesSourceConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("genKubernetesSourceConfig() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createOrUpdateHandlerRole(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := ofevent.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	eventSource := &ofevent.EventSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "es", UID: "uid"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &EventSourceReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+
+	rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}}
+	keep := map[string]bool{}
+	if err := r.createOrUpdateHandlerRole(ctx, eventSource, SourceKindS3, "handler", rules, keep); err != nil {
+		t.Fatal(err)
+	}
+
+	role := &rbacv1.Role{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "handler"}, role); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(role.Rules, rules) {
+		t.Errorf("expected rules %v, got %v", rules, role.Rules)
+	}
+	if !metav1.IsControlledBy(role, eventSource) {
+		t.Errorf("role is not owned by the EventSource")
+	}
+
+	binding := &rbacv1.RoleBinding{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "handler"}, binding); err != nil {
+		t.Fatal(err)
+	}
+	wantSubjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "handler", Namespace: "default"}}
+	if binding.RoleRef.Kind != "Role" || !metav1.IsControlledBy(binding, eventSource) || !reflect.DeepEqual(binding.Subjects, wantSubjects) {
+		t.Errorf("unexpected role binding %v %v", binding.RoleRef, binding.Subjects)
+	}
+
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := c.List(ctx, clusterRoles); err != nil {
+		t.Fatal(err)
+	}
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := c.List(ctx, clusterRoleBindings); err != nil {
+		t.Fatal(err)
+	}
+	if len(clusterRoles.Items) > 0 || len(clusterRoleBindings.Items) > 0 {
+		t.Errorf("expected no cluster-scoped RBAC resources")
+	}
+
+	for _, key := range []string{"ServiceAccount/default/handler", "Role/default/handler", "RoleBinding/default/handler"} {
+		if !keep[key] {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+}
+
+func Test_findKubernetesSourceClusterRole(t *testing.T) {
+	clusterRole := func(name string, scope string, rules ...rbacv1.PolicyRule) rbacv1.ClusterRole {
+		return rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{ofevent.KubernetesSourceClusterRoleLabel: scope}},
+			Rules:      rules,
+		}
+	}
+	allowNamespaces := func(role rbacv1.ClusterRole, namespaces string) rbacv1.ClusterRole {
+		role.Annotations = map[string]string{ofevent.KubernetesSourceNamespacesAnnotation: namespaces}
+		return role
+	}
+	rule := func(group string, resource string, verbs ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{APIGroups: []string{group}, Resources: []string{resource}, Verbs: verbs}
+	}
+	roles := []rbacv1.ClusterRole{
+		clusterRole("pods", ofevent.KubernetesSourceScopeNamespace, rule("", "pods", "get", "list", "watch")),
+		clusterRole("configmaps", ofevent.KubernetesSourceScopeNamespace, rule("", "configmaps", "get", "list"), rule("", "configmaps", "watch")),
+		allowNamespaces(clusterRole("namespaces", ofevent.KubernetesSourceScopeCluster, rule("", "namespaces", "*")), "ops, default"),
+		allowNamespaces(clusterRole("nodes", ofevent.KubernetesSourceScopeCluster, rule("", "nodes", "get", "list", "watch")), "ops"),
+		allowNamespaces(clusterRole("events", ofevent.KubernetesSourceScopeCluster, rule("", "events", "get", "list", "watch")), "*"),
+		clusterRole("endpoints", ofevent.KubernetesSourceScopeCluster, rule("", "endpoints", "get", "list", "watch")),
+		clusterRole("deployments", ofevent.KubernetesSourceScopeNamespace, rule("apps", "deployments", "get", "list")),
+		clusterRole("services", "", rule("", "services", "get", "list", "watch")),
+	}
+
+	tests := []struct {
+		name    string
+		config  *event.KubernetesSourceConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "namespace scope",
+			config: &event.KubernetesSourceConfig{Version: "v1", Resource: "pods", Namespace: "default"},
+			want:   "pods",
+		},
+		{
+			name:   "verbs in several rules",
+			config: &event.KubernetesSourceConfig{Version: "v1", Resource: "configmaps", Namespace: "default"},
+			want:   "configmaps",
+		},
+		{
+			name:   "cluster-scoped kind",
+			config: &event.KubernetesSourceConfig{Version: "v1", Resource: "namespaces"},
+			want:   "namespaces",
+		},
+		{
+			name:    "cluster-scoped kind without the namespace allowed",
+			config:  &event.KubernetesSourceConfig{Version: "v1", Resource: "nodes"},
+			wantErr: true,
+		},
+		{
+			name:   "all namespaces allowed",
+			config: &event.KubernetesSourceConfig{Version: "v1", Resource: "events"},
+			want:   "events",
+		},
+		{
+			name:   "cluster scope in the namespace without the annotation",
+			config: &event.KubernetesSourceConfig{Version: "v1", Resource: "endpoints", Namespace: "default"},
+			want:   "endpoints",
+		},
+		{
+			name:    "cluster scope outside the namespace without the annotation",
+			config:  &event.KubernetesSourceConfig{Version: "v1", Resource: "endpoints", Namespace: "kube-system"},
+			wantErr: true,
+		},
+		{
+			name:    "other namespace without cluster scope",
+			config:  &event.KubernetesSourceConfig{Version: "v1", Resource: "pods", Namespace: "kube-system"},
+			wantErr: true,
+		},
+		{
+			name:    "all namespaces without cluster scope",
+			config:  &event.KubernetesSourceConfig{Version: "v1", Resource: "pods"},
+			wantErr: true,
+		},
+		{
+			name:    "missing verb",
+			config:  &event.KubernetesSourceConfig{Group: "apps", Version: "v1", Resource: "deployments", Namespace: "default"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown scope",
+			config:  &event.KubernetesSourceConfig{Version: "v1", Resource: "services", Namespace: "default"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findKubernetesSourceClusterRole(roles, tt.config, "default")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findKubernetesSourceClusterRole() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("findKubernetesSourceClusterRole() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_createOrUpdateHandlerBinding_outside(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := ofevent.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := kedav1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	eventSource := &ofevent.EventSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "es", UID: "uid"},
+	}
+	// A binding generated before refers to another ClusterRole, which is no longer the first one granting the access.
+	stale := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "default-handler"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "pods"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(stale).Build()
+	r := &EventSourceReconciler{Client: c, Scheme: scheme}
+	ctx := context.Background()
+	wantSubjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "handler", Namespace: "default"}}
+
+	keep := map[string]bool{}
+	pods := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "kubernetes-source-pods"}
+	if err := r.createOrUpdateHandlerBinding(ctx, eventSource, SourceKindKubernetes, "handler", "kube-system", pods, keep); err != nil {
+		t.Fatal(err)
+	}
+	binding := &rbacv1.RoleBinding{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "default-handler"}, binding); err != nil {
+		t.Fatal(err)
+	}
+	if binding.RoleRef != pods || len(binding.OwnerReferences) > 0 || !reflect.DeepEqual(binding.Subjects, wantSubjects) {
+		t.Errorf("unexpected role binding in other namespace %v %v %v", binding.RoleRef, binding.OwnerReferences, binding.Subjects)
+	}
+
+	namespaces := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "kubernetes-source-namespaces"}
+	if err := r.createOrUpdateHandlerBinding(ctx, eventSource, SourceKindKubernetes, "handler", "", namespaces, keep); err != nil {
+		t.Fatal(err)
+	}
+	clusterBinding := &rbacv1.ClusterRoleBinding{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "default-handler"}, clusterBinding); err != nil {
+		t.Fatal(err)
+	}
+	if clusterBinding.RoleRef != namespaces || !reflect.DeepEqual(clusterBinding.Subjects, wantSubjects) {
+		t.Errorf("unexpected cluster role binding %v %v", clusterBinding.RoleRef, clusterBinding.Subjects)
+	}
+
+	roles := &rbacv1.RoleList{}
+	if err := c.List(ctx, roles); err != nil {
+		t.Fatal(err)
+	}
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := c.List(ctx, clusterRoles); err != nil {
+		t.Fatal(err)
+	}
+	if len(roles.Items) > 0 || len(clusterRoles.Items) > 0 {
+		t.Errorf("expected the provisioned ClusterRoles to be bound without generating roles")
+	}
+
+	for _, key := range []string{
+		"ServiceAccount/default/handler",
+		"RoleBinding/kube-system/default-handler",
+		"ClusterRoleBinding//default-handler",
+	} {
+		if !keep[key] {
+			t.Errorf("expected %s to be kept", key)
+		}
+	}
+
+	// The resources outside the namespace are cleaned by the finalizer.
+	if errs := r.cleanHandlerResources(ctx, logr.Discard(), eventSource, map[string]bool{}); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: "default-handler"}, &rbacv1.RoleBinding{}); err == nil {
+		t.Errorf("role binding in other namespace is not cleaned")
+	}
+	if err := c.Get(ctx, client.ObjectKey{Name: "default-handler"}, &rbacv1.ClusterRoleBinding{}); err == nil {
+		t.Errorf("cluster role binding is not cleaned")
+	}
+}
+
+func Test_addStandaloneSourceForFunction(t *testing.T) {
+	r := &EventSourceReconciler{
+		EventSourceConfig: &event.EventSourceConfig{},
+		Function: &ofcore.Function{
+			Spec: ofcore.FunctionSpec{
+				Serving: &ofcore.ServingImpl{
+					Triggers: &ofcore.Triggers{Http: &ofcore.HttpTrigger{}},
+				},
+			},
+		},
+	}
+	eventSource := &ofevent.EventSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "es"},
+	}
+
+	function := r.addStandaloneSourceForFunction(eventSource, "kubernetes", "cm", "handler:latest")
+	if function.Namespace != "default" {
+		t.Errorf("expected namespace default, got %s", function.Namespace)
+	}
+	if function.Spec.Serving.Triggers.Http != nil {
+		t.Errorf("expected no http trigger")
+	}
+	if function.Spec.Serving.Template.ServiceAccountName != function.Name {
+		t.Errorf("expected service account %s, got %s", function.Name, function.Spec.Serving.Template.ServiceAccountName)
+	}
+	if function.Spec.Image != "handler:latest" {
+		t.Errorf("expected image handler:latest, got %s", function.Spec.Image)
+	}
+	if r.Function.Spec.Serving.Triggers.Http == nil {
+		t.Errorf("the function template is modified")
+	}
+}
+
+type failingDeleteClient struct {
+	client.Client
+}
+
+func (c *failingDeleteClient) Delete(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+	return fmt.Errorf("forbidden")
+}
+
+func Test_cleanHandlerResources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := kedav1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	eventSource := &ofevent.EventSource{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "es"},
+	}
+	kept := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "kept", Labels: handlerLabels(eventSource, SourceKindS3),
+	}}
+	stale := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default", Name: "stale", Labels: handlerLabels(eventSource, SourceKindS3),
+	}}
+	keep := map[string]bool{resourceKey("ConfigMap", kept): true}
+	ctx := context.Background()
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kept.DeepCopy(), stale.DeepCopy()).Build()
+	r := &EventSourceReconciler{Client: &failingDeleteClient{Client: c}, Scheme: scheme}
+	errs := r.cleanHandlerResources(ctx, logr.Discard(), eventSource, keep)
+	if want := []string{"s3/ConfigMap/default/stale: forbidden"}; !reflect.DeepEqual(errs, want) {
+		t.Errorf("expected errors %v, got %v", want, errs)
+	}
+
+	r = &EventSourceReconciler{Client: c, Scheme: scheme}
+	if errs := r.cleanHandlerResources(ctx, logr.Discard(), eventSource, keep); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	cms := &corev1.ConfigMapList{}
+	if err := c.List(ctx, cms); err != nil {
+		t.Fatal(err)
+	}
+	if len(cms.Items) != 1 || cms.Items[0].Name != "kept" {
+		t.Errorf("expected only the kept ConfigMap, got %v", cms.Items)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+// RabbitMQQueueBindingContainer is the init container of the handler of a RabbitMQ event source which binds
+// the queue to the exchange.
+const RabbitMQQueueBindingContainer = "bind-queue"
+
+// addRabbitMQQueueBindingForFunction adds the init container which binds the queue of a RabbitMQ event source to its
+// exchange before the handler starts, because the Dapr rabbitmq binding only consumes the queue. The init container
+// runs the standalone handler image, and the connection string is passed to it from the spec or the Secret,
+// so that it is not read by the controller.
+func addRabbitMQQueueBindingForFunction(
+	function *ofcore.Function,
+	spec *ofevent.RabbitMQSpec,
+	config *event.RabbitMQQueueConfig,
+	image string,
+	logLevel string,
+) error {
+	if config == nil {
+		return nil
+	}
+
+	encoded, err := (&event.EventSourceConfig{LogLevel: logLevel, RabbitMQ: config}).EncodeConfig()
+	if err != nil {
+		return err
+	}
+
+	host := corev1.EnvVar{Name: event.RabbitMQHostEnv, Value: spec.Host}
+	if spec.Host == "" {
+		host.ValueFrom = &corev1.EnvVarSource{SecretKeyRef: spec.HostSecret}
+	}
+
+	if function.Spec.Serving.Template == nil {
+		function.Spec.Serving.Template = &corev1.PodSpec{}
+	}
+	function.Spec.Serving.Template.InitContainers = append(function.Spec.Serving.Template.InitContainers, corev1.Container{
+		Name:  RabbitMQQueueBindingContainer,
+		Image: image,
+		Env: []corev1.EnvVar{
+			{Name: "CONFIG", Value: encoded},
+			host,
+		},
+	})
+	return nil
+}
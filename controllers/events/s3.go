@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/core"
+	"github.com/openfunction/pkg/event"
+)
+
+const (
+	defaultS3PollingInterval = "30s"
+	minS3PollingInterval     = time.Second
+)
+
+var s3EventTypes = []string{
+	ofevent.S3EventTypeObjectCreated,
+}
+
+// genS3SourceConfig validates the spec of an S3 event source and generates the config of its handler.
+func genS3SourceConfig(name string, spec *ofevent.S3Spec) (*event.S3SourceConfig, error) {
+	if spec.Bucket == "" {
+		return nil, fmt.Errorf("bucket must be set")
+	}
+
+	endpoint, err := url.Parse(spec.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint %s: %s", spec.Endpoint, err.Error())
+	}
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %s, must be an http or https url", spec.Endpoint)
+	}
+	if strings.Trim(endpoint.Path, "/") != "" {
+		return nil, fmt.Errorf("invalid endpoint %s, must not contain a path", spec.Endpoint)
+	}
+
+	if (spec.AccessKeyID == nil) != (spec.SecretAccessKey == nil) {
+		return nil, fmt.Errorf("accessKeyID and secretAccessKey must be set together")
+	}
+
+	config := &event.S3SourceConfig{
+		Endpoint:        endpoint.Host,
+		Secure:          endpoint.Scheme == "https",
+		Region:          spec.Region,
+		Bucket:          spec.Bucket,
+		Prefix:          spec.Prefix,
+		Suffix:          spec.Suffix,
+		EventTypes:      s3EventTypes,
+		ForcePathStyle:  true,
+		PollingInterval: defaultS3PollingInterval,
+		CursorConfigMap: name,
+	}
+
+	if spec.ForcePathStyle != nil {
+		config.ForcePathStyle = *spec.ForcePathStyle
+	}
+
+	if spec.PollingInterval != nil {
+		interval, err := time.ParseDuration(*spec.PollingInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pollingInterval %s: %s", *spec.PollingInterval, err.Error())
+		}
+		if interval < minS3PollingInterval {
+			return nil, fmt.Errorf("pollingInterval must not be less than %s", minS3PollingInterval)
+		}
+		config.PollingInterval = *spec.PollingInterval
+	}
+
+	if len(spec.EventTypes) > 0 {
+		for _, t := range spec.EventTypes {
+			if t != ofevent.S3EventTypeObjectCreated {
+				return nil, fmt.Errorf("unsupported event type %s, must be one of %v", t, s3EventTypes)
+			}
+		}
+		config.EventTypes = spec.EventTypes
+	}
+
+	return config, nil
+}
+
+// s3SourceRules only allows the handler to read and write its cursor.
+func s3SourceRules(config *event.S3SourceConfig) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{""},
+			Resources:     []string{"configmaps"},
+			ResourceNames: []string{config.CursorConfigMap},
+			Verbs:         []string{"get", "update", "patch"},
+		},
+	}
+}
+
+// createS3SourceCursor creates the ConfigMap which holds the cursor of the handler.
+// The data of the ConfigMap is owned by the handler, so it is never changed by the controller.
+func (r *EventSourceReconciler) createS3SourceCursor(ctx context.Context, eventSource *ofevent.EventSource, config *event.S3SourceConfig, keep map[string]bool) error {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: config.CursorConfigMap, Namespace: eventSource.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		cm.SetLabels(handlerLabels(eventSource, SourceKindS3))
+		return controllerutil.SetControllerReference(eventSource, cm, r.Scheme)
+	}); err != nil {
+		return err
+	}
+	keep[resourceKey("ConfigMap", cm)] = true
+	return nil
+}
+
+// addS3CredentialsForFunction passes the credentials to the handler through environment variables
+// which refer to the Secrets, so that the credentials never appear in the handler config.
+func addS3CredentialsForFunction(function *ofcore.Function, spec *ofevent.S3Spec) {
+	if spec.AccessKeyID == nil || spec.SecretAccessKey == nil {
+		return
+	}
+
+	function.Spec.Serving.Template.Containers = append(function.Spec.Serving.Template.Containers, corev1.Container{
+		Name: core.FunctionContainer,
+		Env: []corev1.EnvVar{
+			{
+				Name:      event.S3AccessKeyIDEnv,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: spec.AccessKeyID},
+			},
+			{
+				Name:      event.S3SecretAccessKeyEnv,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: spec.SecretAccessKey},
+			},
+		},
+	})
+}
@@ -20,8 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
-	"strings"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	"github.com/go-logr/logr"
@@ -34,19 +35,26 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrlevent "sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
 	"github.com/openfunction/pkg/event/eventbus/natsstreaming"
 	"github.com/openfunction/pkg/util"
 )
 
 const (
+	// triggerHandlerImage will be replaced by openfunction/trigger-handler:v5, which is built from cmd/trigger-handler,
+	// once it is released.
 	triggerHandlerImage = "openfunction/trigger-handler:v4"
+
+	// SelectorSinkNamespaceField indexes the Triggers by the namespaces of the Functions selected by their selector sinks.
+	SelectorSinkNamespaceField = ".spec.subscribers.sink.selector.namespace"
 )
 
 // TriggerReconciler reconciles a Trigger object
@@ -54,9 +62,11 @@ type TriggerReconciler struct {
 	client.Client
 	Log           logr.Logger
 	Scheme        *runtime.Scheme
-	TriggerConfig *TriggerConfig
+	TriggerConfig *event.TriggerConfig
 	Function      *ofcore.Function
 	defaultConfig map[string]string
+	// eventBus is the component spec of the EventBus, it is declared for each input and topic output of the handler.
+	eventBus *componentsv1alpha1.ComponentSpec
 }
 
 type Subscribers struct {
@@ -80,6 +90,8 @@ type Subscribers struct {
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=core.openfunction.io,resources=functions/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core.openfunction.io,resources=servings,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
 //+kubebuilder:rbac:groups=dapr.io,resources=components;subscriptions,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -96,9 +108,10 @@ func (r *TriggerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	log.Info("trigger reconcile starting...")
 
 	trigger := &ofevent.Trigger{}
-	r.TriggerConfig = &TriggerConfig{}
-	r.TriggerConfig.Subscribers = map[string]*Subscriber{}
+	r.TriggerConfig = &event.TriggerConfig{}
+	r.TriggerConfig.Subscribers = map[string]*event.Subscriber{}
 	r.TriggerConfig.LogLevel = DefaultLogLevel
+	r.eventBus = nil
 
 	// Get default global configuration from ConfigMap
 	r.defaultConfig = util.GetDefaultConfig(ctx, r.Client, r.Log)
@@ -152,11 +165,27 @@ func (r *TriggerReconciler) createOrUpdateTrigger(ctx context.Context, log logr.
 		return err
 	}
 
+	// Compile the conditions and transforms of the subscribers, so that a typo will not drop events silently.
+	if err := trigger.CompileConditions(); err != nil {
+		condition := ofevent.CreateCondition(
+			ofevent.Error, metav1.ConditionFalse, ofevent.ErrorCompilingCondition,
+		).SetMessage(err.Error())
+		trigger.AddCondition(*condition)
+		log.Error(err, "Failed to compile the conditions or transforms of subscribers.",
+			"namespace", trigger.Namespace, "name", trigger.Name)
+		return err
+	}
+
 	// Handle Subscriber reconcile.
 	if err := r.handleSubscriber(ctx, log, trigger); err != nil {
 		return err
 	}
 
+	// Handle the state store of correlations and windows.
+	if err := r.handleStateStore(log, trigger); err != nil {
+		return err
+	}
+
 	// Handle Trigger function reconcile
 	if err := r.createOrUpdateTriggerFunction(ctx, log, trigger); err != nil {
 		return err
@@ -172,11 +201,11 @@ func (r *TriggerReconciler) createOrUpdateTrigger(ctx context.Context, log logr.
 			"namespace", trigger.Namespace, "name", trigger.Name)
 		return err
 	}
-	condition := ofevent.CreateCondition(
-		ofevent.Ready, metav1.ConditionTrue, ofevent.TriggerIsReady,
-	).SetMessage("Trigger is ready.")
-	trigger.AddCondition(*condition)
-	trigger.SaveStatus(ctx, log, r.Client)
+	// The Trigger has been refreshed from the cluster, set the status again before it is saved on return.
+	trigger.Status.Component = r.TriggerConfig.EventBusComponent
+	trigger.Status.Function = r.Function.Name
+	trigger.Status.Inputs = r.getInputsStatus(ctx, trigger)
+	trigger.AddCondition(*getTriggerReadyCondition(r.Function, trigger.Status.Inputs))
 	log.Info("Trigger reconcile success.", "namespace", trigger.Namespace, "name", trigger.Name)
 	return nil
 }
@@ -215,13 +244,15 @@ func (r *TriggerReconciler) handleEventBus(ctx context.Context, log logr.Logger,
 		if in.Namespace == "" {
 			in.Namespace = trigger.Namespace
 		}
-		r.TriggerConfig.Inputs = append(r.TriggerConfig.Inputs, &Input{
+		subject := fmt.Sprintf(EventBusTopicNameTmpl, in.Namespace, in.EventSource, in.Event)
+		r.TriggerConfig.Inputs = append(r.TriggerConfig.Inputs, &event.Input{
 			Name:        inputName,
 			Namespace:   in.Namespace,
 			EventSource: in.EventSource,
 			Event:       in.Event,
+			Topic:       subject,
 		})
-		subjects = append(subjects, fmt.Sprintf(EventBusTopicNameTmpl, in.Namespace, in.EventSource, in.Event))
+		subjects = append(subjects, subject)
 	}
 
 	// Generate a dapr component based on the specification of EventBus(ClusterEventBus).
@@ -242,8 +273,9 @@ func (r *TriggerReconciler) handleEventBus(ctx context.Context, log logr.Logger,
 		}
 
 		// Generate Keda scaledObject for Nats Streaming EventBus.
-		scaledObject, triggers := eb.GenScaleOptions(subjects)
-		r.Function = r.addEventBusForFunction(trigger, component, subjects, scaledObject, triggers)
+		scaleOptions, triggers := eb.GenScaleOptions(subjects)
+		r.eventBus = &component.Spec
+		r.Function = r.addEventBusForFunction(trigger, component, subjects, scaleOptions, triggers)
 	}
 	return nil
 }
@@ -252,9 +284,6 @@ func (r *TriggerReconciler) handleSubscriber(ctx context.Context, log logr.Logge
 	sinks := map[*ofevent.SinkSpec]bool{}
 	deadLetterSinks := map[*ofevent.SinkSpec]bool{}
 	totalSinks := map[*ofevent.SinkSpec]bool{}
-
-	topics := map[string]bool{}
-	deadLetterTopics := map[string]bool{}
 	totalTopics := map[string]bool{}
 
 	sinkIdx := 1
@@ -268,9 +297,31 @@ func (r *TriggerReconciler) handleSubscriber(ctx context.Context, log logr.Logge
 		//    3. component specifications for aggregate de-duplication of above 1 and 2
 		for _, subscriber := range trigger.Spec.Subscribers {
 			sub := subscriber
-			s := &Subscriber{}
+			// The handler retries the deliveries, the timeout is also applied to the resiliency policy of the outputs.
+			s := &event.Subscriber{Transform: sub.Transform, Delivery: sub.Delivery}
 
-			if sub.Sink != nil && !sinks[sub.Sink] {
+			if isSelectorSink(sub.Sink) && !sinks[sub.Sink] {
+				sinks[sub.Sink] = true
+				totalSinks[sub.Sink] = true
+				components, err := createSelectorSinkComponents(ctx, r.Client, log, trigger, sub.Sink)
+				if err != nil {
+					condition := ofevent.CreateCondition(
+						ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
+					).SetMessage(err.Error())
+					trigger.AddCondition(*condition)
+					log.Error(err, "Failed to generate Trigger components for selector sink of subscriber.",
+						"namespace", trigger.Namespace, "name", trigger.Name)
+					return err
+				}
+				// Keep one sink output per selected Function.
+				for _, component := range components {
+					name := fmt.Sprintf(SinkOutputNameTmpl, "t", trigger.Name, strconv.Itoa(sinkIdx))
+					s.SinkOutputNames = append(s.SinkOutputNames, name)
+					r.Function = addSinkForFunction(name, r.Function, component)
+					setDelivery(name, r.Function, sub.Delivery)
+					sinkIdx += 1
+				}
+			} else if sub.Sink != nil && !sinks[sub.Sink] {
 				sinks[sub.Sink] = true
 				s.SinkOutputName = fmt.Sprintf(SinkOutputNameTmpl, "t", trigger.Name, strconv.Itoa(sinkIdx))
 				if !totalSinks[sub.Sink] {
@@ -288,6 +339,7 @@ func (r *TriggerReconciler) handleSubscriber(ctx context.Context, log logr.Logge
 					if function := addSinkForFunction(s.SinkOutputName, r.Function, component); function != nil {
 						r.Function = function
 					}
+					setDelivery(s.SinkOutputName, r.Function, sub.Delivery)
 					sinkIdx += 1
 				}
 			}
@@ -297,48 +349,46 @@ func (r *TriggerReconciler) handleSubscriber(ctx context.Context, log logr.Logge
 				s.DLSinkOutputName = fmt.Sprintf(SinkOutputNameTmpl, "t", trigger.Name, strconv.Itoa(sinkIdx))
 				if !totalSinks[sub.DeadLetterSink] {
 					totalSinks[sub.DeadLetterSink] = true
-					component, err := createSinkComponent(ctx, r.Client, log, trigger, sub.Sink)
+					component, err := createSinkComponent(ctx, r.Client, log, trigger, sub.DeadLetterSink)
 					if err != nil {
 						condition := ofevent.CreateCondition(
 							ofevent.Error, metav1.ConditionFalse, ofevent.ErrorGenerateComponent,
 						).SetMessage(err.Error())
 						trigger.AddCondition(*condition)
-						log.Error(err, "Failed to generate trigger component for subscriber.",
-							"namespace", trigger.Namespace, "name", trigger.Name, "sinkName", sub.Sink.Ref.Name)
+						log.Error(err, "Failed to generate trigger component for dead letter sink of subscriber.",
+							"namespace", trigger.Namespace, "name", trigger.Name)
 						return err
 					}
-					if function := addSinkForFunction(s.SinkOutputName, r.Function, component); function != nil {
+					if function := addSinkForFunction(s.DLSinkOutputName, r.Function, component); function != nil {
 						r.Function = function
 					}
 					sinkIdx += 1
 				}
 			}
 
-			if sub.Topic != "" && !topics[sub.Topic] {
-				topics[sub.Topic] = true
+			// The subscribers of the same topic share its output.
+			if sub.Topic != "" {
 				s.EventBusOutputName = fmt.Sprintf(EventBusOutputNameTmpl, sub.Topic)
 				if !totalTopics[sub.Topic] {
 					totalTopics[sub.Topic] = true
-					output := &ofcore.DaprIO{
-						Name:      s.EventBusOutputName,
-						Component: r.TriggerConfig.EventBusComponent,
-						Topic:     sub.Topic,
+					if r.eventBus != nil {
+						addComponentForFunction(s.EventBusOutputName, r.Function, r.eventBus)
+						output := newOutput(s.EventBusOutputName, r.eventBus.Type, sub.Topic, "")
+						r.Function.Spec.Serving.Outputs = append(r.Function.Spec.Serving.Outputs, output)
+						setDelivery(s.EventBusOutputName, r.Function, sub.Delivery)
 					}
-					r.Function.Spec.Serving.Outputs = append(r.Function.Spec.Serving.Outputs, output)
 				}
 			}
 
-			if sub.DeadLetterTopic != "" && !deadLetterTopics[sub.DeadLetterTopic] {
-				deadLetterTopics[sub.DeadLetterTopic] = true
+			if sub.DeadLetterTopic != "" {
 				s.DLEventBusOutputName = fmt.Sprintf(EventBusOutputNameTmpl, sub.DeadLetterTopic)
 				if !totalTopics[sub.DeadLetterTopic] {
 					totalTopics[sub.DeadLetterTopic] = true
-					output := &ofcore.DaprIO{
-						Name:      s.DLEventBusOutputName,
-						Component: r.TriggerConfig.EventBusComponent,
-						Topic:     sub.DeadLetterTopic,
+					if r.eventBus != nil {
+						addComponentForFunction(s.DLEventBusOutputName, r.Function, r.eventBus)
+						output := newOutput(s.DLEventBusOutputName, r.eventBus.Type, sub.DeadLetterTopic, "")
+						r.Function.Spec.Serving.Outputs = append(r.Function.Spec.Serving.Outputs, output)
 					}
-					r.Function.Spec.Serving.Outputs = append(r.Function.Spec.Serving.Outputs, output)
 				}
 			}
 			r.TriggerConfig.Subscribers[sub.Condition] = s
@@ -356,6 +406,51 @@ func (r *TriggerReconciler) handleSubscriber(ctx context.Context, log logr.Logge
 	return nil
 }
 
+// handleStateStore declares the state store of the Trigger in the States of the trigger function,
+// and passes the correlation and window to the handler.
+func (r *TriggerReconciler) handleStateStore(log logr.Logger, trigger *ofevent.Trigger) error {
+	if err := trigger.ValidateCorrelation(); err != nil {
+		condition := ofevent.CreateCondition(
+			ofevent.Error, metav1.ConditionFalse, ofevent.ErrorConfiguration,
+		).SetMessage(err.Error())
+		trigger.AddCondition(*condition)
+		log.Error(err, "Failed to validate the correlation of Trigger.",
+			"namespace", trigger.Namespace, "name", trigger.Name)
+		return err
+	}
+
+	stateStore := trigger.Spec.StateStore
+	if stateStore == nil {
+		return nil
+	}
+
+	// An existing state store is referred by its name, otherwise the component is generated along with the function.
+	name := stateStore.Name
+	if stateStore.Spec != nil {
+		name = fmt.Sprintf(TriggerStateStoreNameTmpl, trigger.Name)
+	}
+
+	if r.Function.Spec.Serving.States == nil {
+		r.Function.Spec.Serving.States = map[string]*ofcore.State{}
+	}
+	r.Function.Spec.Serving.States[name] = &ofcore.State{Spec: stateStore.Spec}
+
+	r.TriggerConfig.Correlation = trigger.Spec.Correlation
+	r.TriggerConfig.Window = trigger.Spec.Window
+	r.TriggerConfig.StateStore = name
+
+	// The pending events and the timers of the windows live in the handler, which must not be scaled out or to zero.
+	if trigger.Spec.Correlation != nil || trigger.Spec.Window != nil {
+		if r.Function.Spec.Serving.ScaleOptions == nil {
+			r.Function.Spec.Serving.ScaleOptions = &ofcore.ScaleOptions{}
+		}
+		replicas := int32(1)
+		r.Function.Spec.Serving.ScaleOptions.MinReplicas = &replicas
+		r.Function.Spec.Serving.ScaleOptions.MaxReplicas = &replicas
+	}
+	return nil
+}
+
 func (r *TriggerReconciler) createOrUpdateTriggerFunction(ctx context.Context, log logr.Logger, trigger *ofevent.Trigger) error {
 	log = r.Log.WithName("createOrUpdateTriggerFunction")
 
@@ -420,49 +515,187 @@ func (r *TriggerReconciler) addEventBusForFunction(
 	trigger *ofevent.Trigger,
 	component *componentsv1alpha1.Component,
 	subjects []string,
-	scaledObject *ofcore.KedaScaledObject,
+	scaleOptions *ofcore.ScaleOptions,
 	FunctionTriggers []*v1alpha1.ScaleTriggers,
 ) *ofcore.Function {
 	function := r.Function
 	function.Name = fmt.Sprintf(TriggerWorkloadsNameTmpl, trigger.Name)
 	function.Namespace = trigger.Namespace
 
-	functionInputs := []*ofcore.DaprIO{}
+	var functionInputs []*ofcore.DaprTrigger
 	for _, subject := range subjects {
-		input := &ofcore.DaprIO{
-			Name:      fmt.Sprintf(TriggerInputNameTmpl, subject),
-			Component: component.Name,
-			Topic:     subject,
-		}
-		functionInputs = append(functionInputs, input)
+		name := fmt.Sprintf(TriggerInputNameTmpl, subject)
+		addComponentForFunction(name, function, &component.Spec)
+		functionInputs = append(functionInputs, newDaprTrigger(name, component.Spec.Type, subject))
 	}
-	function.Spec.Serving.Inputs = functionInputs
+	function.Spec.Serving.Triggers.Dapr = functionInputs
 
-	buildingBlockType := strings.Split(component.Spec.Type, ".")[0]
-	if buildingBlockType == ofcore.DaprBindings {
-		function.Spec.Serving.Bindings[component.Name] = &component.Spec
-	}
-	if buildingBlockType == ofcore.DaprPubsub {
-		function.Spec.Serving.Pubsub[component.Name] = &component.Spec
+	if scaleOptions != nil {
+		function.Spec.Serving.ScaleOptions = scaleOptions
 	}
-
-	function.Spec.Serving.ScaleOptions = &ofcore.ScaleOptions{}
-	function.Spec.Serving.ScaleOptions.Keda = &ofcore.KedaScaleOptions{}
-	function.Spec.Serving.ScaleOptions.Keda.ScaledObject = scaledObject
-	function.Spec.Serving.Triggers = []ofcore.Triggers{}
 	for _, funcTrigger := range FunctionTriggers {
 		ft := funcTrigger.DeepCopy()
-		function.Spec.Serving.Triggers = append(function.Spec.Serving.Triggers, ofcore.Triggers{
-			ScaleTriggers: *ft,
-		})
+		function.Spec.Serving.ScaleOptions.Keda.Triggers = append(function.Spec.Serving.ScaleOptions.Keda.Triggers, *ft)
 	}
 	return function
 }
 
+// getInputsStatus reports the readiness of each input according to the status of the event it refers to.
+func (r *TriggerReconciler) getInputsStatus(ctx context.Context, trigger *ofevent.Trigger) []ofevent.InputStatus {
+	var inputs []ofevent.InputStatus
+	for _, input := range r.TriggerConfig.Inputs {
+		status := ofevent.InputStatus{
+			Name:  input.Name,
+			Topic: fmt.Sprintf(EventBusTopicNameTmpl, input.Namespace, input.EventSource, input.Event),
+		}
+
+		eventSource := &ofevent.EventSource{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: input.Namespace, Name: input.EventSource}, eventSource); err != nil {
+			status.Ready = metav1.ConditionFalse
+			status.Reason = ofevent.EventSourceNotFound
+			status.Message = err.Error()
+			if util.IgnoreNotFound(err) != nil {
+				status.Ready = metav1.ConditionUnknown
+			}
+			inputs = append(inputs, status)
+			continue
+		}
+
+		var names []string
+		var statuses []metav1.ConditionStatus
+		for _, event := range eventSource.Status.Events {
+			if event.Name == input.Event {
+				names = append(names, fmt.Sprintf("%s/%s", event.Kind, event.Name))
+				statuses = append(statuses, event.Ready)
+			}
+		}
+
+		if len(statuses) == 0 {
+			status.Ready = metav1.ConditionFalse
+			status.Reason = ofevent.EventNotFound
+			status.Message = fmt.Sprintf("Event %s is not found in EventSource %s/%s", input.Event, input.Namespace, input.EventSource)
+		} else if ready, notReady := aggregateReadiness(names, statuses); ready == metav1.ConditionTrue {
+			status.Ready = metav1.ConditionTrue
+			status.Reason = ofevent.InputIsReady
+		} else {
+			status.Ready = ready
+			status.Reason = ofevent.EventsNotReady
+			status.Message = fmt.Sprintf("Events of EventSource %s/%s are not ready: %s", input.Namespace, input.EventSource, notReady)
+		}
+		inputs = append(inputs, status)
+	}
+
+	sort.Slice(inputs, func(i, j int) bool {
+		return inputs[i].Name < inputs[j].Name
+	})
+	return inputs
+}
+
+// getTriggerReadyCondition returns the Ready condition of the Trigger,
+// which is ready only if its function is running and all of its inputs are ready.
+func getTriggerReadyCondition(function *ofcore.Function, inputs []ofevent.InputStatus) *ofevent.Condition {
+	if status, message := getFunctionReadiness(function); status != metav1.ConditionTrue {
+		return ofevent.CreateCondition(ofevent.Ready, status, ofevent.FunctionNotReady).SetMessage(message)
+	}
+
+	var names []string
+	var statuses []metav1.ConditionStatus
+	for _, input := range inputs {
+		names = append(names, input.Name)
+		statuses = append(statuses, input.Ready)
+	}
+
+	status, notReady := aggregateReadiness(names, statuses)
+	if status == metav1.ConditionTrue {
+		return ofevent.CreateCondition(
+			ofevent.Ready, metav1.ConditionTrue, ofevent.TriggerIsReady,
+		).SetMessage("Trigger is ready.")
+	}
+	return ofevent.CreateCondition(
+		ofevent.Ready, status, ofevent.InputsNotReady,
+	).SetMessage(fmt.Sprintf("Inputs are not ready: %s", notReady))
+}
+
+// getSelectorSinkNamespaces returns the namespaces of the Functions selected by the selector sinks of a Trigger.
+func getSelectorSinkNamespaces(trigger *ofevent.Trigger) []string {
+	set := make(map[string]bool)
+	var namespaces []string
+	for _, sub := range trigger.Spec.Subscribers {
+		if sub == nil || !isSelectorSink(sub.Sink) {
+			continue
+		}
+		namespace := getSinkSelectorNamespace(trigger, sub.Sink.Selector)
+		if !set[namespace] {
+			set[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TriggerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &ofevent.Trigger{}, SelectorSinkNamespaceField, func(rawObj client.Object) []string {
+		return getSelectorSinkNamespaces(rawObj.(*ofevent.Trigger))
+	}); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ofevent.Trigger{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&ofcore.Function{}).
+		Watches(&source.Kind{Type: &ofevent.EventSource{}}, handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
+			triggerList := &ofevent.TriggerList{}
+			c := mgr.GetClient()
+
+			// The inputs of a Trigger may refer to EventSources in other namespaces.
+			if err := c.List(context.TODO(), triggerList); err != nil {
+				return []reconcile.Request{}
+			}
+
+			var reconcileRequests []reconcile.Request
+			for _, trigger := range triggerList.Items {
+				for _, input := range trigger.Spec.Inputs {
+					namespace := input.Namespace
+					if namespace == "" {
+						namespace = trigger.Namespace
+					}
+					if input.EventSource == object.GetName() && namespace == object.GetNamespace() {
+						reconcileRequests = append(reconcileRequests, reconcile.Request{
+							NamespacedName: types.NamespacedName{
+								Namespace: trigger.Namespace,
+								Name:      trigger.Name,
+							},
+						})
+						break
+					}
+				}
+			}
+			return reconcileRequests
+		})).
+		Watches(&source.Kind{Type: &ofcore.Function{}}, handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
+			triggerList := &ofevent.TriggerList{}
+			c := mgr.GetClient()
+
+			// The labels of the Function may have been changed so that it is no longer selected,
+			// so all the Triggers selecting Functions in its namespace are reconciled to update the fan-out sets.
+			// The selector sinks of a Trigger may select Functions in other namespaces.
+			if err := c.List(context.TODO(), triggerList,
+				client.MatchingFields{SelectorSinkNamespaceField: object.GetNamespace()}); err != nil {
+				return []reconcile.Request{}
+			}
+
+			reconcileRequests := make([]reconcile.Request, len(triggerList.Items))
+			for i, trigger := range triggerList.Items {
+				reconcileRequests[i] = reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Namespace: trigger.Namespace,
+						Name:      trigger.Name,
+					},
+				}
+			}
+			return reconcileRequests
+		}), builder.WithPredicates(selectorSinkFunctionPredicate())).
 		Watches(&source.Kind{Type: &ofevent.EventBus{}}, handler.EnqueueRequestsFromMapFunc(func(object client.Object) []reconcile.Request {
 			triggerList := &ofevent.TriggerList{}
 			c := mgr.GetClient()
@@ -516,3 +749,40 @@ func (r *TriggerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		})).
 		Complete(r)
 }
+
+// selectorSinkFunctionPredicate filters the events of the Functions which may change the Functions selected by
+// the selector sinks or their addresses, the status-only updates of the Functions are ignored otherwise.
+// The functions of Triggers are never selected as sinks.
+func selectorSinkFunctionPredicate() predicate.Predicate {
+	selectable := func(object client.Object) bool {
+		_, ok := object.GetLabels()[TriggerControlledLabel]
+		return !ok
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e ctrlevent.CreateEvent) bool {
+			return selectable(e.Object)
+		},
+		UpdateFunc: func(e ctrlevent.UpdateEvent) bool {
+			if !selectable(e.ObjectOld) && !selectable(e.ObjectNew) {
+				return false
+			}
+			oldFn, ok := e.ObjectOld.(*ofcore.Function)
+			if !ok {
+				return false
+			}
+			newFn, ok := e.ObjectNew.(*ofcore.Function)
+			if !ok {
+				return false
+			}
+			return !reflect.DeepEqual(oldFn.Labels, newFn.Labels) ||
+				oldFn.Generation != newFn.Generation ||
+				getFunctionInternalAddress(oldFn) != getFunctionInternalAddress(newFn)
+		},
+		DeleteFunc: func(e ctrlevent.DeleteEvent) bool {
+			return selectable(e.Object)
+		},
+		GenericFunc: func(e ctrlevent.GenericEvent) bool {
+			return selectable(e.Object)
+		},
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,7 +44,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
 	ofngateway "github.com/openfunction/pkg/networking/gateway"
@@ -61,7 +62,10 @@ type GatewayReconciler struct {
 	Log        logr.Logger
 	Scheme     *runtime.Scheme
 	ctx        context.Context
-	k8sGateway *k8sgatewayapiv1alpha2.Gateway
+	k8sGateway *k8sgatewayapiv1beta1.Gateway
+	// GatewayAPIVersion is the Gateway API version served by the cluster,
+	// the Client must read and write the k8s Gateways and HTTPRoutes in this version.
+	GatewayAPIVersion string
 }
 
 //+kubebuilder:rbac:groups=networking.openfunction.io,resources=gateways,verbs=get;list;watch;create;update;patch;delete
@@ -69,7 +73,10 @@ type GatewayReconciler struct {
 //+kubebuilder:rbac:groups=networking.openfunction.io,resources=gateways/finalizers,verbs=update
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=referencegrants;referencepolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -103,7 +110,13 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	} else {
 		if controllerutil.ContainsFinalizer(gateway, GatewayFinalizerName) {
-			if err := r.cleanK8sGatewayResources(gateway); err != nil {
+			if err := r.cleanK8sGatewayResources(gateway); err != nil && !meta.IsNoMatchError(err) {
+				return ctrl.Result{}, err
+			}
+			if err := r.syncFunctionCertificates(gateway, k8sGatewayNamespace(gateway), nil); err != nil {
+				return ctrl.Result{}, err
+			}
+			if err := r.syncReferenceGrants(gateway, nil); err != nil {
 				return ctrl.Result{}, err
 			}
 			controllerutil.RemoveFinalizer(gateway, GatewayFinalizerName)
@@ -114,14 +127,47 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
+	if ofngateway.RoutingModeFor(gateway, r.GatewayAPIVersion) == networkingv1alpha1.RoutingModeIngress {
+		if err := r.cleanGatewayAPIResources(gateway); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileIngressGateway(gateway); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.syncReferenceGrants(gateway, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.updateGatewayAnnotations(gateway)
+	}
+
+	if r.GatewayAPIVersion == "" {
+		r.updateGatewayNotReady(gateway, networkingv1alpha1.GatewayReasonGatewayAPINotServed,
+			"the GatewayAPI routing mode is set, but the cluster does not serve Gateway API")
+		return ctrl.Result{}, nil
+	}
+
 	if err := r.cleanExternalResources(gateway); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if gateway.Spec.GatewayRef == nil && gateway.Spec.GatewayDef == nil {
+		r.updateGatewayNotReady(gateway, networkingv1alpha1.GatewayReasonGatewayNotSpecified,
+			"the routes are served by Gateway API, must specify one of gatewayRef and gatewayDef")
+		return ctrl.Result{}, r.updateGatewayAnnotations(gateway)
+	}
+
 	if err := r.createOrUpdateGateway(gateway); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileFunctionCertificates(gateway); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncReferenceGrants(gateway, r.k8sGateway); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	if err := r.updateGatewayAnnotations(gateway); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -136,20 +182,20 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 func (r *GatewayReconciler) createOrUpdateGateway(gateway *networkingv1alpha1.Gateway) error {
 	defer r.updateGatewayStatus(gateway.Status.DeepCopy(), gateway)
 	log := r.Log.WithName("createOrUpdateGateway")
-	k8sGateway := &k8sgatewayapiv1alpha2.Gateway{}
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{}
 
 	if gateway.Spec.GatewayRef != nil {
 		key := client.ObjectKey{Namespace: gateway.Spec.GatewayRef.Namespace, Name: gateway.Spec.GatewayRef.Name}
 		if err := r.Get(r.ctx, key, k8sGateway); err != nil {
 			log.Error(err, "Failed to get k8s Gateway",
 				"namespace", gateway.Spec.GatewayRef.Namespace, "name", gateway.Spec.GatewayRef.Name)
-			reason := k8sgatewayapiv1alpha2.GatewayReasonNotReconciled
+			reason := k8sgatewayapiv1beta1.GatewayReasonNotReconciled
 			if util.IsNotFound(err) {
 				reason = networkingv1alpha1.GatewayReasonNotFound
 			}
 			gateway.Status.Conditions = []networkingv1alpha1.Condition{
 				{
-					Type:    string(k8sgatewayapiv1alpha2.GatewayConditionReady),
+					Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
 					Status:  metav1.ConditionFalse,
 					Reason:  string(reason),
 					Message: err.Error(),
@@ -184,9 +230,9 @@ func (r *GatewayReconciler) createOrUpdateGateway(gateway *networkingv1alpha1.Ga
 				"namespace", gateway.Spec.GatewayDef.Namespace, "name", gateway.Spec.GatewayDef.Name)
 			gateway.Status.Conditions = []networkingv1alpha1.Condition{
 				{
-					Type:    string(k8sgatewayapiv1alpha2.GatewayConditionReady),
+					Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
 					Status:  metav1.ConditionFalse,
-					Reason:  string(k8sgatewayapiv1alpha2.GatewayReasonNotReconciled),
+					Reason:  string(k8sgatewayapiv1beta1.GatewayReasonNotReconciled),
 					Message: err.Error(),
 				},
 			}
@@ -204,13 +250,13 @@ func (r *GatewayReconciler) createOrUpdateGateway(gateway *networkingv1alpha1.Ga
 func (r *GatewayReconciler) createK8sGateway(gateway *networkingv1alpha1.Gateway) error {
 	log := r.Log.WithName("createK8sGateway")
 	listenersAnnotation, _ := json.Marshal(gateway.Spec.GatewaySpec.Listeners)
-	k8sGateway := &k8sgatewayapiv1alpha2.Gateway{
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        gateway.Spec.GatewayDef.Name,
 			Namespace:   gateway.Spec.GatewayDef.Namespace,
 			Annotations: map[string]string{networkingv1alpha1.GatewayListenersAnnotation: string(listenersAnnotation)},
 		},
-		Spec: k8sgatewayapiv1alpha2.GatewaySpec{
+		Spec: k8sgatewayapiv1beta1.GatewaySpec{
 			GatewayClassName: gateway.Spec.GatewayDef.GatewayClassName,
 			Listeners:        gateway.Spec.GatewaySpec.Listeners,
 		},
@@ -221,7 +267,7 @@ func (r *GatewayReconciler) createK8sGateway(gateway *networkingv1alpha1.Gateway
 			"namespace", gateway.Spec.GatewayDef.Namespace, "name", gateway.Spec.GatewayDef.Name)
 		gateway.Status.Conditions = []networkingv1alpha1.Condition{
 			{
-				Type:    string(k8sgatewayapiv1alpha2.GatewayConditionReady),
+				Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
 				Status:  metav1.ConditionFalse,
 				Reason:  string(networkingv1alpha1.GatewayReasonCreationFailure),
 				Message: err.Error(),
@@ -231,7 +277,7 @@ func (r *GatewayReconciler) createK8sGateway(gateway *networkingv1alpha1.Gateway
 	}
 
 	gateway.Status.Conditions = append(gateway.Status.Conditions, networkingv1alpha1.Condition{
-		Type:    string(k8sgatewayapiv1alpha2.GatewayConditionScheduled),
+		Type:    string(k8sgatewayapiv1beta1.GatewayConditionScheduled),
 		Status:  metav1.ConditionTrue,
 		Reason:  string(networkingv1alpha1.GatewayReasonResourcesAvailable),
 		Message: "Deployed k8s gateway to the cluster",
@@ -244,7 +290,7 @@ func (r *GatewayReconciler) createK8sGateway(gateway *networkingv1alpha1.Gateway
 func (r *GatewayReconciler) reconcileK8sGateway(gateway *networkingv1alpha1.Gateway) error {
 	log := r.Log.WithName("reconcileK8sGateway")
 	var oldGateway networkingv1alpha1.Gateway
-	oldGatewayListenersMapping := make(map[k8sgatewayapiv1alpha2.SectionName]k8sgatewayapiv1alpha2.Listener)
+	oldGatewayListenersMapping := make(map[k8sgatewayapiv1beta1.SectionName]k8sgatewayapiv1beta1.Listener)
 
 	gatewayConfigAnnotation := []byte(gateway.Annotations[networkingv1alpha1.GatewayConfigAnnotation])
 	if err := json.Unmarshal(gatewayConfigAnnotation, &oldGateway); err != nil {
@@ -275,9 +321,9 @@ func (r *GatewayReconciler) reconcileK8sGateway(gateway *networkingv1alpha1.Gate
 			"namespace", r.k8sGateway.Namespace, "name", r.k8sGateway.Name)
 		gateway.Status.Conditions = []networkingv1alpha1.Condition{
 			{
-				Type:    string(k8sgatewayapiv1alpha2.GatewayConditionReady),
+				Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
 				Status:  metav1.ConditionFalse,
-				Reason:  string(k8sgatewayapiv1alpha2.GatewayReasonNotReconciled),
+				Reason:  string(k8sgatewayapiv1beta1.GatewayReasonNotReconciled),
 				Message: err.Error(),
 			},
 		}
@@ -302,6 +348,11 @@ func (r *GatewayReconciler) cleanExternalResources(gateway *networkingv1alpha1.G
 		if err := r.cleanK8sGatewayResources(&oldGateway); err != nil {
 			return err
 		}
+		// The annotation only records the spec of the gateway.
+		oldGateway.Namespace, oldGateway.Name = gateway.Namespace, gateway.Name
+		if err := r.syncFunctionCertificates(&oldGateway, k8sGatewayNamespace(&oldGateway), nil); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -309,7 +360,7 @@ func (r *GatewayReconciler) cleanExternalResources(gateway *networkingv1alpha1.G
 func (r *GatewayReconciler) cleanK8sGatewayResources(gateway *networkingv1alpha1.Gateway) error {
 	log := r.Log.WithName("cleanK8sGatewayResources")
 	if gateway.Spec.GatewayRef != nil {
-		k8sGateway := &k8sgatewayapiv1alpha2.Gateway{}
+		k8sGateway := &k8sgatewayapiv1beta1.Gateway{}
 		key := client.ObjectKey{Namespace: gateway.Spec.GatewayRef.Namespace, Name: gateway.Spec.GatewayRef.Name}
 		if err := r.Get(r.ctx, key, k8sGateway); err != nil {
 			if !util.IsNotFound(err) {
@@ -323,6 +374,11 @@ func (r *GatewayReconciler) cleanK8sGatewayResources(gateway *networkingv1alpha1
 		for name := range needRemoveListenersMapping {
 			delete(k8sGatewayListenersMapping, name)
 		}
+		for name := range k8sGatewayListenersMapping {
+			if strings.HasPrefix(string(name), networkingv1alpha1.FunctionHttpsListenerPrefix) {
+				delete(k8sGatewayListenersMapping, name)
+			}
+		}
 		k8sGateway.Spec.Listeners = ofngateway.ConvertListenersMappingToList(k8sGatewayListenersMapping)
 		if k8sGateway.Annotations != nil {
 			delete(k8sGateway.Annotations, networkingv1alpha1.GatewayListenersAnnotation)
@@ -335,7 +391,7 @@ func (r *GatewayReconciler) cleanK8sGatewayResources(gateway *networkingv1alpha1
 	}
 
 	if gateway.Spec.GatewayDef != nil {
-		k8sGateway := &k8sgatewayapiv1alpha2.Gateway{
+		k8sGateway := &k8sgatewayapiv1beta1.Gateway{
 			ObjectMeta: metav1.ObjectMeta{Namespace: gateway.Spec.GatewayDef.Namespace, Name: gateway.Spec.GatewayDef.Name},
 		}
 		if err := r.Delete(r.ctx, k8sGateway); err != nil {
@@ -429,7 +485,7 @@ func (r *GatewayReconciler) createOrUpdateService(gateway *networkingv1alpha1.Ga
 	// For the k8s gateway controller implements the addresses field, such as istio.
 	if len(r.k8sGateway.Status.Addresses) > 0 {
 		address := r.k8sGateway.Status.Addresses[0]
-		if *address.Type == k8sgatewayapiv1alpha2.HostnameAddressType {
+		if *address.Type == k8sgatewayapiv1beta1.HostnameAddressType {
 			externalName = strings.Split(address.Value, ":")[0]
 		}
 	}
@@ -490,6 +546,15 @@ func (r *GatewayReconciler) mutateService(
 					servicePorts = append(servicePorts, servicePort)
 				}
 			}
+			// The function listeners are not declared in the gateway, expose their port as well.
+			if tls := gateway.Spec.TLS; tls != nil && !containsServicePort(servicePorts, int32(tls.Port)) {
+				servicePorts = append(servicePorts, corev1.ServicePort{
+					Name:       networkingv1alpha1.DefaultHttpsListenerName,
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(tls.Port),
+					TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: int32(tls.Port)},
+				})
+			}
 			service.Spec.Type = corev1.ServiceTypeExternalName
 			service.Spec.Ports = servicePorts
 			service.Spec.ExternalName = externalName
@@ -499,6 +564,15 @@ func (r *GatewayReconciler) mutateService(
 	}
 }
 
+func containsServicePort(ports []corev1.ServicePort, port int32) bool {
+	for _, item := range ports {
+		if item.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *GatewayReconciler) updateGatewayStatus(oldStatus *networkingv1alpha1.GatewayStatus, gateway *networkingv1alpha1.Gateway) {
 	log := r.Log.WithName("updateGatewayStatus")
 	if !equality.Semantic.DeepEqual(oldStatus, gateway.Status.DeepCopy()) {
@@ -510,6 +584,23 @@ func (r *GatewayReconciler) updateGatewayStatus(oldStatus *networkingv1alpha1.Ga
 	}
 }
 
+// updateGatewayNotReady reports the gateway as not ready for a reason which is not resolved by retrying.
+func (r *GatewayReconciler) updateGatewayNotReady(
+	gateway *networkingv1alpha1.Gateway,
+	reason k8sgatewayapiv1beta1.GatewayConditionReason,
+	message string) {
+	defer r.updateGatewayStatus(gateway.Status.DeepCopy(), gateway)
+	r.k8sGateway = nil
+	gateway.Status.Conditions = []networkingv1alpha1.Condition{
+		{
+			Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(reason),
+			Message: message,
+		},
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &networkingv1alpha1.Gateway{}, GatewayField, func(rawObj client.Object) []string {
@@ -525,14 +616,25 @@ func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&networkingv1alpha1.Gateway{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
-		Owns(&corev1.Service{}).
+		Owns(&corev1.Service{})
+	// There are no k8s Gateways and HTTPRoutes to watch if Gateway API is not served.
+	if r.GatewayAPIVersion == "" {
+		return b.Complete(r)
+	}
+
+	return b.
 		Watches(
-			&source.Kind{Type: &k8sgatewayapiv1alpha2.Gateway{}},
+			&source.Kind{Type: ofngateway.NewObject(&k8sgatewayapiv1beta1.Gateway{}, r.GatewayAPIVersion)},
 			handler.EnqueueRequestsFromMapFunc(r.findObjectsForK8sGateway),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}, predicate.Funcs{UpdateFunc: r.filterK8sGatewayUpdateEvent}),
 		).
+		Watches(
+			&source.Kind{Type: ofngateway.NewObject(&k8sgatewayapiv1beta1.HTTPRoute{}, r.GatewayAPIVersion)},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForHTTPRoute),
+			builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{})),
+		).
 		Complete(r)
 }
 
@@ -541,8 +643,11 @@ func (r *GatewayReconciler) filterK8sGatewayUpdateEvent(e event.UpdateEvent) boo
 		return false
 	}
 
-	oldGateway := e.ObjectOld.(*k8sgatewayapiv1alpha2.Gateway).DeepCopy()
-	newGateway := e.ObjectNew.(*k8sgatewayapiv1alpha2.Gateway).DeepCopy()
+	oldGateway := &k8sgatewayapiv1beta1.Gateway{}
+	newGateway := &k8sgatewayapiv1beta1.Gateway{}
+	if ofngateway.ConvertObject(e.ObjectOld, oldGateway) != nil || ofngateway.ConvertObject(e.ObjectNew, newGateway) != nil {
+		return true
+	}
 
 	if !reflect.DeepEqual(oldGateway.Spec, newGateway.Spec) {
 		return true
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	"github.com/openfunction/pkg/constants"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+)
+
+const GatewayReasonIngressMode = "IngressMode"
+
+// ingressControllerService returns the Service of the ingress controller which serves the Ingresses of functions.
+func ingressControllerService(gateway *networkingv1alpha1.Gateway) client.ObjectKey {
+	if gateway.Spec.Ingress != nil && gateway.Spec.Ingress.ControllerService != nil {
+		return client.ObjectKey{
+			Namespace: gateway.Spec.Ingress.ControllerService.Namespace,
+			Name:      gateway.Spec.Ingress.ControllerService.Name,
+		}
+	}
+	return client.ObjectKey{
+		Namespace: networkingv1alpha1.DefaultIngressControllerServiceNamespace,
+		Name:      networkingv1alpha1.DefaultIngressControllerServiceName,
+	}
+}
+
+// cleanGatewayAPIResources cleans the listeners and the certificates of the k8s Gateway
+// once the gateway switches from the `GatewayAPI` routing mode to the `Ingress` routing mode.
+func (r *GatewayReconciler) cleanGatewayAPIResources(gateway *networkingv1alpha1.Gateway) error {
+	log := r.Log.WithName("cleanGatewayAPIResources")
+	if r.GatewayAPIVersion == "" {
+		return nil
+	}
+	gatewayConfigAnnotation, ok := gateway.Annotations[networkingv1alpha1.GatewayConfigAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var oldGateway networkingv1alpha1.Gateway
+	if err := json.Unmarshal([]byte(gatewayConfigAnnotation), &oldGateway); err != nil {
+		log.Error(err, "Failed to Unmarshal GatewayConfigAnnotation")
+		return nil
+	}
+	if ofngateway.RoutingModeFor(&oldGateway, r.GatewayAPIVersion) == networkingv1alpha1.RoutingModeIngress {
+		return nil
+	}
+
+	if err := r.cleanK8sGatewayResources(&oldGateway); err != nil && !meta.IsNoMatchError(err) {
+		return err
+	}
+	// The annotation only records the spec of the gateway.
+	oldGateway.Namespace, oldGateway.Name = gateway.Namespace, gateway.Name
+	return r.syncFunctionCertificates(&oldGateway, k8sGatewayNamespace(&oldGateway), nil)
+}
+
+// reconcileIngressGateway resolves the gateway service to the ingress controller in the `Ingress` routing mode,
+// the k8s Gateway is not managed in this mode.
+func (r *GatewayReconciler) reconcileIngressGateway(gateway *networkingv1alpha1.Gateway) error {
+	log := r.Log.WithName("reconcileIngressGateway")
+	defer r.updateGatewayStatus(gateway.Status.DeepCopy(), gateway)
+	r.k8sGateway = nil
+
+	key := ingressControllerService(gateway)
+	if err := r.Get(r.ctx, key, &corev1.Service{}); err != nil {
+		log.Error(err, "Failed to get ingress controller service", "namespace", key.Namespace, "name", key.Name)
+		gateway.Status.Conditions = []networkingv1alpha1.Condition{
+			{
+				Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(networkingv1alpha1.GatewayReasonNotFound),
+				Message: err.Error(),
+			},
+		}
+		return err
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: gateway.Namespace, Name: networkingv1alpha1.DefaultGatewayServiceName},
+	}
+	op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, service, func() error {
+		service.Spec.Type = corev1.ServiceTypeExternalName
+		service.Spec.ExternalName = fmt.Sprintf("%s.%s.svc.%s", key.Name, key.Namespace, gateway.Spec.ClusterDomain)
+		service.Spec.Ports = []corev1.ServicePort{
+			{
+				Name:       "http",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       int32(constants.DefaultGatewayListenerPort),
+				TargetPort: intstr.IntOrString{Type: intstr.Int, IntVal: int32(constants.DefaultGatewayListenerPort)},
+			},
+		}
+		return ctrl.SetControllerReference(gateway, service, r.Scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to CreateOrUpdate service")
+		return err
+	}
+	log.V(1).Info(fmt.Sprintf("Service %s", op))
+
+	gateway.Status.Conditions = []networkingv1alpha1.Condition{
+		{
+			Type:    string(k8sgatewayapiv1beta1.GatewayConditionReady),
+			Status:  metav1.ConditionTrue,
+			Reason:  GatewayReasonIngressMode,
+			Message: fmt.Sprintf("Routes of functions are served by the ingress controller %s", key),
+		},
+	}
+	gateway.Status.Listeners = nil
+	gateway.Status.Addresses = nil
+	return nil
+}
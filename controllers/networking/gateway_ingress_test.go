@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+)
+
+func newRoutingModeTestGateway(t *testing.T, mode, oldMode networkingv1alpha1.RoutingMode) *networkingv1alpha1.Gateway {
+	gateway := &networkingv1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+		Spec: networkingv1alpha1.GatewaySpec{
+			Domain:        "ofn.io",
+			ClusterDomain: "cluster.local",
+			GatewayRef:    &networkingv1alpha1.GatewayRef{Namespace: "gateway", Name: "gateway"},
+			GatewaySpec: networkingv1alpha1.K8sGatewaySpec{
+				Listeners: []k8sgatewayapiv1beta1.Listener{
+					{Name: "ofn-http-external", Port: 80, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType},
+				},
+			},
+		},
+	}
+	old := gateway.DeepCopy()
+	old.Spec.RoutingMode = oldMode
+	annotation, err := json.Marshal(networkingv1alpha1.Gateway{Spec: old.Spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gateway.Annotations = map[string]string{networkingv1alpha1.GatewayConfigAnnotation: string(annotation)}
+	gateway.Spec.RoutingMode = mode
+	return gateway
+}
+
+func Test_cleanGatewayAPIResources(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldMode       networkingv1alpha1.RoutingMode
+		wantListeners []string
+		wantCleaned   bool
+	}{
+		{
+			name:          "switched from the GatewayAPI routing mode",
+			oldMode:       networkingv1alpha1.RoutingModeGatewayAPI,
+			wantListeners: []string{"user"},
+			wantCleaned:   true,
+		},
+		{
+			name:          "switched from the auto routing mode",
+			oldMode:       "",
+			wantListeners: []string{"user"},
+			wantCleaned:   true,
+		},
+		{
+			name:          "unchanged Ingress routing mode",
+			oldMode:       networkingv1alpha1.RoutingModeIngress,
+			wantListeners: []string{"user", "ofn-http-external", "ofn-https-fn-sample"},
+			wantCleaned:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme(t)
+			gateway := newRoutingModeTestGateway(t, networkingv1alpha1.RoutingModeIngress, tt.oldMode)
+			k8sGateway := &k8sgatewayapiv1beta1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "gateway", Name: "gateway"},
+				Spec: k8sgatewayapiv1beta1.GatewaySpec{
+					Listeners: []k8sgatewayapiv1beta1.Listener{
+						{Name: "user", Port: 8080, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType},
+						{Name: "ofn-http-external", Port: 80, Protocol: k8sgatewayapiv1beta1.HTTPProtocolType},
+						{
+							Name:     networkingv1alpha1.FunctionHttpsListenerPrefix + "sample",
+							Port:     443,
+							Protocol: k8sgatewayapiv1beta1.HTTPSProtocolType,
+						},
+					},
+				},
+			}
+			certificate := &unstructured.Unstructured{}
+			certificate.SetGroupVersionKind(certificateGVK)
+			certificate.SetNamespace("gateway")
+			certificate.SetName("sample")
+			certificate.SetLabels(map[string]string{networkingv1alpha1.GatewayLabel: gatewayLabelValue(gateway)})
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gateway, k8sGateway, certificate).Build()
+			r := &GatewayReconciler{
+				Client:            c,
+				Log:               logr.Discard(),
+				Scheme:            scheme,
+				GatewayAPIVersion: "v1beta1",
+				ctx:               context.Background(),
+			}
+			if err := r.cleanGatewayAPIResources(gateway); err != nil {
+				t.Fatal(err)
+			}
+
+			updated := &k8sgatewayapiv1beta1.Gateway{}
+			if err := c.Get(r.ctx, client.ObjectKeyFromObject(k8sGateway), updated); err != nil {
+				t.Fatal(err)
+			}
+			var listeners []string
+			for _, listener := range updated.Spec.Listeners {
+				listeners = append(listeners, string(listener.Name))
+			}
+			if len(listeners) != len(tt.wantListeners) {
+				t.Fatalf("expected listeners %v, got %v", tt.wantListeners, listeners)
+			}
+			for index := range listeners {
+				if listeners[index] != tt.wantListeners[index] {
+					t.Errorf("expected listeners %v, got %v", tt.wantListeners, listeners)
+				}
+			}
+
+			existing := &unstructured.Unstructured{}
+			existing.SetGroupVersionKind(certificateGVK)
+			err := c.Get(r.ctx, client.ObjectKeyFromObject(certificate), existing)
+			if cleaned := err != nil; cleaned != tt.wantCleaned {
+				t.Errorf("expected certificate cleaned %v, got error %v", tt.wantCleaned, err)
+			}
+		})
+	}
+}
+
+func Test_Reconcile_gatewayAPINotReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       networkingv1alpha1.RoutingMode
+		version    string
+		noRef      bool
+		wantReason k8sgatewayapiv1beta1.GatewayConditionReason
+	}{
+		{
+			name:       "GatewayAPI routing mode not served",
+			mode:       networkingv1alpha1.RoutingModeGatewayAPI,
+			version:    "",
+			wantReason: networkingv1alpha1.GatewayReasonGatewayAPINotServed,
+		},
+		{
+			name:       "auto routing mode without k8s Gateway",
+			mode:       "",
+			version:    "v1beta1",
+			noRef:      true,
+			wantReason: networkingv1alpha1.GatewayReasonGatewayNotSpecified,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme(t)
+			gateway := newRoutingModeTestGateway(t, tt.mode, tt.mode)
+			if tt.noRef {
+				gateway.Spec.GatewayRef = nil
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gateway).Build()
+			r := &GatewayReconciler{Client: c, Log: logr.Discard(), Scheme: scheme, GatewayAPIVersion: tt.version}
+
+			if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(gateway)}); err != nil {
+				t.Fatal(err)
+			}
+			updated := &networkingv1alpha1.Gateway{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(gateway), updated); err != nil {
+				t.Fatal(err)
+			}
+			if len(updated.Status.Conditions) != 1 {
+				t.Fatalf("expected one condition, got %v", updated.Status.Conditions)
+			}
+			condition := updated.Status.Conditions[0]
+			if condition.Status != metav1.ConditionFalse || condition.Reason != string(tt.wantReason) {
+				t.Errorf("expected Ready False with reason %s, got %v", tt.wantReason, condition)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	// FunctionCertificateNameTmpl is the name of the certificate issued for a function, `ofn-{routeNamespace}-{routeName}`.
+	FunctionCertificateNameTmpl = "ofn-%s-%s"
+	// FunctionCertificateSecretNameTmpl is the name of the secret which stores the certificate of a function.
+	FunctionCertificateSecretNameTmpl = "%s-tls"
+)
+
+var certificateGVK = schema.GroupVersionKind{
+	Group:   networkingv1alpha1.CertManagerGroup,
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+func gatewayLabelValue(gateway *networkingv1alpha1.Gateway) string {
+	return fmt.Sprintf("%s.%s", gateway.Namespace, gateway.Name)
+}
+
+func k8sGatewayNamespace(gateway *networkingv1alpha1.Gateway) string {
+	if gateway.Spec.GatewayRef != nil {
+		return gateway.Spec.GatewayRef.Namespace
+	}
+	if gateway.Spec.GatewayDef != nil {
+		return gateway.Spec.GatewayDef.Namespace
+	}
+	return ""
+}
+
+// reconcileFunctionCertificates issues the certificates of the functions attached to the gateway with the issuer
+// of the gateway. A wildcard certificate for `*.{Domain}` is issued by default, which is served by the default HTTPS
+// listener injected by the webhook. In the PerFunction certificate mode, a certificate is issued for each function
+// and the external hostnames of each function are served with dedicated HTTPS listeners of the k8s Gateway.
+// The listeners and certificates are removed once the issuer or the mode is no longer used.
+// A k8s Gateway has at most 64 listeners, the functions beyond the limit are not served over HTTPS,
+// they are reported in the FunctionListeners condition of the gateway.
+func (r *GatewayReconciler) reconcileFunctionCertificates(gateway *networkingv1alpha1.Gateway) error {
+	log := r.Log.WithName("reconcileFunctionCertificates")
+	if r.k8sGateway == nil {
+		return nil
+	}
+
+	var listeners []k8sgatewayapiv1beta1.Listener
+	var skippedRoutes []string
+	certificates := make(map[string]*unstructured.Unstructured)
+	tls := gateway.Spec.TLS
+	if tls != nil && tls.IssuerRef != nil && tls.CertificateMode != networkingv1alpha1.CertificateModePerFunction {
+		name := gateway.WildcardCertificateName()
+		certificates[name] = newCertificate(r.k8sGateway.Namespace, name, gateway.WildcardCertificateSecretName(),
+			[]interface{}{fmt.Sprintf("*.%s", gateway.Spec.Domain)}, tls.IssuerRef)
+	} else if tls != nil && tls.IssuerRef != nil {
+		routes := &k8sgatewayapiv1beta1.HTTPRouteList{}
+		if err := r.List(r.ctx, routes, client.MatchingLabels{gateway.Spec.HttpRouteLabelKey: gatewayLabelValue(gateway)}); err != nil {
+			log.Error(err, "Failed to list HTTPRoutes", "namespace", gateway.Namespace, "name", gateway.Name)
+			return err
+		}
+		sort.Slice(routes.Items, func(i, j int) bool {
+			return types.NamespacedName{Namespace: routes.Items[i].Namespace, Name: routes.Items[i].Name}.String() <
+				types.NamespacedName{Namespace: routes.Items[j].Namespace, Name: routes.Items[j].Name}.String()
+		})
+
+		available := networkingv1alpha1.MaxListeners
+		for _, listener := range r.k8sGateway.Spec.Listeners {
+			if !strings.HasPrefix(string(listener.Name), networkingv1alpha1.FunctionHttpsListenerPrefix) {
+				available -= 1
+			}
+		}
+
+		for _, route := range routes.Items {
+			name := fmt.Sprintf(FunctionCertificateNameTmpl, route.Namespace, route.Name)
+			secretName := fmt.Sprintf(FunctionCertificateSecretNameTmpl, name)
+			var routeListeners []k8sgatewayapiv1beta1.Listener
+			var dnsNames []interface{}
+			for _, hostname := range route.Spec.Hostnames {
+				if strings.HasSuffix(string(hostname), gateway.Spec.ClusterDomain) {
+					continue
+				}
+				routeListeners = append(routeListeners, newFunctionHttpsListener(
+					fmt.Sprintf("%s%s-%s-%d", networkingv1alpha1.FunctionHttpsListenerPrefix, route.Namespace, route.Name, len(dnsNames)),
+					hostname,
+					tls.Port,
+					secretName))
+				dnsNames = append(dnsNames, string(hostname))
+			}
+			if len(dnsNames) == 0 {
+				continue
+			}
+			if len(listeners)+len(routeListeners) > available {
+				skippedRoutes = append(skippedRoutes, fmt.Sprintf("%s/%s", route.Namespace, route.Name))
+				continue
+			}
+			listeners = append(listeners, routeListeners...)
+			certificates[name] = newCertificate(r.k8sGateway.Namespace, name, secretName, dnsNames, tls.IssuerRef)
+		}
+	}
+
+	if err := r.updateFunctionHttpsListeners(gateway, listeners); err != nil {
+		return err
+	}
+
+	if len(skippedRoutes) > 0 {
+		defer r.updateGatewayStatus(gateway.Status.DeepCopy(), gateway)
+		gateway.Status.Conditions = append(gateway.Status.Conditions, networkingv1alpha1.Condition{
+			Type:   networkingv1alpha1.GatewayConditionFunctionListeners,
+			Status: metav1.ConditionFalse,
+			Reason: networkingv1alpha1.GatewayReasonListenerLimitExceeded,
+			Message: fmt.Sprintf("the k8s gateway has at most %d listeners, HTTPRoutes %s are not served over HTTPS",
+				networkingv1alpha1.MaxListeners, strings.Join(skippedRoutes, ", ")),
+		})
+		log.Info("The function listeners exceed the listener limit of k8s Gateway",
+			"namespace", r.k8sGateway.Namespace, "name", r.k8sGateway.Name, "routes", skippedRoutes)
+	}
+
+	return r.syncFunctionCertificates(gateway, r.k8sGateway.Namespace, certificates)
+}
+
+func newCertificate(
+	namespace string,
+	name string,
+	secretName string,
+	dnsNames []interface{},
+	issuerRef *networkingv1alpha1.IssuerRef) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetNamespace(namespace)
+	certificate.SetName(name)
+	certificate.Object["spec"] = map[string]interface{}{
+		"secretName": secretName,
+		"dnsNames":   dnsNames,
+		"issuerRef": map[string]interface{}{
+			"name":  issuerRef.Name,
+			"kind":  issuerRef.Kind,
+			"group": issuerRef.Group,
+		},
+	}
+	return certificate
+}
+
+func newFunctionHttpsListener(
+	name string,
+	hostname k8sgatewayapiv1beta1.Hostname,
+	port k8sgatewayapiv1beta1.PortNumber,
+	secretName string) k8sgatewayapiv1beta1.Listener {
+	namespaceFromAll := k8sgatewayapiv1beta1.NamespacesFromAll
+	mode := k8sgatewayapiv1beta1.TLSModeTerminate
+	return k8sgatewayapiv1beta1.Listener{
+		Name:     k8sgatewayapiv1beta1.SectionName(name),
+		Hostname: &hostname,
+		Port:     port,
+		Protocol: k8sgatewayapiv1beta1.HTTPSProtocolType,
+		TLS: &k8sgatewayapiv1beta1.GatewayTLSConfig{
+			Mode: &mode,
+			CertificateRefs: []k8sgatewayapiv1beta1.SecretObjectReference{
+				{Name: k8sgatewayapiv1beta1.ObjectName(secretName)},
+			},
+		},
+		AllowedRoutes: &k8sgatewayapiv1beta1.AllowedRoutes{
+			Namespaces: &k8sgatewayapiv1beta1.RouteNamespaces{
+				From: &namespaceFromAll,
+			},
+		},
+	}
+}
+
+// updateFunctionHttpsListeners replaces the function listeners of the k8s Gateway with the given listeners,
+// the other listeners are kept in order.
+func (r *GatewayReconciler) updateFunctionHttpsListeners(
+	gateway *networkingv1alpha1.Gateway,
+	functionListeners []k8sgatewayapiv1beta1.Listener) error {
+	log := r.Log.WithName("updateFunctionHttpsListeners")
+
+	sort.Slice(functionListeners, func(i, j int) bool {
+		return functionListeners[i].Name < functionListeners[j].Name
+	})
+
+	var listeners []k8sgatewayapiv1beta1.Listener
+	var oldFunctionListeners []k8sgatewayapiv1beta1.Listener
+	for _, listener := range r.k8sGateway.Spec.Listeners {
+		if strings.HasPrefix(string(listener.Name), networkingv1alpha1.FunctionHttpsListenerPrefix) {
+			oldFunctionListeners = append(oldFunctionListeners, listener)
+		} else {
+			listeners = append(listeners, listener)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(oldFunctionListeners, functionListeners) {
+		return nil
+	}
+
+	r.k8sGateway.Spec.Listeners = append(listeners, functionListeners...)
+	if err := r.Update(r.ctx, r.k8sGateway); err != nil {
+		log.Error(err, "Failed to update function listeners of k8s Gateway",
+			"namespace", r.k8sGateway.Namespace, "name", r.k8sGateway.Name, "gateway", gatewayLabelValue(gateway))
+		return err
+	}
+	log.Info("Updated function listeners of k8s Gateway",
+		"namespace", r.k8sGateway.Namespace, "name", r.k8sGateway.Name, "listeners", len(functionListeners))
+	return nil
+}
+
+// syncFunctionCertificates creates or updates the given certificates in the namespace,
+// and deletes the other certificates generated for the gateway.
+func (r *GatewayReconciler) syncFunctionCertificates(
+	gateway *networkingv1alpha1.Gateway,
+	namespace string,
+	certificates map[string]*unstructured.Unstructured) error {
+	log := r.Log.WithName("syncFunctionCertificates")
+	labels := map[string]string{networkingv1alpha1.GatewayLabel: gatewayLabelValue(gateway)}
+
+	existing := &unstructured.UnstructuredList{}
+	existing.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind + "List"))
+	if err := r.List(r.ctx, existing, client.InNamespace(namespace), client.MatchingLabels(labels)); err != nil {
+		// There is nothing to clean if cert-manager is not installed.
+		if meta.IsNoMatchError(err) && len(certificates) == 0 {
+			return nil
+		}
+		log.Error(err, "Failed to list certificates", "namespace", namespace)
+		return err
+	}
+
+	for index := range existing.Items {
+		item := &existing.Items[index]
+		if _, ok := certificates[item.GetName()]; ok {
+			continue
+		}
+		if err := r.Delete(r.ctx, item); util.IgnoreNotFound(err) != nil {
+			log.Error(err, "Failed to delete certificate", "namespace", namespace, "name", item.GetName())
+			return err
+		}
+		log.Info("Certificate deleted", "namespace", namespace, "name", item.GetName())
+	}
+
+	for name, certificate := range certificates {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(certificateGVK)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		op, err := controllerutil.CreateOrUpdate(r.ctx, r.Client, obj, func() error {
+			objLabels := obj.GetLabels()
+			if objLabels == nil {
+				objLabels = make(map[string]string)
+			}
+			for k, v := range labels {
+				objLabels[k] = v
+			}
+			obj.SetLabels(objLabels)
+			obj.Object["spec"] = certificate.Object["spec"]
+			return nil
+		})
+		if err != nil {
+			log.Error(err, "Failed to CreateOrUpdate certificate", "namespace", namespace, "name", name)
+			return err
+		}
+		log.V(1).Info(fmt.Sprintf("Certificate %s", op), "namespace", namespace, "name", name)
+	}
+
+	return nil
+}
+
+// findObjectsForHTTPRoute enqueues the gateways an HTTPRoute is attached to.
+func (r *GatewayReconciler) findObjectsForHTTPRoute(route client.Object) []reconcile.Request {
+	gateways := &networkingv1alpha1.GatewayList{}
+	if err := r.List(context.TODO(), gateways); err != nil {
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for index := range gateways.Items {
+		gateway := &gateways.Items[index]
+		// Only the certificates issued for each function depend on the HTTPRoutes.
+		if tls := gateway.Spec.TLS; tls == nil || tls.IssuerRef == nil ||
+			tls.CertificateMode != networkingv1alpha1.CertificateModePerFunction {
+			continue
+		}
+		if route.GetLabels()[gateway.Spec.HttpRouteLabelKey] == gatewayLabelValue(gateway) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: gateway.Namespace, Name: gateway.Name},
+			})
+		}
+	}
+	return requests
+}
+
+// syncReferenceGrants creates the ReferenceGrants which allow the k8s Gateway to use the certificates in other
+// namespaces, and deletes the grants which are no longer required. A nil k8s Gateway removes all the grants of the gateway.
+func (r *GatewayReconciler) syncReferenceGrants(
+	gateway *networkingv1alpha1.Gateway,
+	k8sGateway *k8sgatewayapiv1beta1.Gateway) error {
+	log := r.Log.WithName("syncReferenceGrants")
+
+	var grants []ofngateway.ReferenceGrant
+	if k8sGateway != nil {
+		grants = ofngateway.ReferenceGrantsForGateway(k8sGateway)
+	}
+	labels := map[string]string{networkingv1alpha1.GatewayLabel: gatewayLabelValue(gateway)}
+	if err := ofngateway.SyncReferenceGrants(r.ctx, r.Client, labels, grants); err != nil {
+		log.Error(err, "Failed to sync ReferenceGrants", "namespace", gateway.Namespace, "name", gateway.Name)
+		return err
+	}
+	return nil
+}
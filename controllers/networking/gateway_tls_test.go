@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package networking
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := networkingv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sgatewayapiv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	scheme.AddKnownTypeWithName(certificateGVK, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind+"List"), &unstructured.UnstructuredList{})
+	return scheme
+}
+
+func Test_reconcileFunctionCertificates_PerFunction(t *testing.T) {
+	scheme := newTestScheme(t)
+	gateway := &networkingv1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+		Spec: networkingv1alpha1.GatewaySpec{
+			Domain:            "ofn.io",
+			ClusterDomain:     "cluster.local",
+			HttpRouteLabelKey: "app.kubernetes.io/managed-by",
+			TLS: &networkingv1alpha1.GatewayTLS{
+				Port:            443,
+				IssuerRef:       &networkingv1alpha1.IssuerRef{Name: "issuer", Kind: "Issuer", Group: "cert-manager.io"},
+				CertificateMode: networkingv1alpha1.CertificateModePerFunction,
+			},
+		},
+	}
+
+	// The k8s Gateway has room for two function listeners only.
+	var listeners []k8sgatewayapiv1beta1.Listener
+	for i := 0; i < networkingv1alpha1.MaxListeners-2; i++ {
+		listeners = append(listeners, k8sgatewayapiv1beta1.Listener{
+			Name:     k8sgatewayapiv1beta1.SectionName(fmt.Sprintf("listener-%d", i)),
+			Port:     k8sgatewayapiv1beta1.PortNumber(8000 + i),
+			Protocol: k8sgatewayapiv1beta1.HTTPProtocolType,
+		})
+	}
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "gateway", Name: "gateway"},
+		Spec:       k8sgatewayapiv1beta1.GatewaySpec{Listeners: listeners},
+	}
+
+	newRoute := func(name string, hostnames ...k8sgatewayapiv1beta1.Hostname) *k8sgatewayapiv1beta1.HTTPRoute {
+		return &k8sgatewayapiv1beta1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      name,
+				Labels:    map[string]string{gateway.Spec.HttpRouteLabelKey: gatewayLabelValue(gateway)},
+			},
+			Spec: k8sgatewayapiv1beta1.HTTPRouteSpec{Hostnames: hostnames},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		gateway, k8sGateway,
+		newRoute("a", "a.default.ofn.io", "a.default.svc.cluster.local"),
+		newRoute("b", "b.default.ofn.io", "b.ofn.io"),
+		newRoute("c", "c.default.ofn.io"),
+	).Build()
+	r := &GatewayReconciler{Client: c, Log: logr.Discard(), Scheme: scheme, ctx: context.Background(), k8sGateway: k8sGateway}
+
+	if err := r.reconcileFunctionCertificates(gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := &k8sgatewayapiv1beta1.Gateway{}
+	if err := c.Get(r.ctx, client.ObjectKeyFromObject(k8sGateway), updated); err != nil {
+		t.Fatal(err)
+	}
+	var functionListeners []string
+	for _, listener := range updated.Spec.Listeners {
+		if strings.HasPrefix(string(listener.Name), networkingv1alpha1.FunctionHttpsListenerPrefix) {
+			functionListeners = append(functionListeners, string(*listener.Hostname))
+		}
+	}
+	if len(updated.Spec.Listeners) > networkingv1alpha1.MaxListeners {
+		t.Errorf("expected at most %d listeners, got %d", networkingv1alpha1.MaxListeners, len(updated.Spec.Listeners))
+	}
+	if want := "a.default.ofn.io,c.default.ofn.io"; strings.Join(functionListeners, ",") != want {
+		t.Errorf("expected function listeners %s, got %v", want, functionListeners)
+	}
+
+	var condition *networkingv1alpha1.Condition
+	for index := range gateway.Status.Conditions {
+		if gateway.Status.Conditions[index].Type == networkingv1alpha1.GatewayConditionFunctionListeners {
+			condition = &gateway.Status.Conditions[index]
+		}
+	}
+	if condition == nil || condition.Status != metav1.ConditionFalse || !strings.Contains(condition.Message, "default/b") {
+		t.Errorf("expected the skipped route to be reported, got %v", condition)
+	}
+
+	certificates := &unstructured.UnstructuredList{}
+	certificates.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind + "List"))
+	if err := c.List(r.ctx, certificates); err != nil {
+		t.Fatal(err)
+	}
+	if len(certificates.Items) != 2 {
+		t.Errorf("expected certificates of the served routes only, got %d", len(certificates.Items))
+	}
+}
+
+func Test_reconcileFunctionCertificates_Wildcard(t *testing.T) {
+	scheme := newTestScheme(t)
+	gateway := &networkingv1alpha1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openfunction", Name: "openfunction"},
+		Spec: networkingv1alpha1.GatewaySpec{
+			Domain:            "ofn.io",
+			ClusterDomain:     "cluster.local",
+			HttpRouteLabelKey: "app.kubernetes.io/managed-by",
+			TLS: &networkingv1alpha1.GatewayTLS{
+				Port:            443,
+				IssuerRef:       &networkingv1alpha1.IssuerRef{Name: "issuer", Kind: "Issuer", Group: "cert-manager.io"},
+				CertificateMode: networkingv1alpha1.CertificateModeWildcard,
+			},
+		},
+	}
+	// The function listeners and certificates of the PerFunction certificate mode are removed.
+	stale := newCertificate("gateway", "ofn-default-a", "ofn-default-a-tls", []interface{}{"a.default.ofn.io"}, gateway.Spec.TLS.IssuerRef)
+	stale.SetLabels(map[string]string{networkingv1alpha1.GatewayLabel: gatewayLabelValue(gateway)})
+	k8sGateway := &k8sgatewayapiv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "gateway", Name: "gateway"},
+		Spec: k8sgatewayapiv1beta1.GatewaySpec{Listeners: []k8sgatewayapiv1beta1.Listener{
+			{Name: networkingv1alpha1.DefaultHttpsListenerName, Port: 443, Protocol: k8sgatewayapiv1beta1.HTTPSProtocolType},
+			newFunctionHttpsListener(networkingv1alpha1.FunctionHttpsListenerPrefix+"default-a-0", "a.default.ofn.io", 443, "ofn-default-a-tls"),
+		}},
+	}
+	route := &k8sgatewayapiv1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "a",
+			Labels:    map[string]string{gateway.Spec.HttpRouteLabelKey: gatewayLabelValue(gateway)},
+		},
+		Spec: k8sgatewayapiv1beta1.HTTPRouteSpec{Hostnames: []k8sgatewayapiv1beta1.Hostname{"a-default.ofn.io"}},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gateway, k8sGateway, route, stale).Build()
+	r := &GatewayReconciler{Client: c, Log: logr.Discard(), Scheme: scheme, ctx: context.Background(), k8sGateway: k8sGateway}
+
+	if err := r.reconcileFunctionCertificates(gateway); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := &k8sgatewayapiv1beta1.Gateway{}
+	if err := c.Get(r.ctx, client.ObjectKeyFromObject(k8sGateway), updated); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Spec.Listeners) != 1 || updated.Spec.Listeners[0].Name != networkingv1alpha1.DefaultHttpsListenerName {
+		t.Errorf("expected the default HTTPS listener only, got %v", updated.Spec.Listeners)
+	}
+
+	certificates := &unstructured.UnstructuredList{}
+	certificates.SetGroupVersionKind(certificateGVK.GroupVersion().WithKind(certificateGVK.Kind + "List"))
+	if err := c.List(r.ctx, certificates); err != nil {
+		t.Fatal(err)
+	}
+	if len(certificates.Items) != 1 {
+		t.Fatalf("expected the wildcard certificate only, got %d certificates", len(certificates.Items))
+	}
+	certificate := certificates.Items[0]
+	if certificate.GetNamespace() != "gateway" || certificate.GetName() != gateway.WildcardCertificateName() {
+		t.Errorf("unexpected certificate %s/%s", certificate.GetNamespace(), certificate.GetName())
+	}
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	secretName, _, _ := unstructured.NestedString(certificate.Object, "spec", "secretName")
+	if !reflect.DeepEqual(dnsNames, []string{"*.ofn.io"}) || secretName != gateway.WildcardCertificateSecretName() {
+		t.Errorf("unexpected wildcard certificate for %v in secret %s", dnsNames, secretName)
+	}
+
+	// The wildcard certificate does not depend on the HTTPRoutes.
+	if requests := r.findObjectsForHTTPRoute(route); len(requests) != 0 {
+		t.Errorf("expected no requests for the HTTPRoute, got %v", requests)
+	}
+}
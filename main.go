@@ -25,6 +25,7 @@ import (
 	"time"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	resiliencyv1alpha1 "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
 	httpv1alpha1 "github.com/kedacore/http-add-on/operator/apis/http/v1alpha1"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	shipwrightv1alpha1 "github.com/shipwright-io/build/pkg/apis/build/v1alpha1"
@@ -40,7 +41,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
-	k8sgatewayapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8sgatewayapiv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	corev1beta1 "github.com/openfunction/apis/core/v1beta1"
 	corev1beta2 "github.com/openfunction/apis/core/v1beta2"
@@ -51,6 +52,7 @@ import (
 	networkingcontrollers "github.com/openfunction/controllers/networking"
 	"github.com/openfunction/pkg/core/builder"
 	"github.com/openfunction/pkg/core/serving"
+	ofngateway "github.com/openfunction/pkg/networking/gateway"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -63,10 +65,11 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = knserving.AddToScheme(scheme)
 	_ = componentsv1alpha1.AddToScheme(scheme)
+	_ = resiliencyv1alpha1.AddToScheme(scheme)
 	_ = kedav1alpha1.AddToScheme(scheme)
 	_ = httpv1alpha1.AddToScheme(scheme)
 	_ = openfunctionevent.AddToScheme(scheme)
-	_ = k8sgatewayapiv1alpha2.AddToScheme(scheme)
+	_ = k8sgatewayapiv1beta1.AddToScheme(scheme)
 	_ = networkingv1alpha1.AddToScheme(scheme)
 	_ = shipwrightv1alpha1.AddToScheme(scheme)
 	utilruntime.Must(corev1beta1.AddToScheme(scheme))
@@ -132,6 +135,7 @@ func main() {
 	eventBroadcaster.StartRecordingToSink(stopCh)
 	eventRecorder := eventBroadcaster.NewRecorder(mgr.GetScheme(), "openfunction")
 
+	var gatewayAPIVersion string
 	// Check if HPA v2 is supported in the cluster
 	if dc, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig()); err == nil {
 		hpagv := "autoscaling/v2"
@@ -139,12 +143,22 @@ func main() {
 			setupLog.Error(err, fmt.Sprintf("HPA v2 (%s) is not supported in the cluster", hpagv))
 			os.Exit(1)
 		}
+		// Detect the Gateway API version served by the cluster, the newest supported version is preferred.
+		// The routes of functions fall back to Ingress if Gateway API is not served.
+		if gatewayAPIVersion, err = ofngateway.DetectVersion(dc); err != nil {
+			setupLog.Error(err, "unable to detect the Gateway API version")
+			os.Exit(1)
+		} else if gatewayAPIVersion == "" {
+			setupLog.Info("Gateway API is not served in the cluster, the routes of functions are served by Ingress")
+		} else {
+			setupLog.Info("detected Gateway API version", "version", gatewayAPIVersion)
+		}
 	} else {
 		setupLog.Error(err, "unable to create discovery client")
 		os.Exit(1)
 	}
 
-	if err = core.NewFunctionReconciler(mgr, interval, eventRecorder).SetupWithManager(mgr); err != nil {
+	if err = core.NewFunctionReconciler(mgr, interval, gatewayAPIVersion, eventRecorder).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create function controller")
 		os.Exit(1)
 	}
@@ -174,9 +188,10 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&networkingcontrollers.GatewayReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Gateway"),
-		Scheme: mgr.GetScheme(),
+		Client:            ofngateway.NewClient(mgr.GetClient(), mgr.GetCache(), gatewayAPIVersion),
+		Log:               ctrl.Log.WithName("controllers").WithName("Gateway"),
+		Scheme:            mgr.GetScheme(),
+		GatewayAPIVersion: gatewayAPIVersion,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
 		os.Exit(1)
@@ -199,6 +214,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Gateway")
 			os.Exit(1)
 		}
+		if err = (&openfunctionevent.Trigger{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Trigger")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
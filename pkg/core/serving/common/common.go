@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
@@ -56,13 +57,14 @@ const (
 	OpenfunctionDaprServiceEnabled = "openfunction.io/enable-dapr"
 	DefaultDaprProxyImage          = "openfunction/dapr-proxy:v0.1.0"
 
-	DaprEnabled         = "dapr.io/enabled"
-	DaprAppID           = "dapr.io/app-id"
-	DaprLogAsJSON       = "dapr.io/log-as-json"
-	DaprAppProtocol     = "dapr.io/app-protocol"
-	DaprAppPort         = "dapr.io/app-port"
-	DaprMetricsPort     = "dapr.io/metrics-port"
-	DaprListenAddresses = "dapr.io/sidecar-listen-addresses"
+	DaprEnabled           = "dapr.io/enabled"
+	DaprAppID             = "dapr.io/app-id"
+	DaprLogAsJSON         = "dapr.io/log-as-json"
+	DaprAppProtocol       = "dapr.io/app-protocol"
+	DaprAppPort           = "dapr.io/app-port"
+	DaprMetricsPort       = "dapr.io/metrics-port"
+	DaprListenAddresses   = "dapr.io/sidecar-listen-addresses"
+	DaprAppMaxConcurrency = "dapr.io/app-max-concurrency"
 
 	DaprHostEnvVar      = "DAPR_HOST"
 	DaprSidecarIPEnvVar = "DAPR_SIDECAR_IP"
@@ -80,11 +82,17 @@ const (
 	hooksKey   = "hooks"
 	tracingKey = "tracing"
 
-	bindingsPrefix = "bindings"
-	pubsubPrefix   = "pubsub"
-	statePrefix    = "state"
+	bindingsPrefix      = "bindings"
+	pubsubPrefix        = "pubsub"
+	statePrefix         = "state"
+	secretStoresPrefix  = "secretstores"
+	configurationPrefix = "configuration"
+	lockPrefix          = "lock"
 )
 
+// CreateComponents creates or updates the Dapr components declared in the serving.
+// The components are named after the function and the component, so they are reused and
+// updated in place by the servings of the function instead of being recreated by each of them.
 func CreateComponents(
 	ctx context.Context,
 	logger logr.Logger,
@@ -94,6 +102,66 @@ func CreateComponents(
 	log := logger.WithName("CreateDaprComponents").
 		WithValues("Serving", fmt.Sprintf("%s/%s", s.Namespace, s.Name))
 
+	components := getDeclaredComponents(s)
+
+	var names []string
+	for name, daprComponent := range components {
+		component := &componentsv1alpha1.Component{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getComponentName(s, name),
+				Namespace: s.Namespace,
+			},
+		}
+
+		op, err := controllerutil.CreateOrUpdate(ctx, c, component, func() error {
+			if component.ResourceVersion != "" && !isComponentOwner(s, component) {
+				return fmt.Errorf("component %s already exists and is not managed by the serving", component.Name)
+			}
+
+			if component.Labels == nil {
+				component.Labels = map[string]string{}
+			}
+			component.Labels[OpenfunctionManaged] = "true"
+			if fn := GetFunctionName(s); fn != "" {
+				component.Labels[constants.FunctionLabel] = fn
+			} else {
+				component.Labels[ServingLabel] = s.Name
+			}
+
+			component.Spec = componentsv1alpha1.ComponentSpec{}
+			if daprComponent != nil {
+				component.Spec = *daprComponent
+			}
+			component.Scopes = []string{fmt.Sprintf("%s-%s", GetFunctionName(s), s.Namespace)}
+
+			// A component is shared by the servings of a function, it will be garbage collected
+			// once all of them are deleted.
+			return controllerutil.SetOwnerReference(s, component, scheme)
+		})
+		if err != nil {
+			log.Error(err, "Failed to CreateOrUpdate Dapr Component", "Component", name)
+			return err
+		}
+
+		names = append(names, component.Name)
+		log.V(1).Info("Component reconciled", "Component", component.Name, "Operation", op)
+	}
+
+	if len(names) > 0 {
+		sort.Strings(names)
+		s.Status.ResourceRef[daprComponentKey] = strings.Join(names, ",")
+	}
+
+	if err := scopeSharedComponents(ctx, log, c, s); err != nil {
+		log.Error(err, "Failed to scope shared Dapr Components")
+		return err
+	}
+
+	return nil
+}
+
+// getDeclaredComponents returns the specs of the components declared in the serving, keyed by `<type>-<name>`.
+func getDeclaredComponents(s *openfunction.Serving) map[string]*componentsv1alpha1.ComponentSpec {
 	components := map[string]*componentsv1alpha1.ComponentSpec{}
 	for name, component := range s.Spec.Bindings {
 		components[bindingsPrefix+"-"+name] = component.DeepCopy()
@@ -104,49 +172,51 @@ func CreateComponents(
 	}
 
 	for name, component := range s.Spec.States {
-		if component.Spec != nil {
+		if component != nil && component.Spec != nil {
 			components[statePrefix+"-"+name] = component.Spec.DeepCopy()
 		}
 	}
 
-	value := ""
-	for name, daprComponent := range components {
-		dc := daprComponent.DeepCopy()
-		component := &componentsv1alpha1.Component{
-			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: fmt.Sprintf("%s-%s-", s.Name, name),
-				Namespace:    s.Namespace,
-				Labels: map[string]string{
-					OpenfunctionManaged: "true",
-					ServingLabel:        s.Name,
-				},
-			},
-			Scopes: []string{fmt.Sprintf("%s-%s", GetFunctionName(s), s.Namespace)},
-		}
+	for name, component := range s.Spec.SecretStores {
+		components[secretStoresPrefix+"-"+name] = component.DeepCopy()
+	}
 
-		if dc != nil {
-			component.Spec = *dc
-		}
+	for name, component := range s.Spec.Configurations {
+		components[configurationPrefix+"-"+name] = component.DeepCopy()
+	}
 
-		if err := controllerutil.SetControllerReference(s, component, scheme); err != nil {
-			log.Error(err, "Failed to SetControllerReference", "Component", name)
-			return err
-		}
+	for name, component := range s.Spec.Locks {
+		components[lockPrefix+"-"+name] = component.DeepCopy()
+	}
 
-		if err := c.Create(ctx, component); err != nil {
-			log.Error(err, "Failed to Create Dapr Component", "Component", name)
-			return err
-		}
+	return components
+}
 
-		value = fmt.Sprintf("%s%s,", value, component.Name)
-		log.V(1).Info("Component Created", "Component", component.Name)
+// getComponentName returns the stable name of a component declared in the serving,
+// `<function>-<type>-<name>`, or `<serving>-<type>-<name>` if the serving does not belong to a function.
+func getComponentName(s *openfunction.Serving, name string) string {
+	base := GetFunctionName(s)
+	if base == "" {
+		base = s.Name
 	}
 
-	if value != "" {
-		s.Status.ResourceRef[daprComponentKey] = strings.TrimSuffix(value, ",")
+	return fmt.Sprintf("%s-%s", base, name)
+}
+
+// isComponentOwner returns true if the component is managed by OpenFunction for the function of the serving,
+// or for the serving itself if it does not belong to a function. The names of the components
+// declared by different functions may collide, e.g. the binding `b-pubsub-c` of function `a`
+// and the pubsub `c` of function `a-bindings-b`.
+func isComponentOwner(s *openfunction.Serving, component *componentsv1alpha1.Component) bool {
+	if component.Labels[OpenfunctionManaged] != "true" {
+		return false
 	}
 
-	return nil
+	if fn := GetFunctionName(s); fn != "" {
+		return component.Labels[constants.FunctionLabel] == fn
+	}
+
+	return component.Labels[ServingLabel] == s.Name
 }
 
 func CreateDaprProxy(
@@ -215,6 +285,7 @@ func CreateDaprProxy(
 	}
 
 	spec.Containers[0].Env = append(spec.Containers[0].Env, AddPodMetadataEnv(s.Namespace)...)
+	setDaprProxyPodSpec(s, spec)
 
 	template := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
@@ -224,7 +295,7 @@ func CreateDaprProxy(
 		Spec: *spec,
 	}
 
-	var replicas int32 = 1
+	replicas, _ := getDaprProxyReplicas(s)
 	version := constants.DefaultFunctionVersion
 	if s.Spec.Version != nil {
 		version = *s.Spec.Version
@@ -256,7 +327,12 @@ func CreateDaprProxy(
 	logger.V(1).Info("Proxy created", "Workload", deploy.GetName())
 
 	s.Status.ResourceRef[DaprProxyName] = deploy.GetName()
-	return nil
+
+	if err := createDaprProxyPDB(ctx, logger, c, scheme, s, deploy); err != nil {
+		return err
+	}
+
+	return createDaprProxyHPA(ctx, logger, c, scheme, s, deploy)
 }
 
 func CreateFunctionContextENV(ctx context.Context, logger logr.Logger, c client.Client, s *openfunction.Serving, cm map[string]string) ([]corev1.EnvVar, error) {
@@ -301,7 +377,7 @@ func CleanDaprProxy(
 		}
 	}
 
-	return nil
+	return cleanDaprProxyPDBAndHPA(ctx, logger, c, s)
 }
 
 func AddPodMetadataEnv(namespace string) []corev1.EnvVar {
@@ -339,7 +415,10 @@ func GetDaprServiceEnabled(s *openfunction.Serving) bool {
 		if len(s.Spec.Triggers.Dapr) != 0 ||
 			len(s.Spec.Triggers.Inputs) != 0 ||
 			s.Spec.Outputs != nil ||
-			s.Spec.States != nil {
+			s.Spec.States != nil ||
+			s.Spec.SecretStores != nil ||
+			s.Spec.Configurations != nil ||
+			s.Spec.Locks != nil {
 			return true
 		} else {
 			return false
@@ -371,6 +450,24 @@ func NeedCreateDaprSidecar(s *openfunction.Serving) bool {
 	return false
 }
 
+// GetMaxConcurrency returns the maximum number of requests or events processed concurrently by an instance of the function.
+func GetMaxConcurrency(s *openfunction.Serving) *int32 {
+	if s.Spec.Triggers == nil {
+		return nil
+	}
+	if s.Spec.Triggers.Http != nil {
+		return s.Spec.Triggers.Http.MaxConcurrency
+	}
+	return s.Spec.Triggers.MaxConcurrency
+}
+
+// AddMaxConcurrencyAnnotation limits the events delivered concurrently by the Dapr sidecar of the function.
+func AddMaxConcurrencyAnnotation(s *openfunction.Serving, annotations map[string]string) {
+	if maxConcurrency := GetMaxConcurrency(s); maxConcurrency != nil && NeedCreateDaprSidecar(s) {
+		annotations[DaprAppMaxConcurrency] = fmt.Sprintf("%d", *maxConcurrency)
+	}
+}
+
 func GetFunctionName(s *openfunction.Serving) string {
 	return s.Labels[constants.FunctionLabel]
 }
@@ -484,18 +581,19 @@ func GenOpenFunctionContextV1beta1(ctx context.Context, logger logr.Logger, c cl
 }
 
 func getRealComponentName(s *openfunction.Serving, componentName, componentType string) string {
-	resourceRefs := strings.Split(s.Status.ResourceRef[daprComponentKey], ",")
-	realName := componentName
-	for _, resourceRef := range resourceRefs {
-		prefix := fmt.Sprintf("%s-%s-%s-", s.Name, getComponentTypePrefix(componentType), componentName)
-		if strings.HasPrefix(resourceRef, prefix) {
-			if !strings.Contains(strings.TrimPrefix(resourceRef, prefix), "-") {
-				realName = resourceRef
-			}
+	key := fmt.Sprintf("%s-%s", getComponentTypePrefix(componentType), componentName)
+	if _, ok := getDeclaredComponents(s)[key]; !ok {
+		return componentName
+	}
+
+	name := getComponentName(s, key)
+	for _, resourceRef := range strings.Split(s.Status.ResourceRef[daprComponentKey], ",") {
+		if resourceRef == name {
+			return name
 		}
 	}
 
-	return realName
+	return componentName
 }
 
 func getComponentTypePrefix(componentType string) string {
@@ -600,6 +698,24 @@ func getComponentTypeFromServing(s *openfunction.Serving, name string) string {
 		}
 	}
 
+	if s.Spec.SecretStores != nil {
+		if item := s.Spec.SecretStores[name]; item != nil {
+			return item.Type
+		}
+	}
+
+	if s.Spec.Configurations != nil {
+		if item := s.Spec.Configurations[name]; item != nil {
+			return item.Type
+		}
+	}
+
+	if s.Spec.Locks != nil {
+		if item := s.Spec.Locks[name]; item != nil {
+			return item.Type
+		}
+	}
+
 	return ""
 }
 
@@ -728,6 +844,7 @@ func GenOpenFunctionContextV1beta2(ctx context.Context, logger logr.Logger, c cl
 			fnOutput := functionComponent{
 				ComponentName: getRealComponentName(s, output.Dapr.Name, componentType),
 				ComponentType: componentType,
+				Topic:         output.Dapr.Topic,
 				Metadata:      output.Dapr.Metadata,
 				Operation:     output.Dapr.Operation,
 			}
@@ -749,10 +866,34 @@ func GenOpenFunctionContextV1beta2(ctx context.Context, logger logr.Logger, c cl
 		}
 	}
 
+	fc.SecretStores = genFunctionComponents(s, s.Spec.SecretStores)
+	fc.Configurations = genFunctionComponents(s, s.Spec.Configurations)
+	fc.Locks = genFunctionComponents(s, s.Spec.Locks)
+
 	bs, _ := jsoniter.Marshal(fc)
 	return string(bs), nil
 }
 
+func genFunctionComponents(s *openfunction.Serving, components map[string]*componentsv1alpha1.ComponentSpec) map[string]*functionComponent {
+	if len(components) == 0 {
+		return nil
+	}
+
+	fcs := make(map[string]*functionComponent)
+	for name, component := range components {
+		componentType := ""
+		if component != nil {
+			componentType = component.Type
+		}
+		fcs[name] = &functionComponent{
+			ComponentName: getRealComponentName(s, name, componentType),
+			ComponentType: componentType,
+		}
+	}
+
+	return fcs
+}
+
 func getGlobalHooks(logger logr.Logger, cm map[string]string) ([]string, []string) {
 	hooksRaw := ""
 	// To compatible with v1beta1
@@ -0,0 +1,213 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/constants"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	// SharedComponentLabel marks an existing component as shared by the functions in its namespace.
+	// The functions referring to a shared component are added to its `scopes` automatically.
+	SharedComponentLabel = "openfunction.io/shared-component"
+	// ScopedFunctionsAnnotation records the functions added to the `scopes` of a shared component.
+	ScopedFunctionsAnnotation = "openfunction.io/scoped-functions"
+)
+
+// scopeSharedComponents grants the function access to the shared components it refers to.
+// If a shared component is restricted to some apps by `scopes`, the app id of the function is
+// added to it, and the app ids of the functions which no longer exist or whose servings no longer refer to it are removed from it.
+// Shared components without `scopes` are already available to all apps in the namespace.
+func scopeSharedComponents(ctx context.Context, log logr.Logger, c client.Client, s *openfunction.Serving) error {
+	fnName := GetFunctionName(s)
+	if fnName == "" {
+		return nil
+	}
+
+	// The retained and mirrored servings of the function keep running with the components they refer to.
+	servings := &openfunction.ServingList{}
+	if err := c.List(ctx, servings, client.InNamespace(s.Namespace), client.MatchingLabels{constants.FunctionLabel: fnName}); err != nil {
+		return err
+	}
+	referenced := map[string]bool{}
+	for _, name := range getReferencedComponents(s) {
+		referenced[name] = true
+	}
+	for index := range servings.Items {
+		if servings.Items[index].DeletionTimestamp != nil {
+			continue
+		}
+		for _, name := range getReferencedComponents(&servings.Items[index]) {
+			referenced[name] = true
+		}
+	}
+
+	components := &componentsv1alpha1.ComponentList{}
+	if err := c.List(ctx, components, client.InNamespace(s.Namespace), client.MatchingLabels{SharedComponentLabel: "true"}); err != nil {
+		return err
+	}
+
+	for index := range components.Items {
+		component := &components.Items[index]
+		if len(component.Scopes) == 0 {
+			continue
+		}
+
+		appID := fmt.Sprintf("%s-%s", fnName, s.Namespace)
+		recorded := false
+		var scopedFunctions []string
+		removed := map[string]bool{}
+		for _, fn := range strings.Split(component.Annotations[ScopedFunctionsAnnotation], ",") {
+			if fn == "" {
+				continue
+			}
+
+			if fn == fnName {
+				recorded = true
+				continue
+			}
+
+			exist, err := functionExists(ctx, c, s.Namespace, fn)
+			if err != nil {
+				return err
+			}
+
+			if exist {
+				scopedFunctions = append(scopedFunctions, fn)
+			} else {
+				removed[fmt.Sprintf("%s-%s", fn, s.Namespace)] = true
+			}
+		}
+
+		// Only the app ids added by OpenFunction are recorded, so that the scopes set by users are kept.
+		if recorded && !referenced[component.Name] {
+			removed[appID] = true
+		}
+
+		found := false
+		var scopes []string
+		for _, scope := range component.Scopes {
+			if removed[scope] {
+				continue
+			}
+			if scope == appID {
+				found = true
+			}
+			scopes = append(scopes, scope)
+		}
+		if referenced[component.Name] {
+			if !found {
+				scopes = append(scopes, appID)
+				recorded = true
+			}
+			if recorded {
+				scopedFunctions = append(scopedFunctions, fnName)
+			}
+		}
+		// Removing all scopes would make the component available to all apps in the namespace.
+		if len(scopes) == 0 {
+			continue
+		}
+		sort.Strings(scopedFunctions)
+
+		annotation := strings.Join(scopedFunctions, ",")
+		if reflect.DeepEqual(scopes, component.Scopes) && annotation == component.Annotations[ScopedFunctionsAnnotation] {
+			continue
+		}
+
+		component.Scopes = scopes
+		if component.Annotations == nil {
+			component.Annotations = map[string]string{}
+		}
+		component.Annotations[ScopedFunctionsAnnotation] = annotation
+		if err := c.Update(ctx, component); err != nil {
+			return err
+		}
+
+		log.V(1).Info("Shared Component scoped", "Component", component.Name, "Scopes", component.Scopes)
+	}
+
+	return nil
+}
+
+// getReferencedComponents returns the names of the existing components which the serving refers to
+// but does not declare.
+func getReferencedComponents(s *openfunction.Serving) []string {
+	refs := map[string]bool{}
+	addRef := func(ref *openfunction.DaprComponentRef) {
+		if ref != nil && getComponentTypeFromServing(s, ref.Name) == "" {
+			refs[ref.Name] = true
+		}
+	}
+
+	if s.Spec.Triggers != nil {
+		for _, trigger := range s.Spec.Triggers.Dapr {
+			if trigger != nil {
+				addRef(trigger.DaprComponentRef)
+			}
+		}
+
+		for _, input := range s.Spec.Triggers.Inputs {
+			if input != nil && input.Dapr != nil {
+				addRef(input.Dapr.DaprComponentRef)
+			}
+		}
+	}
+
+	for _, output := range s.Spec.Outputs {
+		if output != nil && output.Dapr != nil {
+			addRef(output.Dapr.DaprComponentRef)
+		}
+	}
+
+	for name, state := range s.Spec.States {
+		if state == nil || state.Spec == nil {
+			refs[name] = true
+		}
+	}
+
+	var names []string
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func functionExists(ctx context.Context, c client.Client, namespace, name string) (bool, error) {
+	fn := &openfunction.Function{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, fn); err != nil {
+		if util.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/go-logr/logr"
+	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/constants"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := componentsv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := openfunction.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func newTestServing(fn string, impl openfunction.ServingImpl) *openfunction.Serving {
+	return &openfunction.Serving{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      fn + "-serving",
+			UID:       "uid",
+			Labels:    map[string]string{constants.FunctionLabel: fn},
+		},
+		Spec: openfunction.ServingSpec{ServingImpl: impl},
+		Status: openfunction.ServingStatus{
+			ResourceRef: map[string]string{},
+		},
+	}
+}
+
+func Test_CreateComponents(t *testing.T) {
+	scheme := newTestScheme(t)
+	spec := &componentsv1alpha1.ComponentSpec{Type: "pubsub.kafka"}
+	existing := &componentsv1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      getComponentName(newTestServing("a", openfunction.ServingImpl{}), "bindings-b-pubsub-c"),
+			Labels: map[string]string{
+				OpenfunctionManaged:     "true",
+				constants.FunctionLabel: "a",
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		serving *openfunction.Serving
+		wantErr bool
+	}{
+		{
+			name: "same function",
+			serving: newTestServing("a", openfunction.ServingImpl{
+				Bindings: map[string]*componentsv1alpha1.ComponentSpec{"b-pubsub-c": spec},
+			}),
+		},
+		{
+			name: "colliding function",
+			serving: newTestServing("a-bindings-b", openfunction.ServingImpl{
+				Pubsub: map[string]*componentsv1alpha1.ComponentSpec{"c": spec},
+			}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing.DeepCopy()).Build()
+			err := CreateComponents(context.Background(), logr.Discard(), c, scheme, tt.serving)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateComponents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			component := &componentsv1alpha1.Component{}
+			if err := c.Get(context.Background(), client.ObjectKeyFromObject(existing), component); err != nil {
+				t.Fatal(err)
+			}
+			if component.Labels[constants.FunctionLabel] != "a" {
+				t.Errorf("component is taken over by function %s", component.Labels[constants.FunctionLabel])
+			}
+		})
+	}
+}
+
+func Test_CreateComponents_Servings(t *testing.T) {
+	scheme := newTestScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	ctx := context.Background()
+
+	newServing := func(name, brokers string) *openfunction.Serving {
+		s := newTestServing("foo", openfunction.ServingImpl{
+			Pubsub: map[string]*componentsv1alpha1.ComponentSpec{
+				"kafka": {
+					Type:     "pubsub.kafka",
+					Metadata: []componentsv1alpha1.MetadataItem{{Name: "brokers", Value: componentsv1alpha1.DynamicValue{JSON: v1.JSON{Raw: []byte(`"` + brokers + `"`)}}}},
+				},
+			},
+		})
+		s.Name = name
+		s.UID = types.UID(name)
+		return s
+	}
+
+	// The servings of a new version of the function update the component in place.
+	v1Serving := newServing("foo-v1", "kafka-v1:9092")
+	if err := CreateComponents(ctx, logr.Discard(), c, scheme, v1Serving); err != nil {
+		t.Fatal(err)
+	}
+	name := v1Serving.Status.ResourceRef[daprComponentKey]
+	if name != "foo-pubsub-kafka" {
+		t.Fatalf("expected the stable component name foo-pubsub-kafka, got %s", name)
+	}
+	v2Serving := newServing("foo-v2", "kafka-v2:9092")
+	if err := CreateComponents(ctx, logr.Discard(), c, scheme, v2Serving); err != nil {
+		t.Fatal(err)
+	}
+	if got := v2Serving.Status.ResourceRef[daprComponentKey]; got != name {
+		t.Errorf("serving %s uses component %s, want %s", v2Serving.Name, got, name)
+	}
+	if got := getRealComponentName(v2Serving, "kafka", "pubsub.kafka"); got != name {
+		t.Errorf("serving %s refers to component %s, want %s", v2Serving.Name, got, name)
+	}
+
+	component := &componentsv1alpha1.Component{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, component); err != nil {
+		t.Fatal(err)
+	}
+	if got := component.Spec.Metadata[0].Value.String(); got != "kafka-v2:9092" {
+		t.Errorf("component %s has brokers %s, want kafka-v2:9092", name, got)
+	}
+	if len(component.OwnerReferences) != 2 {
+		t.Errorf("expected the shared component to be owned by 2 servings, got %v", component.OwnerReferences)
+	}
+}
+
+func Test_scopeSharedComponents(t *testing.T) {
+	scheme := newTestScheme(t)
+	shared := &componentsv1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        "shared",
+			Labels:      map[string]string{SharedComponentLabel: "true"},
+			Annotations: map[string]string{ScopedFunctionsAnnotation: "deleted"},
+		},
+		Scopes: []string{"deleted-default", "user-app"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(shared).Build()
+	ctx := context.Background()
+
+	getShared := func() *componentsv1alpha1.Component {
+		component := &componentsv1alpha1.Component{}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(shared), component); err != nil {
+			t.Fatal(err)
+		}
+		return component
+	}
+
+	referring := newTestServing("foo", openfunction.ServingImpl{
+		Outputs: []*openfunction.Output{
+			{Dapr: &openfunction.DaprOutput{DaprComponentRef: &openfunction.DaprComponentRef{Name: "shared"}}},
+		},
+	})
+	if err := scopeSharedComponents(ctx, logr.Discard(), c, referring); err != nil {
+		t.Fatal(err)
+	}
+	component := getShared()
+	if want := []string{"user-app", "foo-default"}; !reflect.DeepEqual(component.Scopes, want) {
+		t.Errorf("expected scopes %v, got %v", want, component.Scopes)
+	}
+	if got := component.Annotations[ScopedFunctionsAnnotation]; got != "foo" {
+		t.Errorf("expected scoped functions foo, got %s", got)
+	}
+
+	// The scope is kept while a retained serving of the function still refers to the component.
+	retained := referring.DeepCopy()
+	retained.Name = "foo-retained"
+	if err := c.Create(ctx, retained); err != nil {
+		t.Fatal(err)
+	}
+	if err := scopeSharedComponents(ctx, logr.Discard(), c, newTestServing("foo", openfunction.ServingImpl{})); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"user-app", "foo-default"}; !reflect.DeepEqual(getShared().Scopes, want) {
+		t.Errorf("expected scopes %v to be kept for the retained serving, got %v", want, getShared().Scopes)
+	}
+
+	if err := c.Delete(ctx, retained); err != nil {
+		t.Fatal(err)
+	}
+	if err := scopeSharedComponents(ctx, logr.Discard(), c, newTestServing("foo", openfunction.ServingImpl{})); err != nil {
+		t.Fatal(err)
+	}
+	component = getShared()
+	if want := []string{"user-app"}; !reflect.DeepEqual(component.Scopes, want) {
+		t.Errorf("expected stale scopes to be removed, got %v", component.Scopes)
+	}
+	if got := component.Annotations[ScopedFunctionsAnnotation]; got != "" {
+		t.Errorf("expected no scoped functions, got %s", got)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/core"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	hostnameTopologyKey = "kubernetes.io/hostname"
+)
+
+// getDaprProxyReplicas returns the initial and the maximum number of the dapr-proxy replicas.
+func getDaprProxyReplicas(s *openfunction.Serving) (int32, int32) {
+	replicas := int32(1)
+	proxy := s.Spec.DaprProxy
+	if proxy == nil {
+		return replicas, replicas
+	}
+
+	if proxy.Replicas != nil {
+		replicas = *proxy.Replicas
+	}
+
+	if proxy.Autoscaling == nil {
+		return replicas, replicas
+	}
+
+	if proxy.Autoscaling.MinReplicas != nil && *proxy.Autoscaling.MinReplicas > replicas {
+		replicas = *proxy.Autoscaling.MinReplicas
+	}
+
+	return replicas, proxy.Autoscaling.MaxReplicas
+}
+
+// setDaprProxyPodSpec sets the probes, resources and the anti-affinity of the dapr-proxy pod.
+func setDaprProxyPodSpec(s *openfunction.Serving, spec *corev1.PodSpec) {
+	probe := &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromString(core.FunctionPort),
+			},
+		},
+		PeriodSeconds: 10,
+	}
+	spec.Containers[0].ReadinessProbe = probe.DeepCopy()
+	spec.Containers[0].LivenessProbe = probe.DeepCopy()
+	spec.Containers[0].LivenessProbe.InitialDelaySeconds = 10
+
+	proxy := s.Spec.DaprProxy
+	if proxy == nil {
+		return
+	}
+
+	if proxy.Resources != nil {
+		spec.Containers[0].Resources = *proxy.Resources
+	}
+
+	if _, maxReplicas := getDaprProxyReplicas(s); maxReplicas <= 1 {
+		return
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{ProxyLabel: s.Name},
+		},
+		TopologyKey: hostnameTopologyKey,
+	}
+	switch proxy.AntiAffinity {
+	case openfunction.DaprProxyAntiAffinityNone:
+		return
+	case openfunction.DaprProxyAntiAffinityRequired:
+		spec.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+			},
+		}
+	default:
+		spec.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{Weight: 100, PodAffinityTerm: term},
+				},
+			},
+		}
+	}
+}
+
+// createDaprProxyPDB creates a PodDisruptionBudget for the dapr-proxy if it can have more than one replica.
+func createDaprProxyPDB(
+	ctx context.Context,
+	logger logr.Logger,
+	c client.Client,
+	scheme *runtime.Scheme,
+	s *openfunction.Serving,
+	deploy *appsv1.Deployment) error {
+	if _, maxReplicas := getDaprProxyReplicas(s); maxReplicas <= 1 {
+		return nil
+	}
+
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploy.Name,
+			Namespace: s.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, c, pdb, func() error {
+		pdb.Labels = deploy.Labels
+		pdb.Spec = policyv1.PodDisruptionBudgetSpec{
+			Selector: deploy.Spec.Selector.DeepCopy(),
+		}
+		if minAvailable := s.Spec.DaprProxy.MinAvailable; minAvailable != nil {
+			pdb.Spec.MinAvailable = minAvailable
+		} else {
+			maxUnavailable := intstr.FromInt(1)
+			pdb.Spec.MaxUnavailable = &maxUnavailable
+		}
+		return controllerutil.SetControllerReference(s, pdb, scheme)
+	})
+	if err != nil {
+		logger.Error(err, "Failed to CreateOrUpdate proxy PodDisruptionBudget")
+		return err
+	}
+
+	logger.V(1).Info(fmt.Sprintf("Proxy PodDisruptionBudget %s", op), "PodDisruptionBudget", pdb.Name)
+	return nil
+}
+
+// createDaprProxyHPA creates a HorizontalPodAutoscaler for the dapr-proxy if autoscaling is enabled.
+func createDaprProxyHPA(
+	ctx context.Context,
+	logger logr.Logger,
+	c client.Client,
+	scheme *runtime.Scheme,
+	s *openfunction.Serving,
+	deploy *appsv1.Deployment) error {
+	if s.Spec.DaprProxy == nil || s.Spec.DaprProxy.Autoscaling == nil {
+		return nil
+	}
+
+	autoscaling := s.Spec.DaprProxy.Autoscaling
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploy.Name,
+			Namespace: s.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, c, hpa, func() error {
+		hpa.Labels = deploy.Labels
+		hpa.Spec = autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       deploy.Name,
+			},
+			MinReplicas: autoscaling.MinReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     autoscaling.Metrics,
+			Behavior:    autoscaling.Behavior,
+		}
+		return controllerutil.SetControllerReference(s, hpa, scheme)
+	})
+	if err != nil {
+		logger.Error(err, "Failed to CreateOrUpdate proxy HorizontalPodAutoscaler")
+		return err
+	}
+
+	logger.V(1).Info(fmt.Sprintf("Proxy HorizontalPodAutoscaler %s", op), "HorizontalPodAutoscaler", hpa.Name)
+	return nil
+}
+
+// IsDaprProxyReady checks whether the dapr-proxy deployment of the serving has ready replicas,
+// and whether the `<app id>-dapr` Service of the dapr-proxy has a ready endpoint backed by them to serve the function.
+// The Service is shared by the dapr-proxies of all servings of the function, so the endpoints of
+// the dapr-proxies of other servings are ignored.
+func IsDaprProxyReady(ctx context.Context, c client.Client, s *openfunction.Serving) (bool, error) {
+	proxy := &appsv1.Deployment{}
+	if err := c.Get(ctx, client.ObjectKey{Name: GetProxyName(s), Namespace: s.Namespace}, proxy); err != nil {
+		return false, err
+	}
+
+	for _, cond := range proxy.Status.Conditions {
+		switch cond.Type {
+		case appsv1.DeploymentProgressing:
+			switch cond.Status {
+			case corev1.ConditionUnknown, corev1.ConditionFalse:
+				return false, nil
+			}
+		case appsv1.DeploymentReplicaFailure:
+			switch cond.Status {
+			case corev1.ConditionUnknown, corev1.ConditionTrue:
+				return false, nil
+			}
+		}
+	}
+
+	if proxy.Status.ObservedGeneration < proxy.Generation || proxy.Status.ReadyReplicas == 0 {
+		return false, nil
+	}
+
+	// The Service is created by the Dapr sidecar injector, the function reaches the dapr-proxy through it.
+	slices := &discoveryv1.EndpointSliceList{}
+	if err := c.List(ctx, slices,
+		client.InNamespace(s.Namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: GetDaprProxyServiceName(s)}); err != nil {
+		return false, err
+	}
+
+	// The pods of the deployment are named after it.
+	podPrefix := proxy.Name + "-"
+	for _, slice := range slices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" ||
+				!strings.HasPrefix(endpoint.TargetRef.Name, podPrefix) {
+				continue
+			}
+			// A nil ready condition should be interpreted as ready.
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// GetDaprProxyServiceName returns the name of the Service which the Dapr sidecar injector creates for the dapr-proxy.
+func GetDaprProxyServiceName(s *openfunction.Serving) string {
+	return fmt.Sprintf("%s-%s-dapr", GetFunctionName(s), s.Namespace)
+}
+
+// GetFunctionNameOfDaprProxyService returns the name of the function whose dapr-proxy is served by the Service,
+// or an empty string if the Service is not the Service of a dapr-proxy.
+func GetFunctionNameOfDaprProxyService(namespace, service string) string {
+	suffix := fmt.Sprintf("-%s-dapr", namespace)
+	if !strings.HasSuffix(service, suffix) {
+		return ""
+	}
+
+	return strings.TrimSuffix(service, suffix)
+}
+
+func cleanDaprProxyPDBAndHPA(
+	ctx context.Context,
+	logger logr.Logger,
+	c client.Client,
+	s *openfunction.Serving) error {
+	pdbList := &policyv1.PodDisruptionBudgetList{}
+	hpaList := &autoscalingv2.HorizontalPodAutoscalerList{}
+	for _, list := range []client.ObjectList{pdbList, hpaList} {
+		if err := c.List(ctx, list, client.InNamespace(s.Namespace), client.MatchingLabels{ProxyLabel: s.Name}); err != nil {
+			return err
+		}
+	}
+
+	var objs []client.Object
+	for index := range pdbList.Items {
+		objs = append(objs, &pdbList.Items[index])
+	}
+	for index := range hpaList.Items {
+		objs = append(objs, &hpaList.Items[index])
+	}
+
+	for _, obj := range objs {
+		if strings.HasPrefix(obj.GetName(), s.Name) {
+			if err := c.Delete(context.Background(), obj); util.IgnoreNotFound(err) != nil {
+				return err
+			}
+			logger.V(1).Info("Delete", "name", obj.GetName())
+		}
+	}
+
+	return nil
+}
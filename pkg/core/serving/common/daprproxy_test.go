@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	policyv1 "k8s.io/api/policy/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+func Test_IsDaprProxyReady(t *testing.T) {
+	scheme := newTestScheme(t)
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServing("foo", openfunction.ServingImpl{})
+	s.Status.ResourceRef[DaprProxyName] = "foo-proxy"
+	proxy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo-proxy"},
+		Status:     appsv1.DeploymentStatus{AvailableReplicas: 1, ReadyReplicas: 1},
+	}
+	crashing := proxy.DeepCopy()
+	crashing.Status = appsv1.DeploymentStatus{}
+	newSlice := func(pod string, ready bool) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "foo-default-dapr-" + pod,
+				Labels:    map[string]string{discoveryv1.LabelServiceName: GetDaprProxyServiceName(s)},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+					TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: pod},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		objs  []client.Object
+		ready bool
+	}{
+		{name: "no endpoints", objs: []client.Object{proxy}},
+		{name: "endpoint not ready", objs: []client.Object{proxy, newSlice("foo-proxy-5d4f8-abcde", false)}},
+		{name: "endpoint ready", objs: []client.Object{proxy, newSlice("foo-proxy-5d4f8-abcde", true)}, ready: true},
+		{name: "only the proxy of a previous serving ready", objs: []client.Object{proxy, newSlice("foo-old-proxy-5d4f8-abcde", true)}},
+		{name: "no ready replicas", objs: []client.Object{crashing, newSlice("foo-old-proxy-5d4f8-abcde", true)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.objs...).Build()
+			ready, err := IsDaprProxyReady(context.Background(), c, s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ready != tt.ready {
+				t.Errorf("IsDaprProxyReady() = %v, want %v", ready, tt.ready)
+			}
+		})
+	}
+
+	if fn := GetFunctionNameOfDaprProxyService("default", GetDaprProxyServiceName(s)); fn != "foo" {
+		t.Errorf("expected function foo, got %s", fn)
+	}
+}
+
+func Test_createDaprProxyPDBAndHPA(t *testing.T) {
+	scheme := newTestScheme(t)
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	maxReplicas := int32(3)
+	s := newTestServing("foo", openfunction.ServingImpl{
+		DaprProxy: &openfunction.DaprProxy{
+			Autoscaling: &openfunction.DaprProxyAutoscaling{MaxReplicas: maxReplicas},
+		},
+	})
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "foo-proxy",
+			Labels:    map[string]string{ProxyLabel: s.Name},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{ProxyLabel: s.Name}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// The PodDisruptionBudget and the HorizontalPodAutoscaler are kept when a previous attempt created them.
+	for i := 0; i < 2; i++ {
+		if err := createDaprProxyPDB(context.Background(), logr.Discard(), c, scheme, s, deploy); err != nil {
+			t.Fatal(err)
+		}
+		if err := createDaprProxyHPA(context.Background(), logr.Discard(), c, scheme, s, deploy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pdb := &policyv1.PodDisruptionBudget{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(deploy), pdb); err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MaxUnavailable == nil || pdb.Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("unexpected PodDisruptionBudget spec %+v", pdb.Spec)
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(deploy), hpa); err != nil {
+		t.Fatal(err)
+	}
+	if hpa.Spec.MaxReplicas != maxReplicas || hpa.Spec.ScaleTargetRef.Name != deploy.Name {
+		t.Errorf("unexpected HorizontalPodAutoscaler spec %+v", hpa.Spec)
+	}
+}
@@ -90,13 +90,16 @@ type functionComponent struct {
 }
 
 type functionContextV1beta2 struct {
-	Name      string                        `json:"name"`
-	Version   string                        `json:"version"`
-	Triggers  *openfunction.Triggers        `json:"triggers,omitempty"`
-	Inputs    map[string]*functionComponent `json:"inputs,omitempty"`
-	Outputs   map[string]*functionComponent `json:"outputs,omitempty"`
-	States    map[string]*functionComponent `json:"states,omitempty"`
-	PreHooks  []string                      `json:"preHooks,omitempty"`
-	PostHooks []string                      `json:"postHooks,omitempty"`
-	Tracing   *openfunction.TracingConfig   `json:"tracing,omitempty"`
+	Name           string                        `json:"name"`
+	Version        string                        `json:"version"`
+	Triggers       *openfunction.Triggers        `json:"triggers,omitempty"`
+	Inputs         map[string]*functionComponent `json:"inputs,omitempty"`
+	Outputs        map[string]*functionComponent `json:"outputs,omitempty"`
+	States         map[string]*functionComponent `json:"states,omitempty"`
+	SecretStores   map[string]*functionComponent `json:"secretStores,omitempty"`
+	Configurations map[string]*functionComponent `json:"configurations,omitempty"`
+	Locks          map[string]*functionComponent `json:"locks,omitempty"`
+	PreHooks       []string                      `json:"preHooks,omitempty"`
+	PostHooks      []string                      `json:"postHooks,omitempty"`
+	Tracing        *openfunction.TracingConfig   `json:"tracing,omitempty"`
 }
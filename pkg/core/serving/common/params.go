@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	// ParamsHashAnnotation records the hash of the data of the Secrets and ConfigMaps referenced by
+	// `paramsFrom` and `envFrom`, a new serving will be created when any of them changes.
+	ParamsHashAnnotation = "openfunction.io/params-hash"
+	// ParamsSourceLabel marks the Secrets and ConfigMaps whose changes roll out the servings referencing them,
+	// only the ones labelled `true` are cached by the controller.
+	ParamsSourceLabel = "openfunction.io/params-source"
+
+	secretSourceKind    = "secret"
+	configMapSourceKind = "configmap"
+)
+
+// GetParamsEnv returns the environment variables generated from `params` and `paramsFrom` of the serving.
+func GetParamsEnv(s *openfunction.Serving) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, k := range sortedKeys(s.Spec.Params) {
+		env = append(env, corev1.EnvVar{
+			Name:  k,
+			Value: s.Spec.Params[k],
+		})
+	}
+
+	var names []string
+	for name := range s.Spec.ParamsFrom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		source := s.Spec.ParamsFrom[name]
+		if source == nil {
+			continue
+		}
+
+		env = append(env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef:    source.SecretKeyRef,
+				ConfigMapKeyRef: source.ConfigMapKeyRef,
+			},
+		})
+	}
+
+	return env
+}
+
+// GetParamsSources returns the keys of the Secrets and ConfigMaps referenced by `paramsFrom` and `envFrom`,
+// in the format of `<kind>,<name>`.
+func GetParamsSources(impl *openfunction.ServingImpl) []string {
+	if impl == nil {
+		return nil
+	}
+
+	sources := map[string]bool{}
+	for _, source := range impl.ParamsFrom {
+		if source == nil {
+			continue
+		}
+		if source.SecretKeyRef != nil {
+			sources[paramsSourceKey(secretSourceKind, source.SecretKeyRef.Name)] = true
+		}
+		if source.ConfigMapKeyRef != nil {
+			sources[paramsSourceKey(configMapSourceKind, source.ConfigMapKeyRef.Name)] = true
+		}
+	}
+
+	for _, source := range impl.EnvFrom {
+		if source.SecretRef != nil {
+			sources[paramsSourceKey(secretSourceKind, source.SecretRef.Name)] = true
+		}
+		if source.ConfigMapRef != nil {
+			sources[paramsSourceKey(configMapSourceKind, source.ConfigMapRef.Name)] = true
+		}
+	}
+
+	var keys []string
+	for k := range sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetParamsSourceKey returns the key used to index a Secret or ConfigMap referenced by `paramsFrom` or `envFrom`,
+// the object can also be the metadata of a Secret or ConfigMap.
+func GetParamsSourceKey(obj client.Object) string {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return paramsSourceKey(secretSourceKind, obj.GetName())
+	case *corev1.ConfigMap:
+		return paramsSourceKey(configMapSourceKind, obj.GetName())
+	case *metav1.PartialObjectMetadata:
+		switch o.GroupVersionKind() {
+		case corev1.SchemeGroupVersion.WithKind("Secret"):
+			return paramsSourceKey(secretSourceKind, obj.GetName())
+		case corev1.SchemeGroupVersion.WithKind("ConfigMap"):
+			return paramsSourceKey(configMapSourceKind, obj.GetName())
+		}
+	}
+	return ""
+}
+
+// GetParamsHash calculates the hash of the data referenced by `paramsFrom` and `envFrom`, only the SHA-256 digests
+// of the values are hashed, so that the data is not exposed by the hash.
+// Only the Secrets and ConfigMaps labelled ParamsSourceLabel are hashed. They are read from sources, which only
+// caches the labelled ones, and their metadata is read from metadata, whose watch triggers the rollout.
+// It returns an empty string if no Secret or ConfigMap is referenced.
+// A missing Secret or ConfigMap is treated as empty, so its creation will trigger a rollout.
+func GetParamsHash(ctx context.Context, sources client.Reader, metadata client.Reader, namespace string, impl *openfunction.ServingImpl) (string, error) {
+	if impl == nil || (len(impl.ParamsFrom) == 0 && len(impl.EnvFrom) == 0) {
+		return "", nil
+	}
+
+	data := map[string]map[string][]byte{}
+	for _, key := range GetParamsSources(impl) {
+		kind, name, _ := strings.Cut(key, ",")
+		d, err := getParamsSourceData(ctx, sources, metadata, namespace, kind, name)
+		if err != nil {
+			return "", err
+		}
+		data[key] = d
+	}
+
+	digest := func(key string, k string) string {
+		v, ok := data[key][k]
+		if !ok {
+			return ""
+		}
+		sum := sha256.Sum256(v)
+		return hex.EncodeToString(sum[:])
+	}
+
+	values := map[string]string{}
+	for name, source := range impl.ParamsFrom {
+		if source == nil {
+			continue
+		}
+		if ref := source.SecretKeyRef; ref != nil {
+			values[fmt.Sprintf("param/%s", name)] = digest(paramsSourceKey(secretSourceKind, ref.Name), ref.Key)
+		}
+		if ref := source.ConfigMapKeyRef; ref != nil {
+			values[fmt.Sprintf("param/%s", name)] = digest(paramsSourceKey(configMapSourceKind, ref.Name), ref.Key)
+		}
+	}
+
+	for index, source := range impl.EnvFrom {
+		var key string
+		if source.SecretRef != nil {
+			key = paramsSourceKey(secretSourceKind, source.SecretRef.Name)
+		} else if source.ConfigMapRef != nil {
+			key = paramsSourceKey(configMapSourceKind, source.ConfigMapRef.Name)
+		}
+		for k := range data[key] {
+			values[fmt.Sprintf("envFrom/%d/%s%s", index, source.Prefix, k)] = digest(key, k)
+		}
+	}
+
+	return util.Hash(values), nil
+}
+
+// getParamsSourceData returns the data of a Secret or ConfigMap, nil is returned if it is missing or not labelled
+// ParamsSourceLabel. An error is returned if the cached object is not in the version of its metadata yet,
+// so that the hash is calculated again.
+func getParamsSourceData(ctx context.Context, sources client.Reader, metadata client.Reader, namespace string, kind string, name string) (map[string][]byte, error) {
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	meta := &metav1.PartialObjectMetadata{}
+	var obj client.Object
+	if kind == secretSourceKind {
+		meta.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+		obj = &corev1.Secret{}
+	} else {
+		meta.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		obj = &corev1.ConfigMap{}
+	}
+
+	if err := metadata.Get(ctx, key, meta); err != nil {
+		return nil, util.IgnoreNotFound(err)
+	}
+	if meta.Labels[ParamsSourceLabel] != "true" {
+		return nil, nil
+	}
+
+	if err := sources.Get(ctx, key, obj); util.IgnoreNotFound(err) != nil {
+		return nil, err
+	}
+	if obj.GetResourceVersion() != meta.ResourceVersion {
+		return nil, fmt.Errorf("%s %s/%s is not synced yet", kind, namespace, name)
+	}
+
+	data := map[string][]byte{}
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		for k, v := range o.Data {
+			data[k] = v
+		}
+	case *corev1.ConfigMap:
+		for k, v := range o.Data {
+			data[k] = []byte(v)
+		}
+		for k, v := range o.BinaryData {
+			data[k] = v
+		}
+	}
+	return data, nil
+}
+
+func paramsSourceKey(kind, name string) string {
+	return fmt.Sprintf("%s,%s", kind, name)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
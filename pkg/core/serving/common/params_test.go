@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+func Test_GetParamsEnv(t *testing.T) {
+	optional := true
+	s := newTestServing("foo", openfunction.ServingImpl{
+		Params: map[string]string{"B": "b", "A": "a"},
+		ParamsFrom: map[string]*openfunction.ParamSource{
+			"TOKEN": {
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret"},
+					Key:                  "token",
+					Optional:             &optional,
+				},
+			},
+			"LEVEL": {
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "config"},
+					Key:                  "level",
+				},
+			},
+			"NIL": nil,
+		},
+	})
+
+	want := []corev1.EnvVar{
+		{Name: "A", Value: "a"},
+		{Name: "B", Value: "b"},
+		{
+			Name: "LEVEL",
+			ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: s.Spec.ParamsFrom["LEVEL"].ConfigMapKeyRef,
+			},
+		},
+		{
+			Name: "TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: s.Spec.ParamsFrom["TOKEN"].SecretKeyRef,
+			},
+		},
+	}
+	if got := GetParamsEnv(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetParamsEnv() = %v, want %v", got, want)
+	}
+}
+
+func Test_GetParamsSources(t *testing.T) {
+	impl := &openfunction.ServingImpl{
+		ParamsFrom: map[string]*openfunction.ParamSource{
+			"TOKEN": {
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret"},
+					Key:                  "token",
+				},
+			},
+			"LEVEL": {
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "config"},
+					Key:                  "level",
+				},
+			},
+		},
+		EnvFrom: []corev1.EnvFromSource{
+			{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "secret"}}},
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}},
+		},
+	}
+
+	want := []string{"configmap,config", "configmap,env", "secret,secret"}
+	if got := GetParamsSources(impl); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetParamsSources() = %v, want %v", got, want)
+	}
+	if got := GetParamsSources(nil); got != nil {
+		t.Errorf("GetParamsSources(nil) = %v, want nil", got)
+	}
+}
+
+func metadataOf(kind, name string) *metav1.PartialObjectMetadata {
+	obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(kind))
+	return obj
+}
+
+func Test_GetParamsSourceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  client.Object
+		want string
+	}{
+		{name: "secret", obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret"}}, want: "secret,secret"},
+		{name: "configmap", obj: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config"}}, want: "configmap,config"},
+		{name: "other", obj: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod"}}, want: ""},
+		{name: "secret metadata", obj: metadataOf("Secret", "secret"), want: "secret,secret"},
+		{name: "configmap metadata", obj: metadataOf("ConfigMap", "config"), want: "configmap,config"},
+		{name: "other metadata", obj: metadataOf("Pod", "pod"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetParamsSourceKey(tt.obj); got != tt.want {
+				t.Errorf("GetParamsSourceKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_GetParamsHash(t *testing.T) {
+	scheme := newTestScheme(t)
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	optional := true
+	impl := &openfunction.ServingImpl{
+		ParamsFrom: map[string]*openfunction.ParamSource{
+			"TOKEN": {
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret"},
+					Key:                  "token",
+				},
+			},
+			"LEVEL": {
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "config"},
+					Key:                  "level",
+					Optional:             &optional,
+				},
+			},
+		},
+	}
+	labelled := map[string]string{ParamsSourceLabel: "true"}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "secret", Labels: labelled},
+		Data:       map[string][]byte{"token": []byte("a"), "other": []byte("other")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	ctx := context.Background()
+	hash := func() string {
+		h, err := GetParamsHash(ctx, c, c, "default", impl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	if h, err := GetParamsHash(ctx, nil, nil, "default", &openfunction.ServingImpl{}); err != nil || h != "" {
+		t.Errorf("GetParamsHash() without references = %q, %v, want empty", h, err)
+	}
+
+	// The optional ConfigMap does not exist.
+	base := hash()
+	if base == "" {
+		t.Fatal("GetParamsHash() returned an empty hash")
+	}
+	if h := hash(); h != base {
+		t.Errorf("GetParamsHash() is not stable, got %s and %s", base, h)
+	}
+
+	// The changes of the metadata and of the keys which are not referenced do not change the hash.
+	secret.Annotations = map[string]string{"note": "rotated soon"}
+	secret.Data["other"] = []byte("changed")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h != base {
+		t.Error("GetParamsHash() changed with the keys which are not referenced")
+	}
+
+	// The change of the referenced key changes the hash.
+	secret.Data["token"] = []byte("b")
+	if err := c.Update(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+	updated := hash()
+	if updated == base {
+		t.Error("GetParamsHash() did not change with the referenced key of the Secret")
+	}
+
+	// The ConfigMap which is not labelled is not hashed.
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "config"},
+		Data:       map[string]string{"level": "debug"},
+	}
+	if err := c.Create(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h != updated {
+		t.Error("GetParamsHash() changed with the ConfigMap which is not labelled")
+	}
+
+	// Labelling the ConfigMap changes the hash.
+	config.Labels = labelled
+	if err := c.Update(ctx, config); err != nil {
+		t.Fatal(err)
+	}
+	if h := hash(); h == updated {
+		t.Error("GetParamsHash() did not change with the labelled ConfigMap")
+	}
+
+	// The hash is not calculated from the data older than the metadata.
+	stale := fake.NewClientBuilder().WithScheme(scheme).Build()
+	if _, err := GetParamsHash(ctx, stale, c, "default", impl); err == nil {
+		t.Error("GetParamsHash() did not fail with the data which is not synced")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	resiliencyv1alpha1 "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	daprResiliencyKey = "dapr.io/resiliency"
+
+	inbound  = "inbound"
+	outbound = "outbound"
+)
+
+// CreateResiliency creates a Dapr Resiliency resource scoped to the function's app-id from the
+// resiliency policies declared on the serving's inputs, outputs and states.
+// It must be called after `CreateComponents` so that the policies target the real component names.
+func CreateResiliency(
+	ctx context.Context,
+	logger logr.Logger,
+	c client.Client,
+	scheme *runtime.Scheme,
+	s *openfunction.Serving) error {
+	log := logger.WithName("CreateDaprResiliency").
+		WithValues("Serving", fmt.Sprintf("%s/%s", s.Namespace, s.Name))
+
+	spec, err := generateResiliencySpec(ctx, c, s)
+	if err != nil {
+		log.Error(err, "Failed to generate Dapr Resiliency")
+		return err
+	}
+
+	if spec == nil {
+		return nil
+	}
+
+	resiliency := &resiliencyv1alpha1.Resiliency{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getResiliencyName(s),
+			Namespace: s.Namespace,
+		},
+	}
+
+	op, err := controllerutil.CreateOrUpdate(ctx, c, resiliency, func() error {
+		if resiliency.Labels == nil {
+			resiliency.Labels = map[string]string{}
+		}
+		resiliency.Labels[OpenfunctionManaged] = "true"
+		resiliency.Labels[ServingLabel] = s.Name
+		resiliency.Spec = *spec
+		resiliency.Scopes = []string{fmt.Sprintf("%s-%s", GetFunctionName(s), s.Namespace)}
+		return controllerutil.SetControllerReference(s, resiliency, scheme)
+	})
+	if err != nil {
+		log.Error(err, "Failed to CreateOrUpdate Dapr Resiliency")
+		return err
+	}
+
+	s.Status.ResourceRef[daprResiliencyKey] = resiliency.Name
+	log.V(1).Info(fmt.Sprintf("Resiliency %s", op), "Resiliency", resiliency.Name)
+	return nil
+}
+
+// CleanResiliency deletes the Dapr Resiliency of the serving, it does nothing if Dapr Resiliency is not served.
+func CleanResiliency(ctx context.Context, logger logr.Logger, c client.Client, s *openfunction.Serving) error {
+	gvr := schema.GroupVersionResource{Group: "dapr.io", Version: "v1alpha1", Resource: "resiliencies"}
+	if _, err := c.RESTMapper().ResourcesFor(gvr); err != nil {
+		return nil
+	}
+
+	resiliency := &resiliencyv1alpha1.Resiliency{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      getResiliencyName(s),
+			Namespace: s.Namespace,
+		},
+	}
+	if err := c.Delete(ctx, resiliency); util.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	logger.V(1).Info("Delete Resiliency", "Resiliency", resiliency.Name)
+	return nil
+}
+
+func getResiliencyName(s *openfunction.Serving) string {
+	return fmt.Sprintf("%s-resiliency", s.Name)
+}
+
+func generateResiliencySpec(ctx context.Context, c client.Client, s *openfunction.Serving) (*resiliencyv1alpha1.ResiliencySpec, error) {
+	spec := &resiliencyv1alpha1.ResiliencySpec{
+		Targets: resiliencyv1alpha1.Targets{
+			Components: map[string]resiliencyv1alpha1.ComponentPolicyNames{},
+		},
+	}
+
+	addPolicy := func(name, componentType, direction string, policy *openfunction.ResiliencyPolicy) {
+		if policy == nil {
+			return
+		}
+
+		componentName := getRealComponentName(s, name, componentType)
+		policyName := fmt.Sprintf("%s-%s", componentName, direction)
+		names := resiliencyv1alpha1.PolicyNames{}
+		if policy.Timeout != "" {
+			if spec.Policies.Timeouts == nil {
+				spec.Policies.Timeouts = map[string]string{}
+			}
+			spec.Policies.Timeouts[policyName] = policy.Timeout
+			names.Timeout = policyName
+		}
+
+		if policy.Retry != nil {
+			if spec.Policies.Retries == nil {
+				spec.Policies.Retries = map[string]resiliencyv1alpha1.Retry{}
+			}
+			spec.Policies.Retries[policyName] = resiliencyv1alpha1.Retry{
+				Policy:      policy.Retry.Policy,
+				Duration:    policy.Retry.Duration,
+				MaxInterval: policy.Retry.MaxInterval,
+				MaxRetries:  policy.Retry.MaxRetries,
+			}
+			names.Retry = policyName
+		}
+
+		if policy.CircuitBreaker != nil {
+			if spec.Policies.CircuitBreakers == nil {
+				spec.Policies.CircuitBreakers = map[string]resiliencyv1alpha1.CircuitBreaker{}
+			}
+			spec.Policies.CircuitBreakers[policyName] = resiliencyv1alpha1.CircuitBreaker{
+				MaxRequests: policy.CircuitBreaker.MaxRequests,
+				Interval:    policy.CircuitBreaker.Interval,
+				Timeout:     policy.CircuitBreaker.Timeout,
+				Trip:        policy.CircuitBreaker.Trip,
+			}
+			names.CircuitBreaker = policyName
+		}
+
+		target := spec.Targets.Components[componentName]
+		if direction == inbound {
+			target.Inbound = names
+		} else {
+			target.Outbound = names
+		}
+		spec.Targets.Components[componentName] = target
+	}
+
+	if s.Spec.Triggers != nil {
+		for _, trigger := range s.Spec.Triggers.Dapr {
+			if trigger == nil || trigger.DaprComponentRef == nil || trigger.Resiliency == nil {
+				continue
+			}
+
+			componentType, err := getComponentType(ctx, c, s, trigger.Name, trigger.Type)
+			if err != nil {
+				return nil, err
+			}
+			addPolicy(trigger.Name, componentType, inbound, trigger.Resiliency)
+		}
+
+		for _, input := range s.Spec.Triggers.Inputs {
+			if input == nil || input.Dapr == nil || input.Dapr.DaprComponentRef == nil || input.Dapr.Resiliency == nil {
+				continue
+			}
+
+			componentType, err := getComponentType(ctx, c, s, input.Dapr.Name, input.Dapr.Type)
+			if err != nil {
+				return nil, err
+			}
+			addPolicy(input.Dapr.Name, componentType, inbound, input.Dapr.Resiliency)
+		}
+	}
+
+	for _, output := range s.Spec.Outputs {
+		if output == nil || output.Dapr == nil || output.Dapr.DaprComponentRef == nil || output.Dapr.Resiliency == nil {
+			continue
+		}
+
+		componentType, err := getComponentType(ctx, c, s, output.Dapr.Name, output.Dapr.Type)
+		if err != nil {
+			return nil, err
+		}
+		addPolicy(output.Dapr.Name, componentType, outbound, output.Dapr.Resiliency)
+	}
+
+	for name, state := range s.Spec.States {
+		if state == nil || state.Resiliency == nil {
+			continue
+		}
+
+		stateType, err := getStateType(ctx, c, s, name, state)
+		if err != nil {
+			return nil, err
+		}
+		addPolicy(name, stateType, outbound, state.Resiliency)
+	}
+
+	if len(spec.Targets.Components) == 0 {
+		return nil, nil
+	}
+
+	return spec, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	resiliencyv1alpha1 "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+func newResiliencyTestServing() *openfunction.Serving {
+	s := newTestServing("foo", openfunction.ServingImpl{
+		Bindings: map[string]*componentsv1alpha1.ComponentSpec{"kafka": {Type: "bindings.kafka"}},
+		Triggers: &openfunction.Triggers{
+			Dapr: []*openfunction.DaprTrigger{
+				{
+					DaprComponentRef: &openfunction.DaprComponentRef{Name: "kafka", Type: "bindings.kafka"},
+					Resiliency:       &openfunction.ResiliencyPolicy{Timeout: "5s"},
+				},
+				{
+					DaprComponentRef: &openfunction.DaprComponentRef{Name: "cron", Type: "bindings.cron"},
+				},
+			},
+		},
+		Outputs: []*openfunction.Output{
+			{
+				Dapr: &openfunction.DaprOutput{
+					DaprComponentRef: &openfunction.DaprComponentRef{Name: "sink", Type: "pubsub.kafka"},
+					Resiliency: &openfunction.ResiliencyPolicy{
+						Retry: &openfunction.RetryPolicy{Policy: "constant", Duration: "1s", MaxRetries: 3},
+						CircuitBreaker: &openfunction.CircuitBreakerPolicy{
+							MaxRequests: 1,
+							Timeout:     "30s",
+							Trip:        "consecutiveFailures > 5",
+						},
+					},
+				},
+			},
+		},
+	})
+	// The kafka binding is declared by the function, the sink pubsub is an existing component.
+	s.Status.ResourceRef[daprComponentKey] = getComponentName(s, "bindings-kafka")
+	return s
+}
+
+func Test_generateResiliencySpec(t *testing.T) {
+	s := newResiliencyTestServing()
+	got, err := generateResiliencySpec(context.Background(), nil, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kafka := s.Status.ResourceRef[daprComponentKey]
+
+	want := &resiliencyv1alpha1.ResiliencySpec{
+		Policies: resiliencyv1alpha1.Policies{
+			Timeouts: map[string]string{kafka + "-inbound": "5s"},
+			Retries: map[string]resiliencyv1alpha1.Retry{
+				"sink-outbound": {Policy: "constant", Duration: "1s", MaxRetries: 3},
+			},
+			CircuitBreakers: map[string]resiliencyv1alpha1.CircuitBreaker{
+				"sink-outbound": {MaxRequests: 1, Timeout: "30s", Trip: "consecutiveFailures > 5"},
+			},
+		},
+		Targets: resiliencyv1alpha1.Targets{
+			Components: map[string]resiliencyv1alpha1.ComponentPolicyNames{
+				kafka: {
+					Inbound: resiliencyv1alpha1.PolicyNames{Timeout: kafka + "-inbound"},
+				},
+				"sink": {
+					Outbound: resiliencyv1alpha1.PolicyNames{Retry: "sink-outbound", CircuitBreaker: "sink-outbound"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("generateResiliencySpec() = %+v, want %+v", got, want)
+	}
+
+	// No Resiliency is generated without policies.
+	s = newTestServing("foo", openfunction.ServingImpl{})
+	if got, err := generateResiliencySpec(context.Background(), nil, s); err != nil || got != nil {
+		t.Errorf("generateResiliencySpec() without policies = %v, %v, want nil", got, err)
+	}
+}
+
+func Test_CreateResiliency(t *testing.T) {
+	scheme := newTestScheme(t)
+	if err := resiliencyv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	mapper.Add(resiliencyv1alpha1.SchemeGroupVersion.WithKind("Resiliency"), meta.RESTScopeNamespace)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).Build()
+
+	s := newResiliencyTestServing()
+	// Creating the Resiliency repeatedly, e.g. on retries of the serving, updates the same resource.
+	for i := 0; i < 2; i++ {
+		if err := CreateResiliency(context.Background(), logr.Discard(), c, scheme, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := &resiliencyv1alpha1.ResiliencyList{}
+	if err := c.List(context.Background(), list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("got %d Resiliencies, want 1", len(list.Items))
+	}
+	resiliency := list.Items[0]
+	if resiliency.Name != "foo-serving-resiliency" || s.Status.ResourceRef[daprResiliencyKey] != resiliency.Name {
+		t.Errorf("unexpected Resiliency name %s, resource ref %s", resiliency.Name, s.Status.ResourceRef[daprResiliencyKey])
+	}
+	if !reflect.DeepEqual(resiliency.Scopes, []string{"foo-default"}) {
+		t.Errorf("unexpected Resiliency scopes %v", resiliency.Scopes)
+	}
+
+	if err := CleanResiliency(context.Background(), logr.Discard(), c, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.List(context.Background(), list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 0 {
+		t.Errorf("got %d Resiliencies after clean, want 0", len(list.Items))
+	}
+}
+
+func Test_CleanResiliency_NotServed(t *testing.T) {
+	scheme := newTestScheme(t)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{})
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).Build()
+
+	if err := CleanResiliency(context.Background(), logr.Discard(), c, newTestServing("foo", openfunction.ServingImpl{})); err != nil {
+		t.Errorf("CleanResiliency() without the Resiliency CRD = %v, want nil", err)
+	}
+}
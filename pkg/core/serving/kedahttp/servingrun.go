@@ -92,6 +92,11 @@ func (r *servingRun) Run(s *openfunction.Serving, cm map[string]string) error {
 		return err
 	}
 
+	if err := common.CreateResiliency(r.ctx, r.log, r.Client, r.scheme, s); err != nil {
+		log.Error(err, "Failed to create Dapr Resiliency")
+		return err
+	}
+
 	workload, err := r.generateWorkload(s, cm)
 	if err != nil {
 		log.Error(err, "Failed to generate workload")
@@ -201,6 +206,10 @@ func (r *servingRun) Clean(s *openfunction.Serving) error {
 		}
 	}
 
+	if err := common.CleanResiliency(r.ctx, log, r.Client, s); err != nil {
+		return err
+	}
+
 	if err := common.CleanDaprProxy(r.ctx, log, r.Client, s); err != nil {
 		return err
 	}
@@ -228,24 +237,10 @@ func (r *servingRun) Result(s *openfunction.Serving) (string, string, string, er
 	}
 
 	if common.NeedCreateDaprProxy(s) {
-		proxy := &appsv1.Deployment{}
-		if err := r.Get(r.ctx, client.ObjectKey{Name: common.GetProxyName(s), Namespace: s.Namespace}, proxy); err != nil {
+		if ready, err := common.IsDaprProxyReady(r.ctx, r.Client, s); err != nil {
 			return "", "", "", err
-		}
-
-		for _, cond := range proxy.Status.Conditions {
-			switch cond.Type {
-			case appsv1.DeploymentProgressing:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionFalse:
-					return "", "", "", nil
-				}
-			case appsv1.DeploymentReplicaFailure:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionTrue:
-					return "", "", "", nil
-				}
-			}
+		} else if !ready {
+			return "", "", "", nil
 		}
 	}
 
@@ -290,6 +285,7 @@ func (r *servingRun) generateWorkload(s *openfunction.Serving, cm map[string]str
 	} else {
 		annotations[common.DaprEnabled] = "true"
 	}
+	common.AddMaxConcurrencyAnnotation(s, annotations)
 
 	spec := s.Spec.Template
 	if spec == nil {
@@ -335,14 +331,8 @@ func (r *servingRun) generateWorkload(s *openfunction.Serving, cm map[string]str
 		container.Env = append(container.Env, env...)
 	}
 
-	if s.Spec.Params != nil {
-		for k, v := range s.Spec.Params {
-			container.Env = append(container.Env, corev1.EnvVar{
-				Name:  k,
-				Value: v,
-			})
-		}
-	}
+	container.Env = append(container.Env, common.GetParamsEnv(s)...)
+	container.EnvFrom = append(container.EnvFrom, s.Spec.EnvFrom...)
 	container.Env = append(container.Env, common.AddPodMetadataEnv(s.Namespace)...)
 
 	if common.NeedCreateDaprProxy(s) {
@@ -475,6 +465,9 @@ func (r *servingRun) createScaler(s *openfunction.Serving, workload runtime.Obje
 	var targetPendingRequests int32 = 100 // Default to 100
 	if keda.HTTPScaledObject.TargetPendingRequests != nil {
 		targetPendingRequests = *keda.HTTPScaledObject.TargetPendingRequests
+	} else if maxConcurrency := common.GetMaxConcurrency(s); maxConcurrency != nil {
+		// The interceptor has no per-function limit, so scale out before an instance exceeds the concurrency.
+		targetPendingRequests = *maxConcurrency
 	}
 	var cooldownPeriod int32 = 300 // Default to 300
 	if keda.HTTPScaledObject.CooldownPeriod != nil {
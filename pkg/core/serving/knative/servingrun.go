@@ -24,7 +24,6 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -83,6 +82,11 @@ func (r *servingRun) Run(s *openfunction.Serving, cm map[string]string) error {
 		return err
 	}
 
+	if err := common.CreateResiliency(r.ctx, r.log, r.Client, r.scheme, s); err != nil {
+		log.Error(err, "Failed to create Dapr Resiliency")
+		return err
+	}
+
 	service, err := r.createService(s, cm)
 	if err != nil {
 		log.Error(err, "Failed to create knative Service")
@@ -137,6 +141,10 @@ func (r *servingRun) Clean(s *openfunction.Serving) error {
 		}
 	}
 
+	if err := common.CleanResiliency(r.ctx, log, r.Client, s); err != nil {
+		return err
+	}
+
 	if err := common.CleanDaprProxy(r.ctx, log, r.Client, s); err != nil {
 		return err
 	}
@@ -172,24 +180,10 @@ func (r *servingRun) Result(s *openfunction.Serving) (string, string, string, er
 	}
 
 	if common.NeedCreateDaprProxy(s) {
-		proxy := &appsv1.Deployment{}
-		if err := r.Get(r.ctx, client.ObjectKey{Name: common.GetProxyName(s), Namespace: s.Namespace}, proxy); err != nil {
+		if ready, err := common.IsDaprProxyReady(r.ctx, r.Client, s); err != nil {
 			return "", "", "", err
-		}
-
-		for _, cond := range proxy.Status.Conditions {
-			switch cond.Type {
-			case appsv1.DeploymentProgressing:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionFalse:
-					return "", "", "", nil
-				}
-			case appsv1.DeploymentReplicaFailure:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionTrue:
-					return "", "", "", nil
-				}
-			}
+		} else if !ready {
+			return "", "", "", nil
 		}
 	}
 
@@ -265,6 +259,7 @@ func (r *servingRun) createService(s *openfunction.Serving, cm map[string]string
 	} else {
 		annotations[common.DaprEnabled] = "true"
 	}
+	common.AddMaxConcurrencyAnnotation(s, annotations)
 
 	template := s.Spec.Template
 	if template == nil {
@@ -307,14 +302,8 @@ func (r *servingRun) createService(s *openfunction.Serving, cm map[string]string
 		container.Env = append(container.Env, env...)
 	}
 
-	if s.Spec.Params != nil {
-		for k, v := range s.Spec.Params {
-			container.Env = append(container.Env, corev1.EnvVar{
-				Name:  k,
-				Value: v,
-			})
-		}
-	}
+	container.Env = append(container.Env, common.GetParamsEnv(s)...)
+	container.EnvFrom = append(container.EnvFrom, s.Spec.EnvFrom...)
 	container.Env = append(container.Env, common.AddPodMetadataEnv(s.Namespace)...)
 
 	if common.NeedCreateDaprProxy(s) {
@@ -354,6 +343,10 @@ func (r *servingRun) createService(s *openfunction.Serving, cm map[string]string
 			containerConcurrency = &c
 		}
 	}
+	if maxConcurrency := common.GetMaxConcurrency(s); maxConcurrency != nil {
+		c := int64(*maxConcurrency)
+		containerConcurrency = &c
+	}
 	service := kservingv1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "serving.knative.dev/v1",
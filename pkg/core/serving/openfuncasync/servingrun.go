@@ -22,6 +22,7 @@ import (
 	"strings"
 
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	resiliencyv1alpha1 "github.com/dapr/dapr/pkg/apis/resiliency/v1alpha1"
 	"github.com/go-logr/logr"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -67,6 +68,10 @@ func Registry(rm meta.RESTMapper) []client.Object {
 		objs = append(objs, &componentsv1alpha1.Component{})
 	}
 
+	if _, err := rm.ResourcesFor(schema.GroupVersionResource{Group: "dapr.io", Version: "v1alpha1", Resource: "resiliencies"}); err == nil {
+		objs = append(objs, &resiliencyv1alpha1.Resiliency{})
+	}
+
 	return objs
 }
 
@@ -95,6 +100,11 @@ func (r *servingRun) Run(s *openfunction.Serving, cm map[string]string) error {
 		return err
 	}
 
+	if err := common.CreateResiliency(r.ctx, r.log, r.Client, r.scheme, s); err != nil {
+		log.Error(err, "Failed to create Dapr Resiliency")
+		return err
+	}
+
 	workload, err := r.generateWorkload(s, cm)
 	if err != nil {
 		log.Error(err, "Failed to create workload")
@@ -214,6 +224,10 @@ func (r *servingRun) Clean(s *openfunction.Serving) error {
 		}
 	}
 
+	if err := common.CleanResiliency(r.ctx, log, r.Client, s); err != nil {
+		return err
+	}
+
 	if err := common.CleanDaprProxy(r.ctx, log, r.Client, s); err != nil {
 		return err
 	}
@@ -243,24 +257,10 @@ func (r *servingRun) Result(s *openfunction.Serving) (string, string, string, er
 	}
 
 	if common.NeedCreateDaprProxy(s) {
-		proxy := &appsv1.Deployment{}
-		if err := r.Get(r.ctx, client.ObjectKey{Name: common.GetProxyName(s), Namespace: s.Namespace}, proxy); err != nil {
+		if ready, err := common.IsDaprProxyReady(r.ctx, r.Client, s); err != nil {
 			return "", "", "", err
-		}
-
-		for _, cond := range proxy.Status.Conditions {
-			switch cond.Type {
-			case appsv1.DeploymentProgressing:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionFalse:
-					return "", "", "", nil
-				}
-			case appsv1.DeploymentReplicaFailure:
-				switch cond.Status {
-				case corev1.ConditionUnknown, corev1.ConditionTrue:
-					return "", "", "", nil
-				}
-			}
+		} else if !ready {
+			return "", "", "", nil
 		}
 	}
 
@@ -316,6 +316,7 @@ func (r *servingRun) generateWorkload(s *openfunction.Serving, cm map[string]str
 	} else {
 		annotations[common.DaprEnabled] = "true"
 	}
+	common.AddMaxConcurrencyAnnotation(s, annotations)
 
 	spec := s.Spec.Template
 	if spec == nil {
@@ -376,14 +377,8 @@ func (r *servingRun) generateWorkload(s *openfunction.Serving, cm map[string]str
 		}...)
 	}
 
-	if s.Spec.Params != nil {
-		for k, v := range s.Spec.Params {
-			container.Env = append(container.Env, corev1.EnvVar{
-				Name:  k,
-				Value: v,
-			})
-		}
-	}
+	container.Env = append(container.Env, common.GetParamsEnv(s)...)
+	container.EnvFrom = append(container.EnvFrom, s.Spec.EnvFrom...)
 	container.Env = append(container.Env, common.AddPodMetadataEnv(s.Namespace)...)
 
 	if appended {
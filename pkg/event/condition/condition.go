@@ -0,0 +1,216 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package condition compiles and evaluates the subscriber conditions, transforms and correlation keys of a Trigger.
+//
+// A condition is a CEL expression which must evaluate to a bool, the following variables can be used in it
+// and in the expressions of transforms:
+//   - `<input name>`: bool, whether the event of the input has been received, e.g. `A && B`.
+//     It is only declared for the input names which are CEL identifiers and are not reserved.
+//   - `inputs`: map, whether the event of each input has been received, e.g. `inputs["input-a"] && B`.
+//   - `event`: map, the CloudEvent attributes of the event being handled, e.g. `event.type == "order.created"`.
+//   - `events`: map, the CloudEvent attributes of the last event of each input, e.g. `events.A.source == "orders"`
+//     or `events["input-a"].source == "orders"`.
+//
+// The trigger handler evaluates all of them. The conditions written before `inputs`, `event` and `events` were
+// declared only refer to the inputs, which were all declared as bool variables, including the inputs named after
+// the reserved variables. Such conditions are compiled in the legacy environment if they do not compile
+// in the current one, so that they keep working.
+package condition
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/google/cel-go/cel"
+)
+
+const (
+	EventVariable  = "event"
+	EventsVariable = "events"
+	InputsVariable = "inputs"
+)
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+	// keywords can not be used as the names of variables in CEL.
+	keywords = map[string]bool{
+		"true": true, "false": true, "null": true, "in": true,
+		"as": true, "break": true, "const": true, "continue": true, "else": true,
+		"for": true, "function": true, "if": true, "import": true, "let": true,
+		"loop": true, "package": true, "namespace": true, "return": true, "var": true, "void": true, "while": true,
+	}
+	variables = map[string]bool{EventVariable: true, EventsVariable: true, InputsVariable: true}
+)
+
+// IsInputVariable returns true if the input name can be used as a variable in the conditions,
+// the other inputs can only be referred through the `inputs` and `events` maps.
+func IsInputVariable(name string) bool {
+	return identifierRegexp.MatchString(name) && !keywords[name] && !variables[name]
+}
+
+// isLegacyInputVariable returns true if the input name is declared as a variable in the legacy environment.
+func isLegacyInputVariable(name string) bool {
+	return identifierRegexp.MatchString(name) && !keywords[name]
+}
+
+// NewEnv creates the CEL environment of the conditions of a Trigger with the given inputs.
+func NewEnv(inputs []string) (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.Variable(EventVariable, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(EventsVariable, cel.MapType(cel.StringType, cel.MapType(cel.StringType, cel.DynType))),
+		cel.Variable(InputsVariable, cel.MapType(cel.StringType, cel.BoolType)),
+	}
+
+	for _, input := range inputs {
+		if IsInputVariable(input) {
+			opts = append(opts, cel.Variable(input, cel.BoolType))
+		}
+	}
+
+	return cel.NewEnv(opts...)
+}
+
+// NewLegacyEnv creates the CEL environment of the conditions written before `inputs`, `event` and `events`
+// were declared, where the inputs are the only variables.
+func NewLegacyEnv(inputs []string) (*cel.Env, error) {
+	var opts []cel.EnvOption
+	for _, input := range inputs {
+		if isLegacyInputVariable(input) {
+			opts = append(opts, cel.Variable(input, cel.BoolType))
+		}
+	}
+
+	return cel.NewEnv(opts...)
+}
+
+// Compile parses and type-checks the condition in the environment, the condition must evaluate to a bool.
+func Compile(env *cel.Env, condition string) error {
+	_, err := compile(env, condition)
+	return err
+}
+
+func compile(env *cel.Env, condition string) (*cel.Ast, error) {
+	ast, issues := env.Compile(condition)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("must evaluate to bool, got %s", ast.OutputType())
+	}
+
+	return ast, nil
+}
+
+// IsLegacy returns true if the condition does not compile in the current environment of the inputs
+// but compiles in the legacy one.
+func IsLegacy(inputs []string, condition string) bool {
+	env, err := NewEnv(inputs)
+	if err != nil {
+		return false
+	}
+	if Compile(env, condition) == nil {
+		return false
+	}
+
+	legacyEnv, err := NewLegacyEnv(inputs)
+	if err != nil {
+		return false
+	}
+	return Compile(legacyEnv, condition) == nil
+}
+
+// Variables holds the values of the variables of a condition.
+type Variables struct {
+	// Inputs holds whether the event of each input has been received.
+	Inputs map[string]bool
+	// Event holds the attributes of the event being handled.
+	Event map[string]interface{}
+	// Events holds the attributes of the last event of each input which has been received.
+	Events map[string]map[string]interface{}
+}
+
+// Condition is a compiled condition evaluated by the trigger handler.
+type Condition struct {
+	program cel.Program
+	legacy  bool
+}
+
+// New compiles the condition in the current environment of the inputs, or in the legacy one
+// if the condition only compiles there.
+func New(inputs []string, condition string) (*Condition, error) {
+	env, err := NewEnv(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := false
+	ast, err := compile(env, condition)
+	if err != nil {
+		legacyEnv, envErr := NewLegacyEnv(inputs)
+		if envErr != nil {
+			return nil, envErr
+		}
+		var legacyErr error
+		if ast, legacyErr = compile(legacyEnv, condition); legacyErr != nil {
+			return nil, err
+		}
+		env = legacyEnv
+		legacy = true
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Condition{program: program, legacy: legacy}, nil
+}
+
+// Eval evaluates the condition with the variables.
+func (c *Condition) Eval(vars *Variables) (bool, error) {
+	out, _, err := c.program.Eval(activation(vars, c.legacy))
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("must evaluate to bool, got %v", out.Value())
+	}
+	return matched, nil
+}
+
+func activation(vars *Variables, legacy bool) map[string]interface{} {
+	values := map[string]interface{}{}
+	for name, received := range vars.Inputs {
+		if legacy && isLegacyInputVariable(name) || IsInputVariable(name) {
+			values[name] = received
+		}
+	}
+	if legacy {
+		return values
+	}
+
+	values[InputsVariable] = vars.Inputs
+	values[EventVariable] = vars.Event
+	events := vars.Events
+	if events == nil {
+		events = map[string]map[string]interface{}{}
+	}
+	values[EventsVariable] = events
+	return values
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// NewKeyEnv creates the CEL environment of the correlation keys, where `event` is the only variable.
+func NewKeyEnv() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable(EventVariable, cel.MapType(cel.StringType, cel.DynType)))
+}
+
+// CompileKey parses and type-checks the expression of the correlation key of an event, which must evaluate to a string.
+func CompileKey(expr string) error {
+	env, err := NewKeyEnv()
+	if err != nil {
+		return err
+	}
+
+	_, err = compileString(env, expr)
+	return err
+}
+
+// Key is a compiled correlation key evaluated by the trigger handler.
+type Key struct {
+	program cel.Program
+}
+
+// NewKey compiles the expression of a correlation key.
+func NewKey(expr string) (*Key, error) {
+	env, err := NewKeyEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, err := compileString(env, expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %q: %s", expr, err.Error())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{program: program}, nil
+}
+
+// Eval returns the correlation key of the event.
+func (k *Key) Eval(event map[string]interface{}) (string, error) {
+	out, _, err := k.program.Eval(map[string]interface{}{EventVariable: event})
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := out.Value().(string)
+	if !ok {
+		return "", fmt.Errorf("key must evaluate to string, got %v", out.Value())
+	}
+	return key, nil
+}
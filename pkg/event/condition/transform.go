@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	dataAttribute            = "data"
+	dataContentTypeAttribute = "datacontenttype"
+	jsonContentType          = "application/json"
+)
+
+var (
+	// Refer to https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/spec.md#naming-conventions
+	attributeNameRegexp = regexp.MustCompile(`^[a-z0-9]{1,20}$`)
+	// The attributes which cannot be set or removed by a transform.
+	immutableAttributes = map[string]bool{"specversion": true, "data": true, "data_base64": true}
+	// The required attributes of a CloudEvent, which cannot be removed.
+	requiredAttributes = map[string]bool{"id": true, "source": true, "type": true}
+	valueType          = reflect.TypeOf(&structpb.Value{})
+)
+
+// ValidateAttributeName checks whether the CloudEvent attribute can be set by a transform.
+func ValidateAttributeName(name string) error {
+	if !attributeNameRegexp.MatchString(name) {
+		return fmt.Errorf("must match the regex %s", attributeNameRegexp.String())
+	}
+
+	if immutableAttributes[name] {
+		return fmt.Errorf("%s cannot be changed", name)
+	}
+
+	return nil
+}
+
+// ValidateRemovedAttribute checks whether the CloudEvent attribute can be removed by a transform.
+func ValidateRemovedAttribute(name string) error {
+	if err := ValidateAttributeName(name); err != nil {
+		return err
+	}
+
+	if requiredAttributes[name] {
+		return fmt.Errorf("%s is required by CloudEvents", name)
+	}
+
+	return nil
+}
+
+// CompileData parses and type-checks the expression of the new payload of an event.
+func CompileData(env *cel.Env, expr string) error {
+	_, err := compileData(env, expr)
+	return err
+}
+
+func compileData(env *cel.Env, expr string) (*cel.Ast, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	return ast, nil
+}
+
+// CompileAttribute parses and type-checks the expression of an attribute, which must evaluate to a string.
+func CompileAttribute(env *cel.Env, expr string) error {
+	_, err := compileString(env, expr)
+	return err
+}
+
+func compileString(env *cel.Env, expr string) (*cel.Ast, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	if ast.OutputType() != cel.StringType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("must evaluate to string, got %s", ast.OutputType())
+	}
+
+	return ast, nil
+}
+
+// Transform is a compiled transform applied by the trigger handler.
+type Transform struct {
+	data       cel.Program
+	attributes map[string]cel.Program
+	remove     []string
+}
+
+// NewTransform compiles the expressions of a transform in the environment of the inputs.
+func NewTransform(inputs []string, data string, attributes map[string]string, remove []string) (*Transform, error) {
+	env, err := NewEnv(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transform{attributes: map[string]cel.Program{}, remove: remove}
+	if data != "" {
+		ast, err := compileData(env, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data %q: %s", data, err.Error())
+		}
+		if t.data, err = env.Program(ast); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, expr := range attributes {
+		if err := ValidateAttributeName(name); err != nil {
+			return nil, fmt.Errorf("invalid attribute %s: %s", name, err.Error())
+		}
+		ast, err := compileString(env, expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute %s %q: %s", name, expr, err.Error())
+		}
+		if t.attributes[name], err = env.Program(ast); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range remove {
+		if err := ValidateRemovedAttribute(name); err != nil {
+			return nil, fmt.Errorf("invalid removed attribute %s: %s", name, err.Error())
+		}
+	}
+
+	return t, nil
+}
+
+// Apply returns a copy of the event of the variables reshaped by the transform,
+// all the expressions are evaluated with the attributes of the original event.
+func (t *Transform) Apply(vars *Variables) (map[string]interface{}, error) {
+	values := activation(vars, false)
+
+	e := map[string]interface{}{}
+	for k, v := range vars.Event {
+		e[k] = v
+	}
+
+	if t.data != nil {
+		out, _, err := t.data.Eval(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate data: %s", err.Error())
+		}
+		value, err := out.ConvertToNative(valueType)
+		if err != nil {
+			return nil, fmt.Errorf("data can not be encoded in JSON: %s", err.Error())
+		}
+		e[dataAttribute] = value.(*structpb.Value).AsInterface()
+		e[dataContentTypeAttribute] = jsonContentType
+	}
+
+	var names []string
+	for name := range t.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out, _, err := t.attributes[name].Eval(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate attribute %s: %s", name, err.Error())
+		}
+		value, ok := out.Value().(string)
+		if !ok {
+			return nil, fmt.Errorf("attribute %s must evaluate to string, got %v", name, out.Value())
+		}
+		e[name] = value
+	}
+
+	for _, name := range t.remove {
+		delete(e, name)
+	}
+
+	return e, nil
+}
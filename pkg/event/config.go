@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+)
+
+const (
+	// S3CursorLastModifiedKey is the key of the cursor ConfigMap which holds the last modified time
+	// of the newest published object in RFC 3339 format.
+	S3CursorLastModifiedKey = "lastModified"
+	// S3CursorKeysKey is the key of the cursor ConfigMap which holds the keys of the published objects
+	// modified shortly before the time of S3CursorLastModifiedKey, one object per line in the format
+	// of `<last modified time in RFC 3339 format> <key>`.
+	S3CursorKeysKey = "keys"
+
+	// S3AccessKeyIDEnv is the environment variable which passes the access key id to the handler.
+	S3AccessKeyIDEnv = "S3_ACCESS_KEY_ID"
+	// S3SecretAccessKeyEnv is the environment variable which passes the secret access key to the handler.
+	S3SecretAccessKeyEnv = "S3_SECRET_ACCESS_KEY"
+
+	// RabbitMQHostEnv is the environment variable which passes the AMQP connection string to the init container
+	// binding the queue of a RabbitMQ event source.
+	RabbitMQHostEnv = "RABBITMQ_HOST"
+)
+
+// EventSourceConfig is passed by the EventSource controller to the handler of an event source.
+type EventSourceConfig struct {
+	EventBusComponent  string `json:"eventBusComponent,omitempty"`
+	EventBusOutputName string `json:"eventBusOutputName,omitempty"`
+	EventBusTopic      string `json:"eventBusTopic,omitempty"`
+	SinkOutputName     string `json:"sinkOutputName,omitempty"`
+	LogLevel           string `json:"logLevel,omitempty"`
+	// Kubernetes is only set for the handler of a Kubernetes event source.
+	Kubernetes *KubernetesSourceConfig `json:"kubernetes,omitempty"`
+	// S3 is only set for the handler of an S3 event source.
+	S3 *S3SourceConfig `json:"s3,omitempty"`
+	// RabbitMQ is only set for the init container which binds the queue of a RabbitMQ event source.
+	RabbitMQ *RabbitMQQueueConfig `json:"rabbitmq,omitempty"`
+}
+
+// KubernetesSourceConfig tells the handler which objects to watch.
+type KubernetesSourceConfig struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Kind     string `json:"kind"`
+	// Namespace of the objects to watch, empty means all namespaces or a cluster-scoped resource.
+	Namespace     string   `json:"namespace,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+	FieldSelector string   `json:"fieldSelector,omitempty"`
+	EventTypes    []string `json:"eventTypes"`
+}
+
+// S3SourceConfig tells the handler which bucket to poll.
+type S3SourceConfig struct {
+	// Endpoint is the host and port of the object storage.
+	Endpoint        string   `json:"endpoint"`
+	Secure          bool     `json:"secure"`
+	Region          string   `json:"region,omitempty"`
+	Bucket          string   `json:"bucket"`
+	Prefix          string   `json:"prefix,omitempty"`
+	Suffix          string   `json:"suffix,omitempty"`
+	EventTypes      []string `json:"eventTypes"`
+	ForcePathStyle  bool     `json:"forcePathStyle"`
+	PollingInterval string   `json:"pollingInterval"`
+	// CursorConfigMap is the ConfigMap in the namespace of the handler where the handler persists its cursor,
+	// so that no event is lost or published twice when the handler restarts.
+	// The cursor is the last modified time of the newest published object under the key `lastModified`,
+	// and the keys of the published objects modified within an overlap window before it under the key `keys`.
+	// Only the objects modified within the overlap window are recorded, so the size of the cursor
+	// does not grow with the bucket.
+	CursorConfigMap string `json:"cursorConfigMap"`
+}
+
+// RabbitMQQueueConfig tells the init container of the handler of a RabbitMQ event source which queue to declare
+// and bind to the exchange, the queue is declared with the arguments the Dapr rabbitmq binding declares it with.
+type RabbitMQQueueConfig struct {
+	QueueName        string `json:"queueName"`
+	Exchange         string `json:"exchange"`
+	RoutingKey       string `json:"routingKey,omitempty"`
+	Durable          bool   `json:"durable,omitempty"`
+	DeleteWhenUnused bool   `json:"deleteWhenUnused,omitempty"`
+	TTLInSeconds     *int64 `json:"ttlInSeconds,omitempty"`
+}
+
+// TriggerConfig is passed by the Trigger controller to the handler of a trigger.
+type TriggerConfig struct {
+	EventBusComponent string                 `json:"eventBusComponent,omitempty"`
+	Inputs            []*Input               `json:"inputs,omitempty"`
+	Subscribers       map[string]*Subscriber `json:"subscribers,omitempty"`
+	LogLevel          string                 `json:"logLevel,omitempty"`
+	// Correlation and Window are applied by the handler before the conditions of the subscribers are evaluated.
+	Correlation *ofevent.CorrelationSpec `json:"correlation,omitempty"`
+	Window      *ofevent.WindowSpec      `json:"window,omitempty"`
+	// StateStore is the name of the state of the trigger function where the handler keeps the pending events.
+	StateStore string `json:"stateStore,omitempty"`
+}
+
+type Input struct {
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace,omitempty"`
+	EventSource string `json:"eventSource"`
+	Event       string `json:"event"`
+	// Topic is the topic of the event bus where the events of the input are published.
+	Topic string `json:"topic,omitempty"`
+}
+
+type Subscriber struct {
+	SinkOutputName string `json:"sinkOutputName,omitempty"`
+	// SinkOutputNames are the outputs of the Functions selected by the sink, the event is delivered to each of them.
+	SinkOutputNames      []string `json:"sinkOutputNames,omitempty"`
+	DLSinkOutputName     string   `json:"dlSinkOutputName,omitempty"`
+	EventBusOutputName   string   `json:"eventBusOutputName,omitempty"`
+	DLEventBusOutputName string   `json:"dlEventBusOutputName,omitempty"`
+	// Transform is applied to the event before it is delivered.
+	Transform *ofevent.Transform `json:"transform,omitempty"`
+	// Delivery defines how the handler retries the failed deliveries to the sink and the topic.
+	Delivery *ofevent.DeliverySpec `json:"delivery,omitempty"`
+}
+
+func (e *EventSourceConfig) EncodeConfig() (string, error) {
+	return encodeEnv(e)
+}
+
+func (e *TriggerConfig) EncodeConfig() (string, error) {
+	return encodeEnv(e)
+}
+
+func encodeEnv(config interface{}) (string, error) {
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	configEncode := base64.StdEncoding.EncodeToString(configBytes)
+	return configEncode, nil
+}
+
+func (e *EventSourceConfig) DecodeEnv(encodedConfig string) (*EventSourceConfig, error) {
+	var config *EventSourceConfig
+	configSpec, err := decodeConfig(encodedConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(configSpec, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (e *TriggerConfig) DecodeEnv(encodedConfig string) (*TriggerConfig, error) {
+	var config *TriggerConfig
+	configSpec, err := decodeConfig(encodedConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(configSpec, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func decodeConfig(encodedConfig string) ([]byte, error) {
+	if len(encodedConfig) > 0 {
+		configSpec, err := base64.StdEncoding.DecodeString(encodedConfig)
+		if err != nil {
+			return nil, err
+		}
+		return configSpec, nil
+	}
+	return nil, errors.New("string length is zero")
+}
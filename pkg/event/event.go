@@ -20,19 +20,19 @@ import (
 	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 )
 
 type OpenFunctionEventSource interface {
 	SetMetadata(key string, value interface{})
 	GetMetadata() map[string]interface{}
 	GenComponent(namespace string, name string) (*componentsv1alpha1.Component, error)
-	GenScaleOptions() (*ofcore.KedaScaledObject, *kedav1alpha1.ScaleTriggers)
+	GenScaleOptions() (*ofcore.ScaleOptions, *kedav1alpha1.ScaleTriggers)
 }
 
 type OpenFunctionEventBus interface {
 	SetMetadata(key string, value interface{})
 	GetMetadata() map[string]interface{}
 	GenComponent(namespace string, name string) (*componentsv1alpha1.Component, error)
-	GenScaleOptions(subjects []string) (*ofcore.KedaScaledObject, []*kedav1alpha1.ScaleTriggers)
+	GenScaleOptions(subjects []string) (*ofcore.ScaleOptions, []*kedav1alpha1.ScaleTriggers)
 }
@@ -24,7 +24,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
 	"github.com/openfunction/pkg/event"
 )
@@ -125,18 +125,18 @@ func (eb *EventBus) GenComponent(namespace string, name string) (*componentsv1al
 	return component, nil
 }
 
-func (eb *EventBus) GenScaleOptions(subjects []string) (*ofcore.KedaScaledObject, []*kedav1alpha1.ScaleTriggers) {
+func (eb *EventBus) GenScaleOptions(subjects []string) (*ofcore.ScaleOptions, []*kedav1alpha1.ScaleTriggers) {
 	if eb.Spec.ScaleOption == nil {
 		return nil, nil
 	}
-	scaledObject := &ofcore.KedaScaledObject{}
+	scaleOptions := &ofcore.ScaleOptions{Keda: &ofcore.KedaScaleOptions{ScaledObject: &ofcore.KedaScaledObject{}}}
 	triggers := []*kedav1alpha1.ScaleTriggers{}
 
 	// handle scaledObject
-	scaledObject.MinReplicaCount = eb.Spec.ScaleOption.MinReplicaCount
-	scaledObject.MaxReplicaCount = eb.Spec.ScaleOption.MaxReplicaCount
-	scaledObject.CooldownPeriod = eb.Spec.ScaleOption.CooldownPeriod
-	scaledObject.PollingInterval = eb.Spec.ScaleOption.PollingInterval
+	scaleOptions.MinReplicas = eb.Spec.ScaleOption.MinReplicaCount
+	scaleOptions.MaxReplicas = eb.Spec.ScaleOption.MaxReplicaCount
+	scaleOptions.Keda.ScaledObject.CooldownPeriod = eb.Spec.ScaleOption.CooldownPeriod
+	scaleOptions.Keda.ScaledObject.PollingInterval = eb.Spec.ScaleOption.PollingInterval
 
 	// handle trigger
 	triggerMD := map[string]string{}
@@ -158,5 +158,5 @@ func (eb *EventBus) GenScaleOptions(subjects []string) (*ofcore.KedaScaledObject
 		triggers = append(triggers, trigger)
 	}
 
-	return scaledObject, triggers
+	return scaleOptions, triggers
 }
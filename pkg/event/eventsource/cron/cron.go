@@ -24,7 +24,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
 	"github.com/openfunction/pkg/event"
 )
@@ -92,6 +92,6 @@ func (es *EventSource) GenComponent(namespace string, name string) (*componentsv
 	return component, nil
 }
 
-func (es *EventSource) GenScaleOptions() (*ofcore.KedaScaledObject, *kedav1alpha1.ScaleTriggers) {
+func (es *EventSource) GenScaleOptions() (*ofcore.ScaleOptions, *kedav1alpha1.ScaleTriggers) {
 	return nil, nil
 }
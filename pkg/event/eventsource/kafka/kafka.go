@@ -24,7 +24,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	ofcore "github.com/openfunction/apis/core/v1beta1"
+	ofcore "github.com/openfunction/apis/core/v1beta2"
 	ofevent "github.com/openfunction/apis/events/v1alpha1"
 	"github.com/openfunction/pkg/event"
 )
@@ -107,18 +107,18 @@ func (es *EventSource) GenComponent(namespace string, name string) (*componentsv
 	return component, nil
 }
 
-func (es *EventSource) GenScaleOptions() (*ofcore.KedaScaledObject, *kedav1alpha1.ScaleTriggers) {
+func (es *EventSource) GenScaleOptions() (*ofcore.ScaleOptions, *kedav1alpha1.ScaleTriggers) {
 	if es.Spec.ScaleOption == nil {
 		return nil, nil
 	}
-	scaledObject := &ofcore.KedaScaledObject{}
+	scaleOptions := &ofcore.ScaleOptions{Keda: &ofcore.KedaScaleOptions{ScaledObject: &ofcore.KedaScaledObject{}}}
 	trigger := &kedav1alpha1.ScaleTriggers{}
 
 	// handle scaledObject
-	scaledObject.MinReplicaCount = es.Spec.ScaleOption.MinReplicaCount
-	scaledObject.MaxReplicaCount = es.Spec.ScaleOption.MaxReplicaCount
-	scaledObject.CooldownPeriod = es.Spec.ScaleOption.CooldownPeriod
-	scaledObject.PollingInterval = es.Spec.ScaleOption.PollingInterval
+	scaleOptions.MinReplicas = es.Spec.ScaleOption.MinReplicaCount
+	scaleOptions.MaxReplicas = es.Spec.ScaleOption.MaxReplicaCount
+	scaleOptions.Keda.ScaledObject.CooldownPeriod = es.Spec.ScaleOption.CooldownPeriod
+	scaleOptions.Keda.ScaledObject.PollingInterval = es.Spec.ScaleOption.PollingInterval
 
 	// handle trigger
 	trigger.Type = ScaledObjectType
@@ -133,5 +133,5 @@ func (es *EventSource) GenScaleOptions() (*ofcore.KedaScaledObject, *kedav1alpha
 	if consumerGroup, exist := md["consumerGroup"]; exist {
 		trigger.Metadata["consumerGroup"] = consumerGroup.(string)
 	}
-	return scaledObject, trigger
+	return scaleOptions, trigger
 }
@@ -0,0 +1,262 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/go-logr/logr"
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	amqp "github.com/rabbitmq/amqp091-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ofcore "github.com/openfunction/apis/core/v1beta2"
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+const (
+	ComponentType    = "bindings.rabbitmq"
+	ComponentVersion = "v1"
+	ScaledObjectType = "rabbitmq"
+	DefaultScaleMode = "QueueLength"
+)
+
+type EventSource struct {
+	mu       sync.Mutex
+	log      logr.Logger
+	Spec     *ofevent.RabbitMQSpec
+	Metadata map[string]interface{}
+}
+
+func NewRabbitMQEventSource(log logr.Logger, spec *ofevent.RabbitMQSpec) *EventSource {
+	es := &EventSource{}
+
+	es.log = log.WithName("RabbitMQEventSource")
+
+	es.Spec = spec
+	es.init()
+	return es
+}
+
+func (es *EventSource) init() {
+	m := map[string]interface{}{}
+
+	// handle mandatory parameters
+	m["queueName"] = es.Spec.QueueName
+
+	// handle optional parameters
+	if es.Spec.Host != "" {
+		m["host"] = es.Spec.Host
+	}
+	if es.Spec.Durable != nil {
+		m["durable"] = *es.Spec.Durable
+	}
+	if es.Spec.DeleteWhenUnused != nil {
+		m["deleteWhenUnused"] = *es.Spec.DeleteWhenUnused
+	}
+	if es.Spec.PrefetchCount != nil {
+		m["prefetchCount"] = *es.Spec.PrefetchCount
+	}
+	if es.Spec.TTLInSeconds != nil {
+		m["ttlInSeconds"] = *es.Spec.TTLInSeconds
+	}
+
+	es.Metadata = m
+}
+
+func (es *EventSource) SetMetadata(key string, value interface{}) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.Metadata[key] = value
+}
+
+func (es *EventSource) GetMetadata() map[string]interface{} {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	return es.Metadata
+}
+
+func (es *EventSource) GenComponent(namespace string, name string) (*componentsv1alpha1.Component, error) {
+	if es.Spec.Host == "" && es.Spec.HostSecret == nil {
+		return nil, errors.New("one of host and hostSecret must be set")
+	}
+	if es.Spec.RoutingKey != nil && es.Spec.Exchange == nil {
+		return nil, errors.New("routingKey must be set with exchange")
+	}
+
+	component := &componentsv1alpha1.Component{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	component.Spec.Type = ComponentType
+	component.Spec.Version = ComponentVersion
+
+	metadataItems, err := event.ConvertMetadata(es.GetMetadata())
+	if err != nil {
+		es.log.Error(err, "failed to generate component", "namespace", namespace, "name", name)
+		return nil, err
+	}
+
+	// The connection string is resolved from the Secret by Dapr, so that it will not appear in the component.
+	if es.Spec.Host == "" {
+		metadataItems = append(metadataItems, componentsv1alpha1.MetadataItem{
+			Name: "host",
+			SecretKeyRef: componentsv1alpha1.SecretKeyRef{
+				Name: es.Spec.HostSecret.Name,
+				Key:  es.Spec.HostSecret.Key,
+			},
+		})
+	}
+	component.Spec.Metadata = metadataItems
+	return component, nil
+}
+
+// GenQueueConfig generates the config of the init container which binds the queue to the exchange,
+// nil is returned if no exchange is set, as the Dapr rabbitmq binding only consumes the queue.
+func (es *EventSource) GenQueueConfig() *event.RabbitMQQueueConfig {
+	if es.Spec.Exchange == nil {
+		return nil
+	}
+
+	config := &event.RabbitMQQueueConfig{
+		QueueName:        es.Spec.QueueName,
+		Exchange:         *es.Spec.Exchange,
+		Durable:          isTrue(es.Spec.Durable),
+		DeleteWhenUnused: isTrue(es.Spec.DeleteWhenUnused),
+		TTLInSeconds:     es.Spec.TTLInSeconds,
+	}
+	if es.Spec.RoutingKey != nil {
+		config.RoutingKey = *es.Spec.RoutingKey
+	}
+	return config
+}
+
+// BindQueue declares the queue and binds it to the exchange with the routing key, the host is the AMQP connection string.
+// It is run by the init container of the handler, so that the queue is bound before the Dapr rabbitmq binding
+// consumes it, and the queue is declared with the arguments the binding declares it with, otherwise the declaration
+// of the binding fails.
+func BindQueue(host string, config *event.RabbitMQQueueConfig) error {
+	conn, err := amqp.Dial(host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+
+	if _, err := ch.QueueDeclare(config.QueueName, config.Durable, config.DeleteWhenUnused, false, false, queueArgs(config)); err != nil {
+		return err
+	}
+	return ch.QueueBind(config.QueueName, config.RoutingKey, config.Exchange, false, nil)
+}
+
+// queueArgs returns the arguments of the queue, the message TTL is in milliseconds.
+func queueArgs(config *event.RabbitMQQueueConfig) amqp.Table {
+	args := amqp.Table{}
+	if config.TTLInSeconds != nil {
+		args["x-message-ttl"] = int(time.Duration(*config.TTLInSeconds) * time.Second / time.Millisecond)
+	}
+	return args
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// GenTriggerAuthentication generates the TriggerAuthentication which provides the `host` parameter of the KEDA
+// rabbitmq trigger from the Secret holding the connection string. Nil is returned if the connection string is not
+// kept in a Secret, or the `authRef` of the scale option refers to a TriggerAuthentication provided by users.
+func (es *EventSource) GenTriggerAuthentication(namespace string, name string) *kedav1alpha1.TriggerAuthentication {
+	if es.Spec.ScaleOption == nil || es.Spec.Host != "" || es.Spec.HostSecret == nil {
+		return nil
+	}
+	if option := es.Spec.ScaleOption.GenericScaleOption; option != nil && option.AuthRef != nil {
+		return nil
+	}
+
+	return &kedav1alpha1.TriggerAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: kedav1alpha1.TriggerAuthenticationSpec{
+			SecretTargetRef: []kedav1alpha1.AuthSecretTargetRef{
+				{
+					Parameter: "host",
+					Name:      es.Spec.HostSecret.Name,
+					Key:       es.Spec.HostSecret.Key,
+				},
+			},
+		},
+	}
+}
+
+// GenScaleOptions generates a KEDA rabbitmq trigger scaling on the length of the queue by default.
+// When the connection string is kept in a Secret, the `authenticationRef` of the trigger should be set to
+// the TriggerAuthentication generated by GenTriggerAuthentication unless `authRef` is set.
+func (es *EventSource) GenScaleOptions() (*ofcore.ScaleOptions, *kedav1alpha1.ScaleTriggers) {
+	if es.Spec.ScaleOption == nil {
+		return nil, nil
+	}
+	scaleOptions := &ofcore.ScaleOptions{Keda: &ofcore.KedaScaleOptions{ScaledObject: &ofcore.KedaScaledObject{}}}
+	trigger := &kedav1alpha1.ScaleTriggers{}
+
+	option := es.Spec.ScaleOption
+	// handle scaledObject
+	if option.GenericScaleOption != nil {
+		scaleOptions.MinReplicas = option.MinReplicaCount
+		scaleOptions.MaxReplicas = option.MaxReplicaCount
+		scaleOptions.Keda.ScaledObject.CooldownPeriod = option.CooldownPeriod
+		scaleOptions.Keda.ScaledObject.PollingInterval = option.PollingInterval
+	}
+
+	// handle trigger
+	trigger.Type = ScaledObjectType
+	trigger.Metadata = map[string]string{}
+	if option.GenericScaleOption != nil {
+		for k, v := range option.Metadata {
+			trigger.Metadata[k] = v
+		}
+		trigger.AuthenticationRef = option.AuthRef
+	}
+	trigger.Metadata["queueName"] = es.Spec.QueueName
+	trigger.Metadata["mode"] = DefaultScaleMode
+	if option.Mode != "" {
+		trigger.Metadata["mode"] = option.Mode
+	}
+	trigger.Metadata["value"] = option.Value
+	if option.Protocol != "" {
+		trigger.Metadata["protocol"] = option.Protocol
+	}
+	if option.VhostName != nil {
+		trigger.Metadata["vhostName"] = *option.VhostName
+	}
+	if es.Spec.Host != "" {
+		trigger.Metadata["host"] = es.Spec.Host
+	}
+	return scaleOptions, trigger
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rabbitmq
+
+import (
+	"reflect"
+	"testing"
+
+	componentsv1alpha1 "github.com/dapr/dapr/pkg/apis/components/v1alpha1"
+	"github.com/go-logr/logr"
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+func getMetadataItem(items []componentsv1alpha1.MetadataItem, name string) *componentsv1alpha1.MetadataItem {
+	for index := range items {
+		if items[index].Name == name {
+			return &items[index]
+		}
+	}
+	return nil
+}
+
+func Test_GenComponent(t *testing.T) {
+	es := NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{QueueName: "orders"})
+	if _, err := es.GenComponent("default", "orders"); err == nil {
+		t.Errorf("expected error if neither host nor hostSecret is set")
+	}
+
+	es = NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{Host: "amqp://rabbitmq:5672", QueueName: "orders"})
+	component, err := es.GenComponent("default", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if component.Spec.Type != ComponentType {
+		t.Errorf("expected component type %s, got %s", ComponentType, component.Spec.Type)
+	}
+	if item := getMetadataItem(component.Spec.Metadata, "host"); item == nil || string(item.Value.Raw) != `"amqp://rabbitmq:5672"` {
+		t.Errorf("unexpected host metadata %v", item)
+	}
+
+	// The exchange is bound by the init container of the handler, the Dapr binding does not support it.
+	exchange, routingKey := "orders", "created"
+	es = NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{
+		Host: "amqp://rabbitmq:5672", QueueName: "orders", Exchange: &exchange, RoutingKey: &routingKey,
+	})
+	component, err = es.GenComponent("default", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if getMetadataItem(component.Spec.Metadata, "exchange") != nil || getMetadataItem(component.Spec.Metadata, "routingKey") != nil {
+		t.Errorf("expected no exchange and routingKey metadata, got %v", component.Spec.Metadata)
+	}
+
+	es = NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{Host: "amqp://rabbitmq:5672", QueueName: "orders", RoutingKey: &routingKey})
+	if _, err := es.GenComponent("default", "orders"); err == nil {
+		t.Errorf("expected error if routingKey is set without exchange")
+	}
+
+	secret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "rabbitmq"}, Key: "host"}
+	es = NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{HostSecret: secret, QueueName: "orders"})
+	component, err = es.GenComponent("default", "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item := getMetadataItem(component.Spec.Metadata, "host"); item == nil ||
+		item.SecretKeyRef.Name != secret.Name || item.SecretKeyRef.Key != secret.Key || len(item.Value.Raw) != 0 {
+		t.Errorf("expected host metadata referring to the secret, got %v", item)
+	}
+}
+
+func Test_GenScaleOptions(t *testing.T) {
+	secret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "rabbitmq"}, Key: "host"}
+	tests := []struct {
+		name     string
+		spec     *ofevent.RabbitMQSpec
+		wantHost string
+		wantAuth bool
+	}{
+		{
+			name: "host",
+			spec: &ofevent.RabbitMQSpec{
+				Host:        "amqp://rabbitmq:5672",
+				QueueName:   "orders",
+				ScaleOption: &ofevent.RabbitMQScaleOption{Value: "10"},
+			},
+			wantHost: "amqp://rabbitmq:5672",
+		},
+		{
+			name: "hostSecret",
+			spec: &ofevent.RabbitMQSpec{
+				HostSecret:  secret,
+				QueueName:   "orders",
+				ScaleOption: &ofevent.RabbitMQScaleOption{Value: "10"},
+			},
+			wantAuth: true,
+		},
+		{
+			name: "hostSecret with authRef",
+			spec: &ofevent.RabbitMQSpec{
+				HostSecret: secret,
+				QueueName:  "orders",
+				ScaleOption: &ofevent.RabbitMQScaleOption{
+					GenericScaleOption: &ofevent.GenericScaleOption{AuthRef: &kedav1alpha1.ScaledObjectAuthRef{Name: "custom"}},
+					Value:              "10",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := NewRabbitMQEventSource(logr.Discard(), tt.spec)
+			_, trigger := es.GenScaleOptions()
+			if trigger.Type != ScaledObjectType || trigger.Metadata["queueName"] != "orders" || trigger.Metadata["mode"] != DefaultScaleMode {
+				t.Errorf("unexpected trigger %v", trigger)
+			}
+			if trigger.Metadata["host"] != tt.wantHost {
+				t.Errorf("expected host %q, got %q", tt.wantHost, trigger.Metadata["host"])
+			}
+
+			auth := es.GenTriggerAuthentication("default", "orders")
+			if (auth != nil) != tt.wantAuth {
+				t.Fatalf("expected TriggerAuthentication %v, got %v", tt.wantAuth, auth)
+			}
+			if auth == nil {
+				return
+			}
+			want := kedav1alpha1.AuthSecretTargetRef{Parameter: "host", Name: secret.Name, Key: secret.Key}
+			if len(auth.Spec.SecretTargetRef) != 1 || auth.Spec.SecretTargetRef[0] != want {
+				t.Errorf("expected secret target %v, got %v", want, auth.Spec.SecretTargetRef)
+			}
+		})
+	}
+}
+
+func Test_GenQueueConfig(t *testing.T) {
+	es := NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{Host: "amqp://rabbitmq:5672", QueueName: "orders"})
+	if config := es.GenQueueConfig(); config != nil {
+		t.Errorf("expected no queue binding without exchange, got %v", config)
+	}
+
+	exchange := "orders"
+	routingKey := "created"
+	durable := true
+	ttl := int64(60)
+	es = NewRabbitMQEventSource(logr.Discard(), &ofevent.RabbitMQSpec{
+		Host:         "amqp://rabbitmq:5672",
+		QueueName:    "orders",
+		Exchange:     &exchange,
+		RoutingKey:   &routingKey,
+		Durable:      &durable,
+		TTLInSeconds: &ttl,
+	})
+	want := &event.RabbitMQQueueConfig{QueueName: "orders", Exchange: "orders", RoutingKey: "created", Durable: true, TTLInSeconds: &ttl}
+	if config := es.GenQueueConfig(); !reflect.DeepEqual(config, want) {
+		t.Errorf("expected queue config %v, got %v", want, config)
+	}
+}
+
+func Test_queueArgs(t *testing.T) {
+	if args := queueArgs(&event.RabbitMQQueueConfig{QueueName: "orders"}); len(args) != 0 {
+		t.Errorf("expected no arguments, got %v", args)
+	}
+
+	ttl := int64(60)
+	args := queueArgs(&event.RabbitMQQueueConfig{QueueName: "orders", TTLInSeconds: &ttl})
+	if args["x-message-ttl"] != 60000 {
+		t.Errorf("expected message ttl 60000ms, got %v", args["x-message-ttl"])
+	}
+}
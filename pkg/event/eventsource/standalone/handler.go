@@ -0,0 +1,298 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package standalone implements the handler of the event sources which have no Dapr input binding,
+// such as the Kubernetes and S3 event sources. The handler watches the source by itself, converts the changes
+// into CloudEvents and publishes them to the outputs of the handler function through the Dapr sidecar.
+package standalone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/openfunction/pkg/constants"
+	"github.com/openfunction/pkg/event"
+)
+
+const (
+	// ConfigEnv holds the encoded EventSourceConfig of the handler.
+	ConfigEnv = "CONFIG"
+	// FunctionContextEnv holds the context of the handler function, which resolves the outputs to Dapr components.
+	FunctionContextEnv = "FUNC_CONTEXT"
+	// DaprGRPCPortEnv is set by the Dapr sidecar injector to the gRPC port of the sidecar.
+	DaprGRPCPortEnv = "DAPR_GRPC_PORT"
+	// PodNamespaceEnv is the namespace of the handler, where the cursor of the S3 event source is.
+	PodNamespaceEnv = "POD_NAMESPACE"
+
+	defaultDaprGRPCPort   = "50001"
+	cloudEventSpecVersion = "1.0"
+	cloudEventContentType = "application/cloudevents+json"
+	bindingsPrefix        = "bindings."
+	defaultOperation      = "create"
+)
+
+// publishBackoff retries a delivery for about a minute, the Dapr sidecar may not be ready when the handler starts.
+var publishBackoff = wait.Backoff{
+	Steps:    7,
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+}
+
+// Event is a CloudEvent in the structured mode, its data is encoded in JSON.
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            string      `json:"time,omitempty"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+func newEvent(id string, source string, eventType string, subject string, t time.Time, data interface{}) *Event {
+	return &Event{
+		SpecVersion:     cloudEventSpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            t.UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// Publisher delivers the events to the outputs of the handler.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Source watches the changes of a source and publishes them until the context is done.
+type Source interface {
+	Start(ctx context.Context) error
+}
+
+type output struct {
+	ComponentName string            `json:"componentName"`
+	ComponentType string            `json:"componentType"`
+	Uri           string            `json:"uri,omitempty"`
+	Operation     string            `json:"operation,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+type functionContext struct {
+	Outputs map[string]*output `json:"outputs,omitempty"`
+}
+
+// LoadConfig reads the config of the handler from the environment variables set by the controller.
+func LoadConfig() (*event.EventSourceConfig, error) {
+	encoded := os.Getenv(ConfigEnv)
+	if encoded == "" {
+		return nil, fmt.Errorf("%s is not set", ConfigEnv)
+	}
+
+	return (&event.EventSourceConfig{}).DecodeEnv(encoded)
+}
+
+// resolveOutputs returns the Dapr components of the event bus and sink outputs of the handler.
+func resolveOutputs(config *event.EventSourceConfig, encodedContext string) ([]*output, error) {
+	fc := &functionContext{}
+	if err := json.Unmarshal([]byte(encodedContext), fc); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", FunctionContextEnv, err.Error())
+	}
+
+	var outputs []*output
+	for _, name := range []string{config.EventBusOutputName, config.SinkOutputName} {
+		if name == "" {
+			continue
+		}
+		o, ok := fc.Outputs[name]
+		if !ok {
+			return nil, fmt.Errorf("output %s is not found in %s", name, FunctionContextEnv)
+		}
+		outputs = append(outputs, o)
+	}
+
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("the handler has neither an event bus nor a sink output")
+	}
+	return outputs, nil
+}
+
+// daprPublisher publishes the events to the pubsub and binding outputs through the Dapr sidecar.
+type daprPublisher struct {
+	client  runtimev1pb.DaprClient
+	outputs []*output
+}
+
+func (p *daprPublisher) Publish(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range p.outputs {
+		if strings.HasPrefix(o.ComponentType, bindingsPrefix) {
+			metadata := map[string]string{"Content-Type": cloudEventContentType}
+			for k, v := range o.Metadata {
+				metadata[k] = v
+			}
+			operation := o.Operation
+			if operation == "" {
+				operation = defaultOperation
+			}
+			if _, err := p.client.InvokeBinding(ctx, &runtimev1pb.InvokeBindingRequest{
+				Name:      o.ComponentName,
+				Data:      data,
+				Metadata:  metadata,
+				Operation: operation,
+			}); err != nil {
+				return fmt.Errorf("failed to deliver event %s to %s: %s", event.ID, o.ComponentName, err.Error())
+			}
+			continue
+		}
+
+		if _, err := p.client.PublishEvent(ctx, &runtimev1pb.PublishEventRequest{
+			PubsubName:      o.ComponentName,
+			Topic:           o.Uri,
+			Data:            data,
+			DataContentType: cloudEventContentType,
+			Metadata:        o.Metadata,
+		}); err != nil {
+			return fmt.Errorf("failed to publish event %s to %s: %s", event.ID, o.ComponentName, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// retryPublisher retries the failed deliveries, so that the events are not dropped while the sidecar is starting.
+type retryPublisher struct {
+	log       logr.Logger
+	publisher Publisher
+}
+
+func (p *retryPublisher) Publish(ctx context.Context, event *Event) error {
+	return retry.OnError(publishBackoff, func(err error) bool {
+		p.log.Error(err, "Failed to publish event, retrying", "id", event.ID)
+		return ctx.Err() == nil
+	}, func() error {
+		return p.publisher.Publish(ctx, event)
+	})
+}
+
+// appCallback lets the Dapr sidecar connect to the handler, the handler has no inputs or subscriptions.
+type appCallback struct {
+	runtimev1pb.UnimplementedAppCallbackServer
+}
+
+func (appCallback) ListTopicSubscriptions(context.Context, *emptypb.Empty) (*runtimev1pb.ListTopicSubscriptionsResponse, error) {
+	return &runtimev1pb.ListTopicSubscriptionsResponse{}, nil
+}
+
+func (appCallback) ListInputBindings(context.Context, *emptypb.Empty) (*runtimev1pb.ListInputBindingsResponse, error) {
+	return &runtimev1pb.ListInputBindingsResponse{}, nil
+}
+
+// Handler runs the source of the config and publishes its events.
+type Handler struct {
+	log    logr.Logger
+	config *event.EventSourceConfig
+	source Source
+}
+
+// NewHandler creates the handler of the source set in the config,
+// the events are published to the outputs resolved by the function context.
+func NewHandler(log logr.Logger, config *event.EventSourceConfig, restConfig *rest.Config) (*Handler, error) {
+	outputs, err := resolveOutputs(config, os.Getenv(FunctionContextEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	port := os.Getenv(DaprGRPCPortEnv)
+	if port == "" {
+		port = defaultDaprGRPCPort
+	}
+	conn, err := grpc.Dial(net.JoinHostPort("127.0.0.1", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	publisher := &retryPublisher{
+		log:       log,
+		publisher: &daprPublisher{client: runtimev1pb.NewDaprClient(conn), outputs: outputs},
+	}
+
+	h := &Handler{log: log, config: config}
+	switch {
+	case config.Kubernetes != nil:
+		client, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		h.source = newKubernetesSource(log, client, config.Kubernetes, publisher)
+	case config.S3 != nil:
+		client, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		s3, err := newS3Client(config.S3, os.Getenv(event.S3AccessKeyIDEnv), os.Getenv(event.S3SecretAccessKeyEnv))
+		if err != nil {
+			return nil, err
+		}
+		store := &s3CursorStore{client: client, namespace: os.Getenv(PodNamespaceEnv), name: config.S3.CursorConfigMap}
+		h.source = newS3Source(log, s3, store, publisher)
+	default:
+		return nil, fmt.Errorf("no source is set in %s", ConfigEnv)
+	}
+
+	return h, nil
+}
+
+// Run serves the Dapr sidecar and runs the source until the context is done.
+func (h *Handler) Run(ctx context.Context) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", constants.DefaultFuncPort))
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	runtimev1pb.RegisterAppCallbackServer(server, appCallback{})
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			h.log.Error(err, "Failed to serve the Dapr sidecar")
+		}
+	}()
+	defer server.GracefulStop()
+
+	return h.source.Start(ctx)
+}
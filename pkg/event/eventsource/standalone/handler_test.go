@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/openfunction/pkg/event"
+)
+
+type fakeDaprClient struct {
+	runtimev1pb.DaprClient
+	published []*runtimev1pb.PublishEventRequest
+	invoked   []*runtimev1pb.InvokeBindingRequest
+}
+
+func (c *fakeDaprClient) PublishEvent(_ context.Context, in *runtimev1pb.PublishEventRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.published = append(c.published, in)
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeDaprClient) InvokeBinding(_ context.Context, in *runtimev1pb.InvokeBindingRequest, _ ...grpc.CallOption) (*runtimev1pb.InvokeBindingResponse, error) {
+	c.invoked = append(c.invoked, in)
+	return &runtimev1pb.InvokeBindingResponse{}, nil
+}
+
+func Test_resolveOutputs(t *testing.T) {
+	fc := `{"outputs": {
+		"ebo-default": {"uri": "default-my-eventsource-cm", "componentName": "esw-es-kubernetes-cm-ebo-default", "componentType": "pubsub.natsstreaming"},
+		"so-es-sink": {"uri": "so-es-sink", "componentName": "esw-es-kubernetes-cm-so-es-sink", "componentType": "bindings.http", "operation": "post"}
+	}}`
+
+	tests := []struct {
+		name    string
+		config  *event.EventSourceConfig
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "event bus and sink",
+			config: &event.EventSourceConfig{EventBusOutputName: "ebo-default", SinkOutputName: "so-es-sink"},
+			want:   []string{"esw-es-kubernetes-cm-ebo-default", "esw-es-kubernetes-cm-so-es-sink"},
+		},
+		{
+			name:   "sink",
+			config: &event.EventSourceConfig{SinkOutputName: "so-es-sink"},
+			want:   []string{"esw-es-kubernetes-cm-so-es-sink"},
+		},
+		{
+			name:    "unknown output",
+			config:  &event.EventSourceConfig{SinkOutputName: "so-es-other"},
+			wantErr: true,
+		},
+		{
+			name:    "no output",
+			config:  &event.EventSourceConfig{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs, err := resolveOutputs(tt.config, fc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveOutputs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, o := range outputs {
+				got = append(got, o.ComponentName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveOutputs() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_daprPublisher(t *testing.T) {
+	client := &fakeDaprClient{}
+	p := &daprPublisher{
+		client: client,
+		outputs: []*output{
+			{ComponentName: "eventbus", ComponentType: "pubsub.natsstreaming", Uri: "default-es-cm"},
+			{ComponentName: "sink", ComponentType: "bindings.http", Operation: "post"},
+		},
+	}
+
+	event := newEvent("1", "/api/v1/namespaces/default/configmaps", "io.openfunction.events.kubernetes.add", "cm",
+		time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), map[string]interface{}{"kind": "ConfigMap"})
+	if err := p.Publish(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(client.published) != 1 || len(client.invoked) != 1 {
+		t.Fatalf("expected the event to be published to the event bus and delivered to the sink, got %d and %d",
+			len(client.published), len(client.invoked))
+	}
+
+	published := client.published[0]
+	if published.PubsubName != "eventbus" || published.Topic != "default-es-cm" || published.DataContentType != cloudEventContentType {
+		t.Errorf("unexpected publish request %v", published)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(published.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "1",
+		"source":          "/api/v1/namespaces/default/configmaps",
+		"type":            "io.openfunction.events.kubernetes.add",
+		"subject":         "cm",
+		"time":            "2023-01-01T00:00:00Z",
+		"datacontenttype": "application/json",
+		"data":            map[string]interface{}{"kind": "ConfigMap"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected event %v, want %v", got, want)
+	}
+
+	invoked := client.invoked[0]
+	if invoked.Name != "sink" || invoked.Operation != "post" || invoked.Metadata["Content-Type"] != cloudEventContentType {
+		t.Errorf("unexpected binding request %v", invoked)
+	}
+	if !reflect.DeepEqual(invoked.Data, published.Data) {
+		t.Errorf("expected the same event to be delivered to the sink")
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+const (
+	// KubernetesEventTypePrefix is the prefix of the type of the events, followed by `add`, `update` or `delete`.
+	KubernetesEventTypePrefix = "io.openfunction.events.kubernetes."
+)
+
+// kubernetesSource watches the objects and publishes an event for each change, the data of the event is the object.
+// The objects which exist when the handler starts are published as `add` events.
+type kubernetesSource struct {
+	log       logr.Logger
+	client    dynamic.Interface
+	config    *event.KubernetesSourceConfig
+	publisher Publisher
+	types     map[string]bool
+}
+
+func newKubernetesSource(log logr.Logger, client dynamic.Interface, config *event.KubernetesSourceConfig, publisher Publisher) *kubernetesSource {
+	types := map[string]bool{}
+	for _, t := range config.EventTypes {
+		types[t] = true
+	}
+
+	return &kubernetesSource{
+		log:       log.WithName("KubernetesSource"),
+		client:    client,
+		config:    config,
+		publisher: publisher,
+		types:     types,
+	}
+}
+
+func (s *kubernetesSource) Start(ctx context.Context) error {
+	gvr := schema.GroupVersionResource{Group: s.config.Group, Version: s.config.Version, Resource: s.config.Resource}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(s.client, 0, s.config.Namespace, func(options *metav1.ListOptions) {
+		options.LabelSelector = s.config.LabelSelector
+		options.FieldSelector = s.config.FieldSelector
+	})
+
+	informer := factory.ForResource(gvr).Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.publish(ctx, ofevent.KubernetesEventTypeAdd, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldObject, ok := oldObj.(*unstructured.Unstructured)
+			if ok && oldObject.GetResourceVersion() == newObj.(*unstructured.Unstructured).GetResourceVersion() {
+				return
+			}
+			s.publish(ctx, ofevent.KubernetesEventTypeUpdate, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			s.publish(ctx, ofevent.KubernetesEventTypeDelete, obj)
+		},
+	}); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("failed to sync the %s to watch", gvr.String())
+	}
+	s.log.Info("Watching objects", "resource", gvr.String(), "namespace", s.config.Namespace)
+
+	<-ctx.Done()
+	return nil
+}
+
+func (s *kubernetesSource) publish(ctx context.Context, eventType string, obj interface{}) {
+	if !s.types[eventType] {
+		return
+	}
+
+	object, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		s.log.Info("Ignore unknown object", "type", fmt.Sprintf("%T", obj))
+		return
+	}
+
+	gvr := schema.GroupVersionResource{Group: s.config.Group, Version: s.config.Version, Resource: s.config.Resource}
+	event := newKubernetesEvent(eventType, gvr, object)
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		s.log.Error(err, "Failed to publish event", "type", event.Type, "subject", event.Subject)
+		return
+	}
+	s.log.V(1).Info("Published event", "type", event.Type, "subject", event.Subject)
+}
+
+// newKubernetesEvent converts the change of the object into a CloudEvent,
+// its source is the API path of the resource in the namespace of the object and its subject is the name of the object.
+func newKubernetesEvent(eventType string, gvr schema.GroupVersionResource, object *unstructured.Unstructured) *Event {
+	source := path.Join("/apis", gvr.Group, gvr.Version)
+	if gvr.Group == "" {
+		source = path.Join("/api", gvr.Version)
+	}
+	if object.GetNamespace() != "" {
+		source = path.Join(source, "namespaces", object.GetNamespace())
+	}
+	source = path.Join(source, gvr.Resource)
+
+	id := fmt.Sprintf("%s.%s.%s", object.GetUID(), object.GetResourceVersion(), eventType)
+	return newEvent(id, source, KubernetesEventTypePrefix+eventType, object.GetName(), time.Now(), object.Object)
+}
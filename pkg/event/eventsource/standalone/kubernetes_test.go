@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+type fakePublisher chan *Event
+
+func (p fakePublisher) Publish(_ context.Context, event *Event) error {
+	p <- event
+	return nil
+}
+
+func newConfigMap(name string, data string) *unstructured.Unstructured {
+	cm := &unstructured.Unstructured{}
+	cm.SetAPIVersion("v1")
+	cm.SetKind("ConfigMap")
+	cm.SetNamespace("default")
+	cm.SetName(name)
+	cm.SetUID(types.UID("uid-" + name))
+	_ = unstructured.SetNestedField(cm.Object, data, "data", "key")
+	return cm
+}
+
+func Test_kubernetesSource(t *testing.T) {
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, newConfigMap("existing", "a"))
+
+	publisher := make(fakePublisher, 10)
+	source := newKubernetesSource(logr.Discard(), client, &event.KubernetesSourceConfig{
+		Version:    "v1",
+		Resource:   "configmaps",
+		Kind:       "ConfigMap",
+		Namespace:  "default",
+		EventTypes: []string{ofevent.KubernetesEventTypeAdd, ofevent.KubernetesEventTypeDelete},
+	}, publisher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+	go func() {
+		defer close(done)
+		if err := source.Start(ctx); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	next := func() *Event {
+		select {
+		case event := <-publisher:
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for the event")
+			return nil
+		}
+	}
+
+	// The existing objects are published when the handler starts.
+	event := next()
+	if event.Type != KubernetesEventTypePrefix+ofevent.KubernetesEventTypeAdd || event.Subject != "existing" {
+		t.Errorf("unexpected event %s of %s", event.Type, event.Subject)
+	}
+	if event.Source != "/api/v1/namespaces/default/configmaps" {
+		t.Errorf("unexpected source %s", event.Source)
+	}
+
+	configMaps := client.Resource(gvr).Namespace("default")
+	if _, err := configMaps.Create(ctx, newConfigMap("created", "a"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	event = next()
+	if event.Type != KubernetesEventTypePrefix+ofevent.KubernetesEventTypeAdd || event.Subject != "created" {
+		t.Errorf("unexpected event %s of %s", event.Type, event.Subject)
+	}
+	if data, _, _ := unstructured.NestedString(event.Data.(map[string]interface{}), "data", "key"); data != "a" {
+		t.Errorf("expected the object in the data, got %v", event.Data)
+	}
+
+	// The update events are not published as they are not selected.
+	updated := newConfigMap("created", "b")
+	updated.SetResourceVersion("2")
+	if _, err := configMaps.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configMaps.Delete(ctx, "created", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	event = next()
+	if event.Type != KubernetesEventTypePrefix+ofevent.KubernetesEventTypeDelete || event.Subject != "created" {
+		t.Errorf("unexpected event %s of %s", event.Type, event.Subject)
+	}
+}
+
+func Test_newKubernetesEvent(t *testing.T) {
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("default")
+	deployment.SetName("web")
+	deployment.SetUID("uid")
+	deployment.SetResourceVersion("10")
+
+	event := newKubernetesEvent(ofevent.KubernetesEventTypeUpdate,
+		schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, deployment)
+	if event.Source != "/apis/apps/v1/namespaces/default/deployments" {
+		t.Errorf("unexpected source %s", event.Source)
+	}
+	if event.ID != "uid.10.update" {
+		t.Errorf("unexpected id %s", event.ID)
+	}
+
+	namespace := &unstructured.Unstructured{}
+	namespace.SetName("ops")
+	event = newKubernetesEvent(ofevent.KubernetesEventTypeAdd, schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, namespace)
+	if event.Source != "/api/v1/namespaces" || event.Subject != "ops" {
+		t.Errorf("unexpected source %s and subject %s", event.Source, event.Subject)
+	}
+}
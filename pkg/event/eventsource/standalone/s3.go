@@ -0,0 +1,319 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+const (
+	// S3EventTypePrefix is the prefix of the type of the events, followed by `ObjectCreated`.
+	S3EventTypePrefix = "io.openfunction.events.s3."
+
+	s3RequestTimeout = time.Minute
+	// s3CursorOverlap is how long the published objects are tracked before the newest one. The objects modified
+	// within it can be listed after newer objects, such as the multipart uploads whose last modified time is the time
+	// they were initiated, or the objects written by clients with skewed clocks.
+	s3CursorOverlap = 15 * time.Minute
+)
+
+// s3Object is an object listed from the bucket.
+type s3Object struct {
+	Key          string
+	LastModified time.Time
+	ETag         string
+	Size         int64
+}
+
+// s3Client lists the objects of a bucket, the requests are signed if the credentials are set,
+// otherwise the bucket is read anonymously.
+type s3Client struct {
+	client *minio.Client
+	config *event.S3SourceConfig
+}
+
+func newS3Client(config *event.S3SourceConfig, accessKeyID string, secretAccessKey string) (*s3Client, error) {
+	lookup := minio.BucketLookupDNS
+	if config.ForcePathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	// The static credentials are anonymous if the keys are empty.
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure:       config.Secure,
+		Region:       config.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3Client{client: client, config: config}, nil
+}
+
+func (c *s3Client) bucketURL() *url.URL {
+	u := &url.URL{Scheme: "http", Host: c.config.Endpoint, Path: "/"}
+	if c.config.Secure {
+		u.Scheme = "https"
+	}
+	if c.config.ForcePathStyle {
+		u.Path = "/" + c.config.Bucket + "/"
+	} else {
+		u.Host = c.config.Bucket + "." + c.config.Endpoint
+	}
+	return u
+}
+
+// listObjects returns the objects whose key starts with the prefix of the config and which are accepted by the filter.
+// The whole prefix is listed, but only the accepted objects are kept in memory.
+func (c *s3Client) listObjects(ctx context.Context, filter func(s3Object) bool) ([]s3Object, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var objects []s3Object
+	for info := range c.client.ListObjects(ctx, c.config.Bucket, minio.ListObjectsOptions{Prefix: c.config.Prefix, Recursive: true}) {
+		if info.Err != nil {
+			return nil, fmt.Errorf("failed to list bucket %s: %s", c.config.Bucket, info.Err.Error())
+		}
+		object := s3Object{Key: info.Key, LastModified: info.LastModified, ETag: info.ETag, Size: info.Size}
+		if filter(object) {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// s3Cursor is the last modified time of the newest published object and the keys of the published objects
+// modified within s3CursorOverlap before it, along with their last modified time.
+// The objects modified before the overlap are regarded as published.
+// The cursor is not saved until the existing objects are recorded, which happens when the handler runs for the first time.
+type s3Cursor struct {
+	Saved        bool
+	LastModified time.Time
+	Keys         map[string]time.Time
+}
+
+// published tells whether the object was published before, an overwritten object is published again.
+func (c *s3Cursor) published(object s3Object) bool {
+	if object.LastModified.Before(c.LastModified.Add(-s3CursorOverlap)) {
+		return true
+	}
+	lastModified, ok := c.Keys[object.Key]
+	return ok && !object.LastModified.After(lastModified)
+}
+
+// advance records the object and forgets the objects modified before the overlap.
+func (c *s3Cursor) advance(object s3Object) {
+	if object.LastModified.After(c.LastModified) {
+		c.LastModified = object.LastModified
+	}
+	c.Keys[object.Key] = object.LastModified
+
+	since := c.LastModified.Add(-s3CursorOverlap)
+	for key, lastModified := range c.Keys {
+		if lastModified.Before(since) {
+			delete(c.Keys, key)
+		}
+	}
+}
+
+// s3CursorStore persists the cursor in the data of the cursor ConfigMap created by the controller.
+type s3CursorStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+}
+
+func (s *s3CursorStore) load(ctx context.Context) (*s3Cursor, error) {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &s3Cursor{Keys: map[string]time.Time{}}
+	if v, ok := cm.Data[event.S3CursorLastModifiedKey]; ok {
+		cursor.Saved = true
+		if cursor.LastModified, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return nil, fmt.Errorf("invalid %s of the cursor %s: %s", event.S3CursorLastModifiedKey, s.name, err.Error())
+		}
+	}
+	for _, line := range strings.Split(cm.Data[event.S3CursorKeysKey], "\n") {
+		if line == "" {
+			continue
+		}
+		// The lines without the last modified time are the keys of the objects modified at the time of the cursor.
+		v, key, found := strings.Cut(line, " ")
+		lastModified, err := time.Parse(time.RFC3339Nano, v)
+		if !found || err != nil {
+			cursor.Keys[line] = cursor.LastModified
+			continue
+		}
+		cursor.Keys[key] = lastModified
+	}
+	return cursor, nil
+}
+
+func (s *s3CursorStore) save(ctx context.Context, cursor *s3Cursor) error {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(cursor.Keys))
+	for key := range cursor.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, cursor.Keys[key].UTC().Format(time.RFC3339Nano)+" "+key)
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[event.S3CursorLastModifiedKey] = cursor.LastModified.UTC().Format(time.RFC3339Nano)
+	cm.Data[event.S3CursorKeysKey] = strings.Join(lines, "\n")
+	if _, err := s.client.CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	cursor.Saved = true
+	return nil
+}
+
+// s3Source polls the bucket and publishes an event for each object created or overwritten since the last poll.
+// The objects which exist when the handler runs for the first time are recorded in the cursor without being published.
+type s3Source struct {
+	log       logr.Logger
+	client    *s3Client
+	config    *event.S3SourceConfig
+	store     *s3CursorStore
+	publisher Publisher
+	types     map[string]bool
+	cursor    *s3Cursor
+}
+
+func newS3Source(log logr.Logger, client *s3Client, store *s3CursorStore, publisher Publisher) *s3Source {
+	types := map[string]bool{}
+	for _, t := range client.config.EventTypes {
+		types[t] = true
+	}
+
+	return &s3Source{
+		log:       log.WithName("S3Source"),
+		client:    client,
+		config:    client.config,
+		store:     store,
+		publisher: publisher,
+		types:     types,
+	}
+}
+
+func (s *s3Source) Start(ctx context.Context) error {
+	interval, err := time.ParseDuration(s.config.PollingInterval)
+	if err != nil {
+		return fmt.Errorf("invalid polling interval %s: %s", s.config.PollingInterval, err.Error())
+	}
+
+	s.log.Info("Polling bucket", "bucket", s.config.Bucket, "interval", interval.String())
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.poll(ctx); err != nil && ctx.Err() == nil {
+			s.log.Error(err, "Failed to poll bucket", "bucket", s.config.Bucket)
+		}
+	}, interval)
+	return nil
+}
+
+// poll publishes the objects which are not published yet in the order of their last modified time,
+// the cursor is saved after each object so that no event is lost or published twice when the handler restarts.
+func (s *s3Source) poll(ctx context.Context) error {
+	if s.cursor == nil {
+		cursor, err := s.store.load(ctx)
+		if err != nil {
+			return err
+		}
+		s.cursor = cursor
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s3RequestTimeout)
+	defer cancel()
+	created, err := s.client.listObjects(ctx, func(object s3Object) bool {
+		return strings.HasSuffix(object.Key, s.config.Suffix) && !s.cursor.published(object)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(created, func(i, j int) bool {
+		if created[i].LastModified.Equal(created[j].LastModified) {
+			return created[i].Key < created[j].Key
+		}
+		return created[i].LastModified.Before(created[j].LastModified)
+	})
+
+	if !s.cursor.Saved {
+		for _, object := range created {
+			s.cursor.advance(object)
+		}
+		s.log.Info("Recorded the existing objects", "bucket", s.config.Bucket, "count", len(created))
+		return s.store.save(ctx, s.cursor)
+	}
+
+	for _, object := range created {
+		if s.types[ofevent.S3EventTypeObjectCreated] {
+			event := s.newEvent(object)
+			if err := s.publisher.Publish(ctx, event); err != nil {
+				return err
+			}
+			s.log.V(1).Info("Published event", "type", event.Type, "subject", event.Subject)
+		}
+
+		s.cursor.advance(object)
+		if err := s.store.save(ctx, s.cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newEvent converts the object into a CloudEvent, its source is the URL of the bucket and its subject is the key.
+func (s *s3Source) newEvent(object s3Object) *Event {
+	id := fmt.Sprintf("%s.%s", object.Key, object.LastModified.UTC().Format(time.RFC3339Nano))
+	data := map[string]interface{}{
+		"bucket":       s.config.Bucket,
+		"key":          object.Key,
+		"size":         object.Size,
+		"eTag":         strings.Trim(object.ETag, `"`),
+		"lastModified": object.LastModified.UTC().Format(time.RFC3339Nano),
+	}
+	return newEvent(id, s.client.bucketURL().String(), S3EventTypePrefix+ofevent.S3EventTypeObjectCreated,
+		object.Key, object.LastModified, data)
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package standalone
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+// fakeBucket serves GetBucketLocation and ListObjectsV2 of a path-style bucket, one object per page.
+type fakeBucket struct {
+	mu      sync.Mutex
+	objects []s3Object
+}
+
+func (b *fakeBucket) put(key string, lastModified time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects = append(b.objects, s3Object{Key: key, LastModified: lastModified, ETag: `"etag"`, Size: 1})
+}
+
+func (b *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := r.URL.Query()["location"]; ok && r.URL.Path == "/images/" {
+		fmt.Fprint(w, `<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/"></LocationConstraint>`)
+		return
+	}
+	if r.URL.Path != "/images/" || r.URL.Query().Get("list-type") != "2" {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>")
+		return
+	}
+
+	var objects []s3Object
+	for _, o := range b.objects {
+		if strings.HasPrefix(o.Key, r.URL.Query().Get("prefix")) {
+			objects = append(objects, o)
+		}
+	}
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+
+	fmt.Fprint(w, `<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	if start < len(objects) {
+		o := objects[start]
+		fmt.Fprintf(w, "<Contents><Key>%s</Key><LastModified>%s</LastModified><ETag>%s</ETag><Size>%d</Size></Contents>",
+			o.Key, o.LastModified.Format("2006-01-02T15:04:05.000Z"), "&quot;etag&quot;", o.Size)
+	}
+	if start+1 < len(objects) {
+		fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", start+1)
+	} else {
+		fmt.Fprint(w, "<IsTruncated>false</IsTruncated>")
+	}
+	fmt.Fprint(w, "</ListBucketResult>")
+}
+
+func Test_s3Source(t *testing.T) {
+	bucket := &fakeBucket{}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket.put("uploads/a.jpg", base)
+
+	config := &event.S3SourceConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Bucket:          "images",
+		Prefix:          "uploads/",
+		Suffix:          ".jpg",
+		EventTypes:      []string{ofevent.S3EventTypeObjectCreated},
+		ForcePathStyle:  true,
+		PollingInterval: "1s",
+		CursorConfigMap: "cursor",
+	}
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cursor", Namespace: "default"}})
+	store := &s3CursorStore{client: clientset, namespace: "default", name: "cursor"}
+	newSource := func(publisher Publisher) *s3Source {
+		client, err := newS3Client(config, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newS3Source(logr.Discard(), client, store, publisher)
+	}
+
+	ctx := context.Background()
+	publisher := make(fakePublisher, 10)
+	source := newSource(publisher)
+
+	// The existing objects are recorded without being published.
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher) != 0 {
+		t.Fatalf("expected the existing objects not to be published, got %d events", len(publisher))
+	}
+	cm, _ := clientset.CoreV1().ConfigMaps("default").Get(ctx, "cursor", metav1.GetOptions{})
+	if cm.Data[event.S3CursorLastModifiedKey] != "2023-01-01T00:00:00Z" || cm.Data[event.S3CursorKeysKey] != "2023-01-01T00:00:00Z uploads/a.jpg" {
+		t.Fatalf("unexpected cursor %v", cm.Data)
+	}
+
+	bucket.put("uploads/d.jpg", base.Add(2*time.Second))
+	bucket.put("uploads/c.txt", base.Add(time.Second))
+	bucket.put("uploads/b.jpg", base)
+	bucket.put("others/e.jpg", base.Add(time.Second))
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The created objects are published in the order of their last modified time.
+	for _, key := range []string{"uploads/b.jpg", "uploads/d.jpg"} {
+		if len(publisher) == 0 {
+			t.Fatalf("expected the event of %s", key)
+		}
+		event := <-publisher
+		if event.Type != S3EventTypePrefix+ofevent.S3EventTypeObjectCreated || event.Subject != key {
+			t.Errorf("unexpected event %s of %s, want the event of %s", event.Type, event.Subject, key)
+		}
+		if event.Source != server.URL+"/images/" {
+			t.Errorf("unexpected source %s", event.Source)
+		}
+		if event.Data.(map[string]interface{})["eTag"] != "etag" {
+			t.Errorf("unexpected data %v", event.Data)
+		}
+	}
+	if len(publisher) != 0 {
+		t.Fatalf("expected no more events, got %d", len(publisher))
+	}
+	cm, _ = clientset.CoreV1().ConfigMaps("default").Get(ctx, "cursor", metav1.GetOptions{})
+	if cm.Data[event.S3CursorLastModifiedKey] != "2023-01-01T00:00:02Z" ||
+		cm.Data[event.S3CursorKeysKey] != "2023-01-01T00:00:00Z uploads/a.jpg\n2023-01-01T00:00:00Z uploads/b.jpg\n2023-01-01T00:00:02Z uploads/d.jpg" {
+		t.Fatalf("unexpected cursor %v", cm.Data)
+	}
+
+	// The published objects are not published again after the handler restarts,
+	// while the objects listed later than newer objects are published.
+	bucket.put("uploads/f.jpg", base.Add(2*time.Second))
+	bucket.put("uploads/g.jpg", base.Add(time.Second))
+	source = newSource(publisher)
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"uploads/g.jpg", "uploads/f.jpg"} {
+		if len(publisher) == 0 {
+			t.Fatalf("expected the event of %s", key)
+		}
+		if event := <-publisher; event.Subject != key {
+			t.Errorf("unexpected event of %s, want the event of %s", event.Subject, key)
+		}
+	}
+	if len(publisher) != 0 {
+		t.Fatalf("expected only the events of the new objects, got %d more events", len(publisher))
+	}
+
+	// The objects modified before the overlap are forgotten, and are regarded as published.
+	bucket.put("uploads/h.jpg", base.Add(s3CursorOverlap+3*time.Second))
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if event := <-publisher; event.Subject != "uploads/h.jpg" {
+		t.Errorf("unexpected event of %s", event.Subject)
+	}
+	bucket.put("uploads/i.jpg", base.Add(2*time.Second))
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher) != 0 {
+		t.Fatalf("expected the objects modified before the overlap not to be published, got %d events", len(publisher))
+	}
+	cm, _ = clientset.CoreV1().ConfigMaps("default").Get(ctx, "cursor", metav1.GetOptions{})
+	if cm.Data[event.S3CursorKeysKey] != "2023-01-01T00:15:03Z uploads/h.jpg" {
+		t.Fatalf("unexpected cursor %v", cm.Data)
+	}
+}
+
+func Test_s3CursorStore_load(t *testing.T) {
+	// The keys saved without the last modified time are modified at the time of the cursor.
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cursor", Namespace: "default"},
+		Data: map[string]string{
+			event.S3CursorLastModifiedKey: "2023-01-01T00:00:02Z",
+			event.S3CursorKeysKey:         "uploads/a b.jpg\n2023-01-01T00:00:01Z uploads/c d.jpg",
+		},
+	})
+	store := &s3CursorStore{client: clientset, namespace: "default", name: "cursor"}
+	cursor, err := store.load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	want := map[string]time.Time{"uploads/a b.jpg": base.Add(2 * time.Second), "uploads/c d.jpg": base.Add(time.Second)}
+	if !cursor.Saved || len(cursor.Keys) != len(want) {
+		t.Fatalf("unexpected cursor %v", cursor)
+	}
+	for key, lastModified := range want {
+		if !cursor.Keys[key].Equal(lastModified) {
+			t.Errorf("unexpected last modified time %s of %s, want %s", cursor.Keys[key], key, lastModified)
+		}
+	}
+}
+
+func Test_s3Source_emptyBucket(t *testing.T) {
+	bucket := &fakeBucket{}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+
+	config := &event.S3SourceConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Bucket:          "images",
+		EventTypes:      []string{ofevent.S3EventTypeObjectCreated},
+		ForcePathStyle:  true,
+		PollingInterval: "1s",
+		CursorConfigMap: "cursor",
+	}
+	clientset := fake.NewSimpleClientset(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cursor", Namespace: "default"}})
+	publisher := make(fakePublisher, 10)
+	client, err := newS3Client(config, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := newS3Source(logr.Discard(), client, &s3CursorStore{client: clientset, namespace: "default", name: "cursor"}, publisher)
+
+	ctx := context.Background()
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first object of a bucket which is empty when the handler runs for the first time is published.
+	bucket.put("a.jpg", time.Now())
+	if err := source.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher) != 1 {
+		t.Fatalf("expected the event of the first object, got %d events", len(publisher))
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"time"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+)
+
+const defaultBackoffDelay = time.Second
+
+// statusCodeRegexp extracts the status code of the sink from the errors of the Dapr HTTP binding,
+// e.g. `received status code 503`.
+var statusCodeRegexp = regexp.MustCompile(`status code (\d{3})`)
+
+// delivery is the retry policy of the deliveries of a subscriber.
+type delivery struct {
+	maxRetries int
+	policy     string
+	delay      time.Duration
+	maxDelay   time.Duration
+	timeout    time.Duration
+	// retryableStatusCodes is nil if all the failures are retried.
+	retryableStatusCodes map[int]bool
+}
+
+// newDelivery converts the delivery of a subscriber, which has been validated by the webhook.
+// The deliveries are not retried if the subscriber has no delivery.
+func newDelivery(spec *ofevent.DeliverySpec) *delivery {
+	d := &delivery{policy: ofevent.BackoffPolicyExponential, delay: defaultBackoffDelay}
+	if spec == nil {
+		return d
+	}
+
+	if spec.MaxRetries != nil {
+		d.maxRetries = int(*spec.MaxRetries)
+	}
+	if spec.BackoffPolicy != "" {
+		d.policy = spec.BackoffPolicy
+	}
+	if delay, err := time.ParseDuration(spec.BackoffDelay); err == nil {
+		d.delay = delay
+	}
+	if maxDelay, err := time.ParseDuration(spec.MaxBackoffDelay); err == nil {
+		d.maxDelay = maxDelay
+	}
+	if timeout, err := time.ParseDuration(spec.Timeout); err == nil {
+		d.timeout = timeout
+	}
+	if len(spec.RetryableStatusCodes) > 0 {
+		d.retryableStatusCodes = map[int]bool{}
+		for _, code := range spec.RetryableStatusCodes {
+			d.retryableStatusCodes[int(code)] = true
+		}
+	}
+	return d
+}
+
+// backoff returns the delay before the n-th retry, starting from 1.
+func (d *delivery) backoff(n int) time.Duration {
+	var delay time.Duration
+	switch d.policy {
+	case ofevent.BackoffPolicyConstant:
+		return d.delay
+	case ofevent.BackoffPolicyLinear:
+		delay = d.delay * time.Duration(n)
+	default:
+		delay = d.delay
+		for i := 1; i < n && (d.maxDelay == 0 || delay < d.maxDelay); i++ {
+			delay *= 2
+		}
+	}
+
+	if d.maxDelay > 0 && delay > d.maxDelay {
+		return d.maxDelay
+	}
+	return delay
+}
+
+// retryable returns true if the failed delivery to the output should be retried,
+// the status codes only apply to the sink.
+func (d *delivery) retryable(err error, sink bool) bool {
+	if !sink || d.retryableStatusCodes == nil {
+		return true
+	}
+
+	match := statusCodeRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return true
+	}
+	code, _ := strconv.Atoi(match[1])
+	return d.retryableStatusCodes[code]
+}
+
+// sleep waits for the duration, it returns false if the context is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+func Test_delivery_backoff(t *testing.T) {
+	maxRetries := int32(5)
+	tests := []struct {
+		name string
+		spec *ofevent.DeliverySpec
+		want []time.Duration
+	}{
+		{
+			name: "constant",
+			spec: &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "constant", BackoffDelay: "2s"},
+			want: []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		{
+			name: "linear",
+			spec: &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "linear", BackoffDelay: "2s", MaxBackoffDelay: "7s"},
+			want: []time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second, 7 * time.Second, 7 * time.Second},
+		},
+		{
+			name: "exponential",
+			spec: &ofevent.DeliverySpec{MaxRetries: &maxRetries, MaxBackoffDelay: "10s"},
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newDelivery(tt.spec)
+			var got []time.Duration
+			for n := 1; n <= d.maxRetries; n++ {
+				got = append(got, d.backoff(n))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// flakySender fails the first deliveries to each output with the errors.
+type flakySender struct {
+	errors   map[string][]error
+	attempts map[string]int
+	sent     map[string]bool
+}
+
+func (s *flakySender) Send(_ context.Context, output string, _ Event) error {
+	if s.attempts == nil {
+		s.attempts = map[string]int{}
+		s.sent = map[string]bool{}
+	}
+	s.attempts[output]++
+	if errs := s.errors[output]; s.attempts[output] <= len(errs) {
+		return errs[s.attempts[output]-1]
+	}
+	s.sent[output] = true
+	return nil
+}
+
+func Test_deliver_retries(t *testing.T) {
+	maxRetries := int32(2)
+	unavailable := errors.New("error invoke output binding: received status code 503")
+	badRequest := errors.New("error invoke output binding: received status code 400")
+	refused := errors.New("connection refused")
+
+	tests := []struct {
+		name         string
+		delivery     *ofevent.DeliverySpec
+		errors       []error
+		wantAttempts int
+		wantDelays   []time.Duration
+		wantDL       bool
+	}{
+		{
+			name:         "no retry",
+			errors:       []error{unavailable},
+			wantAttempts: 1,
+			wantDL:       true,
+		},
+		{
+			name:         "retried",
+			delivery:     &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "linear", BackoffDelay: "1s"},
+			errors:       []error{unavailable, refused},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "retries exhausted",
+			delivery:     &ofevent.DeliverySpec{MaxRetries: &maxRetries, BackoffPolicy: "constant", BackoffDelay: "1s"},
+			errors:       []error{unavailable, unavailable, unavailable},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{time.Second, time.Second},
+			wantDL:       true,
+		},
+		{
+			name:         "retryable status code",
+			delivery:     &ofevent.DeliverySpec{MaxRetries: &maxRetries, RetryableStatusCodes: []int32{503}},
+			errors:       []error{unavailable, refused},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name:         "not retryable status code",
+			delivery:     &ofevent.DeliverySpec{MaxRetries: &maxRetries, RetryableStatusCodes: []int32{503}},
+			errors:       []error{badRequest},
+			wantAttempts: 1,
+			wantDL:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &flakySender{errors: map[string][]error{"so-t-trigger-1": tt.errors}}
+			h := newTestHandler(t, map[string]*event.Subscriber{
+				"A": {SinkOutputName: "so-t-trigger-1", DLSinkOutputName: "so-t-trigger-2", Delivery: tt.delivery},
+			}, sender)
+			var delays []time.Duration
+			h.sleep = func(_ context.Context, d time.Duration) bool {
+				delays = append(delays, d)
+				return true
+			}
+
+			if _, err := h.OnTopicEvent(context.Background(), topicEvent("default-es-a-event-a", "1", "created")); err != nil {
+				t.Fatal(err)
+			}
+			if got := sender.attempts["so-t-trigger-1"]; got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if !reflect.DeepEqual(delays, tt.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, tt.wantDelays)
+			}
+			if got := sender.sent["so-t-trigger-2"]; got != tt.wantDL {
+				t.Errorf("dead letter delivered = %v, want %v", got, tt.wantDL)
+			}
+		})
+	}
+}
+
+func Test_deliver_topicIgnoresStatusCodes(t *testing.T) {
+	maxRetries := int32(1)
+	sender := &flakySender{errors: map[string][]error{"ebo-metrics": {errors.New("received status code 400")}}}
+	h := newTestHandler(t, map[string]*event.Subscriber{
+		"A": {EventBusOutputName: "ebo-metrics", Delivery: &ofevent.DeliverySpec{MaxRetries: &maxRetries, RetryableStatusCodes: []int32{503}}},
+	}, sender)
+	h.sleep = func(context.Context, time.Duration) bool { return true }
+
+	if _, err := h.OnTopicEvent(context.Background(), topicEvent("default-es-a-event-a", "1", "created")); err != nil {
+		t.Fatal(err)
+	}
+	if got := sender.attempts["ebo-metrics"]; got != 2 || !sender.sent["ebo-metrics"] {
+		t.Errorf("attempts = %d, delivered = %v, want the topic retried", got, sender.sent["ebo-metrics"])
+	}
+}
@@ -0,0 +1,535 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trigger implements the handler of a Trigger. The handler subscribes to the topics of the inputs
+// on the event bus, evaluates the conditions of the subscribers on each event and delivers the event
+// to the sinks and topics of the subscribers whose conditions are met, through the Dapr sidecar.
+// If the Trigger has a correlation or a window, the pending events are saved to a Dapr state store periodically,
+// each correlation key and window under its own key.
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/openfunction/pkg/constants"
+	"github.com/openfunction/pkg/event"
+	"github.com/openfunction/pkg/event/condition"
+)
+
+const (
+	// ConfigEnv holds the encoded TriggerConfig of the handler.
+	ConfigEnv = "CONFIG"
+	// FunctionContextEnv holds the context of the handler function, which resolves the inputs and outputs
+	// to Dapr components.
+	FunctionContextEnv = "FUNC_CONTEXT"
+	// DaprHostEnv is set to the host of the Dapr sidecar if it does not run in the pod of the handler.
+	DaprHostEnv = "DAPR_HOST"
+	// DaprGRPCPortEnv is set by the Dapr sidecar injector to the gRPC port of the sidecar.
+	DaprGRPCPortEnv = "DAPR_GRPC_PORT"
+
+	defaultDaprHost       = "127.0.0.1"
+	defaultDaprGRPCPort   = "50001"
+	cloudEventContentType = "application/cloudevents+json"
+	bindingsPrefix        = "bindings."
+	defaultOperation      = "create"
+
+	// inputTTL is how long an input is true after its last event, as the trigger handler always did.
+	inputTTL = time.Minute
+)
+
+// Event is a CloudEvent in the structured mode, the keys are the names of the attributes and `data`.
+type Event map[string]interface{}
+
+// ID returns the id attribute of the event.
+func (e Event) ID() string {
+	id, _ := e["id"].(string)
+	return id
+}
+
+// newEvent converts an event received from the event bus, the data is decoded if it is JSON.
+func newEvent(req *runtimev1pb.TopicEventRequest) Event {
+	e := Event{
+		"specversion":     req.SpecVersion,
+		"id":              req.Id,
+		"source":          req.Source,
+		"type":            req.Type,
+		"datacontenttype": req.DataContentType,
+	}
+
+	if len(req.Data) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(req.Data, &data); err == nil {
+			e["data"] = data
+		} else {
+			e["data"] = string(req.Data)
+		}
+	}
+	return e
+}
+
+// Sender delivers an event to an output of the handler.
+type Sender interface {
+	Send(ctx context.Context, output string, event Event) error
+}
+
+type component struct {
+	ComponentName string            `json:"componentName"`
+	ComponentType string            `json:"componentType"`
+	Uri           string            `json:"uri,omitempty"`
+	Operation     string            `json:"operation,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+type functionContext struct {
+	Inputs  map[string]*component `json:"inputs,omitempty"`
+	Outputs map[string]*component `json:"outputs,omitempty"`
+	States  map[string]*component `json:"states,omitempty"`
+}
+
+// LoadConfig reads the config of the handler from the environment variables set by the controller.
+func LoadConfig() (*event.TriggerConfig, error) {
+	encoded := os.Getenv(ConfigEnv)
+	if encoded == "" {
+		return nil, fmt.Errorf("%s is not set", ConfigEnv)
+	}
+
+	return (&event.TriggerConfig{}).DecodeEnv(encoded)
+}
+
+func parseFunctionContext(encoded string) (*functionContext, error) {
+	fc := &functionContext{}
+	if err := json.Unmarshal([]byte(encoded), fc); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", FunctionContextEnv, err.Error())
+	}
+	return fc, nil
+}
+
+// daprSender delivers the events to the pubsub and binding outputs through the Dapr sidecar.
+type daprSender struct {
+	client  runtimev1pb.DaprClient
+	outputs map[string]*component
+}
+
+func (s *daprSender) Send(ctx context.Context, name string, event Event) error {
+	o, ok := s.outputs[name]
+	if !ok {
+		return fmt.Errorf("output %s is not found in %s", name, FunctionContextEnv)
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(o.ComponentType, bindingsPrefix) {
+		metadata := map[string]string{"Content-Type": cloudEventContentType}
+		for k, v := range o.Metadata {
+			metadata[k] = v
+		}
+		operation := o.Operation
+		if operation == "" {
+			operation = defaultOperation
+		}
+		if _, err := s.client.InvokeBinding(ctx, &runtimev1pb.InvokeBindingRequest{
+			Name:      o.ComponentName,
+			Data:      data,
+			Metadata:  metadata,
+			Operation: operation,
+		}); err != nil {
+			return fmt.Errorf("failed to deliver event %s to %s: %s", event.ID(), o.ComponentName, err.Error())
+		}
+		return nil
+	}
+
+	if _, err := s.client.PublishEvent(ctx, &runtimev1pb.PublishEventRequest{
+		PubsubName:      o.ComponentName,
+		Topic:           o.Uri,
+		Data:            data,
+		DataContentType: cloudEventContentType,
+		Metadata:        o.Metadata,
+	}); err != nil {
+		return fmt.Errorf("failed to publish event %s to %s: %s", event.ID(), o.ComponentName, err.Error())
+	}
+	return nil
+}
+
+type subscriber struct {
+	condition string
+	compiled  *condition.Condition
+	transform *condition.Transform
+	delivery  *delivery
+	config    *event.Subscriber
+}
+
+type received struct {
+	At    time.Time `json:"at"`
+	Event Event     `json:"event"`
+}
+
+// match is a subscriber whose condition is met by the variables.
+type match struct {
+	sub  *subscriber
+	vars *condition.Variables
+}
+
+// Handler evaluates the conditions of the subscribers of a Trigger on the events of its inputs.
+type Handler struct {
+	runtimev1pb.UnimplementedAppCallbackServer
+
+	log           logr.Logger
+	subscriptions []*runtimev1pb.TopicSubscription
+	// inputs maps the topics to the names of the inputs.
+	inputs      map[string][]string
+	inputNames  []string
+	subscribers []*subscriber
+	sender      Sender
+	now         func() time.Time
+	// sleep waits between the retries of a delivery.
+	sleep func(ctx context.Context, d time.Duration) bool
+
+	// correlation and window are nil unless they are set in the Trigger.
+	correlation *correlation
+	window      *window
+	// store is nil unless the Trigger has a state store.
+	store Store
+
+	mu sync.Mutex
+	// received holds the last event of each input.
+	received map[string]*received
+	// groups holds the events of each correlation key.
+	groups map[string]*group
+	// windows holds the open windows of each input by correlation key.
+	windows map[string]map[string]*pending
+	// dirty holds the items changed since they were saved to the store.
+	dirty map[item]bool
+}
+
+// NewHandler creates the handler of the config, the inputs and outputs are resolved by the function context.
+func NewHandler(log logr.Logger, config *event.TriggerConfig) (*Handler, error) {
+	fc, err := parseFunctionContext(os.Getenv(FunctionContextEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	host := os.Getenv(DaprHostEnv)
+	if host == "" {
+		host = defaultDaprHost
+	}
+	port := os.Getenv(DaprGRPCPortEnv)
+	if port == "" {
+		port = defaultDaprGRPCPort
+	}
+	conn, err := grpc.Dial(net.JoinHostPort(host, port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	client := runtimev1pb.NewDaprClient(conn)
+	h, err := newHandler(log, config, fc, &daprSender{client: client, outputs: fc.Outputs})
+	if err != nil {
+		return nil, err
+	}
+
+	if config.StateStore != "" {
+		state, ok := fc.States[config.StateStore]
+		if !ok {
+			return nil, fmt.Errorf("state %s is not found in %s", config.StateStore, FunctionContextEnv)
+		}
+		h.store = &daprStore{client: client, name: state.ComponentName}
+	}
+	return h, nil
+}
+
+func newHandler(log logr.Logger, config *event.TriggerConfig, fc *functionContext, sender Sender) (*Handler, error) {
+	h := &Handler{
+		log:      log,
+		inputs:   map[string][]string{},
+		sender:   sender,
+		now:      time.Now,
+		sleep:    sleep,
+		received: map[string]*received{},
+		groups:   map[string]*group{},
+		windows:  map[string]map[string]*pending{},
+		dirty:    map[item]bool{},
+	}
+
+	var err error
+	if config.Correlation != nil {
+		if h.correlation, err = newCorrelation(config.Correlation); err != nil {
+			return nil, err
+		}
+	}
+	if config.Window != nil {
+		if h.window, err = newWindow(config.Window); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, input := range config.Inputs {
+		h.inputs[input.Topic] = append(h.inputs[input.Topic], input.Name)
+		h.inputNames = append(h.inputNames, input.Name)
+	}
+	sort.Strings(h.inputNames)
+
+	var names []string
+	for name := range fc.Inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		input := fc.Inputs[name]
+		h.subscriptions = append(h.subscriptions, &runtimev1pb.TopicSubscription{
+			PubsubName: input.ComponentName,
+			Topic:      input.Uri,
+		})
+	}
+
+	for cond, sub := range config.Subscribers {
+		compiled, err := condition.New(h.inputNames, cond)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %s", cond, err.Error())
+		}
+		s := &subscriber{condition: cond, compiled: compiled, delivery: newDelivery(sub.Delivery), config: sub}
+		if t := sub.Transform; t != nil {
+			if s.transform, err = condition.NewTransform(h.inputNames, t.Data, t.Attributes, t.Remove); err != nil {
+				return nil, fmt.Errorf("invalid transform of condition %q: %s", cond, err.Error())
+			}
+		}
+		h.subscribers = append(h.subscribers, s)
+	}
+	sort.Slice(h.subscribers, func(i, j int) bool {
+		return h.subscribers[i].condition < h.subscribers[j].condition
+	})
+
+	return h, nil
+}
+
+func (h *Handler) ListTopicSubscriptions(context.Context, *emptypb.Empty) (*runtimev1pb.ListTopicSubscriptionsResponse, error) {
+	return &runtimev1pb.ListTopicSubscriptionsResponse{Subscriptions: h.subscriptions}, nil
+}
+
+func (h *Handler) ListInputBindings(context.Context, *emptypb.Empty) (*runtimev1pb.ListInputBindingsResponse, error) {
+	return &runtimev1pb.ListInputBindingsResponse{}, nil
+}
+
+// OnTopicEvent handles an event of the inputs, the failed deliveries are sent to the dead letter sinks and topics
+// instead of being retried by the event bus, which would deliver the event again to the other subscribers.
+func (h *Handler) OnTopicEvent(ctx context.Context, req *runtimev1pb.TopicEventRequest) (*runtimev1pb.TopicEventResponse, error) {
+	inputs, ok := h.inputs[req.Topic]
+	if !ok {
+		h.log.Info("Dropping event of unknown topic", "topic", req.Topic, "id", req.Id)
+		return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_DROP}, nil
+	}
+
+	h.handle(ctx, inputs, newEvent(req))
+	return &runtimev1pb.TopicEventResponse{Status: runtimev1pb.TopicEventResponse_SUCCESS}, nil
+}
+
+// handle records the event as the last event of the inputs and delivers it to the matched subscribers.
+func (h *Handler) handle(ctx context.Context, inputs []string, e Event) {
+	if h.correlation != nil || h.window != nil {
+		h.handleStateful(ctx, inputs, e)
+		return
+	}
+
+	h.mu.Lock()
+	now := h.now()
+	for _, input := range inputs {
+		h.received[input] = &received{At: now, Event: e}
+	}
+	vars := h.variables(now, e)
+	h.mu.Unlock()
+
+	h.dispatch(ctx, h.match(vars))
+}
+
+// match returns the subscribers whose conditions are met by the variables.
+func (h *Handler) match(vars *condition.Variables) []*match {
+	id := Event(vars.Event).ID()
+
+	var matches []*match
+	for _, sub := range h.subscribers {
+		matched, err := sub.compiled.Eval(vars)
+		if err != nil {
+			h.log.V(1).Info("Failed to evaluate condition", "condition", sub.condition, "id", id, "error", err.Error())
+			continue
+		}
+		if !matched {
+			continue
+		}
+		h.log.V(1).Info("Condition matched", "condition", sub.condition, "id", id)
+		matches = append(matches, &match{sub: sub, vars: vars})
+	}
+	return matches
+}
+
+// dispatch delivers the event of each match to its subscriber, after applying the transform of the subscriber.
+func (h *Handler) dispatch(ctx context.Context, matches []*match) {
+	for _, m := range matches {
+		e := Event(m.vars.Event)
+		if m.sub.transform == nil {
+			h.deliver(ctx, m.sub, e)
+			continue
+		}
+		transformed, err := m.sub.transform.Apply(m.vars)
+		if err != nil {
+			// The event can not be delivered, so it is only sent to the dead letter sink and topic.
+			h.log.Error(err, "Failed to transform event", "condition", m.sub.condition, "id", e.ID())
+			h.deliverDeadLetter(ctx, m.sub, e)
+			continue
+		}
+		h.deliver(ctx, m.sub, transformed)
+	}
+}
+
+// variables returns the variables of the conditions, an input is true if its last event was received
+// within inputTTL. The caller must hold the lock.
+func (h *Handler) variables(now time.Time, e Event) *condition.Variables {
+	vars := &condition.Variables{
+		Inputs: map[string]bool{},
+		Event:  e,
+		Events: map[string]map[string]interface{}{},
+	}
+	for _, name := range h.inputNames {
+		r, ok := h.received[name]
+		if ok && now.Sub(r.At) < inputTTL {
+			vars.Inputs[name] = true
+			vars.Events[name] = r.Event
+		} else {
+			vars.Inputs[name] = false
+		}
+	}
+	return vars
+}
+
+type target struct {
+	output     string
+	deadLetter string
+	sink       bool
+}
+
+// deliver sends the event to the sinks and the topic of the subscriber, or to their dead letter sink and topic
+// if the delivery still fails after the retries. A selector sink fans out to the output of each selected Function.
+func (h *Handler) deliver(ctx context.Context, sub *subscriber, e Event) {
+	targets := []target{{sub.config.SinkOutputName, sub.config.DLSinkOutputName, true}}
+	for _, output := range sub.config.SinkOutputNames {
+		targets = append(targets, target{output, sub.config.DLSinkOutputName, true})
+	}
+	targets = append(targets, target{sub.config.EventBusOutputName, sub.config.DLEventBusOutputName, false})
+
+	for _, target := range targets {
+		if target.output == "" {
+			continue
+		}
+
+		err := h.send(ctx, sub, target.output, target.sink, e)
+		if err == nil {
+			continue
+		}
+		h.log.Error(err, "Failed to deliver event", "condition", sub.condition, "output", target.output, "id", e.ID())
+		if target.deadLetter == "" {
+			continue
+		}
+		if err := h.sender.Send(ctx, target.deadLetter, e); err != nil {
+			h.log.Error(err, "Failed to deliver event to the dead letter output",
+				"condition", sub.condition, "output", target.deadLetter, "id", e.ID())
+		}
+	}
+}
+
+// send delivers the event to the output and retries the failures according to the delivery of the subscriber.
+func (h *Handler) send(ctx context.Context, sub *subscriber, output string, sink bool, e Event) error {
+	d := sub.delivery
+	for retry := 0; ; retry++ {
+		err := h.sendOnce(ctx, d, output, e)
+		if err == nil || retry >= d.maxRetries || !d.retryable(err, sink) {
+			return err
+		}
+
+		delay := d.backoff(retry + 1)
+		h.log.V(1).Info("Retrying delivery", "condition", sub.condition, "output", output, "id", e.ID(),
+			"retry", retry+1, "delay", delay.String(), "error", err.Error())
+		if !h.sleep(ctx, delay) {
+			return err
+		}
+	}
+}
+
+func (h *Handler) sendOnce(ctx context.Context, d *delivery, output string, e Event) error {
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	return h.sender.Send(ctx, output, e)
+}
+
+// deliverDeadLetter sends the event to the dead letter sink and topic of the subscriber.
+func (h *Handler) deliverDeadLetter(ctx context.Context, sub *subscriber, e Event) {
+	for _, deadLetter := range []string{sub.config.DLSinkOutputName, sub.config.DLEventBusOutputName} {
+		if deadLetter == "" {
+			continue
+		}
+		if err := h.sender.Send(ctx, deadLetter, e); err != nil {
+			h.log.Error(err, "Failed to deliver event to the dead letter output",
+				"condition", sub.condition, "output", deadLetter, "id", e.ID())
+		}
+	}
+}
+
+// Run serves the Dapr sidecar until the context is done.
+func (h *Handler) Run(ctx context.Context) error {
+	if err := h.restore(ctx); err != nil {
+		return err
+	}
+	if h.correlation != nil || h.window != nil {
+		go h.tick(ctx)
+	}
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", constants.DefaultFuncPort))
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer()
+	runtimev1pb.RegisterAppCallbackServer(server, h)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(lis)
+	}()
+
+	select {
+	case <-ctx.Done():
+		server.GracefulStop()
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+const testFunctionContext = `{
+	"inputs": {
+		"ti-default-es-a-event-a": {"uri": "default-es-a-event-a", "componentName": "t-trigger-ebft-trigger", "componentType": "pubsub.natsstreaming"},
+		"ti-default-es-b-event-b": {"uri": "default-es-b-event-b", "componentName": "t-trigger-ebft-trigger", "componentType": "pubsub.natsstreaming"}
+	},
+	"outputs": {
+		"so-t-trigger-1": {"uri": "so-t-trigger-1", "componentName": "t-trigger-so-t-trigger-1", "componentType": "bindings.http", "operation": "post"},
+		"ebo-metrics": {"uri": "metrics", "componentName": "t-trigger-ebo-metrics", "componentType": "pubsub.natsstreaming"}
+	}
+}`
+
+type fakeDaprClient struct {
+	runtimev1pb.DaprClient
+	published []*runtimev1pb.PublishEventRequest
+	invoked   []*runtimev1pb.InvokeBindingRequest
+}
+
+func (c *fakeDaprClient) PublishEvent(_ context.Context, in *runtimev1pb.PublishEventRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.published = append(c.published, in)
+	return &emptypb.Empty{}, nil
+}
+
+func (c *fakeDaprClient) InvokeBinding(_ context.Context, in *runtimev1pb.InvokeBindingRequest, _ ...grpc.CallOption) (*runtimev1pb.InvokeBindingResponse, error) {
+	c.invoked = append(c.invoked, in)
+	return &runtimev1pb.InvokeBindingResponse{}, nil
+}
+
+type fakeSender struct {
+	sent map[string][]string
+	fail map[string]bool
+}
+
+func (s *fakeSender) Send(_ context.Context, output string, e Event) error {
+	if s.fail[output] {
+		return errors.New("unavailable")
+	}
+	if s.sent == nil {
+		s.sent = map[string][]string{}
+	}
+	s.sent[output] = append(s.sent[output], e.ID())
+	return nil
+}
+
+func newTestHandler(t *testing.T, subscribers map[string]*event.Subscriber, sender Sender) *Handler {
+	fc, err := parseFunctionContext(testFunctionContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &event.TriggerConfig{
+		Inputs: []*event.Input{
+			{Name: "A", Namespace: "default", EventSource: "es-a", Event: "event-a", Topic: "default-es-a-event-a"},
+			{Name: "input-b", Namespace: "default", EventSource: "es-b", Event: "event-b", Topic: "default-es-b-event-b"},
+		},
+		Subscribers: subscribers,
+	}
+	h, err := newHandler(logr.Discard(), config, fc, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func topicEvent(topic string, id string, eventType string) *runtimev1pb.TopicEventRequest {
+	return &runtimev1pb.TopicEventRequest{
+		Id:              id,
+		Source:          "es",
+		Type:            eventType,
+		SpecVersion:     "1.0",
+		DataContentType: "application/json",
+		Data:            []byte(`{"size": 3}`),
+		Topic:           topic,
+		PubsubName:      "t-trigger-ebft-trigger",
+	}
+}
+
+func Test_ListTopicSubscriptions(t *testing.T) {
+	h := newTestHandler(t, nil, &fakeSender{})
+
+	resp, err := h.ListTopicSubscriptions(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range resp.Subscriptions {
+		got = append(got, s.PubsubName+"/"+s.Topic)
+	}
+	want := []string{"t-trigger-ebft-trigger/default-es-a-event-a", "t-trigger-ebft-trigger/default-es-b-event-b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListTopicSubscriptions() = %v, want %v", got, want)
+	}
+}
+
+func Test_OnTopicEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		events    []*runtimev1pb.TopicEventRequest
+		// elapsed is the time between the events.
+		elapsed time.Duration
+		want    []string
+	}{
+		{
+			name:      "legacy input",
+			condition: "A",
+			events:    []*runtimev1pb.TopicEventRequest{topicEvent("default-es-a-event-a", "1", "created")},
+			want:      []string{"1"},
+		},
+		{
+			name:      "all inputs",
+			condition: `A && inputs["input-b"]`,
+			events: []*runtimev1pb.TopicEventRequest{
+				topicEvent("default-es-a-event-a", "1", "created"),
+				topicEvent("default-es-b-event-b", "2", "created"),
+			},
+			want: []string{"2"},
+		},
+		{
+			name:      "input expired",
+			condition: `A && inputs["input-b"]`,
+			events: []*runtimev1pb.TopicEventRequest{
+				topicEvent("default-es-a-event-a", "1", "created"),
+				topicEvent("default-es-b-event-b", "2", "created"),
+			},
+			elapsed: 2 * time.Minute,
+		},
+		{
+			name:      "event attributes",
+			condition: `event.type == "deleted" && event.data.size > 2`,
+			events: []*runtimev1pb.TopicEventRequest{
+				topicEvent("default-es-a-event-a", "1", "created"),
+				topicEvent("default-es-a-event-a", "2", "deleted"),
+			},
+			want: []string{"2"},
+		},
+		{
+			name:      "events of the inputs",
+			condition: `"A" in events && events.A.type == "created" && inputs["input-b"]`,
+			events: []*runtimev1pb.TopicEventRequest{
+				topicEvent("default-es-a-event-a", "1", "created"),
+				topicEvent("default-es-b-event-b", "2", "created"),
+				topicEvent("default-es-b-event-b", "3", "created"),
+			},
+			want: []string{"2", "3"},
+		},
+		{
+			name:      "missing event",
+			condition: `events["input-b"].type == "created"`,
+			events:    []*runtimev1pb.TopicEventRequest{topicEvent("default-es-a-event-a", "1", "created")},
+		},
+		{
+			name:      "unknown topic",
+			condition: "A",
+			events:    []*runtimev1pb.TopicEventRequest{topicEvent("default-es-c-event-c", "1", "created")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			h := newTestHandler(t, map[string]*event.Subscriber{
+				tt.condition: {SinkOutputName: "so-t-trigger-1"},
+			}, sender)
+			now := time.Now()
+			h.now = func() time.Time { return now }
+
+			for _, e := range tt.events {
+				if _, err := h.OnTopicEvent(context.Background(), e); err != nil {
+					t.Fatal(err)
+				}
+				now = now.Add(tt.elapsed)
+			}
+			if got := sender.sent["so-t-trigger-1"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("delivered %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deliver_deadLetter(t *testing.T) {
+	sender := &fakeSender{fail: map[string]bool{"so-t-trigger-1": true}}
+	h := newTestHandler(t, map[string]*event.Subscriber{
+		"A": {
+			SinkOutputName:     "so-t-trigger-1",
+			DLSinkOutputName:   "so-t-trigger-2",
+			EventBusOutputName: "ebo-metrics",
+		},
+	}, sender)
+
+	if _, err := h.OnTopicEvent(context.Background(), topicEvent("default-es-a-event-a", "1", "created")); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"so-t-trigger-2": {"1"}, "ebo-metrics": {"1"}}
+	if !reflect.DeepEqual(sender.sent, want) {
+		t.Errorf("delivered %v, want %v", sender.sent, want)
+	}
+}
+
+func Test_deliver_fanOut(t *testing.T) {
+	sender := &fakeSender{fail: map[string]bool{"so-t-trigger-2": true}}
+	h := newTestHandler(t, map[string]*event.Subscriber{
+		"A": {
+			SinkOutputNames:  []string{"so-t-trigger-1", "so-t-trigger-2", "so-t-trigger-3"},
+			DLSinkOutputName: "so-t-trigger-4",
+		},
+	}, sender)
+
+	if _, err := h.OnTopicEvent(context.Background(), topicEvent("default-es-a-event-a", "1", "created")); err != nil {
+		t.Fatal(err)
+	}
+	// The event is delivered to each selected Function, the failed one sends it to the dead letter sink.
+	want := map[string][]string{"so-t-trigger-1": {"1"}, "so-t-trigger-3": {"1"}, "so-t-trigger-4": {"1"}}
+	if !reflect.DeepEqual(sender.sent, want) {
+		t.Errorf("delivered %v, want %v", sender.sent, want)
+	}
+}
+
+type recordingSender struct {
+	sent map[string][]Event
+}
+
+func (s *recordingSender) Send(_ context.Context, output string, e Event) error {
+	if s.sent == nil {
+		s.sent = map[string][]Event{}
+	}
+	s.sent[output] = append(s.sent[output], e)
+	return nil
+}
+
+func Test_transform(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform *ofevent.Transform
+		want      Event
+		// deadLetter is true if the event can not be transformed and is sent to the dead letter sink.
+		deadLetter bool
+	}{
+		{
+			name: "data and attributes",
+			transform: &ofevent.Transform{
+				Data:       `{"size": event.data.size * 2.0, "source": event.source}`,
+				Attributes: map[string]string{"type": `"resized"`, "origin": "events.A.type"},
+			},
+			want: Event{
+				"specversion":     "1.0",
+				"id":              "1",
+				"source":          "es",
+				"type":            "resized",
+				"origin":          "created",
+				"datacontenttype": "application/json",
+				"data":            map[string]interface{}{"size": float64(6), "source": "es"},
+			},
+		},
+		{
+			name:      "remove",
+			transform: &ofevent.Transform{Remove: []string{"datacontenttype"}},
+			want: Event{
+				"specversion": "1.0",
+				"id":          "1",
+				"source":      "es",
+				"type":        "created",
+				"data":        map[string]interface{}{"size": float64(3)},
+			},
+		},
+		{
+			name:       "evaluation error",
+			transform:  &ofevent.Transform{Attributes: map[string]string{"type": "event.data.missing"}},
+			deadLetter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &recordingSender{}
+			h := newTestHandler(t, map[string]*event.Subscriber{
+				"A": {SinkOutputName: "so-t-trigger-1", DLSinkOutputName: "so-t-trigger-2", Transform: tt.transform},
+			}, sender)
+
+			e := topicEvent("default-es-a-event-a", "1", "created")
+			if _, err := h.OnTopicEvent(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.deadLetter {
+				if len(sender.sent["so-t-trigger-1"]) != 0 || len(sender.sent["so-t-trigger-2"]) != 1 ||
+					!reflect.DeepEqual(sender.sent["so-t-trigger-2"][0], newEvent(e)) {
+					t.Errorf("delivered %v, want the original event in the dead letter sink", sender.sent)
+				}
+				return
+			}
+			if got := sender.sent["so-t-trigger-1"]; len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("delivered %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_daprSender(t *testing.T) {
+	fc, err := parseFunctionContext(testFunctionContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &fakeDaprClient{}
+	s := &daprSender{client: client, outputs: fc.Outputs}
+	e := newEvent(topicEvent("default-es-a-event-a", "1", "created"))
+
+	for _, output := range []string{"so-t-trigger-1", "ebo-metrics"} {
+		if err := s.Send(context.Background(), output, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Send(context.Background(), "so-t-trigger-3", e); err == nil {
+		t.Error("Send() to an unknown output should fail")
+	}
+
+	if len(client.invoked) != 1 || client.invoked[0].Name != "t-trigger-so-t-trigger-1" || client.invoked[0].Operation != "post" ||
+		client.invoked[0].Metadata["Content-Type"] != cloudEventContentType {
+		t.Errorf("invoked %v", client.invoked)
+	}
+	if len(client.published) != 1 || client.published[0].PubsubName != "t-trigger-ebo-metrics" || client.published[0].Topic != "metrics" {
+		t.Errorf("published %v", client.published)
+	}
+
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(client.published[0].Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "1",
+		"source":          "es",
+		"type":            "created",
+		"datacontenttype": "application/json",
+		"data":            map[string]interface{}{"size": float64(3)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("published event %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,529 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+
+	commonv1pb "github.com/dapr/dapr/pkg/proto/common/v1"
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event/condition"
+)
+
+const (
+	// indexKey is the key of the keys of the pending items in the state store, Dapr prefixes it with the app id
+	// of the handler. Each correlation key and window is saved under its own key, so that an event only saves
+	// the items it changed.
+	indexKey = "pending-events"
+	// tickInterval is how often the windows are checked for closing, the correlations for expiring,
+	// and the changed items are saved.
+	tickInterval = 100 * time.Millisecond
+
+	jsonContentType = "application/json"
+
+	itemReceived = "received"
+	itemGroup    = "group"
+	itemWindow   = "window"
+)
+
+// Store persists the pending events of the correlations and windows, so that they survive a restart of the handler.
+type Store interface {
+	// Load returns the saved items.
+	Load(ctx context.Context) ([][]byte, error)
+	// Save saves the items by their keys and deletes the items of the deleted keys.
+	Save(ctx context.Context, items map[string][]byte, deleted []string) error
+}
+
+// daprStore keeps the pending events in a Dapr state store.
+type daprStore struct {
+	client runtimev1pb.DaprClient
+	name   string
+	// keys are the keys of the saved items, they are saved under indexKey when they change.
+	keys map[string]bool
+}
+
+func (s *daprStore) Load(ctx context.Context) ([][]byte, error) {
+	s.keys = map[string]bool{}
+	resp, err := s.client.GetState(ctx, &runtimev1pb.GetStateRequest{StoreName: s.name, Key: indexKey})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pending events from %s: %s", s.name, err.Error())
+	}
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(resp.Data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid keys of the pending events: %s", err.Error())
+	}
+	bulk, err := s.client.GetBulkState(ctx, &runtimev1pb.GetBulkStateRequest{StoreName: s.name, Keys: keys})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pending events from %s: %s", s.name, err.Error())
+	}
+
+	var items [][]byte
+	for _, item := range bulk.Items {
+		if item.Error != "" {
+			return nil, fmt.Errorf("failed to get the pending events %s from %s: %s", item.Key, s.name, item.Error)
+		}
+		// The item was deleted after the keys were saved.
+		if len(item.Data) == 0 {
+			continue
+		}
+		s.keys[item.Key] = true
+		items = append(items, item.Data)
+	}
+	return items, nil
+}
+
+func (s *daprStore) Save(ctx context.Context, items map[string][]byte, deleted []string) error {
+	keys := map[string]bool{}
+	for key := range s.keys {
+		keys[key] = true
+	}
+
+	var states []*commonv1pb.StateItem
+	for key, data := range items {
+		states = append(states, &commonv1pb.StateItem{Key: key, Value: data})
+		keys[key] = true
+	}
+	for _, key := range deleted {
+		delete(keys, key)
+	}
+	if !reflect.DeepEqual(keys, s.keys) {
+		var index []string
+		for key := range keys {
+			index = append(index, key)
+		}
+		sort.Strings(index)
+		data, err := json.Marshal(index)
+		if err != nil {
+			return err
+		}
+		states = append(states, &commonv1pb.StateItem{Key: indexKey, Value: data})
+	}
+
+	if len(states) > 0 {
+		if _, err := s.client.SaveState(ctx, &runtimev1pb.SaveStateRequest{StoreName: s.name, States: states}); err != nil {
+			return fmt.Errorf("failed to save the pending events to %s: %s", s.name, err.Error())
+		}
+	}
+	s.keys = keys
+
+	if len(deleted) > 0 {
+		var states []*commonv1pb.StateItem
+		for _, key := range deleted {
+			states = append(states, &commonv1pb.StateItem{Key: key})
+		}
+		if _, err := s.client.DeleteBulkState(ctx, &runtimev1pb.DeleteBulkStateRequest{StoreName: s.name, States: states}); err != nil {
+			return fmt.Errorf("failed to delete the pending events from %s: %s", s.name, err.Error())
+		}
+	}
+	return nil
+}
+
+// correlation evaluates the correlation keys of the events.
+type correlation struct {
+	key       *condition.Key
+	inputKeys map[string]*condition.Key
+	ttl       time.Duration
+}
+
+func newCorrelation(spec *ofevent.CorrelationSpec) (*correlation, error) {
+	c := &correlation{inputKeys: map[string]*condition.Key{}}
+
+	var err error
+	if c.key, err = condition.NewKey(spec.Key); err != nil {
+		return nil, err
+	}
+	for input, expr := range spec.InputKeys {
+		if c.inputKeys[input], err = condition.NewKey(expr); err != nil {
+			return nil, fmt.Errorf("invalid key of input %s: %s", input, err.Error())
+		}
+	}
+	if c.ttl, err = time.ParseDuration(spec.TTL); err != nil {
+		return nil, fmt.Errorf("invalid correlation ttl %q: %s", spec.TTL, err.Error())
+	}
+	return c, nil
+}
+
+// eval returns the correlation key of the event of the input.
+func (c *correlation) eval(input string, e Event) (string, error) {
+	key := c.key
+	if k, ok := c.inputKeys[input]; ok {
+		key = k
+	}
+	return key.Eval(e)
+}
+
+// window accumulates the events of each input and correlation key.
+type window struct {
+	typ      string
+	duration time.Duration
+	size     int
+}
+
+func newWindow(spec *ofevent.WindowSpec) (*window, error) {
+	w := &window{typ: spec.Type}
+	if spec.Duration != "" {
+		var err error
+		if w.duration, err = time.ParseDuration(spec.Duration); err != nil {
+			return nil, fmt.Errorf("invalid window duration %q: %s", spec.Duration, err.Error())
+		}
+	}
+	if spec.Size != nil {
+		w.size = int(*spec.Size)
+	}
+	return w, nil
+}
+
+// add adds the event to the pending window, it returns true if the window is full.
+func (w *window) add(p *pending, now time.Time, e Event) bool {
+	p.Last = now
+	if w.typ == ofevent.WindowTypeDebounce {
+		p.Events = []Event{e}
+		return false
+	}
+
+	p.Events = append(p.Events, e)
+	return w.size > 0 && len(p.Events) >= w.size
+}
+
+// due returns true if the pending window is closed at the time.
+func (w *window) due(p *pending, now time.Time) bool {
+	if w.typ == ofevent.WindowTypeDebounce {
+		return now.Sub(p.Last) >= w.duration
+	}
+	return w.duration > 0 && now.Sub(p.First) >= w.duration
+}
+
+// emit returns the event evaluated for a closed window. A batch has the attributes of its last event,
+// and its data is the list of the events.
+func (w *window) emit(p *pending) Event {
+	last := p.Events[len(p.Events)-1]
+	if w.typ == ofevent.WindowTypeDebounce {
+		return last
+	}
+
+	e := Event{}
+	for k, v := range last {
+		e[k] = v
+	}
+	data := make([]interface{}, len(p.Events))
+	for i, event := range p.Events {
+		data[i] = map[string]interface{}(event)
+	}
+	e["data"] = data
+	e["datacontenttype"] = jsonContentType
+	return e
+}
+
+// group holds the last event of each input sharing a correlation key.
+type group struct {
+	First  time.Time        `json:"first"`
+	Events map[string]Event `json:"events"`
+}
+
+// pending is an open window of an input and a correlation key.
+type pending struct {
+	First  time.Time `json:"first"`
+	Last   time.Time `json:"last"`
+	Events []Event   `json:"events"`
+}
+
+// item identifies a pending item, which is the last event of an input, the events of a correlation key
+// or a window of an input and a correlation key.
+type item struct {
+	kind  string
+	input string
+	key   string
+}
+
+// stateKey returns the key of the item in the state store.
+func (i item) stateKey() string {
+	switch i.kind {
+	case itemReceived:
+		return fmt.Sprintf("%s/%s", i.kind, url.PathEscape(i.input))
+	case itemGroup:
+		return fmt.Sprintf("%s/%s", i.kind, url.PathEscape(i.key))
+	default:
+		return fmt.Sprintf("%s/%s/%s", i.kind, url.PathEscape(i.input), url.PathEscape(i.key))
+	}
+}
+
+// record is the value of an item in the state store.
+type record struct {
+	Kind     string    `json:"kind"`
+	Input    string    `json:"input,omitempty"`
+	Key      string    `json:"key,omitempty"`
+	Received *received `json:"received,omitempty"`
+	Group    *group    `json:"group,omitempty"`
+	Window   *pending  `json:"window,omitempty"`
+}
+
+// handleStateful correlates the event and adds it to the windows before the conditions are evaluated.
+func (h *Handler) handleStateful(ctx context.Context, inputs []string, e Event) {
+	var matches []*match
+
+	h.mu.Lock()
+	now := h.now()
+	for _, input := range inputs {
+		key := ""
+		if h.correlation != nil {
+			var err error
+			if key, err = h.correlation.eval(input, e); err != nil {
+				h.log.Error(err, "Failed to evaluate the correlation key, dropping event", "input", input, "id", e.ID())
+				continue
+			}
+		}
+
+		if h.window == nil {
+			matches = append(matches, h.evaluate(now, key, input, e)...)
+			continue
+		}
+
+		if h.windows[input] == nil {
+			h.windows[input] = map[string]*pending{}
+		}
+		p, ok := h.windows[input][key]
+		if !ok {
+			p = &pending{First: now}
+			h.windows[input][key] = p
+		}
+		h.changed(itemWindow, input, key)
+		if h.window.add(p, now, e) {
+			h.closeWindow(input, key)
+			matches = append(matches, h.evaluate(now, key, input, h.window.emit(p))...)
+		}
+	}
+	h.mu.Unlock()
+
+	h.dispatch(ctx, matches)
+}
+
+// evaluate records the event of the input and returns the matched subscribers. With a correlation, the conditions
+// are evaluated on the events of the same key, which are discarded once a subscriber matches.
+// The caller must hold the lock.
+func (h *Handler) evaluate(now time.Time, key string, input string, e Event) []*match {
+	if h.correlation == nil {
+		h.received[input] = &received{At: now, Event: e}
+		h.changed(itemReceived, input, "")
+		return h.match(h.variables(now, e))
+	}
+
+	g, ok := h.groups[key]
+	if !ok || now.Sub(g.First) >= h.correlation.ttl {
+		g = &group{First: now, Events: map[string]Event{}}
+		h.groups[key] = g
+	}
+	g.Events[input] = e
+	h.changed(itemGroup, "", key)
+
+	vars := &condition.Variables{
+		Inputs: map[string]bool{},
+		Event:  e,
+		Events: map[string]map[string]interface{}{},
+	}
+	for _, name := range h.inputNames {
+		event, ok := g.Events[name]
+		vars.Inputs[name] = ok
+		if ok {
+			vars.Events[name] = event
+		}
+	}
+
+	matches := h.match(vars)
+	if len(matches) > 0 {
+		delete(h.groups, key)
+	}
+	return matches
+}
+
+// closeWindow removes the window of the input and correlation key. The caller must hold the lock.
+func (h *Handler) closeWindow(input string, key string) {
+	h.changed(itemWindow, input, key)
+	delete(h.windows[input], key)
+	if len(h.windows[input]) == 0 {
+		delete(h.windows, input)
+	}
+}
+
+// changed records that the item has changed since it was saved. The caller must hold the lock.
+func (h *Handler) changed(kind string, input string, key string) {
+	if h.store != nil {
+		h.dirty[item{kind: kind, input: input, key: key}] = true
+	}
+}
+
+// tick closes the windows, expires the correlations and saves the changed items until the context is done.
+func (h *Handler) tick(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.flush(ctx)
+			h.persist(ctx)
+		}
+	}
+}
+
+// flush evaluates the windows closed by now and discards the expired correlations.
+func (h *Handler) flush(ctx context.Context) {
+	var matches []*match
+
+	h.mu.Lock()
+	now := h.now()
+	if h.window != nil {
+		for _, input := range h.inputNames {
+			var keys []string
+			for key := range h.windows[input] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				p := h.windows[input][key]
+				if !h.window.due(p, now) {
+					continue
+				}
+				h.closeWindow(input, key)
+				matches = append(matches, h.evaluate(now, key, input, h.window.emit(p))...)
+			}
+		}
+	}
+
+	if h.correlation != nil {
+		for key, g := range h.groups {
+			if now.Sub(g.First) >= h.correlation.ttl {
+				delete(h.groups, key)
+				h.changed(itemGroup, "", key)
+				h.log.V(1).Info("Correlation expired", "key", key)
+			}
+		}
+	}
+
+	h.mu.Unlock()
+
+	h.dispatch(ctx, matches)
+}
+
+// persist saves the items changed since the last call and deletes the removed ones. The state store is called
+// without holding the lock, so that the events are not blocked by it, and the items are saved again on the next
+// tick if it fails. It is only called by tick, so the saves are not reordered.
+func (h *Handler) persist(ctx context.Context) {
+	if h.store == nil {
+		return
+	}
+
+	h.mu.Lock()
+	dirty := h.dirty
+	h.dirty = map[item]bool{}
+	items := map[string][]byte{}
+	var deleted []string
+	for i := range dirty {
+		r := h.record(i)
+		if r == nil {
+			deleted = append(deleted, i.stateKey())
+			continue
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			h.log.Error(err, "Failed to encode the pending events", "key", i.stateKey())
+			continue
+		}
+		items[i.stateKey()] = data
+	}
+	h.mu.Unlock()
+
+	if len(items) == 0 && len(deleted) == 0 {
+		return
+	}
+	sort.Strings(deleted)
+	if err := h.store.Save(ctx, items, deleted); err != nil {
+		h.log.Error(err, "Failed to save the pending events")
+		h.mu.Lock()
+		for i := range dirty {
+			h.dirty[i] = true
+		}
+		h.mu.Unlock()
+	}
+}
+
+// record returns the record of the item, nil is returned if the item has been removed. The caller must hold the lock.
+func (h *Handler) record(i item) *record {
+	r := &record{Kind: i.kind, Input: i.input, Key: i.key}
+	switch i.kind {
+	case itemReceived:
+		if r.Received = h.received[i.input]; r.Received == nil {
+			return nil
+		}
+	case itemGroup:
+		if r.Group = h.groups[i.key]; r.Group == nil {
+			return nil
+		}
+	default:
+		if r.Window = h.windows[i.input][i.key]; r.Window == nil {
+			return nil
+		}
+	}
+	return r
+}
+
+// restore loads the pending events saved before the handler restarted.
+func (h *Handler) restore(ctx context.Context) error {
+	if h.store == nil {
+		return nil
+	}
+
+	items, err := h.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, data := range items {
+		r := &record{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return fmt.Errorf("invalid pending events: %s", err.Error())
+		}
+		switch {
+		case r.Kind == itemReceived && r.Received != nil:
+			h.received[r.Input] = r.Received
+		case r.Kind == itemGroup && r.Group != nil:
+			h.groups[r.Key] = r.Group
+		case r.Kind == itemWindow && r.Window != nil:
+			if h.windows[r.Input] == nil {
+				h.windows[r.Input] = map[string]*pending{}
+			}
+			h.windows[r.Input][r.Key] = r.Window
+		}
+	}
+	return nil
+}
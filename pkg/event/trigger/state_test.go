@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	runtimev1pb "github.com/dapr/dapr/pkg/proto/runtime/v1"
+	"github.com/go-logr/logr"
+
+	ofevent "github.com/openfunction/apis/events/v1alpha1"
+	"github.com/openfunction/pkg/event"
+)
+
+type memoryStore struct {
+	items map[string][]byte
+	err   error
+}
+
+func (s *memoryStore) Load(context.Context) ([][]byte, error) {
+	var keys []string
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var items [][]byte
+	for _, key := range keys {
+		items = append(items, s.items[key])
+	}
+	return items, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, items map[string][]byte, deleted []string) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.items == nil {
+		s.items = map[string][]byte{}
+	}
+	for key, data := range items {
+		s.items[key] = data
+	}
+	for _, key := range deleted {
+		delete(s.items, key)
+	}
+	return nil
+}
+
+func (s *memoryStore) keys() []string {
+	var keys []string
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newStatefulHandler creates a handler whose clock is advanced by the returned function.
+func newStatefulHandler(
+	t *testing.T,
+	subscribers map[string]*event.Subscriber,
+	correlation *ofevent.CorrelationSpec,
+	window *ofevent.WindowSpec,
+	store Store,
+	sender Sender,
+) (*Handler, func(d time.Duration)) {
+	fc, err := parseFunctionContext(testFunctionContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &event.TriggerConfig{
+		Inputs: []*event.Input{
+			{Name: "A", Namespace: "default", EventSource: "es-a", Event: "event-a", Topic: "default-es-a-event-a"},
+			{Name: "input-b", Namespace: "default", EventSource: "es-b", Event: "event-b", Topic: "default-es-b-event-b"},
+		},
+		Subscribers: subscribers,
+		Correlation: correlation,
+		Window:      window,
+	}
+	h, err := newHandler(logr.Discard(), config, fc, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.store = store
+	if err := h.restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	return h, func(d time.Duration) { now = now.Add(d) }
+}
+
+func orderEvent(topic string, id string, order int) *runtimev1pb.TopicEventRequest {
+	e := topicEvent(topic, id, "created")
+	e.Data = []byte(fmt.Sprintf(`{"order": "%d"}`, order))
+	return e
+}
+
+func Test_correlation(t *testing.T) {
+	sender := &fakeSender{}
+	h, advance := newStatefulHandler(t, map[string]*event.Subscriber{
+		`A && inputs["input-b"]`: {SinkOutputName: "so-t-trigger-1"},
+	}, &ofevent.CorrelationSpec{Key: "event.data.order", TTL: "1m"}, nil, &memoryStore{}, sender)
+
+	ctx := context.Background()
+	for _, req := range []*runtimev1pb.TopicEventRequest{
+		orderEvent("default-es-a-event-a", "a1", 1),
+		orderEvent("default-es-b-event-b", "b2", 2),
+		orderEvent("default-es-b-event-b", "b1", 1),
+		// The correlation of order 1 has been discarded by the subscriber.
+		orderEvent("default-es-b-event-b", "b1-again", 1),
+	} {
+		if _, err := h.OnTopicEvent(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := sender.sent["so-t-trigger-1"], []string{"b1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered = %v, want %v", got, want)
+	}
+
+	// The correlation of order 2 expires before A arrives.
+	advance(time.Minute)
+	h.flush(ctx)
+	if _, err := h.OnTopicEvent(ctx, orderEvent("default-es-a-event-a", "a2", 2)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sender.sent["so-t-trigger-1"], []string{"b1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered = %v, want %v", got, want)
+	}
+}
+
+func Test_correlation_invalidKey(t *testing.T) {
+	sender := &fakeSender{}
+	h, _ := newStatefulHandler(t, map[string]*event.Subscriber{
+		"A": {SinkOutputName: "so-t-trigger-1"},
+	}, &ofevent.CorrelationSpec{Key: "event.data.missing", TTL: "1m"}, nil, nil, sender)
+
+	if _, err := h.OnTopicEvent(context.Background(), orderEvent("default-es-a-event-a", "a1", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("delivered = %v, want the event dropped", sender.sent)
+	}
+}
+
+func Test_window_debounce(t *testing.T) {
+	sender := &fakeSender{}
+	h, advance := newStatefulHandler(t, map[string]*event.Subscriber{
+		"A": {SinkOutputName: "so-t-trigger-1"},
+	}, nil, &ofevent.WindowSpec{Type: ofevent.WindowTypeDebounce, Duration: "10s"}, nil, sender)
+
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := h.OnTopicEvent(ctx, topicEvent("default-es-a-event-a", id, "created")); err != nil {
+			t.Fatal(err)
+		}
+		advance(5 * time.Second)
+		h.flush(ctx)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("delivered = %v before the burst ends", sender.sent)
+	}
+
+	advance(5 * time.Second)
+	h.flush(ctx)
+	if got, want := sender.sent["so-t-trigger-1"], []string{"3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered = %v, want %v", got, want)
+	}
+}
+
+func Test_window_batch(t *testing.T) {
+	size := int32(2)
+	sender := &recordingSender{}
+	h, advance := newStatefulHandler(t, map[string]*event.Subscriber{
+		"A": {SinkOutputName: "so-t-trigger-1"},
+	}, nil, &ofevent.WindowSpec{Type: ofevent.WindowTypeBatch, Size: &size, Duration: "1m"}, nil, sender)
+
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := h.OnTopicEvent(ctx, topicEvent("default-es-a-event-a", id, "created")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The batch of 3 is closed by its duration.
+	advance(time.Minute)
+	h.flush(ctx)
+
+	sent := sender.sent["so-t-trigger-1"]
+	if len(sent) != 2 {
+		t.Fatalf("delivered %d batches, want 2", len(sent))
+	}
+	for i, want := range [][]string{{"1", "2"}, {"3"}} {
+		batch := sent[i]
+		if batch.ID() != want[len(want)-1] || batch["datacontenttype"] != "application/json" {
+			t.Errorf("batch %d = %v, want the attributes of event %s", i, batch, want[len(want)-1])
+		}
+		var ids []string
+		for _, e := range batch["data"].([]interface{}) {
+			ids = append(ids, e.(map[string]interface{})["id"].(string))
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("batch %d has events %v, want %v", i, ids, want)
+		}
+	}
+}
+
+func Test_restore(t *testing.T) {
+	store := &memoryStore{}
+	subscribers := map[string]*event.Subscriber{`A && inputs["input-b"]`: {SinkOutputName: "so-t-trigger-1"}}
+	correlation := &ofevent.CorrelationSpec{Key: "event.data.order", TTL: "1m"}
+	ctx := context.Background()
+
+	h, _ := newStatefulHandler(t, subscribers, correlation, nil, store, &fakeSender{})
+	if _, err := h.OnTopicEvent(ctx, orderEvent("default-es-a-event-a", "a1", 1)); err != nil {
+		t.Fatal(err)
+	}
+	h.persist(ctx)
+
+	// The handler restarts and restores the event of A.
+	sender := &fakeSender{}
+	h, _ = newStatefulHandler(t, subscribers, correlation, nil, store, sender)
+	if _, err := h.OnTopicEvent(ctx, orderEvent("default-es-b-event-b", "b1", 1)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sender.sent["so-t-trigger-1"], []string{"b1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered = %v, want %v", got, want)
+	}
+}
+
+func Test_persist(t *testing.T) {
+	store := &memoryStore{}
+	size := int32(2)
+	h, _ := newStatefulHandler(t, map[string]*event.Subscriber{
+		`A && inputs["input-b"]`: {SinkOutputName: "so-t-trigger-1"},
+	}, &ofevent.CorrelationSpec{Key: "event.data.order", TTL: "1m"},
+		&ofevent.WindowSpec{Type: ofevent.WindowTypeBatch, Size: &size, Duration: "1m"}, store, &fakeSender{})
+
+	ctx := context.Background()
+	for _, req := range []*runtimev1pb.TopicEventRequest{
+		orderEvent("default-es-a-event-a", "a1", 1),
+		orderEvent("default-es-a-event-a", "a2", 2),
+		orderEvent("default-es-b-event-b", "b1", 1),
+	} {
+		if _, err := h.OnTopicEvent(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The events are only saved by the ticker, each window under its own key.
+	if len(store.items) != 0 {
+		t.Fatalf("saved %v before the tick", store.keys())
+	}
+	h.persist(ctx)
+	want := []string{"window/A/1", "window/A/2", "window/input-b/1"}
+	if got := store.keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+
+	// The window of order 1 is full, it is deleted and the correlation of order 1 is saved.
+	if _, err := h.OnTopicEvent(ctx, orderEvent("default-es-a-event-a", "a3", 1)); err != nil {
+		t.Fatal(err)
+	}
+	// The items are saved again on the next tick if the store fails.
+	store.err = fmt.Errorf("unavailable")
+	h.persist(ctx)
+	store.err = nil
+	h.persist(ctx)
+	want = []string{"group/1", "window/A/2", "window/input-b/1"}
+	if got := store.keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+}
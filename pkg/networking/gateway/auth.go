@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+const (
+	// DefaultAPIKeyHeader is the header carrying the API key if it is not specified.
+	DefaultAPIKeyHeader = "x-api-key"
+	// JWTProviderName is the name of the JWT provider generated for a function.
+	JWTProviderName = "ofn-jwt"
+)
+
+// SecurityPolicyGVK is the policy resource of Envoy Gateway which enforces the authentication of the HTTPRoutes.
+var SecurityPolicyGVK = schema.GroupVersionKind{
+	Group:   "gateway.envoyproxy.io",
+	Version: "v1alpha1",
+	Kind:    "SecurityPolicy",
+}
+
+// NewSecurityPolicySpec translates the authentication of a function into the spec of a SecurityPolicy
+// which targets the HTTPRoute with the given name.
+func NewSecurityPolicySpec(routeName string, auth *openfunction.HttpAuth) map[string]interface{} {
+	spec := map[string]interface{}{
+		"targetRefs": httpRouteTargetRefs(routeName),
+	}
+
+	if jwt := auth.JWT; jwt != nil {
+		provider := map[string]interface{}{
+			"name":       JWTProviderName,
+			"issuer":     jwt.Issuer,
+			"remoteJWKS": map[string]interface{}{"uri": jwt.JWKSURI},
+		}
+		if len(jwt.Audiences) != 0 {
+			var audiences []interface{}
+			for _, audience := range jwt.Audiences {
+				audiences = append(audiences, audience)
+			}
+			provider["audiences"] = audiences
+		}
+		spec["jwt"] = map[string]interface{}{"providers": []interface{}{provider}}
+	}
+
+	if apiKey := auth.APIKey; apiKey != nil {
+		header := apiKey.Header
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		spec["apiKeyAuth"] = map[string]interface{}{
+			"credentialRefs": []interface{}{
+				map[string]interface{}{"name": apiKey.SecretRef.Name},
+			},
+			"extractFrom": []interface{}{
+				map[string]interface{}{"headers": []interface{}{header}},
+			},
+		}
+	}
+
+	if basic := auth.Basic; basic != nil {
+		spec["basicAuth"] = map[string]interface{}{
+			"users": map[string]interface{}{"name": basic.SecretRef.Name},
+		}
+	}
+
+	return spec
+}
+
+// httpRouteTargetRefs returns the targetRefs of a policy attached to the HTTPRoute with the given name.
+func httpRouteTargetRefs(routeName string) []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"group": GroupName,
+			"kind":  "HTTPRoute",
+			"name":  routeName,
+		},
+	}
+}
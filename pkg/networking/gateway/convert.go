@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+// The route types of the function share the JSON schema of the Gateway API route types,
+// so they are converted through their JSON representation. Fields which are not known
+// by the destination type are dropped.
+func convert[In, Out any](in In) Out {
+	var out Out
+	// Marshaling these plain structs never fails.
+	bs, _ := json.Marshal(in)
+	_ = json.Unmarshal(bs, &out)
+	return out
+}
+
+// ToHostnames converts the hostnames of a function route into Gateway API hostnames.
+func ToHostnames(hostnames []openfunction.Hostname) []v1beta1.Hostname {
+	return convert[[]openfunction.Hostname, []v1beta1.Hostname](hostnames)
+}
+
+// FromHostnames converts Gateway API hostnames into the hostnames of a function route.
+func FromHostnames(hostnames []v1beta1.Hostname) []openfunction.Hostname {
+	return convert[[]v1beta1.Hostname, []openfunction.Hostname](hostnames)
+}
+
+// ToHTTPRouteRules converts the rules of a function route into Gateway API HTTPRoute rules.
+func ToHTTPRouteRules(rules []openfunction.HTTPRouteRule) []v1beta1.HTTPRouteRule {
+	return convert[[]openfunction.HTTPRouteRule, []v1beta1.HTTPRouteRule](rules)
+}
+
+// FromHTTPRouteRules converts Gateway API HTTPRoute rules into the rules of a function route.
+func FromHTTPRouteRules(rules []v1beta1.HTTPRouteRule) []openfunction.HTTPRouteRule {
+	return convert[[]v1beta1.HTTPRouteRule, []openfunction.HTTPRouteRule](rules)
+}
+
+// ToHTTPRouteMatches converts the matches of a function route into Gateway API HTTPRoute matches.
+func ToHTTPRouteMatches(matches []openfunction.HTTPRouteMatch) []v1beta1.HTTPRouteMatch {
+	return convert[[]openfunction.HTTPRouteMatch, []v1beta1.HTTPRouteMatch](matches)
+}
+
+// ToHTTPPathMatches converts the path matches of a function route status into Gateway API path matches.
+func ToHTTPPathMatches(paths []openfunction.HTTPPathMatch) []v1beta1.HTTPPathMatch {
+	return convert[[]openfunction.HTTPPathMatch, []v1beta1.HTTPPathMatch](paths)
+}
+
+// FromHTTPPathMatches converts Gateway API path matches into the path matches of a function route status.
+func FromHTTPPathMatches(paths []v1beta1.HTTPPathMatch) []openfunction.HTTPPathMatch {
+	return convert[[]v1beta1.HTTPPathMatch, []openfunction.HTTPPathMatch](paths)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"reflect"
+	"testing"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+func Test_HTTPRouteRulesRoundTrip(t *testing.T) {
+	prefix := openfunction.PathMatchPathPrefix
+	path := "/api"
+	kind := openfunction.Kind("Service")
+	port := openfunction.PortNumber(80)
+	weight := int32(10)
+	rules := []openfunction.HTTPRouteRule{{
+		Matches: []openfunction.HTTPRouteMatch{{
+			Path:    &openfunction.HTTPPathMatch{Type: &prefix, Value: &path},
+			Headers: []openfunction.HTTPHeaderMatch{{Name: "X-Api-Version", Value: "1"}},
+		}},
+		Filters: []openfunction.HTTPRouteFilter{{
+			Type: openfunction.HTTPRouteFilterRequestHeaderModifier,
+			RequestHeaderModifier: &openfunction.HTTPRequestHeaderFilter{
+				Add: []openfunction.HTTPHeader{{Name: "X-Tenant", Value: "demo"}},
+			},
+		}},
+		BackendRefs: []openfunction.HTTPBackendRef{{
+			BackendRef: openfunction.BackendRef{
+				BackendObjectReference: openfunction.BackendObjectReference{Kind: &kind, Name: "sample", Port: &port},
+				Weight:                 &weight,
+			},
+		}},
+	}}
+
+	converted := ToHTTPRouteRules(rules)
+	if len(converted) != 1 || *converted[0].Matches[0].Path.Value != path ||
+		converted[0].Matches[0].Headers[0].Name != "X-Api-Version" ||
+		*converted[0].BackendRefs[0].Weight != weight {
+		t.Fatalf("unexpected converted rules %+v", converted)
+	}
+	if got := FromHTTPRouteRules(converted); !reflect.DeepEqual(got, rules) {
+		t.Errorf("FromHTTPRouteRules(ToHTTPRouteRules()) = %+v, want %+v", got, rules)
+	}
+	if got := ToHTTPRouteRules(nil); got != nil {
+		t.Errorf("expected nil rules, got %+v", got)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// EnvoyGatewayControllerName is the controller name of the GatewayClasses of Envoy Gateway,
+	// which is the only implementation serving the policies generated for functions.
+	EnvoyGatewayControllerName = "gateway.envoyproxy.io/gatewayclass-controller"
+
+	PolicyConditionAccepted = "Accepted"
+)
+
+// PolicyAcceptedCondition returns the Accepted condition of a policy of Envoy Gateway for the current generation of
+// the policy, nil is returned if the policy has not been processed yet. Envoy Gateway reports the conditions per
+// ancestor Gateway since v1.0 and in the top level of the status before, the condition of any ancestor which does
+// not accept the policy is returned.
+func PolicyAcceptedCondition(policy *unstructured.Unstructured) (*metav1.Condition, error) {
+	var conditionLists [][]interface{}
+	if conditions, ok, _ := unstructured.NestedSlice(policy.Object, "status", "conditions"); ok {
+		conditionLists = append(conditionLists, conditions)
+	}
+	ancestors, _, _ := unstructured.NestedSlice(policy.Object, "status", "ancestors")
+	for _, ancestor := range ancestors {
+		if ancestor, ok := ancestor.(map[string]interface{}); ok {
+			if conditions, ok, _ := unstructured.NestedSlice(ancestor, "conditions"); ok {
+				conditionLists = append(conditionLists, conditions)
+			}
+		}
+	}
+
+	var accepted *metav1.Condition
+	for _, list := range conditionLists {
+		var conditions []metav1.Condition
+		for _, item := range list {
+			content, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			var condition metav1.Condition
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &condition); err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, condition)
+		}
+		condition := meta.FindStatusCondition(conditions, PolicyConditionAccepted)
+		// The conditions of the former generations are stale.
+		if condition == nil || (condition.ObservedGeneration != 0 && condition.ObservedGeneration < policy.GetGeneration()) {
+			continue
+		}
+		if condition.Status != metav1.ConditionTrue {
+			return condition, nil
+		}
+		accepted = condition
+	}
+	return accepted, nil
+}
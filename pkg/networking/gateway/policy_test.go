@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_PolicyAcceptedCondition(t *testing.T) {
+	condition := func(status string, generation int64) interface{} {
+		return map[string]interface{}{
+			"type":               PolicyConditionAccepted,
+			"status":             status,
+			"reason":             "Test",
+			"message":            "",
+			"observedGeneration": generation,
+			"lastTransitionTime": "2023-01-01T00:00:00Z",
+		}
+	}
+	ancestor := func(conditions ...interface{}) interface{} {
+		return map[string]interface{}{"conditions": conditions}
+	}
+
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		want   *metav1.ConditionStatus
+	}{
+		{
+			name: "not processed",
+		},
+		{
+			name:   "accepted by all ancestors",
+			status: map[string]interface{}{"ancestors": []interface{}{ancestor(condition("True", 2)), ancestor(condition("True", 2))}},
+			want:   statusPtr(metav1.ConditionTrue),
+		},
+		{
+			name:   "rejected by an ancestor",
+			status: map[string]interface{}{"ancestors": []interface{}{ancestor(condition("True", 2)), ancestor(condition("False", 2))}},
+			want:   statusPtr(metav1.ConditionFalse),
+		},
+		{
+			name:   "stale generation",
+			status: map[string]interface{}{"ancestors": []interface{}{ancestor(condition("True", 1))}},
+		},
+		{
+			name:   "top level conditions",
+			status: map[string]interface{}{"conditions": []interface{}{condition("True", 2)}},
+			want:   statusPtr(metav1.ConditionTrue),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			policy.SetGeneration(2)
+			if tt.status != nil {
+				policy.Object["status"] = tt.status
+			}
+			got, err := PolicyAcceptedCondition(policy)
+			if err != nil {
+				t.Fatalf("PolicyAcceptedCondition() error = %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && got.Status != *tt.want) {
+				t.Errorf("PolicyAcceptedCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func statusPtr(status metav1.ConditionStatus) *metav1.ConditionStatus {
+	return &status
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+// BackendTrafficPolicyGVK is the policy resource of Envoy Gateway which enforces the rate limit of the HTTPRoutes.
+var BackendTrafficPolicyGVK = schema.GroupVersionKind{
+	Group:   "gateway.envoyproxy.io",
+	Version: "v1alpha1",
+	Kind:    "BackendTrafficPolicy",
+}
+
+// NewBackendTrafficPolicySpec translates the rate limit of a function into the spec of a BackendTrafficPolicy
+// which targets the HTTPRoute with the given name.
+// The requests sharing one bucket are limited by the local rate limit of each Envoy, while the keyed requests
+// require the global rate limit, as the local rate limit of Envoy Gateway can not separate the requests.
+// The global rate limit is only accepted if the rate limit service of Envoy Gateway is enabled.
+// Envoy Gateway counts the requests in fixed windows, so the burst is not supported.
+func NewBackendTrafficPolicySpec(routeName string, rateLimit *openfunction.RateLimit) map[string]interface{} {
+	rule := map[string]interface{}{
+		"limit": map[string]interface{}{
+			"requests": int64(rateLimit.RequestsPerSecond),
+			"unit":     "Second",
+		},
+	}
+
+	limitType := "Local"
+	if key := rateLimit.Key; key != nil {
+		limitType = "Global"
+		if key.Header != "" {
+			rule["clientSelectors"] = []interface{}{
+				map[string]interface{}{
+					"headers": []interface{}{
+						map[string]interface{}{"name": key.Header, "type": "Distinct"},
+					},
+				},
+			}
+		}
+	}
+
+	rules := []interface{}{rule}
+	// A source CIDR only matches the clients of its address family, so each family has its own rule.
+	if key := rateLimit.Key; key != nil && key.Header == "" && key.ClientIP {
+		rules = nil
+		for _, cidr := range []string{"0.0.0.0/0", "::/0"} {
+			cidrRule := runtime.DeepCopyJSON(rule)
+			cidrRule["clientSelectors"] = []interface{}{
+				map[string]interface{}{
+					"sourceCIDR": map[string]interface{}{"type": "Distinct", "value": cidr},
+				},
+			}
+			rules = append(rules, cidrRule)
+		}
+	}
+
+	limit := map[string]interface{}{"type": limitType}
+	if limitType == "Local" {
+		limit["local"] = map[string]interface{}{"rules": rules}
+	} else {
+		limit["global"] = map[string]interface{}{"rules": rules}
+	}
+
+	return map[string]interface{}{
+		"targetRefs": httpRouteTargetRefs(routeName),
+		"rateLimit":  limit,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	openfunction "github.com/openfunction/apis/core/v1beta2"
+)
+
+func Test_NewBackendTrafficPolicySpec(t *testing.T) {
+	tests := []struct {
+		name      string
+		rateLimit *openfunction.RateLimit
+		limitType string
+		selectors []interface{}
+	}{
+		{
+			name:      "shared bucket",
+			rateLimit: &openfunction.RateLimit{RequestsPerSecond: 10},
+			limitType: "Local",
+			selectors: []interface{}{nil},
+		},
+		{
+			name:      "keyed by header",
+			rateLimit: &openfunction.RateLimit{RequestsPerSecond: 10, Key: &openfunction.RateLimitKey{Header: "x-user"}},
+			limitType: "Global",
+			selectors: []interface{}{
+				[]interface{}{map[string]interface{}{
+					"headers": []interface{}{map[string]interface{}{"name": "x-user", "type": "Distinct"}},
+				}},
+			},
+		},
+		{
+			name:      "keyed by client IP",
+			rateLimit: &openfunction.RateLimit{RequestsPerSecond: 10, Key: &openfunction.RateLimitKey{ClientIP: true}},
+			limitType: "Global",
+			selectors: []interface{}{
+				[]interface{}{map[string]interface{}{
+					"sourceCIDR": map[string]interface{}{"type": "Distinct", "value": "0.0.0.0/0"},
+				}},
+				[]interface{}{map[string]interface{}{
+					"sourceCIDR": map[string]interface{}{"type": "Distinct", "value": "::/0"},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := NewBackendTrafficPolicySpec("sample", tt.rateLimit)
+			if limitType, _, _ := unstructured.NestedString(spec, "rateLimit", "type"); limitType != tt.limitType {
+				t.Errorf("expected the %s rate limit, got %s", tt.limitType, limitType)
+			}
+			rules, _, _ := unstructured.NestedSlice(spec, "rateLimit", map[string]string{"Local": "local", "Global": "global"}[tt.limitType], "rules")
+			if len(rules) != len(tt.selectors) {
+				t.Fatalf("expected %d rules, got %d", len(tt.selectors), len(rules))
+			}
+			for index, rule := range rules {
+				rule := rule.(map[string]interface{})
+				if requests, _, _ := unstructured.NestedInt64(rule, "limit", "requests"); requests != 10 {
+					t.Errorf("rule %d: expected 10 requests, got %d", index, requests)
+				}
+				selectors, ok := rule["clientSelectors"]
+				if !ok {
+					selectors = nil
+				}
+				if tt.selectors[index] == nil && selectors == nil {
+					continue
+				}
+				if !reflect.DeepEqual(selectors, tt.selectors[index]) {
+					t.Errorf("rule %d: expected the client selectors %v, got %v", index, tt.selectors[index], selectors)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	"github.com/openfunction/pkg/util"
+)
+
+const (
+	ReferenceGrantKind = "ReferenceGrant"
+	// ReferencePolicyKind is the former name of ReferenceGrant, which is served by Gateway API v0.4.x.
+	ReferencePolicyKind = "ReferencePolicy"
+
+	// GatewayReferenceGrantNameTmpl is the name of the grant generated for a Gateway, `ofn-gateway-{namespace}-{name}`.
+	GatewayReferenceGrantNameTmpl = "ofn-gateway-%s-%s"
+)
+
+// ReferenceGrant allows the objects of a namespace to refer to the objects in the namespace of the grant.
+// The spec shares the same schema in all versions of ReferenceGrant and ReferencePolicy.
+type ReferenceGrant struct {
+	Namespace string
+	Name      string
+	Spec      v1beta1.ReferenceGrantSpec
+}
+
+// ReferenceGrantGVK returns the served version of ReferenceGrant, or ReferencePolicy if ReferenceGrant is not served.
+// A NoMatch error is returned if the cluster serves neither of them.
+func ReferenceGrantGVK(mapper meta.RESTMapper) (schema.GroupVersionKind, error) {
+	var err error
+	for _, kind := range []string{ReferenceGrantKind, ReferencePolicyKind} {
+		var mapping *meta.RESTMapping
+		mapping, err = mapper.RESTMapping(schema.GroupKind{Group: GroupName, Kind: kind})
+		if err == nil {
+			return mapping.GroupVersionKind, nil
+		}
+		if !meta.IsNoMatchError(err) {
+			return schema.GroupVersionKind{}, err
+		}
+	}
+	return schema.GroupVersionKind{}, err
+}
+
+// CrossNamespaceRef is an object in another namespace which is referred to by a route.
+type CrossNamespaceRef struct {
+	Namespace string
+	Group     string
+	Kind      string
+	Name      string
+}
+
+func (ref CrossNamespaceRef) String() string {
+	if ref.Group == "" {
+		return fmt.Sprintf("%s %s/%s", ref.Kind, ref.Namespace, ref.Name)
+	}
+	return fmt.Sprintf("%s.%s %s/%s", ref.Kind, ref.Group, ref.Namespace, ref.Name)
+}
+
+// CrossNamespaceRefsForHTTPRoute returns the backends of an HTTPRoute in other namespaces,
+// including the backends of the RequestMirror filters.
+func CrossNamespaceRefsForHTTPRoute(route *v1beta1.HTTPRoute) []CrossNamespaceRef {
+	refs := make(map[CrossNamespaceRef]bool)
+	addRef := func(ref v1beta1.BackendObjectReference) {
+		if ref.Namespace == nil || string(*ref.Namespace) == route.Namespace {
+			return
+		}
+		r := CrossNamespaceRef{Namespace: string(*ref.Namespace), Kind: "Service", Name: string(ref.Name)}
+		if ref.Group != nil {
+			r.Group = string(*ref.Group)
+		}
+		if ref.Kind != nil {
+			r.Kind = string(*ref.Kind)
+		}
+		refs[r] = true
+	}
+	addFilters := func(filters []v1beta1.HTTPRouteFilter) {
+		for _, filter := range filters {
+			if filter.RequestMirror != nil {
+				addRef(filter.RequestMirror.BackendRef)
+			}
+		}
+	}
+
+	for _, rule := range route.Spec.Rules {
+		addFilters(rule.Filters)
+		for _, backendRef := range rule.BackendRefs {
+			addRef(backendRef.BackendObjectReference)
+			addFilters(backendRef.Filters)
+		}
+	}
+
+	var sorted []CrossNamespaceRef
+	for ref := range refs {
+		sorted = append(sorted, ref)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+	return sorted
+}
+
+// UnpermittedRefs returns the refs of which the namespaces have no ReferenceGrant allowing the given objects to refer
+// to them. Nothing is returned if the cluster serves neither ReferenceGrant nor ReferencePolicy.
+func UnpermittedRefs(
+	ctx context.Context,
+	c client.Client,
+	from v1beta1.ReferenceGrantFrom,
+	refs []CrossNamespaceRef) ([]CrossNamespaceRef, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	gvk, err := ReferenceGrantGVK(c.RESTMapper())
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	specs := make(map[string][]v1beta1.ReferenceGrantSpec)
+	var unpermitted []CrossNamespaceRef
+	for _, ref := range refs {
+		if _, ok := specs[ref.Namespace]; !ok {
+			list := &unstructured.UnstructuredList{}
+			list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+			if err := c.List(ctx, list, client.InNamespace(ref.Namespace)); err != nil {
+				return nil, err
+			}
+			specs[ref.Namespace] = []v1beta1.ReferenceGrantSpec{}
+			for _, item := range list.Items {
+				content, _, _ := unstructured.NestedMap(item.Object, "spec")
+				var spec v1beta1.ReferenceGrantSpec
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec); err != nil {
+					return nil, err
+				}
+				specs[ref.Namespace] = append(specs[ref.Namespace], spec)
+			}
+		}
+		if !permits(specs[ref.Namespace], from, ref) {
+			unpermitted = append(unpermitted, ref)
+		}
+	}
+	return unpermitted, nil
+}
+
+func permits(specs []v1beta1.ReferenceGrantSpec, from v1beta1.ReferenceGrantFrom, ref CrossNamespaceRef) bool {
+	for _, spec := range specs {
+		fromMatched := false
+		for _, f := range spec.From {
+			if f.Group == from.Group && f.Kind == from.Kind && f.Namespace == from.Namespace {
+				fromMatched = true
+				break
+			}
+		}
+		if !fromMatched {
+			continue
+		}
+		for _, to := range spec.To {
+			if string(to.Group) == ref.Group && string(to.Kind) == ref.Kind &&
+				(to.Name == nil || *to.Name == "" || string(*to.Name) == ref.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReferenceGrantsForGateway returns the grants required by the certificates of a Gateway in other namespaces.
+func ReferenceGrantsForGateway(gateway *v1beta1.Gateway) []ReferenceGrant {
+	targets := make(map[string]map[string]v1beta1.ReferenceGrantTo)
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.TLS == nil {
+			continue
+		}
+		for _, ref := range listener.TLS.CertificateRefs {
+			if ref.Namespace == nil || string(*ref.Namespace) == gateway.Namespace {
+				continue
+			}
+			to := v1beta1.ReferenceGrantTo{Kind: "Secret", Name: &ref.Name}
+			if ref.Group != nil {
+				to.Group = *ref.Group
+			}
+			if ref.Kind != nil {
+				to.Kind = *ref.Kind
+			}
+			namespace := string(*ref.Namespace)
+			if targets[namespace] == nil {
+				targets[namespace] = make(map[string]v1beta1.ReferenceGrantTo)
+			}
+			targets[namespace][fmt.Sprintf("%s/%s/%s", to.Group, to.Kind, ref.Name)] = to
+		}
+	}
+
+	from := v1beta1.ReferenceGrantFrom{Group: GroupName, Kind: "Gateway", Namespace: v1beta1.Namespace(gateway.Namespace)}
+	return newReferenceGrants(fmt.Sprintf(GatewayReferenceGrantNameTmpl, gateway.Namespace, gateway.Name), from, targets)
+}
+
+func newReferenceGrants(
+	name string,
+	from v1beta1.ReferenceGrantFrom,
+	targets map[string]map[string]v1beta1.ReferenceGrantTo) []ReferenceGrant {
+	var grants []ReferenceGrant
+	for namespace, tos := range targets {
+		keys := make([]string, 0, len(tos))
+		for key := range tos {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		grant := ReferenceGrant{
+			Namespace: namespace,
+			Name:      name,
+			Spec:      v1beta1.ReferenceGrantSpec{From: []v1beta1.ReferenceGrantFrom{from}},
+		}
+		for _, key := range keys {
+			grant.Spec.To = append(grant.Spec.To, tos[key])
+		}
+		grants = append(grants, grant)
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].Namespace < grants[j].Namespace
+	})
+	return grants
+}
+
+// SyncReferenceGrants creates or updates the given grants with the labels, and deletes the other grants
+// with the labels in all namespaces. Nothing is done if the cluster serves neither ReferenceGrant nor ReferencePolicy,
+// as no Gateway API implementation checks the cross-namespace references against them in that case.
+func SyncReferenceGrants(ctx context.Context, c client.Client, labels map[string]string, grants []ReferenceGrant) error {
+	gvk, err := ReferenceGrantGVK(c.RESTMapper())
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil
+		}
+		return err
+	}
+
+	desired := make(map[string]ReferenceGrant)
+	for _, grant := range grants {
+		desired[grant.Namespace+"/"+grant.Name] = grant
+	}
+
+	existing := &unstructured.UnstructuredList{}
+	existing.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := c.List(ctx, existing, client.MatchingLabels(labels)); err != nil {
+		return err
+	}
+	for index := range existing.Items {
+		item := &existing.Items[index]
+		if _, ok := desired[item.GetNamespace()+"/"+item.GetName()]; ok {
+			continue
+		}
+		if err := c.Delete(ctx, item); util.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	for _, grant := range grants {
+		spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&grant.Spec)
+		if err != nil {
+			return err
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace(grant.Namespace)
+		obj.SetName(grant.Name)
+		if _, err := controllerutil.CreateOrUpdate(ctx, c, obj, func() error {
+			objLabels := obj.GetLabels()
+			if objLabels == nil {
+				objLabels = make(map[string]string)
+			}
+			for k, v := range labels {
+				objLabels[k] = v
+			}
+			obj.SetLabels(objLabels)
+			obj.Object["spec"] = spec
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_CrossNamespaceRefsForHTTPRoute(t *testing.T) {
+	local := v1beta1.Namespace("default")
+	remote := v1beta1.Namespace("backend")
+	backendRef := func(namespace *v1beta1.Namespace, name string) v1beta1.BackendObjectReference {
+		return v1beta1.BackendObjectReference{Name: v1beta1.ObjectName(name), Namespace: namespace}
+	}
+	route := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample"},
+		Spec: v1beta1.HTTPRouteSpec{
+			Rules: []v1beta1.HTTPRouteRule{
+				{
+					BackendRefs: []v1beta1.HTTPBackendRef{
+						{BackendRef: v1beta1.BackendRef{BackendObjectReference: backendRef(&local, "sample-v1")}},
+						{BackendRef: v1beta1.BackendRef{BackendObjectReference: backendRef(&remote, "shared")}},
+					},
+					Filters: []v1beta1.HTTPRouteFilter{{
+						Type:          v1beta1.HTTPRouteFilterRequestMirror,
+						RequestMirror: &v1beta1.HTTPRequestMirrorFilter{BackendRef: backendRef(&remote, "mirror")},
+					}},
+				},
+				{
+					BackendRefs: []v1beta1.HTTPBackendRef{
+						{BackendRef: v1beta1.BackendRef{BackendObjectReference: backendRef(nil, "sample-v2")}},
+						{BackendRef: v1beta1.BackendRef{BackendObjectReference: backendRef(&remote, "shared")}},
+					},
+				},
+			},
+		},
+	}
+
+	want := []CrossNamespaceRef{
+		{Namespace: "backend", Kind: "Service", Name: "mirror"},
+		{Namespace: "backend", Kind: "Service", Name: "shared"},
+	}
+	if got := CrossNamespaceRefsForHTTPRoute(route); !reflect.DeepEqual(got, want) {
+		t.Errorf("CrossNamespaceRefsForHTTPRoute() = %v, want %v", got, want)
+	}
+
+	route.Spec.Rules = route.Spec.Rules[1:2]
+	route.Spec.Rules[0].BackendRefs = route.Spec.Rules[0].BackendRefs[:1]
+	if refs := CrossNamespaceRefsForHTTPRoute(route); len(refs) != 0 {
+		t.Errorf("expected no refs for local backends, got %v", refs)
+	}
+}
+
+func Test_UnpermittedRefs(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: GroupName, Version: V1Beta1, Kind: ReferenceGrantKind}
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+	scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(ReferenceGrantKind+"List"), &unstructured.UnstructuredList{})
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{gvk.GroupVersion()})
+	mapper.Add(gvk, meta.RESTScopeNamespace)
+
+	grant := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"from": []interface{}{
+				map[string]interface{}{"group": GroupName, "kind": "HTTPRoute", "namespace": "default"},
+			},
+			"to": []interface{}{
+				map[string]interface{}{"group": "", "kind": "Service", "name": "shared"},
+			},
+		},
+	}}
+	grant.SetGroupVersionKind(gvk)
+	grant.SetNamespace("backend")
+	grant.SetName("allow-default")
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(grant).Build()
+
+	from := v1beta1.ReferenceGrantFrom{Group: GroupName, Kind: "HTTPRoute", Namespace: "default"}
+	refs := []CrossNamespaceRef{
+		{Namespace: "backend", Kind: "Service", Name: "mirror"},
+		{Namespace: "backend", Kind: "Service", Name: "shared"},
+		{Namespace: "other", Kind: "Service", Name: "shared"},
+	}
+	got, err := UnpermittedRefs(context.TODO(), c, from, refs)
+	if err != nil {
+		t.Fatalf("UnpermittedRefs() error = %v", err)
+	}
+	want := []CrossNamespaceRef{refs[0], refs[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnpermittedRefs() = %v, want %v", got, want)
+	}
+
+	from.Namespace = "tenant"
+	if got, _ := UnpermittedRefs(context.TODO(), c, from, refs[1:2]); len(got) != 1 {
+		t.Errorf("expected the grant not to allow the routes of other namespaces, got %v", got)
+	}
+
+	// No grant is checked if the cluster serves neither ReferenceGrant nor ReferencePolicy.
+	c = fake.NewClientBuilder().WithScheme(runtime.NewScheme()).
+		WithRESTMapper(meta.NewDefaultRESTMapper(nil)).Build()
+	if got, err := UnpermittedRefs(context.TODO(), c, from, refs); err != nil || len(got) != 0 {
+		t.Errorf("expected no unpermitted refs, got %v, %v", got, err)
+	}
+}
+
+func Test_ReferenceGrantsForGateway(t *testing.T) {
+	remote := v1beta1.Namespace("certs")
+	gateway := &v1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample"},
+		Spec: v1beta1.GatewaySpec{
+			Listeners: []v1beta1.Listener{{
+				Name: "https",
+				TLS: &v1beta1.GatewayTLSConfig{
+					CertificateRefs: []v1beta1.SecretObjectReference{{Name: "wildcard", Namespace: &remote}},
+				},
+			}},
+		},
+	}
+	grants := ReferenceGrantsForGateway(gateway)
+	if len(grants) != 1 || grants[0].Namespace != "certs" || grants[0].Name != "ofn-gateway-default-sample" {
+		t.Fatalf("unexpected grants %v", grants)
+	}
+	if to := grants[0].Spec.To; len(to) != 1 || to[0].Kind != "Secret" || string(*to[0].Name) != "wildcard" {
+		t.Errorf("unexpected to %v", to)
+	}
+}
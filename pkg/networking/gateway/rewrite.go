@@ -0,0 +1,43 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import "sigs.k8s.io/gateway-api/apis/v1beta1"
+
+// NewPrefixRewrite returns the URLRewrite filter replacing the matched path prefix with the given prefix.
+func NewPrefixRewrite(prefix string) v1beta1.HTTPRouteFilter {
+	return v1beta1.HTTPRouteFilter{
+		Type: v1beta1.HTTPRouteFilterURLRewrite,
+		URLRewrite: &v1beta1.HTTPURLRewriteFilter{
+			Path: &v1beta1.HTTPPathModifier{Type: v1beta1.PrefixMatchHTTPPathModifier, ReplacePrefixMatch: &prefix},
+		},
+	}
+}
+
+// PrefixRewriteOf returns the prefix the URLRewrite filter of a rule replaces the matched path prefix with.
+func PrefixRewriteOf(rule v1beta1.HTTPRouteRule) (string, bool) {
+	for _, filter := range rule.Filters {
+		if filter.Type != v1beta1.HTTPRouteFilterURLRewrite || filter.URLRewrite == nil {
+			continue
+		}
+		path := filter.URLRewrite.Path
+		if path != nil && path.Type == v1beta1.PrefixMatchHTTPPathModifier && path.ReplacePrefixMatch != nil {
+			return *path.ReplacePrefixMatch, true
+		}
+	}
+	return "", false
+}
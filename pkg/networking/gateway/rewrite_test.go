@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"testing"
+
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_PrefixRewriteOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   v1beta1.HTTPRouteRule
+		prefix string
+		ok     bool
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name: "other filters",
+			rule: v1beta1.HTTPRouteRule{
+				Filters: []v1beta1.HTTPRouteFilter{{Type: v1beta1.HTTPRouteFilterRequestHeaderModifier}},
+			},
+		},
+		{
+			name: "prefix rewrite",
+			rule: v1beta1.HTTPRouteRule{
+				Filters: []v1beta1.HTTPRouteFilter{
+					{Type: v1beta1.HTTPRouteFilterRequestHeaderModifier},
+					NewPrefixRewrite("/"),
+				},
+			},
+			prefix: "/",
+			ok:     true,
+		},
+		{
+			name: "full path rewrite",
+			rule: v1beta1.HTTPRouteRule{
+				Filters: []v1beta1.HTTPRouteFilter{{
+					Type: v1beta1.HTTPRouteFilterURLRewrite,
+					URLRewrite: &v1beta1.HTTPURLRewriteFilter{
+						Path: &v1beta1.HTTPPathModifier{Type: v1beta1.FullPathHTTPPathModifier},
+					},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, ok := PrefixRewriteOf(tt.rule)
+			if prefix != tt.prefix || ok != tt.ok {
+				t.Errorf("PrefixRewriteOf() = %q, %v, want %q, %v", prefix, ok, tt.prefix, tt.ok)
+			}
+		})
+	}
+}
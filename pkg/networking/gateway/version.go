@@ -0,0 +1,406 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+
+	networkingv1alpha1 "github.com/openfunction/apis/networking/v1alpha1"
+)
+
+const (
+	GroupName = "gateway.networking.k8s.io"
+	V1        = "v1"
+	V1Beta1   = "v1beta1"
+	V1Alpha2  = "v1alpha2"
+)
+
+// SupportedVersions lists the supported Gateway API versions in the order of preference.
+var SupportedVersions = []string{V1, V1Beta1, V1Alpha2}
+
+// DetectVersion returns the most preferred Gateway API version which serves both Gateways and HTTPRoutes in the cluster,
+// an empty version is returned if the cluster serves none of the supported versions.
+func DetectVersion(dc discovery.DiscoveryInterface) (string, error) {
+	for _, version := range SupportedVersions {
+		resources, err := dc.ServerResourcesForGroupVersion(schema.GroupVersion{Group: GroupName, Version: version}.String())
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		if servesResources(resources, "gateways", "httproutes") {
+			return version, nil
+		}
+	}
+	return "", nil
+}
+
+// RoutingModeFor returns the routing mode of the gateway when the cluster serves the given Gateway API version.
+func RoutingModeFor(gateway *networkingv1alpha1.Gateway, version string) networkingv1alpha1.RoutingMode {
+	if gateway.Spec.RoutingMode != "" {
+		return gateway.Spec.RoutingMode
+	}
+	if version == "" {
+		return networkingv1alpha1.RoutingModeIngress
+	}
+	return networkingv1alpha1.RoutingModeGatewayAPI
+}
+
+func servesResources(list *metav1.APIResourceList, names ...string) bool {
+	served := make(map[string]bool)
+	for _, resource := range list.APIResources {
+		served[resource.Name] = true
+	}
+	for _, name := range names {
+		if !served[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// The controllers work with the v1beta1 types of Gateway API v0.7.0, the GatewayClasses, Gateways, HTTPRoutes and
+// ReferenceGrants of the served version share the same schema for the fields the controllers use, and are translated
+// through unstructured objects.
+func kindOf(obj runtime.Object) (string, bool) {
+	switch obj.(type) {
+	case *v1beta1.ReferenceGrant:
+		return ReferenceGrantKind, true
+	case *v1beta1.ReferenceGrantList:
+		return ReferenceGrantKind + "List", true
+	case *v1beta1.GatewayClass:
+		return "GatewayClass", true
+	case *v1beta1.GatewayClassList:
+		return "GatewayClassList", true
+	case *v1beta1.Gateway:
+		return "Gateway", true
+	case *v1beta1.GatewayList:
+		return "GatewayList", true
+	case *v1beta1.HTTPRoute:
+		return "HTTPRoute", true
+	case *v1beta1.HTTPRouteList:
+		return "HTTPRouteList", true
+	default:
+		return "", false
+	}
+}
+
+// NewObject returns the object to watch for the given v1beta1 object in the given version, it is an unstructured
+// object since the served version may have no Go types. ConvertObject drops the fields missing in the v1beta1 types.
+func NewObject(obj client.Object, version string) client.Object {
+	kind, ok := kindOf(obj)
+	if !ok || version == "" || isReferenceGrantKind(kind) {
+		return obj
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: GroupName, Version: version, Kind: kind})
+	return u
+}
+
+// ConvertObject converts an object received from the watches into the v1beta1 object.
+func ConvertObject(in runtime.Object, out client.Object) error {
+	if u, ok := in.(*unstructured.Unstructured); ok {
+		return fromUnstructured(u.Object, out)
+	}
+	if reflect.TypeOf(in) != reflect.TypeOf(out) {
+		return fmt.Errorf("can not convert %T to %T", in, out)
+	}
+	reflect.ValueOf(out).Elem().Set(reflect.ValueOf(in).Elem())
+	return nil
+}
+
+func fromUnstructured(u map[string]interface{}, out runtime.Object) error {
+	reflect.ValueOf(out).Elem().Set(reflect.Zero(reflect.TypeOf(out).Elem()))
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, out); err != nil {
+		return err
+	}
+	// The TypeMeta of the served version does not apply to the v1beta1 object.
+	out.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	return nil
+}
+
+// NewClient returns a client which reads and writes the v1beta1 GatewayClasses, Gateways and HTTPRoutes in the given
+// version, and the v1beta1 ReferenceGrants in their served version. They are read as unstructured objects from the
+// given cache, the other objects are accessed with the given client.
+func NewClient(c client.Client, cache client.Reader, version string) client.Client {
+	if version == "" {
+		return c
+	}
+	return &versionedClient{Client: c, cache: cache, version: version}
+}
+
+type versionedClient struct {
+	client.Client
+	cache   client.Reader
+	version string
+}
+
+func isReferenceGrantKind(kind string) bool {
+	return kind == ReferenceGrantKind || kind == ReferenceGrantKind+"List"
+}
+
+// gvk returns the served version of the kind. ReferenceGrant is served in other versions than the routes,
+// e.g. Gateway API v1 serves it in v1beta1 only, so its served version is resolved with the RESTMapper.
+func (c *versionedClient) gvk(kind string) (schema.GroupVersionKind, error) {
+	if !isReferenceGrantKind(kind) {
+		return schema.GroupVersionKind{Group: GroupName, Version: c.version, Kind: kind}, nil
+	}
+	gvk, err := ReferenceGrantGVK(c.RESTMapper())
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	if kind != ReferenceGrantKind {
+		gvk.Kind += "List"
+	}
+	return gvk, nil
+}
+
+func (c *versionedClient) toUnstructured(obj client.Object) (*unstructured.Unstructured, bool, error) {
+	kind, ok := kindOf(obj)
+	if !ok {
+		return nil, false, nil
+	}
+	gvk, err := c.gvk(kind)
+	if err != nil {
+		return nil, true, err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, true, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, true, nil
+}
+
+// toLiveUnstructured converts the v1beta1 object into the unstructured object of the served version
+// with the fields of the live object which are missing in the v1beta1 types, so that writing the
+// v1beta1 object only changes the fields the controllers own.
+func (c *versionedClient) toLiveUnstructured(ctx context.Context, obj client.Object) (*unstructured.Unstructured, bool, error) {
+	u, ok, err := c.toUnstructured(obj)
+	if !ok || err != nil {
+		return u, ok, err
+	}
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(u.GroupVersionKind())
+	if err := c.cache.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		return u, true, client.IgnoreNotFound(err)
+	}
+	// The fields of the live object which do not survive the conversion into the v1beta1 object are unknown.
+	typed := reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+	if err := fromUnstructured(live.Object, typed); err != nil {
+		return nil, true, err
+	}
+	known, _, err := c.toUnstructured(typed)
+	if err != nil {
+		return nil, true, err
+	}
+	mergeUnknownFields(u.Object, live.Object, known.Object)
+	return u, true, nil
+}
+
+// mergeUnknownFields copies the fields of the live content which are missing in the known content into the
+// desired content. The items of the lists are matched by their names, the lists without names are owned
+// by the desired content.
+func mergeUnknownFields(desired, live, known map[string]interface{}) {
+	for key, liveValue := range live {
+		knownValue, ok := known[key]
+		if !ok {
+			if _, ok := desired[key]; !ok {
+				desired[key] = runtime.DeepCopyJSONValue(liveValue)
+			}
+			continue
+		}
+		switch liveValue := liveValue.(type) {
+		case map[string]interface{}:
+			desiredMap, isDesiredMap := desired[key].(map[string]interface{})
+			knownMap, isKnownMap := knownValue.(map[string]interface{})
+			if isDesiredMap && isKnownMap {
+				mergeUnknownFields(desiredMap, liveValue, knownMap)
+			}
+		case []interface{}:
+			desiredList, isDesiredList := desired[key].([]interface{})
+			knownList, isKnownList := knownValue.([]interface{})
+			if isDesiredList && isKnownList && len(knownList) == len(liveValue) {
+				mergeUnknownItems(desiredList, liveValue, knownList)
+			}
+		}
+	}
+}
+
+func mergeUnknownItems(desired, live, known []interface{}) {
+	index := make(map[string]int)
+	for i, item := range live {
+		if name, ok := itemName(item); ok {
+			index[name] = i
+		}
+	}
+	for _, item := range desired {
+		name, ok := itemName(item)
+		if !ok {
+			continue
+		}
+		i, ok := index[name]
+		if !ok {
+			continue
+		}
+		liveItem, _ := live[i].(map[string]interface{})
+		knownItem, ok := known[i].(map[string]interface{})
+		if ok {
+			mergeUnknownFields(item.(map[string]interface{}), liveItem, knownItem)
+		}
+	}
+}
+
+func itemName(item interface{}) (string, bool) {
+	content, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	name, ok := content["name"].(string)
+	return name, ok
+}
+
+func (c *versionedClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	kind, ok := kindOf(obj)
+	if !ok {
+		return c.Client.Get(ctx, key, obj, opts...)
+	}
+	gvk, err := c.gvk(kind)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if err := c.cache.Get(ctx, key, u, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
+
+func (c *versionedClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	kind, ok := kindOf(list)
+	if !ok {
+		return c.Client.List(ctx, list, opts...)
+	}
+	gvk, err := c.gvk(kind)
+	if err != nil {
+		return err
+	}
+	u := &unstructured.UnstructuredList{}
+	u.SetGroupVersionKind(gvk)
+	if err := c.cache.List(ctx, u, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.UnstructuredContent(), list)
+}
+
+func (c *versionedClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	u, ok, err := c.toUnstructured(obj)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	} else if err != nil {
+		return err
+	}
+	if err := c.Client.Create(ctx, u, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
+
+func (c *versionedClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	u, ok, err := c.toLiveUnstructured(ctx, obj)
+	if !ok {
+		return c.Client.Update(ctx, obj, opts...)
+	} else if err != nil {
+		return err
+	}
+	if err := c.Client.Update(ctx, u, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
+
+func (c *versionedClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	u, ok, err := c.toLiveUnstructured(ctx, obj)
+	if !ok {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	} else if err != nil {
+		return err
+	}
+	if err := c.Client.Patch(ctx, u, patch, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
+
+func (c *versionedClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	u, ok, err := c.toUnstructured(obj)
+	if !ok {
+		return c.Client.Delete(ctx, obj, opts...)
+	} else if err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, u, opts...)
+}
+
+func (c *versionedClient) Status() client.SubResourceWriter {
+	return &versionedStatusWriter{SubResourceWriter: c.Client.Status(), client: c}
+}
+
+type versionedStatusWriter struct {
+	client.SubResourceWriter
+	client *versionedClient
+}
+
+func (w *versionedStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	u, ok, err := w.client.toLiveUnstructured(ctx, obj)
+	if !ok {
+		return w.SubResourceWriter.Update(ctx, obj, opts...)
+	} else if err != nil {
+		return err
+	}
+	if err := w.SubResourceWriter.Update(ctx, u, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
+
+func (w *versionedStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	u, ok, err := w.client.toLiveUnstructured(ctx, obj)
+	if !ok {
+		return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+	} else if err != nil {
+		return err
+	}
+	if err := w.SubResourceWriter.Patch(ctx, u, patch, opts...); err != nil {
+		return err
+	}
+	return fromUnstructured(u.Object, obj)
+}
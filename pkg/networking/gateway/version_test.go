@@ -0,0 +1,414 @@
+/*
+Copyright 2023 The OpenFunction Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_DetectVersion(t *testing.T) {
+	resources := func(version string, names ...string) *metav1.APIResourceList {
+		list := &metav1.APIResourceList{GroupVersion: GroupName + "/" + version}
+		for _, name := range names {
+			list.APIResources = append(list.APIResources, metav1.APIResource{Name: name})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		want      string
+	}{
+		{
+			name: "none served",
+			want: "",
+		},
+		{
+			name:      "v1alpha2 served",
+			resources: []*metav1.APIResourceList{resources(V1Alpha2, "gateways", "httproutes")},
+			want:      V1Alpha2,
+		},
+		{
+			name: "v1 preferred",
+			resources: []*metav1.APIResourceList{
+				resources(V1Alpha2, "gateways", "httproutes"),
+				resources(V1, "gateways", "httproutes", "gatewayclasses"),
+			},
+			want: V1,
+		},
+		{
+			name: "v1 without httproutes",
+			resources: []*metav1.APIResourceList{
+				resources(V1Alpha2, "gateways", "httproutes"),
+				resources(V1, "gateways", "gatewayclasses"),
+			},
+			want: V1Alpha2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: tt.resources}}
+			got, err := DetectVersion(dc)
+			if err != nil {
+				t.Fatalf("DetectVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newVersionedTestClient serves Gateways and HTTPRoutes in the given version, and ReferenceGrants in v1beta1
+// as Gateway API v1 does.
+func newVersionedTestClient(version string, objs ...client.Object) (client.Client, client.Client) {
+	scheme := runtime.NewScheme()
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: GroupName, Version: V1Beta1}})
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: GroupName, Version: version, Kind: "Gateway"},
+		{Group: GroupName, Version: version, Kind: "HTTPRoute"},
+		{Group: GroupName, Version: V1Beta1, Kind: ReferenceGrantKind},
+	} {
+		scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		scheme.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objs...).Build()
+	return NewClient(c, c, version), c
+}
+
+func Test_versionedClient_ReadWrite(t *testing.T) {
+	vc, c := newVersionedTestClient(V1)
+
+	sectionName := v1beta1.SectionName("http")
+	route := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample"},
+		Spec: v1beta1.HTTPRouteSpec{
+			CommonRouteSpec: v1beta1.CommonRouteSpec{
+				ParentRefs: []v1beta1.ParentReference{{Name: "openfunction", SectionName: &sectionName}},
+			},
+			Hostnames: []v1beta1.Hostname{"sample.default.ofn.io"},
+		},
+	}
+	if err := vc.Create(context.TODO(), route); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: GroupName, Version: V1, Kind: "HTTPRoute"})
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(route), u); err != nil {
+		t.Fatalf("expected the HTTPRoute to be stored in %s: %v", V1, err)
+	}
+
+	got := &v1beta1.HTTPRoute{}
+	if err := vc.Get(context.TODO(), client.ObjectKeyFromObject(route), got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(got.Spec.Hostnames) != 1 || got.Spec.Hostnames[0] != "sample.default.ofn.io" {
+		t.Errorf("unexpected hostnames %v", got.Spec.Hostnames)
+	}
+	if !got.GetObjectKind().GroupVersionKind().Empty() {
+		t.Errorf("expected the TypeMeta of the served version to be dropped, got %v", got.GetObjectKind().GroupVersionKind())
+	}
+
+	list := &v1beta1.HTTPRouteList{}
+	if err := vc.List(context.TODO(), list, client.InNamespace("default")); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "sample" {
+		t.Errorf("unexpected routes %v", list.Items)
+	}
+
+	if err := vc.Delete(context.TODO(), got); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(route), u); err == nil {
+		t.Errorf("expected the HTTPRoute to be deleted")
+	}
+}
+
+func Test_versionedClient_UpdateKeepsUnknownFields(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": GroupName + "/" + V1,
+		"kind":       "Gateway",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "eg",
+		},
+		"spec": map[string]interface{}{
+			"gatewayClassName": "eg",
+			"infrastructure": map[string]interface{}{
+				"labels": map[string]interface{}{"team": "ops"},
+			},
+			"listeners": []interface{}{
+				map[string]interface{}{
+					"name":     "http",
+					"port":     int64(80),
+					"protocol": "HTTP",
+					"allowedRoutes": map[string]interface{}{
+						"namespaces": map[string]interface{}{"from": "All"},
+					},
+					"experimental": "on",
+				},
+				map[string]interface{}{
+					"name":     "ofn-http",
+					"port":     int64(80),
+					"protocol": "HTTP",
+				},
+			},
+		},
+	}}
+	vc, c := newVersionedTestClient(V1, live)
+
+	gateway := &v1beta1.Gateway{}
+	if err := vc.Get(context.TODO(), client.ObjectKeyFromObject(live), gateway); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	hostname := v1beta1.Hostname("*.ofn.io")
+	var listeners []v1beta1.Listener
+	for _, listener := range gateway.Spec.Listeners {
+		if listener.Name == "ofn-http" {
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	listeners = append(listeners, v1beta1.Listener{Name: "ofn-http-wildcard", Hostname: &hostname, Port: 80, Protocol: "HTTP"})
+	gateway.Spec.Listeners = listeners
+	if err := vc.Update(context.TODO(), gateway); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(live.GroupVersionKind())
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(live), got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	labels, _, _ := unstructured.NestedStringMap(got.Object, "spec", "infrastructure", "labels")
+	if labels["team"] != "ops" {
+		t.Errorf("expected the unknown spec.infrastructure to be kept, got %v", got.Object["spec"])
+	}
+	items, _, _ := unstructured.NestedSlice(got.Object, "spec", "listeners")
+	var names []string
+	for _, item := range items {
+		names = append(names, item.(map[string]interface{})["name"].(string))
+	}
+	if len(names) != 2 || names[0] != "http" || names[1] != "ofn-http-wildcard" {
+		t.Errorf("expected the listeners owned by the controller to be replaced, got %v", names)
+	}
+	from, _, _ := unstructured.NestedString(items[0].(map[string]interface{}), "allowedRoutes", "namespaces", "from")
+	experimental, _, _ := unstructured.NestedString(items[0].(map[string]interface{}), "experimental")
+	if from != "All" || experimental != "on" {
+		t.Errorf("expected the fields of the user listener to be kept, got %v", items[0])
+	}
+}
+
+func Test_versionedClient_ReferenceGrant(t *testing.T) {
+	vc, c := newVersionedTestClient(V1)
+
+	name := v1beta1.ObjectName("tls")
+	grant := &v1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "certs", Name: "grant"},
+		Spec: v1beta1.ReferenceGrantSpec{
+			From: []v1beta1.ReferenceGrantFrom{{Group: GroupName, Kind: "Gateway", Namespace: "gateway"}},
+			To:   []v1beta1.ReferenceGrantTo{{Kind: "Secret", Name: &name}},
+		},
+	}
+	if err := vc.Create(context.TODO(), grant); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// The grant is stored in the served version of ReferenceGrant instead of the version of the routes.
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(schema.GroupVersionKind{Group: GroupName, Version: V1Beta1, Kind: ReferenceGrantKind})
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(grant), u); err != nil {
+		t.Fatalf("expected the ReferenceGrant to be stored in %s: %v", V1Beta1, err)
+	}
+
+	list := &v1beta1.ReferenceGrantList{}
+	if err := vc.List(context.TODO(), list, client.InNamespace("certs")); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Items) != 1 || len(list.Items[0].Spec.To) != 1 || *list.Items[0].Spec.To[0].Name != name {
+		t.Errorf("unexpected grants %v", list.Items)
+	}
+
+	if err := vc.Delete(context.TODO(), &list.Items[0]); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(grant), u); err == nil {
+		t.Errorf("expected the ReferenceGrant to be deleted")
+	}
+
+	if obj := NewObject(&v1beta1.ReferenceGrant{}, V1); obj.GetObjectKind().GroupVersionKind().Version == V1 {
+		t.Errorf("expected no watch object of ReferenceGrant in %s", V1)
+	}
+}
+
+func newLiveHTTPRoute() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": GroupName + "/" + V1,
+		"kind":       "HTTPRoute",
+		"metadata": map[string]interface{}{
+			"namespace": "default",
+			"name":      "sample",
+		},
+		"spec": map[string]interface{}{
+			"hostnames": []interface{}{"sample.default.ofn.io"},
+			"unknown":   "kept",
+		},
+		"status": map[string]interface{}{
+			"parents": []interface{}{},
+			"unknown": "kept",
+		},
+	}}
+}
+
+func Test_versionedClient_Patch(t *testing.T) {
+	live := newLiveHTTPRoute()
+	vc, c := newVersionedTestClient(V1, live)
+
+	route := &v1beta1.HTTPRoute{}
+	if err := vc.Get(context.TODO(), client.ObjectKeyFromObject(live), route); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	patch := client.MergeFrom(route.DeepCopy())
+	route.Spec.Hostnames = []v1beta1.Hostname{"sample.ofn.io"}
+	if err := vc.Patch(context.TODO(), route, patch); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if len(route.Spec.Hostnames) != 1 || route.Spec.Hostnames[0] != "sample.ofn.io" {
+		t.Errorf("expected the patched object to be returned, got %v", route.Spec.Hostnames)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(live.GroupVersionKind())
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(live), got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	hostnames, _, _ := unstructured.NestedStringSlice(got.Object, "spec", "hostnames")
+	unknown, _, _ := unstructured.NestedString(got.Object, "spec", "unknown")
+	if len(hostnames) != 1 || hostnames[0] != "sample.ofn.io" || unknown != "kept" {
+		t.Errorf("unexpected patched spec %v", got.Object["spec"])
+	}
+}
+
+func Test_versionedClient_Status(t *testing.T) {
+	live := newLiveHTTPRoute()
+	vc, c := newVersionedTestClient(V1, live)
+
+	route := &v1beta1.HTTPRoute{}
+	if err := vc.Get(context.TODO(), client.ObjectKeyFromObject(live), route); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	route.Status.Parents = []v1beta1.RouteParentStatus{{
+		ParentRef:      v1beta1.ParentReference{Name: "eg"},
+		ControllerName: "gateway.envoyproxy.io/gatewayclass-controller",
+	}}
+	if err := vc.Status().Update(context.TODO(), route); err != nil {
+		t.Fatalf("Status().Update() error = %v", err)
+	}
+
+	patch := client.MergeFrom(route.DeepCopy())
+	route.Status.Parents[0].ControllerName = "example.com/gateway-controller"
+	if err := vc.Status().Patch(context.TODO(), route, patch); err != nil {
+		t.Fatalf("Status().Patch() error = %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(live.GroupVersionKind())
+	if err := c.Get(context.TODO(), client.ObjectKeyFromObject(live), got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	parents, _, _ := unstructured.NestedSlice(got.Object, "status", "parents")
+	unknown, _, _ := unstructured.NestedString(got.Object, "status", "unknown")
+	if len(parents) != 1 || unknown != "kept" {
+		t.Fatalf("unexpected status %v", got.Object["status"])
+	}
+	controllerName, _, _ := unstructured.NestedString(parents[0].(map[string]interface{}), "controllerName")
+	if controllerName != "example.com/gateway-controller" {
+		t.Errorf("expected the patched status, got controller %s", controllerName)
+	}
+}
+
+func Test_mergeUnknownFields(t *testing.T) {
+	live := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"owned":   "old",
+			"cleared": "old",
+			"unknown": "kept",
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "owned": "old", "unknown": "kept"},
+				map[string]interface{}{"name": "b", "owned": "old"},
+			},
+		},
+	}
+	known := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"owned":   "old",
+			"cleared": "old",
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "owned": "old"},
+				map[string]interface{}{"name": "b", "owned": "old"},
+			},
+		},
+	}
+	desired := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"owned": "new",
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "owned": "new"},
+				map[string]interface{}{"name": "c", "owned": "new"},
+			},
+		},
+	}
+
+	mergeUnknownFields(desired, live, known)
+
+	spec := desired["spec"].(map[string]interface{})
+	if spec["owned"] != "new" {
+		t.Errorf("expected the owned field to be overwritten, got %v", spec["owned"])
+	}
+	if _, ok := spec["cleared"]; ok {
+		t.Errorf("expected the cleared field to stay cleared, got %v", spec["cleared"])
+	}
+	if spec["unknown"] != "kept" {
+		t.Errorf("expected the unknown field to be kept, got %v", spec["unknown"])
+	}
+	items := spec["items"].([]interface{})
+	if len(items) != 2 {
+		t.Fatalf("expected the desired items, got %v", items)
+	}
+	if a := items[0].(map[string]interface{}); a["owned"] != "new" || a["unknown"] != "kept" {
+		t.Errorf("unexpected item %v", a)
+	}
+	if c := items[1].(map[string]interface{}); len(c) != 2 {
+		t.Errorf("unexpected item %v", c)
+	}
+}